@@ -0,0 +1,38 @@
+// /nexusl/ds/complex.go
+// .
+// Constantes complejas
+// .
+// Un número complejo es una constante cuyo Value es un complex128. Su nombre
+// público es la forma literal del lenguaje: la parte real, el signo y la
+// parte imaginaria con el sufijo i ("2+3i", "-1.5-0.5i"). Un imaginario puro
+// se escribe sin parte real ("3i") y la parte imaginaria nula se conserva
+// ("2+0i") para no confundir el complejo con el real 2.
+// .
+package ds
+
+import (
+	"strconv"
+	"strings"
+)
+
+// NewComplexSymbol crea la constante compleja c, sin registrarla (ver newValueSymbol).
+func NewComplexSymbol(c complex128) *Symbol {
+	return newValueSymbol(FormatComplex(c), c)
+}
+
+// FormatComplex devuelve la forma literal de c.
+func FormatComplex(c complex128) string {
+	im := strconv.FormatFloat(imag(c), 'g', -1, 64) + "i"
+	if real(c) == 0 {
+		return im
+	}
+	if !strings.HasPrefix(im, "-") && !strings.HasPrefix(im, "+") {
+		im = "+" + im
+	}
+	return strconv.FormatFloat(real(c), 'g', -1, 64) + im
+}
+
+// ParseComplex interpreta un literal complejo: "2+3i", "3i", "-1.5-0.5i".
+func ParseComplex(s string) (complex128, error) {
+	return strconv.ParseComplex(s, 128)
+}
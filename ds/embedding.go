@@ -0,0 +1,80 @@
+// /nexusl/ds/embedding.go
+// .
+// Vecinos semánticos por embedding
+// .
+// Los símbolos del sistema pueden traer un embedding desde definitions.db.
+// NearestSymbols ordena los demás símbolos por la similitud coseno de sus
+// embeddings, para sugerir conceptos cercanos (ver el hover del servidor LSP).
+// .
+package ds
+
+import (
+	"cmp"
+	"math"
+	"slices"
+)
+
+// Neighbour es un símbolo cercano a otro y su similitud coseno, en [-1,1].
+type Neighbour struct {
+	Symbol     *Symbol
+	Similarity float64
+}
+
+// CosineSimilarity devuelve la similitud coseno de a y b, o 0 si tienen
+// distinta dimensión o alguno es nulo.
+func CosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		x, y := float64(a[i]), float64(b[i])
+		dot += x * y
+		na += x * x
+		nb += y * y
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// NearestSymbols devuelve hasta k símbolos con nombre público cuyo embedding
+// es más parecido al de s, del más al menos parecido (a igual similitud, por
+// nombre). Devuelve nil si s no tiene embedding.
+func NearestSymbols(s *Symbol, k int) []Neighbour {
+	if len(s.Embedding) == 0 || k <= 0 {
+		return nil
+	}
+	mu.Lock()
+	var near []Neighbour
+	for _, other := range SymbolsByPublicName {
+		if other == s || len(other.Embedding) != len(s.Embedding) {
+			continue
+		}
+		near = append(near, Neighbour{Symbol: other, Similarity: CosineSimilarity(s.Embedding, other.Embedding)})
+	}
+	mu.Unlock()
+	slices.SortFunc(near, func(a, b Neighbour) int {
+		if c := cmp.Compare(b.Similarity, a.Similarity); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Symbol.PublicName, b.Symbol.PublicName)
+	})
+	return near[:min(k, len(near))]
+}
+
+// SymbolsOfThing devuelve los símbolos con nombre público de la categoría
+// thing, ordenados por nombre.
+func SymbolsOfThing(thing ThingType) []*Symbol {
+	mu.Lock()
+	var syms []*Symbol
+	for _, s := range SymbolsByPublicName {
+		if s.Thing == thing {
+			syms = append(syms, s)
+		}
+	}
+	mu.Unlock()
+	slices.SortFunc(syms, func(a, b *Symbol) int { return cmp.Compare(a.PublicName, b.PublicName) })
+	return syms
+}
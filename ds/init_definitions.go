@@ -3,8 +3,9 @@ package ds
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
+	"slices"
 	"strconv" // Necesario para convertir string a float
 	"strings" // Necesario para strings.Fields
 
@@ -30,6 +31,50 @@ func parseEmbeddingString(s string) ([]float32, error) {
 	return embedding, nil
 }
 
+// KeywordAlias es otra forma de escribir una palabra clave en un idioma
+// (Language "es", Alias "hecho", Keyword "fact"). Se guardan en la tabla
+// keyword_aliases, junto a system_symbols.
+type KeywordAlias struct {
+	Language string
+	Alias    string
+	Keyword  string
+}
+
+// keywordAliases son los alias de la última carga de la DB.
+var keywordAliases []KeywordAlias
+
+// KeywordAliases devuelve los alias de palabras clave cargados de la DB,
+// ordenados por idioma y alias.
+func KeywordAliases() []KeywordAlias {
+	return slices.Clone(keywordAliases)
+}
+
+// loadKeywordAliases lee la tabla keyword_aliases; una DB anterior a la tabla
+// no tiene alias.
+func loadKeywordAliases(db *sql.DB) ([]KeywordAlias, error) {
+	var name string
+	err := db.QueryRow("SELECT name FROM sqlite_master WHERE type = 'table' AND name = 'keyword_aliases'").Scan(&name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to look for the keyword_aliases table: %w", err)
+	}
+	rows, err := db.Query("SELECT language, alias, keyword FROM keyword_aliases ORDER BY language, alias")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query keyword aliases from DB: %w", err)
+	}
+	defer rows.Close()
+	var aliases []KeywordAlias
+	for rows.Next() {
+		var a KeywordAlias
+		if err := rows.Scan(&a.Language, &a.Alias, &a.Keyword); err != nil {
+			return nil, fmt.Errorf("failed to scan keyword alias row: %w", err)
+		}
+		aliases = append(aliases, a)
+	}
+	return aliases, rows.Err()
+}
+
 // LoadSystemDefinitionsFromDB carga los símbolos del sistema desde una DB SQLite.
 // dbPath es la ruta al archivo definitions.db
 func LoadSystemDefinitionsFromDB(dbPath string) error {
@@ -61,7 +106,7 @@ func LoadSystemDefinitionsFromDB(dbPath string) error {
 		if embeddingDataString.Valid && embeddingDataString.String != "" {
 			embedding, err := parseEmbeddingString(embeddingDataString.String)
 			if err != nil {
-				log.Printf("Warning: Failed to parse embedding string for symbol %s: %v", name, err)
+				dbLog.Warn("failed to parse embedding string", "symbol", name, "err", err)
 			} else {
 				s.Embedding = embedding
 			}
@@ -70,11 +115,11 @@ func LoadSystemDefinitionsFromDB(dbPath string) error {
 		// Aquí es donde asignarías los Proc a las macros/funciones built-in
 		// Assign Proc based on ThingType (or other criteria from DB)
 		if thingStringFromDB == "TripletScope" {
-			fmt.Printf("INFO: Loading TripletScope '%s'\n", name) // Improved print for clarity
+			dbLog.Debug("loading TripletScope", "name", name)
 			// Capture 'name' in the closure for the Proc function
 			scopeName := name // Important: create a local variable for the closure
 			s.Proc = func(args ...interface{}) (interface{}, error) {
-				fmt.Printf("TripletScope '%s' invoked with args: %v\n", scopeName, args) // Use captured scopeName
+				dbLog.Debug("TripletScope invoked", "name", scopeName, "args", args)
 				return nil, nil
 			}
 		}
@@ -88,6 +133,12 @@ func LoadSystemDefinitionsFromDB(dbPath string) error {
 		// }
 	}
 
-	fmt.Println("System definitions loaded successfully into memory, including embeddings (parsed from string).")
+	aliases, err := loadKeywordAliases(db)
+	if err != nil {
+		return err
+	}
+	keywordAliases = aliases
+
+	dbLog.Info("system definitions loaded", "source", dbPath, "aliases", len(aliases))
 	return nil
 }
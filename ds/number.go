@@ -0,0 +1,94 @@
+// /nexusl/ds/number.go
+// .
+// Constantes numéricas exactas
+// .
+// Además de int64, float64 y complex128, el Value de una constante numérica
+// puede ser:
+// .
+//
+//	*big.Int    un entero que no cabe en int64 (ej. un número de serie de 20 cifras)
+//	*big.Rat    un racional exacto, escrito p/q
+//	Decimal     un decimal exacto, escrito con el sufijo d: 19.99d
+//
+// .
+// Los constructores normalizan el valor: un entero que cabe en int64 se
+// guarda como int64 y un racional entero como entero, de modo que cada
+// número tiene una sola representación por clase.
+// .
+package ds
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// ErrInvalidDecimal indica que un texto no es un literal decimal.
+var ErrInvalidDecimal = errors.New("ds: invalid decimal literal")
+
+// Decimal es el número exacto Unscaled × 10^-Scale. La escala se conserva:
+// 2.50d y 2.5d valen lo mismo pero se escriben distinto.
+type Decimal struct {
+	Unscaled *big.Int
+	Scale    int
+}
+
+// ParseDecimal interpreta un literal decimal: "19.99", "-3", "0.50d".
+func ParseDecimal(s string) (Decimal, error) {
+	text := strings.TrimSuffix(s, "d")
+	digits, scale := text, 0
+	if i := strings.IndexByte(text, '.'); i >= 0 {
+		digits, scale = text[:i]+text[i+1:], len(text)-i-1
+	}
+	n, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+	return Decimal{Unscaled: n, Scale: scale}, nil
+}
+
+// String devuelve el decimal sin sufijo, con todas sus cifras de escala.
+func (d Decimal) String() string {
+	s := new(big.Int).Abs(d.Unscaled).String()
+	if d.Scale > 0 {
+		if len(s) <= d.Scale {
+			s = strings.Repeat("0", d.Scale-len(s)+1) + s
+		}
+		s = s[:len(s)-d.Scale] + "." + s[len(s)-d.Scale:]
+	}
+	if d.Unscaled.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// Rat devuelve el valor exacto del decimal.
+func (d Decimal) Rat() *big.Rat {
+	den := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(d.Scale)), nil)
+	return new(big.Rat).SetFrac(d.Unscaled, den)
+}
+
+// Los constructores de números crean valores sin registrar (ver newValueSymbol).
+
+// NewIntegerSymbol crea la constante entera n: un int64 si cabe, si no un *big.Int.
+func NewIntegerSymbol(n *big.Int) *Symbol {
+	if n.IsInt64() {
+		return newValueSymbol(n.String(), n.Int64())
+	}
+	return newValueSymbol(n.String(), new(big.Int).Set(n))
+}
+
+// NewRationalSymbol crea la constante racional r, escrita p/q. Un racional
+// entero se guarda como entero.
+func NewRationalSymbol(r *big.Rat) *Symbol {
+	if r.IsInt() {
+		return NewIntegerSymbol(r.Num())
+	}
+	return newValueSymbol(r.String(), new(big.Rat).Set(r))
+}
+
+// NewDecimalSymbol crea la constante decimal d, escrita con el sufijo d.
+func NewDecimalSymbol(d Decimal) *Symbol {
+	return newValueSymbol(d.String()+"d", d)
+}
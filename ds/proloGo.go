@@ -1,6 +1,9 @@
 package ds
 
-import "fmt"
+import "github.com/devicemxl/nexusl/logging"
+
+// unifyLog registra la actividad del entorno de unificación.
+var unifyLog = logging.Logger(logging.Unify)
 
 // UnificationBinding representa una única ligadura de una variable a un valor dentro de un entorno.
 type UnificationBinding struct {
@@ -59,7 +62,7 @@ func (env *Environment) Backtrack() {
 	// desapilando solo las ligaduras hechas desde el último punto de elección.
 	// Aquí, simplemente vacía el Trail y el mapa para un ejemplo didáctico.
 	// Para un backtracking granular, necesitas un "checkpoint" en el Trail.
-	fmt.Println("INFO: Backtracking environment.")
+	unifyLog.Debug("backtracking environment", "bindings", len(env.Trail))
 	for _, bind := range env.Trail {
 		if bind.WasBound {
 			env.Bindings[bind.Variable.ID] = bind.OldValue
@@ -4,6 +4,8 @@ package ds
 import (
 	"fmt"
 	"sync"
+
+	"github.com/devicemxl/nexusl/logging"
 )
 
 // Global maps for symbol management
@@ -16,25 +18,25 @@ var (
 	// Símbolos predefinidos fundamentales para el motor de unificación
 	NullSymbol      *Symbol // Representa la lista vacía o el término nulo
 	AnonymousSymbol *Symbol // Representa la variable anónima (_)
+
+	dsLog = logging.Logger(logging.DS)
+	dbLog = logging.Logger(logging.DB)
 )
 
 func init() {
-	fmt.Println("INFO: ds/symbol.go init() started.")
+	dsLog.Debug("symbol table init started")
 	// --- IMPORTANTE: ELIMINAR ESTAS LÍNEAS DE LOCK/UNLOCK AQUÍ ---
 	// mu.Lock()
 	// defer mu.Unlock()
 	// -------------------------------------------------------------
 
-	fmt.Println("INFO: ds/symbol.go init() - Initializing nextID, SymbolsByID, SymbolsByPublicName.")
 	nextID = 1000 // Starting ID for symbols
 	SymbolsByID = make(map[SymbolID]*Symbol)
 	SymbolsByPublicName = make(map[string]*Symbol)
 
-	// --- Debugging NullSymbol creation ---
-	fmt.Println("INFO: ds/symbol.go init() - Creating NullSymbol.")
+	// --- Creación de NullSymbol ---
 	// NewSymbol() ya maneja su propio bloqueo, no necesitamos el bloqueo externo de init.
 	NullSymbol = NewSymbol()
-	fmt.Println("INFO: ds/symbol.go init() - NullSymbol created. Assigning properties.")
 	NullSymbol.PublicName = "nil"
 	NullSymbol.Thing = LiteralType
 	NullSymbol.LogicalType = LT_Null
@@ -44,20 +46,18 @@ func init() {
 	// o mover esta lógica a NewSymbol. Pero usualmente init se considera de un solo hilo.
 	// Para simplificar, asumiremos que init es de un solo hilo y las llamadas a NewSymbol son suficientes.
 	SymbolsByPublicName["nil"] = NullSymbol
-	fmt.Println("INFO: ds/symbol.go init() - NullSymbol fully initialized.")
+	dsLog.Debug("predefined symbol created", "name", "nil", "id", NullSymbol.ID)
 
-	// --- Debugging AnonymousSymbol creation ---
-	fmt.Println("INFO: ds/symbol.go init() - Creating AnonymousSymbol.")
+	// --- Creación de AnonymousSymbol ---
 	AnonymousSymbol = NewSymbol()
-	fmt.Println("INFO: ds/symbol.go init() - AnonymousSymbol created. Assigning properties.")
 	AnonymousSymbol.PublicName = "_"
 	AnonymousSymbol.Thing = IdentifierType
 	AnonymousSymbol.LogicalType = LT_Anonymous
 	AnonymousSymbol.State = Embodied
 	SymbolsByPublicName["_"] = AnonymousSymbol
-	fmt.Println("INFO: ds/symbol.go init() - AnonymousSymbol fully initialized.")
+	dsLog.Debug("predefined symbol created", "name", "_", "id", AnonymousSymbol.ID)
 
-	fmt.Println("INFO: ds/symbol.go init() finished.")
+	dsLog.Debug("symbol table init finished")
 }
 
 // SymbolID es el identificador único para un Símbolo.
@@ -191,6 +191,19 @@ func NewSymbol() *Symbol {
 	return s
 }
 
+// NewTermSymbol crea un símbolo con un ID propio, sin registrarlo en
+// SymbolsByID ni en SymbolsByPublicName. Es para los términos que crea el
+// motor lógico (átomos, números, variables renombradas, resultados): no
+// deben ocultar los símbolos del sistema con el mismo nombre ni vivir más que
+// quien los usa.
+func NewTermSymbol() *Symbol {
+	mu.Lock()
+	id := nextID
+	nextID++
+	mu.Unlock()
+	return &Symbol{ID: id, State: Exists, Thing: IdentifierType, LogicalType: LT_Undefined}
+}
+
 // NewSymbolWithPublicName es un helper para crear y asignar nombre de una vez.
 // Se inicializa con ThingType y LogicalType Undefined, a ser especificados.
 func NewSymbolWithPublicName(name string, thingType ThingType) *Symbol {
@@ -225,10 +238,24 @@ func NewConstantSymbol(name string, value interface{}) *Symbol {
 	return s
 }
 
+// newValueSymbol crea una constante de nombre name y valor value sin
+// registrarla: los números son valores y no se buscan por nombre, así que no
+// deben ocupar SymbolsByID ni tapar un nombre de SymbolsByPublicName.
+func newValueSymbol(name string, value interface{}) *Symbol {
+	s := NewTermSymbol()
+	s.PublicName = name
+	s.Thing = LiteralType
+	s.LogicalType = LT_Constant
+	s.Value = value
+	s.State = Embodied
+	return s
+}
+
 // NewListSymbol crea un nuevo Symbol que representa un par cons de lista.
 // `head` y `tail` deben ser *Symbol. Para una lista vacía, `tail` debe ser `NullSymbol`.
+// Un par es un valor, como los términos del motor: no se registra en SymbolsByID.
 func NewListSymbol(head, tail *Symbol) *Symbol {
-	s := NewSymbol()
+	s := NewTermSymbol()
 	s.Thing = LiteralType // O podrías definir un ThingType específico como "ListThing"
 	s.LogicalType = LT_List
 	s.Value = &ListPair{Head: head, Tail: tail}
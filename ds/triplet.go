@@ -22,6 +22,9 @@ type Triplet struct {
 	// Ejemplos: Symbol (para 'david'), []interface{} (para colecciones),
 	// Triplet (para tripletas anidadas como objetos).
 	Object interface{}
+	// Truth: Grado de verdad neutrosófico fermateano (ver truth.go). Es opcional;
+	// nil significa un hecho booleano, equivalente a TruthTrue.
+	Truth *Truth
 }
 
 // NewTriplet crea una nueva instancia de Triplet con los componentes dados.
@@ -36,6 +39,23 @@ func NewTriplet(s *Symbol, p interface{}, o interface{}, scope *Symbol) *Triplet
 	}
 }
 
+// SetTruth asigna un grado de verdad a la tripleta, comprobando que sea fermateano.
+func (t *Triplet) SetTruth(v Truth) error {
+	if !v.Valid() {
+		return fmt.Errorf("%w: %s", ErrNotFermatean, v)
+	}
+	t.Truth = &v
+	return nil
+}
+
+// Degree devuelve el grado de verdad de la tripleta; TruthTrue si no tiene uno asignado.
+func (t *Triplet) Degree() Truth {
+	if t.Truth == nil {
+		return TruthTrue
+	}
+	return *t.Truth
+}
+
 // formatInterfaceValue es una función auxiliar para formatear los valores de interface{}
 // de manera recursiva y legible para el método String().
 func formatInterfaceValue(val interface{}) string {
@@ -76,5 +96,8 @@ func (t *Triplet) String() string {
 		scopeStr = t.Scope.PublicName // Assuming Symbol has PublicName, or use Name if it's the public one
 	}
 
+	if t.Truth != nil {
+		return fmt.Sprintf("(%s %s %s) [%s] %s", subjectStr, predicateStr, objectStr, scopeStr, t.Truth)
+	}
 	return fmt.Sprintf("(%s %s %s) [%s]", subjectStr, predicateStr, objectStr, scopeStr)
 }
@@ -0,0 +1,271 @@
+// /nexusl/ds/truth.go
+// .
+// Valores de verdad neutrosóficos fermateanos.
+// .
+// Un hecho no tiene por qué ser simplemente verdadero o falso: cada tripleta
+// puede llevar un grado de verdad (T), de indeterminación (I) y de falsedad (F),
+// cada uno en [0,1] e independientes entre sí, con la restricción fermateana
+// T³ + I³ + F³ ≤ 1 (ver internal/trunKV/docs/8_FnEvaluation.md).
+// .
+package ds
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrNotFermatean indica que un grado (T, I, F) no cumple la restricción fermateana.
+var ErrNotFermatean = errors.New("ds: truth degree violates T³+I³+F³ <= 1")
+
+// fermateanTolerance absorbe el error de redondeo al comprobar la restricción.
+const fermateanTolerance = 1e-9
+
+// Truth es un valor de verdad neutrosófico fermateano.
+type Truth struct {
+	T float64 // Grado de verdad.
+	I float64 // Grado de indeterminación.
+	F float64 // Grado de falsedad.
+}
+
+// Valores de verdad de referencia. TruthMaybe y TruthUnknown corresponden a
+// los literales MAYBE y UNKNOWN del lenguaje: MAYBE aún es determinable,
+// UNKNOWN es ausencia total de conocimiento.
+var (
+	TruthTrue    = Truth{T: 1}
+	TruthFalse   = Truth{F: 1}
+	TruthMaybe   = Truth{T: 0.5, I: 0.5, F: 0.5}
+	TruthUnknown = Truth{I: 1}
+)
+
+// NewTruth crea un valor de verdad y comprueba que sea fermateano.
+func NewTruth(t, i, f float64) (Truth, error) {
+	v := Truth{T: t, I: i, F: f}
+	if !v.Valid() {
+		return Truth{}, fmt.Errorf("%w: %s", ErrNotFermatean, v)
+	}
+	return v, nil
+}
+
+// Valid indica si cada grado está en [0,1] y se cumple T³ + I³ + F³ ≤ 1.
+func (v Truth) Valid() bool {
+	for _, x := range []float64{v.T, v.I, v.F} {
+		if math.IsNaN(x) || x < 0 || x > 1 {
+			return false
+		}
+	}
+	return cube(v.T)+cube(v.I)+cube(v.F) <= 1+fermateanTolerance
+}
+
+// Normalize escala los tres grados para que cumplan la restricción fermateana.
+// Un valor que ya la cumple se devuelve sin cambios.
+func (v Truth) Normalize() Truth {
+	v.T, v.I, v.F = clamp01(v.T), clamp01(v.I), clamp01(v.F)
+	sum := cube(v.T) + cube(v.I) + cube(v.F)
+	if sum <= 1 {
+		return v
+	}
+	k := math.Cbrt(1 / sum)
+	return Truth{T: v.T * k, I: v.I * k, F: v.F * k}
+}
+
+// Score es la función de puntuación (2 + T³ − I³ − F³) / 3, en [0,1].
+// Sirve para ordenar creencias: más alto es más creíble.
+func (v Truth) Score() float64 {
+	return (2 + cube(v.T) - cube(v.I) - cube(v.F)) / 3
+}
+
+// Accuracy es la función de exactitud T³ − F³, en [-1,1]. Desempata valores con la misma puntuación.
+func (v Truth) Accuracy() float64 {
+	return cube(v.T) - cube(v.F)
+}
+
+// Compare ordena dos valores por puntuación y luego por exactitud.
+// Devuelve -1 si v es menos creíble que w, 1 si lo es más y 0 si son equivalentes.
+func (v Truth) Compare(w Truth) int {
+	if c := compareFloat(v.Score(), w.Score()); c != 0 {
+		return c
+	}
+	return compareFloat(v.Accuracy(), w.Accuracy())
+}
+
+// Dominates indica si v domina a w en el sentido de Pareto: no tiene menos
+// verdad ni más indeterminación ni más falsedad, y mejora en alguna de ellas.
+func (v Truth) Dominates(w Truth) bool {
+	if v.T < w.T || v.I > w.I || v.F > w.F {
+		return false
+	}
+	return v.T > w.T || v.I < w.I || v.F < w.F
+}
+
+// Distance es la divergencia |ΔT| + |ΔI| + |ΔF| entre dos valores.
+func (v Truth) Distance(w Truth) float64 {
+	return math.Abs(v.T-w.T) + math.Abs(v.I-w.I) + math.Abs(v.F-w.F)
+}
+
+// String devuelve el valor como <T, I, F>.
+func (v Truth) String() string {
+	return fmt.Sprintf("<%.3g, %.3g, %.3g>", v.T, v.I, v.F)
+}
+
+func cube(x float64) float64 { return x * x * x }
+
+func clamp01(x float64) float64 {
+	switch {
+	case math.IsNaN(x) || x < 0:
+		return 0
+	case x > 1:
+		return 1
+	}
+	return x
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b-fermateanTolerance:
+		return -1
+	case a > b+fermateanTolerance:
+		return 1
+	}
+	return 0
+}
+
+// TruthValue es un valor de verdad trivaluado de tiempo de ejecución.
+// TV_Maybe y TV_Unknown ocupan el mismo lugar intermedio en las conectivas,
+// pero conservan su diferencia: MAYBE aún se puede determinar (por ejemplo,
+// un sensor que todavía no responde) y UNKNOWN indica que no hay conocimiento.
+type TruthValue int
+
+const (
+	TV_False   TruthValue = iota // Se sabe que es falso.
+	TV_Maybe                     // Aún no se sabe, pero es determinable.
+	TV_Unknown                   // No hay conocimiento.
+	TV_True                      // Se sabe que es verdadero.
+)
+
+// String devuelve el literal del lenguaje para el valor.
+func (v TruthValue) String() string {
+	switch v {
+	case TV_False:
+		return "false"
+	case TV_Maybe:
+		return "maybe"
+	case TV_Unknown:
+		return "unknown"
+	case TV_True:
+		return "true"
+	default:
+		return fmt.Sprintf("UnknownTruthValue(%d)", v)
+	}
+}
+
+// level devuelve la posición del valor en la escala de Łukasiewicz {0, ½, 1}.
+func (v TruthValue) level() float64 {
+	switch v {
+	case TV_True:
+		return 1
+	case TV_False:
+		return 0
+	}
+	return 0.5
+}
+
+// Indeterminate indica si el valor es TV_Maybe o TV_Unknown.
+func (v TruthValue) Indeterminate() bool {
+	return v == TV_Maybe || v == TV_Unknown
+}
+
+// Degree traduce el valor a un grado fermateano.
+func (v TruthValue) Degree() Truth {
+	switch v {
+	case TV_True:
+		return TruthTrue
+	case TV_False:
+		return TruthFalse
+	case TV_Maybe:
+		return TruthMaybe
+	}
+	return TruthUnknown
+}
+
+// Value reduce un grado fermateano a un valor trivaluado: verdadero o falso si
+// ese grado predomina y supera ½; UNKNOWN si predomina la indeterminación; MAYBE en otro caso.
+func (v Truth) Value() TruthValue {
+	switch {
+	case v.T >= 0.5 && v.T > v.F && v.T > v.I:
+		return TV_True
+	case v.F >= 0.5 && v.F > v.T && v.F > v.I:
+		return TV_False
+	case v.I >= 0.5 && v.I >= v.T && v.I >= v.F:
+		return TV_Unknown
+	}
+	return TV_Maybe
+}
+
+// Logic selecciona la familia de conectivas trivaluadas.
+type Logic int
+
+const (
+	Kleene      Logic = iota // Lógica fuerte de Kleene (K3).
+	Lukasiewicz              // Lógica de Łukasiewicz (Ł3): solo cambia la implicación.
+)
+
+// String devuelve el nombre de la lógica.
+func (l Logic) String() string {
+	switch l {
+	case Kleene:
+		return "kleene"
+	case Lukasiewicz:
+		return "lukasiewicz"
+	default:
+		return fmt.Sprintf("UnknownLogic(%d)", l)
+	}
+}
+
+// Not es la negación: intercambia verdadero y falso y deja intacto un valor indeterminado.
+func (l Logic) Not(a TruthValue) TruthValue {
+	switch a {
+	case TV_True:
+		return TV_False
+	case TV_False:
+		return TV_True
+	}
+	return a
+}
+
+// And es la conjunción (mínimo).
+func (l Logic) And(a, b TruthValue) TruthValue {
+	return fromLevel(math.Min(a.level(), b.level()), a, b)
+}
+
+// Or es la disyunción (máximo).
+func (l Logic) Or(a, b TruthValue) TruthValue {
+	return fromLevel(math.Max(a.level(), b.level()), a, b)
+}
+
+// Imply es la implicación. En Kleene equivale a ¬a ∨ b; en Łukasiewicz es
+// min(1, 1 − a + b), de modo que un valor indeterminado se implica a sí mismo.
+func (l Logic) Imply(a, b TruthValue) TruthValue {
+	if l == Lukasiewicz {
+		return fromLevel(math.Min(1, 1-a.level()+b.level()), a, b)
+	}
+	return l.Or(l.Not(a), b)
+}
+
+// fromLevel convierte un nivel de la escala en un valor. Un resultado
+// intermedio es TV_Maybe solo si ningún operando era TV_Unknown: la falta
+// total de conocimiento domina sobre lo que aún es determinable.
+func fromLevel(x float64, operands ...TruthValue) TruthValue {
+	switch x {
+	case 1:
+		return TV_True
+	case 0:
+		return TV_False
+	}
+	for _, op := range operands {
+		if op == TV_Unknown {
+			return TV_Unknown
+		}
+	}
+	return TV_Maybe
+}
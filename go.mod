@@ -3,3 +3,5 @@ module github.com/devicemxl/nexusl
 go 1.24.3
 
 require github.com/mattn/go-sqlite3 v1.14.28
+
+require golang.org/x/text v0.34.0
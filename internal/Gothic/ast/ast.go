@@ -2,7 +2,9 @@ package ast
 
 import (
 	"fmt"
+	"math/big"
 	"strings"
+	"unicode"
 
 	"github.com/devicemxl/nexusl/ds" // Asumimos que ds ya define Symbol y ThingType
 	"github.com/devicemxl/nexusl/internal/Gothic/token"
@@ -46,11 +48,29 @@ func (p *Program) String() string {
 	return out.String()
 }
 
+// DocComment devuelve el texto de las líneas '///' consecutivas que terminan
+// justo en la línea anterior a tok, sin el marcador ni el primer espacio. Un
+// comentario de otro tipo o una línea en blanco corta la documentación.
+func DocComment(tok token.Token) string {
+	var lines []string
+	line := tok.Line - 1
+	for i := len(tok.Leading) - 1; i >= 0; i-- {
+		c := tok.Leading[i]
+		if c.Type != token.DOC_COMMENT || c.Line != line {
+			break
+		}
+		lines = append([]string{strings.TrimPrefix(strings.TrimPrefix(c.Text, "///"), " ")}, lines...)
+		line--
+	}
+	return strings.Join(lines, "\n")
+}
+
 // --- Nodos Específicos para `fact Car is symbol;` ---
 
 // FactStatement representa una declaración de `fact` atómica.
 type FactStatement struct {
 	Token     token.Token // El token 'fact'
+	Doc       string      // Comentario de documentación (///) de la sentencia
 	Scope     *ds.Symbol  // Referencia al Symbol del scope "fact"
 	Subject   Expression
 	Predicate Expression // <--- ¡CAMBIO CLAVE AQUÍ! Ahora es Expression
@@ -63,6 +83,143 @@ func (fs *FactStatement) String() string {
 	return fmt.Sprintf("%s %s %s %s;", fs.TokenLiteral(), fs.Subject.String(), fs.Predicate.String(), fs.Object.String())
 }
 
+// RuleStatement representa una regla: 'rule Cabeza if Condición and ...;',
+// donde la cabeza y cada condición son tripletas. La cabeza se cree cuando se
+// cumplen todas las condiciones con los mismos valores de sus variables:
+//
+//	rule ?d is unsafe if ?d is door and ?d has alarm_off;
+type RuleStatement struct {
+	Token token.Token // El token 'rule'
+	Doc   string      // Comentario de documentación (///) de la sentencia
+	Scope *ds.Symbol  // Referencia al Symbol del scope "rule"
+	Head  *Triple
+	Body  []*Triple // Las condiciones, en orden
+}
+
+func (rs *RuleStatement) statementNode()       {}
+func (rs *RuleStatement) TokenLiteral() string { return rs.Token.Word }
+func (rs *RuleStatement) String() string {
+	parts := []string{rs.TokenLiteral(), rs.Head.String()}
+	for _, c := range rs.Body {
+		parts = append(parts, c.String())
+	}
+	return strings.Join(parts, " ") + ";"
+}
+
+// Triple es una tripleta 'Sujeto Predicado Objeto' de una regla. En el cuerpo
+// Token es la palabra que la introduce ('if' o 'and'); en la cabeza está vacío.
+type Triple struct {
+	Token     token.Token
+	Subject   Expression
+	Predicate Expression
+	Object    Expression
+}
+
+func (t *Triple) String() string {
+	s := fmt.Sprintf("%s %s %s", t.Subject.String(), t.Predicate.String(), t.Object.String())
+	if t.Token.Word != "" {
+		s = t.Token.Word + " " + s
+	}
+	return s
+}
+
+// RetractStatement representa una declaración `retract Subject Predicate Object;`,
+// que quita un hecho base y todo lo que se derivó de él.
+type RetractStatement struct {
+	Token     token.Token // El token 'retract'
+	Doc       string      // Comentario de documentación (///) de la sentencia
+	Subject   Expression
+	Predicate Expression
+	Object    Expression
+}
+
+func (rs *RetractStatement) statementNode()       {}
+func (rs *RetractStatement) TokenLiteral() string { return rs.Token.Word }
+func (rs *RetractStatement) String() string {
+	return fmt.Sprintf("%s %s %s %s;", rs.TokenLiteral(), rs.Subject.String(), rs.Predicate.String(), rs.Object.String())
+}
+
+// ExplainStatement representa una declaración `explain Subject Predicate Object;`,
+// que demuestra la tripleta y muestra su árbol de prueba.
+type ExplainStatement struct {
+	Token     token.Token // El token 'explain'
+	Doc       string      // Comentario de documentación (///) de la sentencia
+	Subject   Expression
+	Predicate Expression
+	Object    Expression
+}
+
+func (es *ExplainStatement) statementNode()       {}
+func (es *ExplainStatement) TokenLiteral() string { return es.Token.Word }
+func (es *ExplainStatement) String() string {
+	return fmt.Sprintf("%s %s %s %s;", es.TokenLiteral(), es.Subject.String(), es.Predicate.String(), es.Object.String())
+}
+
+// TraceStatement representa una declaración `trace Predicate;`, que activa el
+// trazado de las llamadas a ese predicado.
+type TraceStatement struct {
+	Token     token.Token // El token 'trace'
+	Doc       string      // Comentario de documentación (///) de la sentencia
+	Predicate Expression
+}
+
+func (ts *TraceStatement) statementNode()       {}
+func (ts *TraceStatement) TokenLiteral() string { return ts.Token.Word }
+func (ts *TraceStatement) String() string {
+	return fmt.Sprintf("%s %s;", ts.TokenLiteral(), ts.Predicate.String())
+}
+
+// TableStatement representa una declaración `table Predicate/Arity;`, que
+// resuelve las llamadas a ese predicado con tabulación.
+type TableStatement struct {
+	Token     token.Token // El token 'table'
+	Doc       string      // Comentario de documentación (///) de la sentencia
+	Predicate Expression
+	Arity     Expression // Un *IntegerLiteral
+}
+
+func (ts *TableStatement) statementNode()       {}
+func (ts *TableStatement) TokenLiteral() string { return ts.Token.Word }
+func (ts *TableStatement) String() string {
+	return fmt.Sprintf("%s %s/%s;", ts.TokenLiteral(), ts.Predicate.String(), ts.Arity.String())
+}
+
+// AlgebraStatement representa una operación de álgebra simbólica:
+// `simplify E;`, `expand E;`, `derive E X;`, `substitute E X V;` o `eq_solve Eq X;`.
+type AlgebraStatement struct {
+	Token     token.Token // La palabra clave de la operación
+	Doc       string      // Comentario de documentación (///) de la sentencia
+	Arguments []Expression
+}
+
+func (as *AlgebraStatement) statementNode()       {}
+func (as *AlgebraStatement) TokenLiteral() string { return as.Token.Word }
+func (as *AlgebraStatement) String() string {
+	parts := []string{as.TokenLiteral()}
+	for _, a := range as.Arguments {
+		parts = append(parts, a.String())
+	}
+	return strings.Join(parts, " ") + ";"
+}
+
+// BadStatement representa una sentencia con errores de sintaxis. El parser la
+// conserva, con todos sus tokens, para que las herramientas (formateador,
+// editor) sigan trabajando sobre un archivo roto.
+type BadStatement struct {
+	Token  token.Token   // El primer token de la sentencia
+	Tokens []token.Token // Sus tokens, incluido el ';' final si lo tenía
+}
+
+func (bs *BadStatement) statementNode()       {}
+func (bs *BadStatement) TokenLiteral() string { return bs.Token.Word }
+func (bs *BadStatement) String() string {
+	words := make([]string, len(bs.Tokens))
+	for i, t := range bs.Tokens {
+		words[i] = t.Word
+	}
+	return strings.Join(words, " ")
+}
+
 // Identifier representa un identificador (como "Car" o "symbol")
 type Identifier struct {
 	Token token.Token // El token IDENTIFIER
@@ -73,6 +230,16 @@ func (i *Identifier) expressionNode()      {}
 func (i *Identifier) TokenLiteral() string { return i.Token.Word }
 func (i *Identifier) String() string       { return i.Value }
 
+// Variable representa una variable lógica de una regla, como "?d".
+type Variable struct {
+	Token token.Token // El token VARIABLE
+	Name  string      // El nombre, sin el '?'
+}
+
+func (v *Variable) expressionNode()      {}
+func (v *Variable) TokenLiteral() string { return v.Token.Word }
+func (v *Variable) String() string       { return "?" + v.Name }
+
 // Simplemente para que puedas ver los valores en el AST
 // Más adelante, "symbol" no será solo un Identifier, sino un Symbol Type.
 // Esto es para que el parser pueda manejarlo por ahora.
@@ -89,13 +256,45 @@ type StringLiteral struct {
 
 func (sl *StringLiteral) expressionNode()      {}
 func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Word }
-func (sl *StringLiteral) String() string       { return fmt.Sprintf("%q", sl.Value) } // Formatea con comillas para visualización
+func (sl *StringLiteral) String() string       { return Quote(sl.Value) }
 
-// IntegerLiteral representa un valor numérico entero literal.
-// Ej: 123, 42
+// Quote escribe s como un literal de cadena que el lexer lee de vuelta igual:
+// entre comillas dobles, con los escapes \n \t \r \0 \\ \" y \u{hex} para
+// el resto de los caracteres no imprimibles.
+func Quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\r':
+			b.WriteString(`\r`)
+		case 0:
+			b.WriteString(`\0`)
+		default:
+			if unicode.IsPrint(r) {
+				b.WriteRune(r)
+			} else {
+				fmt.Fprintf(&b, `\u{%x}`, r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// IntegerLiteral representa un valor numérico entero literal, de cualquier tamaño.
+// Ej: 123, 42, 12345678901234567890
 type IntegerLiteral struct {
 	Token token.Token // El token INT
-	Value int64       // El valor entero (ej. 123)
+	Value *big.Int    // El valor entero (ej. 123)
 }
 
 func (il *IntegerLiteral) expressionNode()      {}
@@ -113,6 +312,28 @@ func (fl *FloatLiteral) expressionNode()      {}
 func (fl *FloatLiteral) TokenLiteral() string { return fl.Token.Word }
 func (fl *FloatLiteral) String() string       { return fl.Token.Word } // Devuelve la representación en cadena del número
 
+// DecimalLiteral representa un valor decimal exacto literal.
+// Ej: 19.99d, 100d
+type DecimalLiteral struct {
+	Token token.Token // El token DECIMAL
+	Value ds.Decimal  // El valor exacto (ej. 1999 con escala 2)
+}
+
+func (dl *DecimalLiteral) expressionNode()      {}
+func (dl *DecimalLiteral) TokenLiteral() string { return dl.Token.Word }
+func (dl *DecimalLiteral) String() string       { return dl.Value.String() + "d" }
+
+// ComplexLiteral representa un número complejo literal.
+// Ej: 3i, 2+3i, -1.5-0.5i
+type ComplexLiteral struct {
+	Token token.Token // El token COMPLEX (o el '-' que lo precede)
+	Value complex128  // El valor complejo (ej. 2+3i)
+}
+
+func (cl *ComplexLiteral) expressionNode()      {}
+func (cl *ComplexLiteral) TokenLiteral() string { return cl.Token.Word }
+func (cl *ComplexLiteral) String() string       { return ds.FormatComplex(cl.Value) }
+
 // BooleanLiteral representa un valor booleano literal.
 // Ej: true, false
 type BooleanLiteral struct {
@@ -123,3 +344,21 @@ type BooleanLiteral struct {
 func (bl *BooleanLiteral) expressionNode()      {}
 func (bl *BooleanLiteral) TokenLiteral() string { return bl.Token.Word }
 func (bl *BooleanLiteral) String() string       { return bl.Token.Word } // Devuelve "true" o "false"
+
+// SExpression representa una expresión entre paréntesis con el operador
+// primero, como (+ x (* 2 y)) o (sin x).
+type SExpression struct {
+	Token     token.Token // El token '('
+	Operator  token.Token // El operador o la función
+	Arguments []Expression
+}
+
+func (se *SExpression) expressionNode()      {}
+func (se *SExpression) TokenLiteral() string { return se.Token.Word }
+func (se *SExpression) String() string {
+	parts := []string{se.Operator.Word}
+	for _, a := range se.Arguments {
+		parts = append(parts, a.String())
+	}
+	return "(" + strings.Join(parts, " ") + ")"
+}
@@ -0,0 +1,137 @@
+package ast_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/devicemxl/nexusl/ds"
+	"github.com/devicemxl/nexusl/internal/Gothic/ast"
+	"github.com/devicemxl/nexusl/internal/Gothic/lexer"
+	"github.com/devicemxl/nexusl/internal/Gothic/metamodel"
+	"github.com/devicemxl/nexusl/internal/Gothic/parser"
+)
+
+const src = "/// Un coche.\n" +
+	"fact Car is symbol; // nota\n" +
+	"fact serial id 12345678901234567890;\n" +
+	"fact price value 19.99d;\n" +
+	"fact v phasor 2+3i;\n" +
+	"fact ok flag true;\n" +
+	"fact name label \"año\\n\";\n" +
+	"retract Car is symbol;\n" +
+	"explain Car has 1.5;\n" +
+	"trace is;\n" +
+	"table path/2;\n" +
+	"rule ?d is unsafe if ?d is door and ?d has alarm_off;\n" +
+	"derive (+ (* 2 x) (sin x)) x;\n" +
+	"fact broken;\n"
+
+func parse(t *testing.T) *ast.Program {
+	t.Helper()
+	// Los scopes vienen normalmente de la base de definiciones.
+	for _, name := range []string{"fact", "rule"} {
+		if _, ok := ds.LookupSymbolByPublicName(name); !ok {
+			ds.NewSymbolWithPublicName(name, ds.TripletScopeType)
+		}
+	}
+	p := parser.New(lexer.New(src), metamodel.NewMetamodelFacade())
+	prog := p.ParseProgram()
+	if len(p.Errors()) != 1 {
+		t.Fatalf("parser errors = %v, want only the broken fact", p.Errors())
+	}
+	return prog
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	prog := parse(t)
+	data, err := json.Marshal(prog)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	for _, want := range []string{
+		`"kind":"FactStatement","token":{"type":"FACT","word":"fact","line":2,"column":1,"endLine":2,"endColumn":5,"leading":[{"type":"///","text":"/// Un coche.","line":1,"column":1}]},"doc":"Un coche.","scope":{"id":`,
+		`"kind":"IntegerLiteral"`, `"value":"12345678901234567890"`,
+		`"value":"19.99"`, `"value":[2,3]`,
+		`"kind":"RuleStatement"`, `"kind":"Variable"`, `"kind":"TableStatement"`,
+		`"kind":"BadStatement"`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("JSON does not contain %s:\n%s", want, data)
+		}
+	}
+
+	var back ast.Program
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(&back, prog) {
+		t.Errorf("round trip changed the program:\n%s\nwant\n%s", back.String(), prog.String())
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		data string
+		want error
+	}{
+		{`{"kind":"Nope"}`, ast.ErrUnknownKind},
+		{`{"kind":"FactStatement","scope":{"name":"nope"}}`, ast.ErrUnknownScope},
+		{`{"kind":"TraceStatement"}`, ast.ErrMissingNode},
+		{`{"kind":"IntegerLiteral","value":"1.5"}`, ast.ErrBadValue},
+		{`{"kind":"Program","statements":[{"kind":"Identifier","value":"x"}]}`, ast.ErrUnknownKind},
+	}
+	for _, tt := range tests {
+		if _, err := ast.UnmarshalNode([]byte(tt.data)); !errors.Is(err, tt.want) {
+			t.Errorf("UnmarshalNode(%s) = %v, want %v", tt.data, err, tt.want)
+		}
+	}
+}
+
+func TestInspectAndRewrite(t *testing.T) {
+	prog := parse(t)
+
+	var idents []string
+	ast.Inspect(prog, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Identifier); ok {
+			idents = append(idents, id.Value)
+		}
+		// No entrar en las expresiones S.
+		_, isSExpr := n.(*ast.SExpression)
+		return !isSExpr
+	})
+	want := []string{"Car", "is", "symbol", "serial", "id", "price", "value", "v", "phasor",
+		"ok", "flag", "name", "label", "Car", "is", "symbol", "Car", "has", "is", "path",
+		"is", "unsafe", "is", "door", "has", "alarm_off", "x"}
+	if !reflect.DeepEqual(idents, want) {
+		t.Errorf("identifiers = %v, want %v", idents, want)
+	}
+
+	// Renombrar Car y quitar las sentencias trace y las rotas.
+	ast.Rewrite(prog, func(n ast.Node) ast.Node {
+		switch n := n.(type) {
+		case *ast.Identifier:
+			if n.Value == "Car" {
+				return &ast.Identifier{Token: n.Token, Value: "Auto"}
+			}
+		case *ast.TraceStatement, *ast.BadStatement:
+			return nil
+		}
+		return n
+	})
+	var lines []string
+	for _, s := range prog.Statements {
+		lines = append(lines, s.String())
+	}
+	got := strings.Join(lines, "\n")
+	for _, want := range []string{"fact Auto is symbol;", "retract Auto is symbol;", "derive (+ (* 2 x) (sin x)) x;"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("rewritten program lacks %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "trace") || strings.Contains(got, "broken") {
+		t.Errorf("rewritten program still has trace or broken statements:\n%s", got)
+	}
+}
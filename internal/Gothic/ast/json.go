@@ -0,0 +1,370 @@
+// Gothic/ast/json.go
+//
+// Serialización del AST a JSON, para herramientas y agentes escritos en otros
+// lenguajes. Cada nodo es un objeto con "kind" (el nombre del tipo Go, como
+// "FactStatement") y sus campos; los tokens llevan su posición, su fin y sus
+// comentarios, así que la ida y vuelta no pierde nada:
+//
+//	{"kind": "FactStatement",
+//	 "token": {"type": "FACT", "word": "fact", "line": 1, "column": 1, ...},
+//	 "scope": {"id": 1002, "name": "fact", "thing": "TripletScope"},
+//	 "subject": {"kind": "Identifier", "token": {...}, "value": "Car"}, ...}
+//
+// Los valores de los literales van en "value": los enteros y decimales como
+// cadenas (no caben en un número de JSON sin perder precisión) y los
+// complejos como [real, imaginaria]. Al leer, el scope se resuelve por nombre
+// entre los símbolos de ds.
+package ast
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/devicemxl/nexusl/ds"
+	"github.com/devicemxl/nexusl/internal/Gothic/token"
+)
+
+// Errores de UnmarshalNode.
+var (
+	ErrUnknownKind  = errors.New("ast: unknown node kind")
+	ErrUnknownScope = errors.New("ast: unknown scope")
+	ErrBadValue     = errors.New("ast: bad literal value")
+	ErrMissingNode  = errors.New("ast: missing node")
+)
+
+// jsonNode es la forma en JSON de cualquier nodo; cada tipo usa sus campos.
+type jsonNode struct {
+	Kind       string          `json:"kind"`
+	Token      *token.Token    `json:"token,omitempty"`
+	Doc        string          `json:"doc,omitempty"`
+	Scope      *jsonScope      `json:"scope,omitempty"`
+	Statements []*jsonNode     `json:"statements,omitempty"`
+	Subject    *jsonNode       `json:"subject,omitempty"`
+	Predicate  *jsonNode       `json:"predicate,omitempty"`
+	Object     *jsonNode       `json:"object,omitempty"`
+	Head       *jsonNode       `json:"head,omitempty"`
+	Body       []*jsonNode     `json:"body,omitempty"`
+	Arity      *jsonNode       `json:"arity,omitempty"`
+	Operator   *token.Token    `json:"operator,omitempty"`
+	Arguments  []*jsonNode     `json:"arguments,omitempty"`
+	Tokens     []token.Token   `json:"tokens,omitempty"`
+	Value      json.RawMessage `json:"value,omitempty"`
+}
+
+// jsonScope es el símbolo del scope de un FactStatement o un RuleStatement.
+type jsonScope struct {
+	ID    ds.SymbolID  `json:"id"`
+	Name  string       `json:"name"`
+	Thing ds.ThingType `json:"thing"`
+}
+
+// MarshalNode devuelve node en JSON.
+func MarshalNode(node Node) ([]byte, error) {
+	j, err := encode(node)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalNode lee un nodo escrito por MarshalNode.
+func UnmarshalNode(data []byte) (Node, error) {
+	var j jsonNode
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, err
+	}
+	return decode(&j)
+}
+
+// MarshalJSON escribe el programa con MarshalNode.
+func (p *Program) MarshalJSON() ([]byte, error) {
+	return MarshalNode(p)
+}
+
+// UnmarshalJSON lee un programa escrito con MarshalJSON.
+func (p *Program) UnmarshalJSON(data []byte) error {
+	n, err := UnmarshalNode(data)
+	if err != nil {
+		return err
+	}
+	prog, ok := n.(*Program)
+	if !ok {
+		return fmt.Errorf("%w: %T is not a Program", ErrUnknownKind, n)
+	}
+	*p = *prog
+	return nil
+}
+
+func encode(node Node) (*jsonNode, error) {
+	var err error
+	expr := func(e Expression) *jsonNode {
+		if err != nil {
+			return nil
+		}
+		var j *jsonNode
+		j, err = encode(e)
+		return j
+	}
+	exprs := func(es []Expression) []*jsonNode {
+		out := make([]*jsonNode, len(es))
+		for i, e := range es {
+			out[i] = expr(e)
+		}
+		return out
+	}
+	triple := func(t *Triple) *jsonNode {
+		tj := &jsonNode{Kind: "Triple", Subject: expr(t.Subject), Predicate: expr(t.Predicate), Object: expr(t.Object)}
+		if t.Token.Word != "" {
+			tj.Token = &t.Token
+		}
+		return tj
+	}
+	value := func(v any) json.RawMessage {
+		raw, merr := json.Marshal(v)
+		if err == nil {
+			err = merr
+		}
+		return raw
+	}
+
+	j := &jsonNode{}
+	switch n := node.(type) {
+	case *Program:
+		j.Kind = "Program"
+		for _, s := range n.Statements {
+			sj, serr := encode(s)
+			if serr != nil {
+				return nil, serr
+			}
+			j.Statements = append(j.Statements, sj)
+		}
+	case *FactStatement:
+		j.Kind, j.Token, j.Doc = "FactStatement", &n.Token, n.Doc
+		if n.Scope != nil {
+			j.Scope = &jsonScope{ID: n.Scope.ID, Name: n.Scope.PublicName, Thing: n.Scope.Thing}
+		}
+		j.Subject, j.Predicate, j.Object = expr(n.Subject), expr(n.Predicate), expr(n.Object)
+	case *RuleStatement:
+		j.Kind, j.Token, j.Doc = "RuleStatement", &n.Token, n.Doc
+		if n.Scope != nil {
+			j.Scope = &jsonScope{ID: n.Scope.ID, Name: n.Scope.PublicName, Thing: n.Scope.Thing}
+		}
+		j.Head = triple(n.Head)
+		for _, c := range n.Body {
+			j.Body = append(j.Body, triple(c))
+		}
+	case *RetractStatement:
+		j.Kind, j.Token, j.Doc = "RetractStatement", &n.Token, n.Doc
+		j.Subject, j.Predicate, j.Object = expr(n.Subject), expr(n.Predicate), expr(n.Object)
+	case *ExplainStatement:
+		j.Kind, j.Token, j.Doc = "ExplainStatement", &n.Token, n.Doc
+		j.Subject, j.Predicate, j.Object = expr(n.Subject), expr(n.Predicate), expr(n.Object)
+	case *TraceStatement:
+		j.Kind, j.Token, j.Doc = "TraceStatement", &n.Token, n.Doc
+		j.Predicate = expr(n.Predicate)
+	case *TableStatement:
+		j.Kind, j.Token, j.Doc = "TableStatement", &n.Token, n.Doc
+		j.Predicate, j.Arity = expr(n.Predicate), expr(n.Arity)
+	case *AlgebraStatement:
+		j.Kind, j.Token, j.Doc = "AlgebraStatement", &n.Token, n.Doc
+		j.Arguments = exprs(n.Arguments)
+	case *BadStatement:
+		j.Kind, j.Token, j.Tokens = "BadStatement", &n.Token, n.Tokens
+	case *Identifier:
+		j.Kind, j.Token, j.Value = "Identifier", &n.Token, value(n.Value)
+	case *Variable:
+		j.Kind, j.Token, j.Value = "Variable", &n.Token, value(n.Name)
+	case *StringLiteral:
+		j.Kind, j.Token, j.Value = "StringLiteral", &n.Token, value(n.Value)
+	case *IntegerLiteral:
+		j.Kind, j.Token, j.Value = "IntegerLiteral", &n.Token, value(n.Value.String())
+	case *FloatLiteral:
+		j.Kind, j.Token, j.Value = "FloatLiteral", &n.Token, value(n.Value)
+	case *DecimalLiteral:
+		j.Kind, j.Token, j.Value = "DecimalLiteral", &n.Token, value(n.Value.String())
+	case *ComplexLiteral:
+		j.Kind, j.Token, j.Value = "ComplexLiteral", &n.Token, value([2]float64{real(n.Value), imag(n.Value)})
+	case *BooleanLiteral:
+		j.Kind, j.Token, j.Value = "BooleanLiteral", &n.Token, value(n.Value)
+	case *SExpression:
+		j.Kind, j.Token, j.Operator = "SExpression", &n.Token, &n.Operator
+		j.Arguments = exprs(n.Arguments)
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnknownKind, node)
+	}
+	return j, err
+}
+
+func decode(j *jsonNode) (Node, error) {
+	var err error
+	tok := func(t *token.Token) token.Token {
+		if t == nil {
+			return token.Token{}
+		}
+		return *t
+	}
+	expr := func(c *jsonNode) Expression {
+		if err != nil {
+			return nil
+		}
+		if c == nil {
+			err = fmt.Errorf("%w: expression in %s", ErrMissingNode, j.Kind)
+			return nil
+		}
+		n, derr := decode(c)
+		if derr != nil {
+			err = derr
+			return nil
+		}
+		e, ok := n.(Expression)
+		if !ok {
+			err = fmt.Errorf("%w: %s is not an expression", ErrUnknownKind, c.Kind)
+		}
+		return e
+	}
+	exprs := func(cs []*jsonNode) []Expression {
+		if cs == nil {
+			return nil
+		}
+		out := make([]Expression, len(cs))
+		for i, c := range cs {
+			out[i] = expr(c)
+		}
+		return out
+	}
+	triple := func(c *jsonNode) *Triple {
+		if c == nil {
+			if err == nil {
+				err = fmt.Errorf("%w: triple in %s", ErrMissingNode, j.Kind)
+			}
+			return nil
+		}
+		return &Triple{Token: tok(c.Token), Subject: expr(c.Subject), Predicate: expr(c.Predicate), Object: expr(c.Object)}
+	}
+	value := func(v any) {
+		if err == nil {
+			if uerr := json.Unmarshal(j.Value, v); uerr != nil {
+				err = fmt.Errorf("%w: %s: %w", ErrBadValue, j.Kind, uerr)
+			}
+		}
+	}
+
+	var node Node
+	switch j.Kind {
+	case "Program":
+		p := &Program{Statements: make([]Statement, 0, len(j.Statements))}
+		for _, c := range j.Statements {
+			n, derr := decode(c)
+			if derr != nil {
+				return nil, derr
+			}
+			s, ok := n.(Statement)
+			if !ok {
+				return nil, fmt.Errorf("%w: %s is not a statement", ErrUnknownKind, c.Kind)
+			}
+			p.Statements = append(p.Statements, s)
+		}
+		node = p
+	case "FactStatement":
+		s := &FactStatement{Token: tok(j.Token), Doc: j.Doc}
+		if s.Scope, err = scope(j.Scope); err != nil {
+			return nil, err
+		}
+		s.Subject, s.Predicate, s.Object = expr(j.Subject), expr(j.Predicate), expr(j.Object)
+		node = s
+	case "RuleStatement":
+		s := &RuleStatement{Token: tok(j.Token), Doc: j.Doc}
+		if s.Scope, err = scope(j.Scope); err != nil {
+			return nil, err
+		}
+		s.Head = triple(j.Head)
+		for _, c := range j.Body {
+			s.Body = append(s.Body, triple(c))
+		}
+		node = s
+	case "RetractStatement":
+		s := &RetractStatement{Token: tok(j.Token), Doc: j.Doc}
+		s.Subject, s.Predicate, s.Object = expr(j.Subject), expr(j.Predicate), expr(j.Object)
+		node = s
+	case "ExplainStatement":
+		s := &ExplainStatement{Token: tok(j.Token), Doc: j.Doc}
+		s.Subject, s.Predicate, s.Object = expr(j.Subject), expr(j.Predicate), expr(j.Object)
+		node = s
+	case "TraceStatement":
+		node = &TraceStatement{Token: tok(j.Token), Doc: j.Doc, Predicate: expr(j.Predicate)}
+	case "TableStatement":
+		node = &TableStatement{Token: tok(j.Token), Doc: j.Doc, Predicate: expr(j.Predicate), Arity: expr(j.Arity)}
+	case "AlgebraStatement":
+		node = &AlgebraStatement{Token: tok(j.Token), Doc: j.Doc, Arguments: exprs(j.Arguments)}
+	case "BadStatement":
+		node = &BadStatement{Token: tok(j.Token), Tokens: j.Tokens}
+	case "Identifier":
+		n := &Identifier{Token: tok(j.Token)}
+		value(&n.Value)
+		node = n
+	case "Variable":
+		n := &Variable{Token: tok(j.Token)}
+		value(&n.Name)
+		node = n
+	case "StringLiteral":
+		n := &StringLiteral{Token: tok(j.Token)}
+		value(&n.Value)
+		node = n
+	case "IntegerLiteral":
+		var s string
+		value(&s)
+		n := &IntegerLiteral{Token: tok(j.Token)}
+		if err == nil {
+			var ok bool
+			if n.Value, ok = new(big.Int).SetString(s, 10); !ok {
+				err = fmt.Errorf("%w: integer %q", ErrBadValue, s)
+			}
+		}
+		node = n
+	case "FloatLiteral":
+		n := &FloatLiteral{Token: tok(j.Token)}
+		value(&n.Value)
+		node = n
+	case "DecimalLiteral":
+		var s string
+		value(&s)
+		n := &DecimalLiteral{Token: tok(j.Token)}
+		if err == nil {
+			var derr error
+			if n.Value, derr = ds.ParseDecimal(s); derr != nil {
+				err = fmt.Errorf("%w: %w", ErrBadValue, derr)
+			}
+		}
+		node = n
+	case "ComplexLiteral":
+		var parts [2]float64
+		value(&parts)
+		node = &ComplexLiteral{Token: tok(j.Token), Value: complex(parts[0], parts[1])}
+	case "BooleanLiteral":
+		n := &BooleanLiteral{Token: tok(j.Token)}
+		value(&n.Value)
+		node = n
+	case "SExpression":
+		node = &SExpression{Token: tok(j.Token), Operator: tok(j.Operator), Arguments: exprs(j.Arguments)}
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKind, j.Kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// scope resuelve por nombre el scope de una sentencia; nil si no lo tiene.
+func scope(j *jsonScope) (*ds.Symbol, error) {
+	if j == nil {
+		return nil, nil
+	}
+	sym, ok := ds.LookupSymbolByPublicName(j.Name)
+	if !ok || sym.Thing != ds.TripletScopeType {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownScope, j.Name)
+	}
+	return sym, nil
+}
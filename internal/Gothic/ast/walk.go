@@ -0,0 +1,132 @@
+// Gothic/ast/walk.go
+package ast
+
+import "fmt"
+
+// Visitor recorre el AST con Walk: Visit se llama con cada nodo y, si
+// devuelve un Visitor w distinto de nil, Walk visita los hijos del nodo con w
+// y termina con w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk recorre node en profundidad, en el orden del código fuente.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+	for _, child := range children(node) {
+		Walk(v, child)
+	}
+	v.Visit(nil)
+}
+
+// inspector adapta una función a Visitor.
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect recorre node en profundidad llamando a f con cada nodo; si f
+// devuelve false no se visitan sus hijos. Tras los hijos de un nodo se llama
+// a f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
+
+// children devuelve los hijos directos de un nodo.
+func children(node Node) []Node {
+	var out []Node
+	switch n := node.(type) {
+	case *Program:
+		for _, s := range n.Statements {
+			out = append(out, s)
+		}
+	case *FactStatement:
+		out = append(out, n.Subject, n.Predicate, n.Object)
+	case *RuleStatement:
+		for _, t := range append([]*Triple{n.Head}, n.Body...) {
+			out = append(out, t.Subject, t.Predicate, t.Object)
+		}
+	case *RetractStatement:
+		out = append(out, n.Subject, n.Predicate, n.Object)
+	case *ExplainStatement:
+		out = append(out, n.Subject, n.Predicate, n.Object)
+	case *TraceStatement:
+		out = append(out, n.Predicate)
+	case *TableStatement:
+		out = append(out, n.Predicate, n.Arity)
+	case *AlgebraStatement:
+		for _, a := range n.Arguments {
+			out = append(out, a)
+		}
+	case *SExpression:
+		for _, a := range n.Arguments {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// Rewrite transforma node de abajo arriba: reemplaza cada nodo por lo que
+// devuelve f después de transformar sus hijos, y devuelve la nueva raíz. Los
+// nodos se modifican en su sitio. Si f devuelve nil para una sentencia de un
+// Program, la sentencia se quita; devolver nil en otro lugar, o un nodo que no
+// cabe donde estaba (una sentencia como expresión), es un error de
+// programación y provoca un panic.
+func Rewrite(node Node, f func(Node) Node) Node {
+	switch n := node.(type) {
+	case *Program:
+		stmts := n.Statements[:0]
+		for _, s := range n.Statements {
+			if r := Rewrite(s, f); r != nil {
+				stmts = append(stmts, mustStatement(r))
+			}
+		}
+		n.Statements = stmts
+	case *FactStatement:
+		n.Subject, n.Predicate, n.Object = rewriteExpr(n.Subject, f), rewriteExpr(n.Predicate, f), rewriteExpr(n.Object, f)
+	case *RuleStatement:
+		for _, t := range append([]*Triple{n.Head}, n.Body...) {
+			t.Subject, t.Predicate, t.Object = rewriteExpr(t.Subject, f), rewriteExpr(t.Predicate, f), rewriteExpr(t.Object, f)
+		}
+	case *RetractStatement:
+		n.Subject, n.Predicate, n.Object = rewriteExpr(n.Subject, f), rewriteExpr(n.Predicate, f), rewriteExpr(n.Object, f)
+	case *ExplainStatement:
+		n.Subject, n.Predicate, n.Object = rewriteExpr(n.Subject, f), rewriteExpr(n.Predicate, f), rewriteExpr(n.Object, f)
+	case *TraceStatement:
+		n.Predicate = rewriteExpr(n.Predicate, f)
+	case *TableStatement:
+		n.Predicate, n.Arity = rewriteExpr(n.Predicate, f), rewriteExpr(n.Arity, f)
+	case *AlgebraStatement:
+		for i, a := range n.Arguments {
+			n.Arguments[i] = rewriteExpr(a, f)
+		}
+	case *SExpression:
+		for i, a := range n.Arguments {
+			n.Arguments[i] = rewriteExpr(a, f)
+		}
+	}
+	return f(node)
+}
+
+func rewriteExpr(e Expression, f func(Node) Node) Expression {
+	r := Rewrite(e, f)
+	expr, ok := r.(Expression)
+	if !ok {
+		panic(fmt.Sprintf("ast.Rewrite: %T replaced by %T, want an Expression", e, r))
+	}
+	return expr
+}
+
+func mustStatement(n Node) Statement {
+	s, ok := n.(Statement)
+	if !ok {
+		panic(fmt.Sprintf("ast.Rewrite: statement replaced by %T, want a Statement", n))
+	}
+	return s
+}
@@ -0,0 +1,196 @@
+// Gothic/diag/diag.go
+//
+// # Diagnósticos estructurados del lenguaje
+//
+// El lexer, el parser y el intérprete informan de los problemas con un
+// Diagnostic: gravedad, un código estable, el tramo del código fuente, el
+// mensaje, notas y arreglos sugeridos. Un Diagnostic es también un error
+// (Unwrap devuelve el error de origen, así que errors.Is sigue funcionando)
+// y su texto conserva el formato de siempre: "Line 3, Column 7: mensaje".
+//
+// Render lo escribe para una terminal, con la línea afectada y carets bajo el
+// tramo; WriteJSON lo escribe para los editores.
+package diag
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/devicemxl/nexusl/internal/Gothic/token"
+)
+
+// Códigos estables. El prefijo indica quién informa: L el lexer, P el parser,
+// R el intérprete, F el formateador. Un código no cambia de significado nunca; si un problema
+// deja de existir, su código no se reutiliza.
+const (
+	UnterminatedLiteral = "L0001" // Literal de cadena o carácter sin cerrar
+	InvalidEscape       = "L0002" // Secuencia de escape desconocida o mal formada
+	UnexpectedChar      = "L0003" // Carácter que no empieza ningún token
+	UnterminatedComment = "L0004" // Comentario /* sin */
+	ReadError           = "L0005" // Error del io.Reader de entrada
+	UnknownLanguage     = "L0006" // Pragma //nexusl:lang con un idioma sin vocabulario
+	InvalidChar         = "L0007" // Literal de carácter vacío o con más de un carácter
+
+	UnexpectedToken = "P0001" // Token que no puede empezar una expresión
+	ExpectedToken   = "P0002" // Falta un token concreto (';', ')'...)
+	UnknownScope    = "P0003" // Palabra clave de sentencia sin scope en el metamodelo
+	WrongArity      = "P0004" // Operación con un número de argumentos incorrecto
+	InvalidNumber   = "P0005" // Literal numérico que no se puede interpretar
+
+	Unsupported   = "R0001" // Sentencia que el intérprete no sabe ejecutar
+	BadStatement  = "R0002" // Sentencia con errores de sintaxis
+	RuntimeFailed = "R0003" // Error al ejecutar la sentencia
+
+	Untranslatable = "F0001" // Identificador que es palabra clave en el idioma de destino
+)
+
+// Severity es la gravedad de un diagnóstico.
+type Severity int
+
+const (
+	Error Severity = iota + 1
+	Warning
+	Info
+	Hint
+)
+
+var severityNames = map[Severity]string{Error: "error", Warning: "warning", Info: "info", Hint: "hint"}
+
+func (s Severity) String() string {
+	if name, ok := severityNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("severity(%d)", int(s))
+}
+
+// MarshalJSON escribe la gravedad por su nombre: "error", "warning"...
+func (s Severity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// UnmarshalJSON lee la gravedad por su nombre.
+func (s *Severity) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	for sev, n := range severityNames {
+		if n == name {
+			*s = sev
+			return nil
+		}
+	}
+	return fmt.Errorf("diag: unknown severity %q", name)
+}
+
+// Position es una posición del código fuente: línea y columna 1-based, con
+// la columna contada en runas.
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Span es el tramo [Start, End) del código fuente. Un tramo vacío marca un
+// punto, por ejemplo donde falta un ';'.
+type Span struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// TokenSpan devuelve el tramo que ocupa tok.
+func TokenSpan(tok token.Token) Span {
+	end := Position{Line: tok.EndLine, Column: tok.EndColumn}
+	if end.Line == 0 {
+		end = Position{Line: tok.Line, Column: tok.Column} // Token sin fin conocido
+	}
+	return Span{Start: Position{Line: tok.Line, Column: tok.Column}, End: end}
+}
+
+// PointAfter devuelve el tramo vacío justo después de tok.
+func PointAfter(tok token.Token) Span {
+	end := TokenSpan(tok).End
+	return Span{Start: end, End: end}
+}
+
+// Fix es un arreglo sugerido: reemplazar Span por Replacement.
+type Fix struct {
+	Message     string `json:"message"`
+	Span        Span   `json:"span"`
+	Replacement string `json:"replacement"`
+}
+
+// Diagnostic es un problema del código fuente.
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	Code     string   `json:"code"`
+	Span     Span     `json:"span"`
+	Message  string   `json:"message"`
+	Notes    []string `json:"notes,omitempty"`
+	Fixes    []Fix    `json:"fixes,omitempty"`
+	Err      error    `json:"-"` // Error de origen, si lo hay
+}
+
+// Errorf crea un diagnóstico de gravedad Error.
+func Errorf(code string, span Span, format string, args ...any) Diagnostic {
+	return Diagnostic{Severity: Error, Code: code, Span: span, Message: fmt.Sprintf(format, args...)}
+}
+
+// Wrap crea un diagnóstico de gravedad Error a partir de err.
+func Wrap(code string, span Span, err error) Diagnostic {
+	return Diagnostic{Severity: Error, Code: code, Span: span, Message: err.Error(), Err: err}
+}
+
+// WithNote devuelve d con una nota más.
+func (d Diagnostic) WithNote(format string, args ...any) Diagnostic {
+	d.Notes = append(slices.Clip(d.Notes), fmt.Sprintf(format, args...))
+	return d
+}
+
+// WithFix devuelve d con un arreglo sugerido más.
+func (d Diagnostic) WithFix(message string, span Span, replacement string) Diagnostic {
+	d.Fixes = append(slices.Clip(d.Fixes), Fix{Message: message, Span: span, Replacement: replacement})
+	return d
+}
+
+// Error devuelve "Line L, Column C: mensaje".
+func (d Diagnostic) Error() string {
+	return fmt.Sprintf("Line %d, Column %d: %s", d.Span.Start.Line, d.Span.Start.Column, d.Message)
+}
+
+// Unwrap devuelve el error de origen.
+func (d Diagnostic) Unwrap() error { return d.Err }
+
+// Sort ordena los diagnósticos por posición, conservando el orden de los que
+// empiezan en el mismo sitio.
+func Sort(diags []Diagnostic) {
+	slices.SortStableFunc(diags, func(a, b Diagnostic) int {
+		return cmp.Or(cmp.Compare(a.Span.Start.Line, b.Span.Start.Line), cmp.Compare(a.Span.Start.Column, b.Span.Start.Column))
+	})
+}
+
+// HasErrors informa si algún diagnóstico tiene gravedad Error.
+func HasErrors(diags []Diagnostic) bool {
+	return slices.ContainsFunc(diags, func(d Diagnostic) bool { return d.Severity == Error })
+}
+
+// Strings devuelve el texto (Error) de cada diagnóstico.
+func Strings(diags []Diagnostic) []string {
+	out := make([]string, len(diags))
+	for i, d := range diags {
+		out[i] = d.Error()
+	}
+	return out
+}
+
+// WriteJSON escribe los diagnósticos como un array JSON, uno por elemento.
+func WriteJSON(w io.Writer, diags []Diagnostic) error {
+	if diags == nil {
+		diags = []Diagnostic{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(diags)
+}
@@ -0,0 +1,95 @@
+package diag_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/devicemxl/nexusl/internal/Gothic/diag"
+	"github.com/devicemxl/nexusl/internal/Gothic/lexer"
+	"github.com/devicemxl/nexusl/internal/Gothic/parser"
+)
+
+func TestRenderAndJSON(t *testing.T) {
+	src := "explain door is open;\n" +
+		"explain c d e f;\n" +
+		"explain x \"sin cerrar\n" +
+		"explain\tñu is \"a\\q\";"
+	p := parser.New(lexer.New(src), nil)
+	p.ParseProgram()
+	diags := p.Diagnostics()
+	if len(diags) != 3 {
+		t.Fatalf("diagnostics = %q, want 3", diag.Strings(diags))
+	}
+
+	var out strings.Builder
+	if err := diag.RenderAll(&out, "hechos.nxl", src, diags); err != nil {
+		t.Fatalf("RenderAll: %v", err)
+	}
+	want := `error[P0002]: Expected next token to be ;, got IDENTIFIER instead ("f")
+ --> hechos.nxl:2:15
+  |
+2 | explain c d e f;
+  |               ^
+  = help: insert ';': ;
+
+error[L0001]: lexer: unterminated literal: missing closing "
+ --> hechos.nxl:3:11
+  |
+3 | explain x "sin cerrar
+  |           ^^^^^^^^^^^
+  = note: a "..." string cannot span lines; use """...""" or a raw string
+  = help: close the literal: "
+
+error[L0002]: lexer: invalid escape sequence: \q
+ --> hechos.nxl:4:15
+  |
+4 | explain	ñu is "a\q";
+  |        	      ^^^^^
+  = note: valid escapes are \n \t \r \0 \\ \" \' and \u{XXXX}
+`
+	if out.String() != want {
+		t.Errorf("RenderAll =\n%s\nwant\n%s", out.String(), want)
+	}
+
+	fix := diags[0].Fixes[0]
+	if fix.Span.Start != (diag.Position{Line: 2, Column: 14}) || fix.Span.End != fix.Span.Start {
+		t.Errorf("fix span = %+v, want the point after 'e' at 2:14", fix.Span)
+	}
+	if got := diags[1].Fixes[0].Span.Start; got != (diag.Position{Line: 3, Column: 22}) {
+		t.Errorf("fix position = %+v, want 3:22 (after the broken literal)", got)
+	}
+
+	var js strings.Builder
+	if err := diag.WriteJSON(&js, diags[:1]); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+	var decoded []diag.Diagnostic
+	if err := json.Unmarshal([]byte(js.String()), &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v\n%s", err, js.String())
+	}
+	if len(decoded) != 1 || decoded[0].Severity != diag.Error || decoded[0].Code != diag.ExpectedToken ||
+		decoded[0].Span != diags[0].Span || len(decoded[0].Fixes) != 1 {
+		t.Errorf("decoded = %+v, want %+v", decoded, diags[:1])
+	}
+	if !strings.Contains(js.String(), `"severity": "error"`) {
+		t.Errorf("JSON severity is not a name:\n%s", js.String())
+	}
+}
+
+func TestDiagnosticIsError(t *testing.T) {
+	base := errors.New("motor: fallo")
+	span := diag.Span{Start: diag.Position{Line: 3, Column: 7}, End: diag.Position{Line: 3, Column: 9}}
+	var err error = diag.Wrap(diag.RuntimeFailed, span, base).WithNote("nota")
+	if err.Error() != "Line 3, Column 7: motor: fallo" {
+		t.Errorf("Error() = %q", err.Error())
+	}
+	if !errors.Is(err, base) {
+		t.Error("errors.Is does not reach the wrapped error")
+	}
+	var d diag.Diagnostic
+	if !errors.As(err, &d) || d.Code != diag.RuntimeFailed || len(d.Notes) != 1 {
+		t.Errorf("errors.As = %+v", d)
+	}
+}
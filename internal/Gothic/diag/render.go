@@ -0,0 +1,103 @@
+// Gothic/diag/render.go
+package diag
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Render escribe d al estilo de los compiladores:
+//
+//	error[P0002]: Expected next token to be ;, got IDENTIFIER instead ("f")
+//	 --> hechos.nxl:3:15
+//	  |
+//	3 | explain c d e f;
+//	  |               ^
+//	  = help: insert ';': ;
+//
+// name es el nombre del archivo y src su contenido; si la línea no está en src
+// (entrada leída por flujo, por ejemplo) se omite el fragmento.
+func Render(w io.Writer, name, src string, d Diagnostic) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s[%s]: %s\n", d.Severity, d.Code, d.Message)
+	start := d.Span.Start
+	line, ok := sourceLine(src, start.Line)
+	gutter := strings.Repeat(" ", len(strconv.Itoa(start.Line)))
+	fmt.Fprintf(&b, "%s--> %s:%d:%d\n", gutter, name, start.Line, start.Column)
+	if ok {
+		fmt.Fprintf(&b, "%s |\n", gutter)
+		fmt.Fprintf(&b, "%d | %s\n", start.Line, line)
+		fmt.Fprintf(&b, "%s | %s\n", gutter, carets(line, d.Span))
+	}
+	for _, note := range d.Notes {
+		fmt.Fprintf(&b, "%s = note: %s\n", gutter, note)
+	}
+	for _, fix := range d.Fixes {
+		fmt.Fprintf(&b, "%s = help: %s: %s\n", gutter, fix.Message, fix.Replacement)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// RenderAll escribe todos los diagnósticos, separados por una línea en blanco.
+func RenderAll(w io.Writer, name, src string, diags []Diagnostic) error {
+	for i, d := range diags {
+		if i > 0 {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+		if err := Render(w, name, src, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sourceLine devuelve la línea n (1-based) de src, sin el salto de línea.
+func sourceLine(src string, n int) (string, bool) {
+	if n < 1 || src == "" {
+		return "", false
+	}
+	for i := 1; ; i++ {
+		end := strings.IndexByte(src, '\n')
+		if i == n {
+			if end < 0 {
+				return strings.TrimSuffix(src, "\r"), true
+			}
+			return strings.TrimSuffix(src[:end], "\r"), true
+		}
+		if end < 0 {
+			return "", false
+		}
+		src = src[end+1:]
+	}
+}
+
+// carets subraya en line el tramo span: un '^' por runa, al menos uno, y hasta
+// el final de la línea si el tramo sigue en otra. Los tabuladores de la línea
+// se copian para que los carets queden alineados.
+func carets(line string, span Span) string {
+	var b strings.Builder
+	col := 1
+	for _, r := range line {
+		if col >= span.Start.Column {
+			break
+		}
+		if r == '\t' {
+			b.WriteByte('\t')
+		} else {
+			b.WriteByte(' ')
+		}
+		col++
+	}
+	width := utf8.RuneCountInString(line) - span.Start.Column + 1
+	if span.End.Line == span.Start.Line {
+		width = span.End.Column - span.Start.Column
+	}
+	b.WriteString(strings.Repeat("^", max(width, 1)))
+	return b.String()
+}
@@ -0,0 +1,92 @@
+// Gothic/fmtcmd.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/devicemxl/nexusl/internal/Gothic/diag"
+	"github.com/devicemxl/nexusl/internal/Gothic/format"
+	"github.com/devicemxl/nexusl/internal/Gothic/metamodel"
+	"github.com/devicemxl/nexusl/internal/Gothic/token"
+)
+
+// runFmt implementa 'fmt [-w] [-d] [-json] [-width N] [-lang L] [-to L] [archivos]':
+// sin archivos lee la entrada estándar. -lang es el idioma de los archivos
+// sin pragma //nexusl:lang y -to traduce sus palabras clave a otro idioma.
+// Con -json los diagnósticos de cada archivo se escriben en la salida de
+// errores como un array JSON (ver diag.WriteJSON), vacío si no hay. Devuelve el código de salida: 1 si algún archivo tiene errores (y entonces
+// no se reescribe) o no se pudo leer.
+func runFmt(args []string, mm *metamodel.MetamodelDefinitions) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	write := flags.Bool("w", false, "write the result back to the source file")
+	diff := flags.Bool("d", false, "print a diff instead of the formatted source")
+	width := flags.Int("width", format.DefaultWidth, "line width for wrapping s-expressions")
+	lang := flags.String("lang", "", "keyword language of files without a //nexusl:lang pragma")
+	to := flags.String("to", "", "translate the keywords to this language")
+	asJSON := flags.Bool("json", false, "print diagnostics as JSON")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	for _, name := range []string{*lang, *to} {
+		if _, ok := token.Language(name); name != "" && !ok {
+			fmt.Fprintf(os.Stderr, "fmt: unknown language %q (known: %s)\n", name, strings.Join(token.Languages(), ", "))
+			return 2
+		}
+	}
+	opts := format.Options{Width: *width, Language: *lang, Translate: *to}
+
+	if flags.NArg() == 0 {
+		src, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return fmtSource("<stdin>", string(src), false, *diff, *asJSON, opts, mm)
+	}
+	status := 0
+	for _, name := range flags.Args() {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+			continue
+		}
+		if code := fmtSource(name, string(src), *write, *diff, *asJSON, opts, mm); code != 0 {
+			status = code
+		}
+	}
+	return status
+}
+
+// fmtSource formatea un archivo y escribe el resultado, la diferencia o el
+// archivo reescrito.
+func fmtSource(name, src string, write, diff, asJSON bool, opts format.Options, mm *metamodel.MetamodelDefinitions) int {
+	out, diags := format.Source(src, mm, opts)
+	if asJSON {
+		diag.WriteJSON(os.Stderr, diags)
+	}
+	if diag.HasErrors(diags) {
+		if !asJSON {
+			diag.RenderAll(os.Stderr, name, src, diags)
+		}
+		return 1
+	}
+	switch {
+	case diff:
+		fmt.Print(format.Diff(name, name+" (formatted)", src, out))
+	case write:
+		if out != src {
+			if err := os.WriteFile(name, []byte(out), 0o644); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+		}
+	default:
+		fmt.Print(out)
+	}
+	return 0
+}
@@ -0,0 +1,149 @@
+// Gothic/format/diff.go
+package format
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// diffContext es la cantidad de líneas sin cambios alrededor de cada cambio.
+const diffContext = 3
+
+// edit es una línea del guion de edición: ' ' se conserva, '-' se borra de
+// a y '+' se inserta de b.
+type edit struct {
+	kind byte
+	line string
+}
+
+// Diff devuelve las diferencias entre a y b en formato unificado, con los
+// nombres oldName y newName en la cabecera, o "" si son iguales.
+func Diff(oldName, newName, a, b string) string {
+	if a == b {
+		return ""
+	}
+	edits := editScript(splitLines(a), splitLines(b))
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldName, newName)
+
+	// aLine y bLine son la cantidad de líneas de a y de b antes de edits[i].
+	aLine, bLine := make([]int, len(edits)+1), make([]int, len(edits)+1)
+	for i, e := range edits {
+		aLine[i+1], bLine[i+1] = aLine[i], bLine[i]
+		if e.kind != '+' {
+			aLine[i+1]++
+		}
+		if e.kind != '-' {
+			bLine[i+1]++
+		}
+	}
+	for i := 0; i < len(edits); {
+		if edits[i].kind == ' ' {
+			i++
+			continue
+		}
+		// Un trozo va desde el contexto anterior al cambio hasta el contexto
+		// posterior al último cambio que esté a menos de 2*diffContext líneas.
+		start := max(0, i-diffContext)
+		end, equal := i, 0
+		for j := i; j < len(edits) && equal <= 2*diffContext; j++ {
+			if edits[j].kind == ' ' {
+				equal++
+			} else {
+				end, equal = j+1, 0
+			}
+		}
+		end = min(len(edits), end+diffContext)
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n",
+			hunkRange(aLine[start], aLine[end]-aLine[start]), hunkRange(bLine[start], bLine[end]-bLine[start]))
+		for _, e := range edits[start:end] {
+			out.WriteByte(e.kind)
+			out.WriteString(e.line)
+			if !strings.HasSuffix(e.line, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = end
+	}
+	return out.String()
+}
+
+// hunkRange escribe 'inicio,largo' como diff -u: sin el largo si es 1 y con
+// la línea anterior como inicio si el trozo está vacío.
+func hunkRange(before, n int) string {
+	switch n {
+	case 0:
+		return fmt.Sprintf("%d,0", before)
+	case 1:
+		return fmt.Sprintf("%d", before+1)
+	}
+	return fmt.Sprintf("%d,%d", before+1, n)
+}
+
+// splitLines divide s en líneas, cada una con su '\n' (salvo quizá la última).
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// editScript calcula el guion de edición más corto de a a b con el
+// algoritmo de Myers.
+func editScript(a, b []string) []edit {
+	n, m := len(a), len(b)
+	off := n + m + 1
+	v := make([]int, 2*off+1)
+	var trace [][]int
+search:
+	for d := 0; d <= n+m; d++ {
+		trace = append(trace, slices.Clone(v))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	var edits []edit
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+			prevK = k + 1
+		}
+		prevX := v[off+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			edits = append(edits, edit{' ', a[x-1]})
+			x, y = x-1, y-1
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			edits = append(edits, edit{'+', b[y-1]})
+			y--
+		} else {
+			edits = append(edits, edit{'-', a[x-1]})
+			x--
+		}
+	}
+	slices.Reverse(edits)
+	return edits
+}
@@ -0,0 +1,571 @@
+// Gothic/format/format.go
+//
+// # Formateador canónico del código fuente
+//
+// Source parsea un archivo y lo vuelve a escribir con un estilo único: una
+// sentencia por línea, un espacio entre sus partes, las columnas de los
+// bloques de tripletas (fact, retract, explain) alineadas y las expresiones S
+// en una línea o partidas según el ancho. Un bloque es una serie de sentencias
+// sin líneas en blanco entre ellas.
+//
+// Los comentarios viajan como trivia de los tokens y se conservan: los que
+// preceden a una sentencia, en sus propias líneas; el que la sigue en la misma
+// línea, detrás del ';' (alineado con los del bloque). Una sentencia con
+// errores, o con comentarios en medio, se copia tal como está escrita. Los
+// literales de cadena también se copian tal cual, para no perder su forma
+// (cruda, multilínea, con escapes).
+//
+// Formatear un resultado de Source lo deja igual.
+//
+// Con Options.Translate, Source además traduce el archivo a otro idioma:
+// escribe cada palabra clave como la escribe ese idioma ('fact' <-> 'hecho')
+// y cambia, o añade, el pragma //nexusl:lang. Solo cambian las palabras
+// clave, así que el programa significa lo mismo; si un identificador del
+// original es palabra clave en el idioma de destino, Source informa con un
+// diagnóstico Untranslatable.
+package format
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/devicemxl/nexusl/internal/Gothic/ast"
+	"github.com/devicemxl/nexusl/internal/Gothic/diag"
+	"github.com/devicemxl/nexusl/internal/Gothic/lexer"
+	"github.com/devicemxl/nexusl/internal/Gothic/metamodel"
+	"github.com/devicemxl/nexusl/internal/Gothic/parser"
+	"github.com/devicemxl/nexusl/internal/Gothic/token"
+)
+
+// DefaultWidth es el ancho de línea por omisión.
+const DefaultWidth = 80
+
+// Options ajusta el formato.
+type Options struct {
+	Width     int    // Ancho a partir del cual se parten las expresiones S; 0 usa DefaultWidth
+	Language  string // Idioma de un archivo sin pragma //nexusl:lang; "" usa el vocabulario por defecto
+	Translate string // Idioma al que traducir las palabras clave; "" no traduce
+}
+
+// Source devuelve src formateado y los diagnósticos del parser. Las sentencias
+// con errores se conservan tal cual, así que el resultado es utilizable aunque
+// haya diagnósticos; las herramientas suelen negarse a reescribir en ese caso.
+func Source(src string, mm *metamodel.MetamodelDefinitions, opts Options) (string, []diag.Diagnostic) {
+	if opts.Width <= 0 {
+		opts.Width = DefaultWidth
+	}
+	var vocab, target *token.Vocabulary
+	for _, lang := range []struct {
+		name string
+		v    **token.Vocabulary
+	}{{opts.Language, &vocab}, {opts.Translate, &target}} {
+		if lang.name == "" {
+			continue
+		}
+		v, ok := token.Language(lang.name)
+		if !ok {
+			return src, []diag.Diagnostic{diag.Errorf(diag.UnknownLanguage, diag.Span{}, "unknown language %q", lang.name)}
+		}
+		*lang.v = v
+	}
+	newLexer := func() *lexer.Lexer {
+		l := lexer.New(src)
+		l.Vocabulary = vocab
+		return l
+	}
+	p := parser.New(newLexer(), mm)
+	prog := p.ParseProgram()
+	diags := p.Diagnostics()
+
+	f := &formatter{src: src, lineStart: lineOffsets(src), width: opts.Width}
+	l := newLexer()
+	toks := tokens(l)
+	if target != nil {
+		f.source, f.target = l.ActiveVocabulary(), target
+		diags = append(diags, f.untranslatable(toks, opts.Translate)...)
+		f.setPragma(&toks[0], opts.Translate)
+	}
+	var units []unit
+	next := 0
+	for i, stmt := range prog.Statements {
+		start := f.indexOf(toks, next, statementToken(stmt))
+		end := len(toks) - 1 // EOF
+		if i+1 < len(prog.Statements) {
+			end = f.indexOf(toks, start+1, statementToken(prog.Statements[i+1]))
+		}
+		units = append(units, f.unit(stmt, toks[start:end]))
+		next = end
+	}
+	f.print(units, toks[len(toks)-1].Leading)
+	return f.out.String(), diags
+}
+
+// tokens lee todos los tokens de l, incluido el EOF final.
+func tokens(l *lexer.Lexer) []token.Token {
+	var toks []token.Token
+	for {
+		tok := l.Ensambladora()
+		toks = append(toks, tok)
+		if tok.Type == token.EOF {
+			return toks
+		}
+	}
+}
+
+// statementToken devuelve el primer token de la sentencia.
+func statementToken(stmt ast.Statement) token.Token {
+	switch s := stmt.(type) {
+	case *ast.FactStatement:
+		return s.Token
+	case *ast.RuleStatement:
+		return s.Token
+	case *ast.RetractStatement:
+		return s.Token
+	case *ast.ExplainStatement:
+		return s.Token
+	case *ast.TraceStatement:
+		return s.Token
+	case *ast.TableStatement:
+		return s.Token
+	case *ast.AlgebraStatement:
+		return s.Token
+	case *ast.BadStatement:
+		return s.Token
+	}
+	return token.Token{}
+}
+
+// indexOf busca, a partir de from, el token que empieza donde tok.
+func (f *formatter) indexOf(toks []token.Token, from int, tok token.Token) int {
+	for i := from; i < len(toks)-1; i++ {
+		if toks[i].Line == tok.Line && toks[i].Column == tok.Column {
+			return i
+		}
+	}
+	return len(toks) - 1
+}
+
+// unit es una sentencia lista para imprimir. Las tripletas de una línea tienen
+// cuatro celdas (palabra clave, sujeto, predicado y objeto con su ';') que se
+// alinean con las del bloque; el resto, una sola.
+type unit struct {
+	leading  []token.Trivia
+	cells    []string
+	trailing string
+	// Líneas del código original que ocupa la sentencia, con sus comentarios.
+	startLine, endLine int
+	// Línea del primer token de la sentencia, tras sus comentarios iniciales.
+	line int
+}
+
+func (u unit) alignable() bool {
+	if len(u.cells) != 4 {
+		return false
+	}
+	for _, c := range u.cells {
+		if strings.Contains(c, "\n") {
+			return false
+		}
+	}
+	return true
+}
+
+type formatter struct {
+	src       string
+	lineStart []int
+	width     int
+	out       strings.Builder
+
+	// Vocabularios del original y del idioma de destino; target es nil si
+	// no se traduce.
+	source, target *token.Vocabulary
+}
+
+// word devuelve cómo se escribe tok en el resultado: traducida si es una
+// palabra clave y se está traduciendo, tal cual en otro caso.
+func (f *formatter) word(tok token.Token) string {
+	if f.target == nil || tok.Type == token.IDENTIFIER {
+		return tok.Word
+	}
+	if _, ok := f.source.Lookup(tok.Word); !ok {
+		return tok.Word
+	}
+	return f.target.Spelling(f.source.Canonical(tok.Word))
+}
+
+// untranslatable informa de los identificadores que en el idioma lang serían
+// palabras clave: traducirlos cambiaría el programa.
+func (f *formatter) untranslatable(toks []token.Token, lang string) []diag.Diagnostic {
+	var diags []diag.Diagnostic
+	for _, t := range toks {
+		if t.Type == token.IDENTIFIER && f.target.Class(t.Word) != token.IDENTIFIER {
+			diags = append(diags, diag.Errorf(diag.Untranslatable, diag.TokenSpan(t),
+				"identifier %q is a keyword in language %q", t.Word, lang).
+				WithNote("rename it before translating"))
+		}
+	}
+	return diags
+}
+
+// setPragma deja en los comentarios iniciales de first el pragma del idioma
+// lang: cambia el que haya o, si no hay y lang no es el idioma por defecto,
+// añade uno separado del resto por una línea en blanco.
+func (f *formatter) setPragma(first *token.Token, lang string) {
+	text := fmt.Sprintf("%s %s", lexer.LanguagePragma, lang)
+	for i, c := range first.Leading {
+		if _, ok := lexer.PragmaLanguage(c.Text); ok && c.Type == token.SINGLE_LINE_COMMENT {
+			first.Leading[i].Text = text
+			return
+		}
+	}
+	if lang == token.DefaultLanguage {
+		return
+	}
+	line := first.Line
+	if len(first.Leading) > 0 {
+		line = first.Leading[0].Line
+	}
+	pragma := token.Trivia{Type: token.SINGLE_LINE_COMMENT, Text: text, Line: line - 2, Column: 1}
+	first.Leading = append([]token.Trivia{pragma}, first.Leading...)
+}
+
+// unit prepara la sentencia stmt, cuyos tokens son toks.
+func (f *formatter) unit(stmt ast.Statement, toks []token.Token) unit {
+	first, last := toks[0], toks[len(toks)-1]
+	u := unit{leading: first.Leading, startLine: first.Line, endLine: last.EndLine, line: first.Line}
+	if len(u.leading) > 0 {
+		u.startLine = u.leading[0].Line
+	}
+	var trailing []string
+	for _, c := range last.Trailing {
+		trailing = append(trailing, c.Text)
+		u.endLine = triviaEnd(c)
+	}
+	u.trailing = strings.Join(trailing, " ")
+
+	if _, bad := stmt.(*ast.BadStatement); bad || innerComments(toks) {
+		u.cells = []string{f.verbatim(toks)}
+		return u
+	}
+	switch s := stmt.(type) {
+	case *ast.FactStatement:
+		u.cells = f.triple(s.Token, s.Subject, s.Predicate, s.Object)
+	case *ast.RuleStatement:
+		u.cells = []string{f.rule(s)}
+	case *ast.RetractStatement:
+		u.cells = f.triple(s.Token, s.Subject, s.Predicate, s.Object)
+	case *ast.ExplainStatement:
+		u.cells = f.triple(s.Token, s.Subject, s.Predicate, s.Object)
+	case *ast.TraceStatement:
+		u.cells = []string{f.words(s.Token, []ast.Expression{s.Predicate})}
+	case *ast.TableStatement:
+		u.cells = []string{f.word(s.Token) + " " + f.flat(s.Predicate) + "/" + f.flat(s.Arity) + ";"}
+	case *ast.AlgebraStatement:
+		u.cells = []string{f.words(s.Token, s.Arguments)}
+	}
+	return u
+}
+
+// innerComments indica si hay comentarios entre los tokens de una sentencia.
+func innerComments(toks []token.Token) bool {
+	for i, t := range toks {
+		if (i > 0 && len(t.Leading) > 0) || (i < len(toks)-1 && len(t.Trailing) > 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// verbatim copia el texto original de toks, con las palabras clave traducidas.
+func (f *formatter) verbatim(toks []token.Token) string {
+	first, last := toks[0], toks[len(toks)-1]
+	if f.target == nil {
+		return f.slice(first.Line, first.Column, last.EndLine, last.EndColumn)
+	}
+	var b strings.Builder
+	line, col := first.Line, first.Column
+	for _, t := range toks {
+		b.WriteString(f.slice(line, col, t.Line, t.Column))
+		if w := f.word(t); w != t.Word {
+			b.WriteString(w)
+		} else {
+			b.WriteString(f.slice(t.Line, t.Column, t.EndLine, t.EndColumn))
+		}
+		line, col = t.EndLine, t.EndColumn
+	}
+	return b.String()
+}
+
+// triple formatea 'palabra sujeto predicado objeto;' en sus cuatro celdas.
+func (f *formatter) triple(kw token.Token, parts ...ast.Expression) []string {
+	kwText := f.word(kw)
+	cells := []string{kwText}
+	col := width(kwText) + 1
+	for i, e := range parts {
+		text := f.expr(e, col)
+		if i == len(parts)-1 {
+			text += ";"
+		}
+		cells = append(cells, text)
+		col += lastLineWidth(text) + 1
+	}
+	return cells
+}
+
+// rule formatea una regla en una línea si cabe; si no, con cada condición
+// en su propia línea:
+//
+//	rule ?d is unsafe
+//	  if ?d is door
+//	  and ?d has alarm_off;
+func (f *formatter) rule(s *ast.RuleStatement) string {
+	parts := []string{f.word(s.Token), f.ruleTriple(s.Head, 0)}
+	for _, c := range s.Body {
+		parts = append(parts, f.ruleTriple(c, 0))
+	}
+	if flat := strings.Join(parts, " ") + ";"; !strings.Contains(flat, "\n") && width(flat) <= f.width {
+		return flat
+	}
+	var b strings.Builder
+	b.WriteString(f.word(s.Token) + " ")
+	b.WriteString(f.ruleTriple(s.Head, lastLineWidth(b.String())))
+	for _, c := range s.Body {
+		b.WriteString("\n  " + f.ruleTriple(c, 2))
+	}
+	b.WriteByte(';')
+	return b.String()
+}
+
+// ruleTriple formatea una tripleta de una regla, con su 'if' o 'and', a
+// partir de la columna col.
+func (f *formatter) ruleTriple(t *ast.Triple, col int) string {
+	var b strings.Builder
+	if t.Token.Word != "" {
+		b.WriteString(f.word(t.Token) + " ")
+	}
+	for i, e := range []ast.Expression{t.Subject, t.Predicate, t.Object} {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		at := lastLineWidth(b.String())
+		if !strings.Contains(b.String(), "\n") {
+			at += col
+		}
+		b.WriteString(f.expr(e, at))
+	}
+	return b.String()
+}
+
+// words formatea la palabra clave seguida de sus argumentos y el ';'.
+func (f *formatter) words(kw token.Token, args []ast.Expression) string {
+	var b strings.Builder
+	b.WriteString(f.word(kw))
+	col := width(b.String())
+	for _, e := range args {
+		b.WriteByte(' ')
+		text := f.expr(e, col+1)
+		b.WriteString(text)
+		col = lastLineWidth(b.String())
+	}
+	b.WriteByte(';')
+	return b.String()
+}
+
+// expr formatea una expresión que empieza en la columna col (0-based).
+func (f *formatter) expr(e ast.Expression, col int) string {
+	if se, ok := e.(*ast.SExpression); ok {
+		return f.sexpr(se, col)
+	}
+	return f.flat(e)
+}
+
+// flat formatea una expresión en una sola línea (salvo las cadenas multilínea).
+func (f *formatter) flat(e ast.Expression) string {
+	switch e := e.(type) {
+	case *ast.StringLiteral:
+		return f.slice(e.Token.Line, e.Token.Column, e.Token.EndLine, e.Token.EndColumn)
+	case *ast.ComplexLiteral:
+		return e.Token.Word // Tal como se escribió
+	case *ast.Identifier:
+		return f.word(e.Token) // Value es la palabra canónica, no la escrita
+	case *ast.BooleanLiteral:
+		return f.word(e.Token)
+	case *ast.SExpression:
+		parts := []string{f.word(e.Operator)}
+		for _, a := range e.Arguments {
+			parts = append(parts, f.flat(a))
+		}
+		return "(" + strings.Join(parts, " ") + ")"
+	}
+	return e.String()
+}
+
+// sexpr escribe (op a b c) en una línea si cabe; si no, deja el primer
+// argumento junto al operador y alinea los demás debajo de él:
+//
+//	(+ (* 2 x)
+//	   (* 3 y))
+func (f *formatter) sexpr(e *ast.SExpression, col int) string {
+	flat := f.flat(e)
+	if len(e.Arguments) == 0 || (!strings.Contains(flat, "\n") && col+width(flat) <= f.width) {
+		return flat
+	}
+	var b strings.Builder
+	op := f.word(e.Operator)
+	b.WriteString("(" + op + " ")
+	argCol := col + width(op) + 2
+	for i, a := range e.Arguments {
+		if i > 0 {
+			b.WriteString("\n" + strings.Repeat(" ", argCol))
+		}
+		b.WriteString(f.expr(a, argCol))
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// print escribe las sentencias y, al final, los comentarios que quedan antes del EOF.
+func (f *formatter) print(units []unit, tail []token.Trivia) {
+	prevEnd := 0 // Última línea del código original ya escrita
+	for i := 0; i < len(units); {
+		// Un bloque de tripletas alineables sin líneas en blanco en medio.
+		j := i + 1
+		if units[i].alignable() {
+			for j < len(units) && units[j].alignable() && units[j].startLine-units[j-1].endLine <= 1 {
+				j++
+			}
+		}
+		lines := alignBlock(units[i:j])
+		for k, u := range units[i:j] {
+			f.separate(prevEnd, u.startLine)
+			f.comments(u.leading, u.line)
+			f.out.WriteString(lines[k] + "\n")
+			prevEnd = u.endLine
+		}
+		i = j
+	}
+	if len(tail) > 0 {
+		f.separate(prevEnd, tail[0].Line)
+		f.comments(tail, 0)
+	}
+}
+
+// alignBlock devuelve el texto de cada sentencia del bloque, con las celdas
+// y los comentarios finales alineados en columnas.
+func alignBlock(units []unit) []string {
+	var colWidth [3]int
+	if len(units) > 1 {
+		for _, u := range units {
+			for c := range colWidth {
+				colWidth[c] = max(colWidth[c], width(u.cells[c]))
+			}
+		}
+	}
+	lines := make([]string, len(units))
+	commentCol := 0
+	for k, u := range units {
+		var b strings.Builder
+		for c, cell := range u.cells {
+			if c > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(cell)
+			if c < len(colWidth) && c < len(u.cells)-1 {
+				b.WriteString(strings.Repeat(" ", max(0, colWidth[c]-width(cell))))
+			}
+		}
+		lines[k] = b.String()
+		if u.trailing != "" {
+			commentCol = max(commentCol, lastLineWidth(lines[k]))
+		}
+	}
+	for k, u := range units {
+		if u.trailing == "" {
+			continue
+		}
+		pad := 1
+		if len(units) > 1 {
+			pad += commentCol - lastLineWidth(lines[k])
+		}
+		lines[k] += strings.Repeat(" ", pad) + u.trailing
+	}
+	return lines
+}
+
+// separate deja una línea en blanco (nunca más) si el original la tenía.
+func (f *formatter) separate(prevEnd, start int) {
+	if prevEnd > 0 && start-prevEnd > 1 {
+		f.out.WriteByte('\n')
+	}
+}
+
+// comments escribe los comentarios que preceden a una sentencia, cada uno en
+// su línea salvo que compartan línea con lo que les sigue (un /* */ delante
+// de la sentencia). next es la línea de la sentencia, o 0 al final del archivo.
+func (f *formatter) comments(cs []token.Trivia, next int) {
+	for i, c := range cs {
+		end, following := triviaEnd(c), next
+		if i+1 < len(cs) {
+			following = cs[i+1].Line
+		}
+		f.out.WriteString(strings.TrimRight(c.Text, " \t\r"))
+		switch {
+		case end == following:
+			f.out.WriteByte(' ')
+		case following-end > 1:
+			f.out.WriteString("\n\n")
+		default:
+			f.out.WriteByte('\n')
+		}
+	}
+}
+
+// triviaEnd devuelve la línea en que termina un comentario.
+func triviaEnd(c token.Trivia) int {
+	return c.Line + strings.Count(c.Text, "\n")
+}
+
+// lineOffsets devuelve el desplazamiento en bytes del inicio de cada línea.
+func lineOffsets(src string) []int {
+	offsets := []int{0}
+	for i := 0; i < len(src); i++ {
+		if src[i] == '\n' {
+			offsets = append(offsets, i+1)
+		}
+	}
+	return offsets
+}
+
+// offset convierte una posición (línea y columna en runas, 1-based) en un
+// desplazamiento en bytes de src.
+func (f *formatter) offset(line, column int) int {
+	if line < 1 || line > len(f.lineStart) {
+		return len(f.src)
+	}
+	off := f.lineStart[line-1]
+	for ; column > 1 && off < len(f.src); column-- {
+		_, size := utf8.DecodeRuneInString(f.src[off:])
+		off += size
+	}
+	return off
+}
+
+// slice devuelve el texto original entre dos posiciones, la final excluida.
+func (f *formatter) slice(line, column, endLine, endColumn int) string {
+	start, end := f.offset(line, column), f.offset(endLine, endColumn)
+	if end < start {
+		return ""
+	}
+	return f.src[start:end]
+}
+
+// width es el ancho de s en columnas (runas).
+func width(s string) int {
+	return utf8.RuneCountInString(s)
+}
+
+// lastLineWidth es el ancho de la última línea de s.
+func lastLineWidth(s string) int {
+	return width(s[strings.LastIndexByte(s, '\n')+1:])
+}
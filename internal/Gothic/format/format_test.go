@@ -0,0 +1,213 @@
+package format_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/devicemxl/nexusl/ds"
+	"github.com/devicemxl/nexusl/internal/Gothic/diag"
+	"github.com/devicemxl/nexusl/internal/Gothic/format"
+	"github.com/devicemxl/nexusl/internal/Gothic/metamodel"
+	"github.com/devicemxl/nexusl/internal/Gothic/token"
+)
+
+func facade() *metamodel.MetamodelDefinitions {
+	// Los scopes 'fact' y 'rule' vienen normalmente de la base de definiciones.
+	for _, name := range []string{"fact", "rule"} {
+		if _, ok := ds.LookupSymbolByPublicName(name); !ok {
+			ds.NewSymbolWithPublicName(name, ds.TripletScopeType)
+		}
+	}
+	return metamodel.NewMetamodelFacade()
+}
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		name  string
+		width int
+		input string
+		want  string
+	}{
+		{
+			name: "aligned triplet block",
+			input: "fact   Car is   symbol;\n" +
+				"fact Bicycle has wheels ;\n" +
+				"retract door is open;\n",
+			want: "fact    Car     is  symbol;\n" +
+				"fact    Bicycle has wheels;\n" +
+				"retract door    is  open;\n",
+		},
+		{
+			name: "blank lines split blocks and collapse",
+			input: "fact a is b;\n\n\n\nfact ccc is d;\n" +
+				"fact e has f;",
+			want: "fact a is b;\n\n" +
+				"fact ccc is  d;\n" +
+				"fact e   has f;\n",
+		},
+		{
+			name: "comments are kept",
+			input: "// Hechos de la casa\n" +
+				"/// La puerta.\n" +
+				"fact door is open; // por ahora\n" +
+				"fact window is closed;   /* siempre */\n" +
+				"\n" +
+				"# fin\n",
+			want: "// Hechos de la casa\n" +
+				"/// La puerta.\n" +
+				"fact door   is open;   // por ahora\n" +
+				"fact window is closed; /* siempre */\n" +
+				"\n" +
+				"# fin\n",
+		},
+		{
+			name:  "one line comment before a statement",
+			input: "// c\nfact Car is symbol;",
+			want:  "// c\nfact Car is symbol;\n",
+		},
+		{
+			name:  "one doc comment before a statement",
+			input: "/// El coche.\nfact Car is symbol;\n",
+			want:  "/// El coche.\nfact Car is symbol;\n",
+		},
+		{
+			name:  "comment followed by a blank line",
+			input: "// header\n\nfact a is b;\nfact c is d;\n",
+			want:  "// header\n\nfact a is b;\nfact c is d;\n",
+		},
+		{
+			name:  "block comment on the statement line",
+			input: "/* c */ fact a is b;",
+			want:  "/* c */ fact a is b;\n",
+		},
+		{
+			name:  "one statement per line",
+			input: "fact a is b; trace is;table path / 2;simplify   (+  x  (* 2 y));",
+			want: "fact a is b;\n" +
+				"trace is;\n" +
+				"table path/2;\n" +
+				"simplify (+ x (* 2 y));\n",
+		},
+		{
+			name:  "s-expressions wrap by width",
+			width: 24,
+			input: "simplify (+ (* 2 x) (* 3 y) (sin z));",
+			want: "simplify (+ (* 2 x)\n" +
+				"            (* 3 y)\n" +
+				"            (sin z));\n",
+		},
+		{
+			name:  "literals as written",
+			input: "fact v phasor 2+3i;\nfact s says `C:\\raw`;\nfact n count -42;\nfact p price 19.990d;",
+			want: "fact v phasor 2+3i;\n" +
+				"fact s says   `C:\\raw`;\n" +
+				"fact n count  -42;\n" +
+				"fact p price  19.990d;\n",
+		},
+		{
+			name:  "broken and commented statements are copied",
+			input: "fact a b;\nfact c /* sujeto */ is d;\n  fact e   is f;",
+			want: "fact a b;\n" +
+				"fact c /* sujeto */ is d;\n" +
+				"fact e is f;\n",
+		},
+	}
+	mm := facade()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := format.Source(tt.input, mm, format.Options{Width: tt.width})
+			if got != tt.want {
+				t.Fatalf("Source() =\n%s\nwant\n%s", got, tt.want)
+			}
+			again, _ := format.Source(got, mm, format.Options{Width: tt.width})
+			if again != got {
+				t.Errorf("not idempotent:\n%s\nthen\n%s", got, again)
+			}
+		})
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	mm := facade()
+	es, err := token.DefaultVocabulary().WithAliases(map[string]string{
+		"hecho": "fact", "retirar": "retract", "es": "is", "tiene": "has", "símbolo": "symbol",
+		"regla": "rule", "si": "if", "y": "and",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token.SetLanguage("es", es)
+	defer token.SetLanguage("es", nil)
+
+	en := "/// Un coche.\n" +
+		"fact Car is symbol; // nota\n" +
+		"retract Car /* en medio */ has wheels;\n"
+	spanish := "//nexusl:lang es\n\n" +
+		"/// Un coche.\n" +
+		"hecho Car es símbolo; // nota\n" +
+		"retirar Car /* en medio */ tiene wheels;\n"
+	got, diags := format.Source(en, mm, format.Options{Translate: "es"})
+	if len(diags) != 0 || got != spanish {
+		t.Fatalf("en -> es =\n%s\nwant\n%s (diags %v)", got, spanish, diags)
+	}
+	back, diags := format.Source(got, mm, format.Options{Translate: "en"})
+	if want := "//nexusl:lang en\n\n" + en; len(diags) != 0 || back != want {
+		t.Errorf("es -> en =\n%s\nwant\n%s (diags %v)", back, want, diags)
+	}
+
+	// El pragma como único comentario no se come la primera sentencia.
+	got, diags = format.Source("//nexusl:lang es\nhecho Coche es símbolo;\nhecho Coche tiene b;", mm, format.Options{Translate: "en"})
+	if want := "//nexusl:lang en\nfact Coche is  symbol;\nfact Coche has b;\n"; len(diags) != 0 || got != want {
+		t.Errorf("pragma only, es -> en =\n%s\nwant\n%s (diags %v)", got, want, diags)
+	}
+	got, _ = format.Source("fact Coche is symbol;", mm, format.Options{Translate: "es"})
+	if want := "//nexusl:lang es\n\nhecho Coche es símbolo;\n"; got != want {
+		t.Errorf("no comments, en -> es =\n%s\nwant\n%s", got, want)
+	}
+
+	// Las conectivas de las reglas también se traducen, de ida y de vuelta.
+	rule := "rule ?x is mortal if ?x is man and ?x has body;\n"
+	got, diags = format.Source(rule, mm, format.Options{Translate: "es"})
+	if want := "//nexusl:lang es\n\nregla ?x es mortal si ?x es man y ?x tiene body;\n"; len(diags) != 0 || got != want {
+		t.Errorf("rule, en -> es =\n%s\nwant\n%s (diags %v)", got, want, diags)
+	}
+	back, diags = format.Source(got, mm, format.Options{Translate: "en"})
+	if want := "//nexusl:lang en\n\n" + rule; len(diags) != 0 || back != want {
+		t.Errorf("rule, es -> en =\n%s\nwant\n%s (diags %v)", back, want, diags)
+	}
+
+	// Sin pragma, el idioma viene de las opciones.
+	got, _ = format.Source("hecho Car es b;", mm, format.Options{Language: "es", Translate: "en"})
+	if got != "fact Car is b;\n" {
+		t.Errorf("Language option: got %q", got)
+	}
+
+	_, diags = format.Source("fact hecho is b;", mm, format.Options{Translate: "es"})
+	if len(diags) != 1 || diags[0].Code != diag.Untranslatable {
+		t.Errorf("identifier that is a Spanish keyword: diags = %v", diags)
+	}
+	_, diags = format.Source("fact a is b;", mm, format.Options{Translate: "xx"})
+	if len(diags) != 1 || diags[0].Code != diag.UnknownLanguage {
+		t.Errorf("unknown language: diags = %v", diags)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := "fact a is b;\nfact c is d;\nfact e is f;\n"
+	b := "fact a is b;\nfact c is  d;\nfact e is f;\n"
+	want := "--- x.nxl\n+++ x.nxl (formatted)\n" +
+		"@@ -1,3 +1,3 @@\n" +
+		" fact a is b;\n" +
+		"-fact c is d;\n" +
+		"+fact c is  d;\n" +
+		" fact e is f;\n"
+	if got := format.Diff("x.nxl", "x.nxl (formatted)", a, b); got != want {
+		t.Errorf("Diff() =\n%s\nwant\n%s", got, want)
+	}
+	if got := format.Diff("x", "y", a, a); got != "" {
+		t.Errorf("Diff of equal texts = %q, want empty", got)
+	}
+	if !strings.HasPrefix(format.Diff("x", "y", "", "fact a is b;\n"), "--- x\n+++ y\n@@ -0,0 +1 @@\n") {
+		t.Errorf("Diff from empty has a wrong header")
+	}
+}
@@ -7,6 +7,10 @@ import (
 
 // StepOne limpia el código de comentarios de línea única (//) y multi-línea (/* */).
 // Devuelve el código limpio como un solo string, conservando los saltos de línea y el espaciado original.
+//
+// Deprecated: el lexer reconoce los comentarios por sí mismo, respeta los
+// literales ("http://x.org") y los conserva como trivia. StepOne recorta
+// cualquier '//' o '#' aunque esté dentro de una cadena.
 func StepOne(fullProgramText string) string {
 	var cleanedTextBuilder strings.Builder
 	inMultiLineComment := false
@@ -2,12 +2,41 @@
 package lexer
 
 import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+
 	tk "github.com/devicemxl/nexusl/internal/Gothic/token"
+	"github.com/devicemxl/nexusl/logging"
+	"golang.org/x/text/unicode/norm"
 )
 
-// Ensambladora es el método principal que devuelve el próximo tk.Token
+// lexerLog es el logger del subsistema lexer (ver el paquete logging).
+var lexerLog = logging.Logger(logging.Lexer)
+
+// Ensambladora es el método principal que devuelve el próximo tk.Token, con
+// los comentarios que lo rodean como trivia.
 func (l *Lexer) Ensambladora() tk.Token {
-	l.SkipWhitespace()
+	l.compact()
+	leading := l.readTrivia()
+	l.markTokenStart()
+	tok := l.readToken()
+	l.sawToken = true
+	tok.EndLine, tok.EndColumn = l.chLine, l.chColumn
+	tok.Leading = leading
+	if tok.Type != tk.EOF {
+		tok.Trailing = l.readTrailingTrivia()
+	}
+	if lexerLog.Enabled(context.Background(), slog.LevelDebug) {
+		lexerLog.Debug("token", "type", tok.Type, "word", tok.Word, "line", tok.Line, "column", tok.Column)
+	}
+	return tok
+}
+
+// readToken lee el token que empieza en l.Ch, ya sin espacios ni comentarios delante.
+func (l *Lexer) readToken() tk.Token {
 
 	// 1. CAPTURA LA POSICIÓN DE INICIO DEL TOKEN AQUÍ.
 	// Esto es l.Position cuando el token comienza.
@@ -74,16 +103,34 @@ func (l *Lexer) Ensambladora() tk.Token {
 		return tok
 
 	case '\'':
-		l.ReadChar()
-		literal := l.readCharLiteral()
-		tok = l.NewToken(tk.CHAR, literal, startTokenPosition)
-		return tok // readCharLiteral ya avanzó el puntero
+		l.ReadChar() // Consume la comilla de apertura
+		literal, err := l.readDelimited("'", false, true)
+		if n := utf8.RuneCountInString(norm.NFC.String(literal)); err == nil && n != 1 {
+			err = fmt.Errorf("%w: '%s'", ErrInvalidChar, literal)
+		}
+		return l.literalToken(tk.CHAR, literal, err, startTokenPosition, "'")
 
 	case '"':
-		l.ReadChar()
-		literal := l.readStringLiteral()
-		tok = l.NewToken(tk.STRING, literal, startTokenPosition)
-		return tok // readStringLiteral ya avanzó el puntero
+		// "texto" con escapes, o """texto""" que además puede ocupar varias líneas.
+		tokenType, delim := tk.STRING, `"`
+		if strings.HasPrefix(l.Input[l.Position:], `"""`) {
+			tokenType, delim = tk.MULTILINE_STRING, `"""`
+		}
+		for i := 0; i < len(delim); i++ {
+			l.ReadChar() // Consume la apertura
+		}
+		if tokenType == tk.MULTILINE_STRING && l.Ch == '\n' {
+			l.ReadChar() // El salto de línea tras la apertura no forma parte del texto
+		}
+		literal, err := l.readDelimited(delim, tokenType == tk.MULTILINE_STRING, true)
+		return l.literalToken(tokenType, literal, err, startTokenPosition, delim)
+
+	case '?': // Variable lógica: ?nombre
+		if !IsLetter(l.peekChar()) {
+			return l.illegal(startTokenPosition)
+		}
+		l.ReadChar() // Consume '?'
+		return l.NewToken(tk.VARIABLE, "?"+l.ReadIdentifier(), startTokenPosition)
 
 	case '@': // Manejo de Builders
 		if l.peekChar() == '(' { // Es el inicio de un List Builder "@("
@@ -160,7 +207,7 @@ func (l *Lexer) Ensambladora() tk.Token {
 			l.ReadChar() // Consume '/'
 			l.ReadChar() // Consume '='
 			tok = l.NewToken(tk.ASSIGN_DIVIDE, l.Input[startTokenPosition:l.Position], startTokenPosition)
-		} else { // División normal /
+		} else { // División normal / ('//' y '/*' son comentarios y ya se leyeron como trivia)
 			tok = l.NewToken(tk.DIVIDE, string(l.Ch), startTokenPosition)
 			l.ReadChar() // Consume el carácter actual
 		}
@@ -171,11 +218,6 @@ func (l *Lexer) Ensambladora() tk.Token {
 		l.ReadChar() // Consume el carácter actual
 		return tok
 
-	case '#':
-		tok = l.NewToken(tk.ILLEGAL, string(l.Ch), startTokenPosition) // Asumo que es ilegal si no es comentario
-		l.ReadChar()                                                   // Consume el carácter actual
-		return tok
-
 	case '%':
 		if l.peekChar() == '=' { // ASSIGN_MODULO %=
 			l.ReadChar() // Consume '%'
@@ -187,10 +229,10 @@ func (l *Lexer) Ensambladora() tk.Token {
 		}
 		return tok
 
-	case '`':
-		tok = l.NewToken(tk.BACKTICK, string(l.Ch), startTokenPosition)
-		l.ReadChar() // Consume el carácter actual
-		return tok
+	case '`': // Cadena cruda: sin escapes y puede ocupar varias líneas
+		l.ReadChar() // Consume la comilla invertida de apertura
+		literal, err := l.readDelimited("`", true, false)
+		return l.literalToken(tk.STRING, literal, err, startTokenPosition, "`")
 
 	case '^':
 		tok = l.NewToken(tk.BIT_XOR, string(l.Ch), startTokenPosition)
@@ -286,20 +328,15 @@ func (l *Lexer) Ensambladora() tk.Token {
 			tok.Line = l.Line
 			tok.Column = l.Column
 			literal := l.ReadIdentifier() // ReadIdentifier ya avanza l.Ch
-			// --- ¡EL CAMBIO CLAVE ESTÁ AQUÍ! ---
-			// Usar LookupIdent para determinar si es una palabra clave o un IDENTIFIER
-			tok.Type = LookupIdent(tok.Word)
-			//
-			tok = l.NewToken(tk.LookupIdent(literal), literal, startTokenPosition)
+			// El vocabulario decide si es una palabra clave o un IDENTIFIER.
+			tok = l.NewToken(l.lookupIdent(literal), literal, startTokenPosition)
 			return tok // ReadIdentifier ya avanzó el puntero
 		} else if isDigit(l.Ch) {
 			literal, tokenType := l.ReadNumber() // ReadNumber ya avanza l.Ch
 			tok = l.NewToken(tokenType, literal, startTokenPosition)
 			return tok // ReadNumber ya avanzó el puntero
 		} else {
-			tok = l.NewToken(tk.ILLEGAL, string(l.Ch), startTokenPosition)
-			l.ReadChar() // Consume el carácter ilegal
-			return tok
+			return l.illegal(startTokenPosition)
 		}
 	}
 }
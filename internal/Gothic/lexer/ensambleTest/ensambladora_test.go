@@ -1,7 +1,12 @@
 package lexer_test // Nota: Cambiado a package GothicLexer_test
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/devicemxl/nexusl/internal/Gothic/lexer" // Ajusta la ruta de importación si es diferente
 	tk "github.com/devicemxl/nexusl/internal/Gothic/token"
@@ -233,6 +238,77 @@ func TestEnsambladora(t *testing.T) {
 				{Type: tk.EOF, Word: "", Line: 1, Column: 8},
 			},
 		},
+		{
+			name:  "complex",
+			input: `3i 2+3i 1.5e-3i 2 - 4i 3in`,
+			expectedTokens: []tk.Token{
+				{Type: tk.COMPLEX, Word: "3i", Line: 1, Column: 1},
+				{Type: tk.COMPLEX, Word: "2+3i", Line: 1, Column: 4},
+				{Type: tk.COMPLEX, Word: "1.5e-3i", Line: 1, Column: 9},
+				{Type: tk.INTEGER, Word: "2", Line: 1, Column: 17},
+				{Type: tk.MINUS, Word: "-", Line: 1, Column: 19},
+				{Type: tk.COMPLEX, Word: "4i", Line: 1, Column: 21},
+				{Type: tk.INTEGER, Word: "3", Line: 1, Column: 24},
+				{Type: tk.IDENTIFIER, Word: "in", Line: 1, Column: 25},
+			},
+		},
+		{
+			name:  "decimal",
+			input: `19.99d 100d 2dx`,
+			expectedTokens: []tk.Token{
+				{Type: tk.DECIMAL, Word: "19.99d", Line: 1, Column: 1},
+				{Type: tk.DECIMAL, Word: "100d", Line: 1, Column: 8},
+				{Type: tk.INTEGER, Word: "2", Line: 1, Column: 13},
+				{Type: tk.IDENTIFIER, Word: "dx", Line: 1, Column: 14},
+			},
+		},
+		{
+			name:  "string escapes",
+			input: `"a\"b\\c\n" '\'' "\u{41}\u{f1}"`,
+			expectedTokens: []tk.Token{
+				{Type: tk.STRING, Word: "a\"b\\c\n", Line: 1, Column: 1},
+				{Type: tk.CHAR, Word: "'", Line: 1, Column: 13},
+				{Type: tk.STRING, Word: "Añ", Line: 1, Column: 18},
+				{Type: tk.EOF, Word: "", Line: 1, Column: 32},
+			},
+		},
+		{
+			name:  "raw and multi-line strings",
+			input: "x = \"\"\"\nuno\n  dos\"\"\" `C:\\raw\\n\nfin` y",
+			expectedTokens: []tk.Token{
+				{Type: tk.IDENTIFIER, Word: "x", Line: 1, Column: 1},
+				{Type: tk.ASSIGN_EQUAL, Word: "=", Line: 1, Column: 3},
+				{Type: tk.MULTILINE_STRING, Word: "uno\n  dos", Line: 1, Column: 5},
+				{Type: tk.STRING, Word: "C:\\raw\\n\nfin", Line: 3, Column: 10},
+				{Type: tk.IDENTIFIER, Word: "y", Line: 4, Column: 6},
+				{Type: tk.EOF, Word: "", Line: 4, Column: 7},
+			},
+		},
+		{
+			name:  "unicode identifiers",
+			input: "año := \"ñandú\" ubicación\nnin\u0303o λ2 x",
+			expectedTokens: []tk.Token{
+				{Type: tk.IDENTIFIER, Word: "año", Line: 1, Column: 1},
+				{Type: tk.ASSIGN, Word: ":=", Line: 1, Column: 5},
+				{Type: tk.STRING, Word: "ñandú", Line: 1, Column: 8},
+				{Type: tk.IDENTIFIER, Word: "ubicación", Line: 1, Column: 16},
+				{Type: tk.IDENTIFIER, Word: "niño", Line: 2, Column: 1}, // n + U+0303 normalizado a NFC
+				{Type: tk.IDENTIFIER, Word: "λ2", Line: 2, Column: 7},
+				{Type: tk.IDENTIFIER, Word: "x", Line: 2, Column: 10},
+				{Type: tk.EOF, Word: "", Line: 2, Column: 11},
+			},
+		},
+		{
+			name:  "unterminated literals",
+			input: "\"bad\\q\" \"abc\nx `open",
+			expectedTokens: []tk.Token{
+				{Type: tk.ILLEGAL, Word: `"bad\q"`, Line: 1, Column: 1},
+				{Type: tk.ILLEGAL, Word: `"abc`, Line: 1, Column: 9},
+				{Type: tk.IDENTIFIER, Word: "x", Line: 2, Column: 1},
+				{Type: tk.ILLEGAL, Word: "`open", Line: 2, Column: 3},
+				{Type: tk.EOF, Word: "", Line: 2, Column: 8},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -291,3 +367,208 @@ func TestEnsambladora(t *testing.T) {
 		})
 	}
 }
+
+// TestLiteralErrors comprueba que los literales mal formados dejan un error
+// con su posición en Lexer.Diagnostics.
+func TestLiteralErrors(t *testing.T) {
+	l := lexer.New("\"bad\\q\" \"abc\nx `open")
+	for tok := l.Ensambladora(); tok.Type != tk.EOF; tok = l.Ensambladora() {
+	}
+	expected := []string{
+		`Line 1, Column 1: lexer: invalid escape sequence: \q`,
+		`Line 1, Column 9: lexer: unterminated literal: missing closing "`,
+		"Line 2, Column 3: lexer: unterminated literal: missing closing `",
+	}
+	if len(l.Errors()) != len(expected) {
+		t.Fatalf("errores = %q, esperado %q", l.Errors(), expected)
+	}
+	for i, msg := range expected {
+		if l.Errors()[i] != msg {
+			t.Errorf("error %d = %q, esperado %q", i, l.Errors()[i], msg)
+		}
+	}
+}
+
+// TestCommentTrivia comprueba que los comentarios se leen dentro del lexer,
+// respetan los literales y quedan como trivia de los tokens vecinos.
+func TestCommentTrivia(t *testing.T) {
+	input := "/// Sitio web\n" +
+		"fact Site url \"http://x.org\"; // la url\n" +
+		"# etiqueta\n" +
+		"fact Tag name \"#1\" /* fin */;"
+	l := lexer.New(input)
+	var tokens []tk.Token
+	for tok := l.Ensambladora(); tok.Type != tk.EOF; tok = l.Ensambladora() {
+		tokens = append(tokens, tok)
+	}
+	var words []string
+	for _, tok := range tokens {
+		words = append(words, tok.Word)
+	}
+	want := []string{"fact", "Site", "url", "http://x.org", ";", "fact", "Tag", "name", "#1", ";"}
+	if strings.Join(words, " ") != strings.Join(want, " ") {
+		t.Fatalf("words = %q, want %q", words, want)
+	}
+	checks := []struct {
+		trivia []tk.Trivia
+		want   tk.Trivia
+	}{
+		{tokens[0].Leading, tk.Trivia{Type: tk.DOC_COMMENT, Text: "/// Sitio web", Line: 1, Column: 1}},
+		{tokens[4].Trailing, tk.Trivia{Type: tk.SINGLE_LINE_COMMENT, Text: "// la url", Line: 2, Column: 31}},
+		{tokens[5].Leading, tk.Trivia{Type: tk.COMMENT_INLINE, Text: "# etiqueta", Line: 3, Column: 1}},
+		{tokens[8].Trailing, tk.Trivia{Type: tk.COMMENT_MULTI_LINE, Text: "/* fin */", Line: 4, Column: 20}},
+	}
+	for i, c := range checks {
+		if len(c.trivia) != 1 || c.trivia[0] != c.want {
+			t.Errorf("trivia %d = %+v, want [%+v]", i, c.trivia, c.want)
+		}
+	}
+	if len(l.Errors()) != 0 {
+		t.Errorf("errors = %q", l.Errors())
+	}
+
+	l = lexer.New("x /* sin cerrar")
+	l.Ensambladora()
+	if len(l.Errors()) != 1 || !strings.Contains(l.Errors()[0], "Line 1, Column 3: lexer: unterminated comment") {
+		t.Errorf("errors = %q, want an unterminated comment at 1:3", l.Errors())
+	}
+}
+
+// TestLanguagePragma comprueba que //nexusl:lang antes del primer token
+// cambia el vocabulario y que después ya no tiene efecto.
+func TestLanguagePragma(t *testing.T) {
+	es, err := tk.DefaultVocabulary().WithAliases(map[string]string{"hecho": "fact", "tiene": "has"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tk.SetLanguage("es", es)
+	defer tk.SetLanguage("es", nil)
+
+	classes := func(l *lexer.Lexer) []tk.TokenClass {
+		var out []tk.TokenClass
+		for tok := l.Ensambladora(); tok.Type != tk.EOF; tok = l.Ensambladora() {
+			out = append(out, tok.Type)
+		}
+		return out
+	}
+	l := lexer.New("// otro comentario\n//nexusl:lang es\nhecho Car tiene wheels;")
+	want := []tk.TokenClass{tk.FACT, tk.IDENTIFIER, tk.HAS, tk.IDENTIFIER, tk.SEMICOLON}
+	if got := classes(l); fmt.Sprint(got) != fmt.Sprint(want) || l.ActiveVocabulary() != es {
+		t.Errorf("with pragma: classes = %v, want %v", got, want)
+	}
+
+	l = lexer.New("hecho Car tiene wheels;\n//nexusl:lang es\n")
+	want = []tk.TokenClass{tk.IDENTIFIER, tk.IDENTIFIER, tk.IDENTIFIER, tk.IDENTIFIER, tk.SEMICOLON}
+	if got := classes(l); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("late pragma: classes = %v, want %v", got, want)
+	}
+
+	l = lexer.New("//nexusl:lang xx\nfact a is b;")
+	classes(l)
+	if len(l.Errors()) != 1 || !strings.Contains(l.Errors()[0], `lexer: unknown language: "xx"`) {
+		t.Errorf("errors = %q, want an unknown language", l.Errors())
+	}
+}
+
+// TestStreamingLexer comprueba que NewReader produce los mismos tokens, con las
+// mismas posiciones, que New, aunque la entrada ocupe varios bloques y el
+// io.Reader entregue un byte por lectura.
+func TestStreamingLexer(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; sb.Len() < 200<<10; i++ {
+		fmt.Fprintf(&sb, "fact niño%d ubicación \"calle %d\"; // n.º %d\n", i, i, i)
+		if i%500 == 0 {
+			fmt.Fprintf(&sb, "fact nota%d texto \"\"\"\nlínea uno\nlínea dos\"\"\" # fin\n", i)
+		}
+	}
+	input := sb.String()
+
+	for name, r := range map[string]*lexer.Lexer{
+		"chunks":   lexer.NewReader(strings.NewReader(input)),
+		"one byte": lexer.NewReader(iotest.OneByteReader(strings.NewReader(input))),
+	} {
+		want := lexer.New(input)
+		for i := 0; ; i++ {
+			w, g := want.Ensambladora(), r.Ensambladora()
+			if w.Type != g.Type || w.Word != g.Word || w.Line != g.Line || w.Column != g.Column ||
+				fmt.Sprint(w.Leading, w.Trailing) != fmt.Sprint(g.Leading, g.Trailing) {
+				t.Fatalf("%s: token %d = %+v, want %+v", name, i, g, w)
+			}
+			if w.Type == tk.EOF {
+				break
+			}
+		}
+		if len(r.Errors()) != 0 {
+			t.Errorf("%s: errors = %q", name, r.Errors())
+		}
+	}
+
+	failing := lexer.NewReader(io.MultiReader(strings.NewReader("fact a b c;\n"), iotest.ErrReader(errors.New("disco"))))
+	for tok := failing.Ensambladora(); tok.Type != tk.EOF; tok = failing.Ensambladora() {
+	}
+	if len(failing.Errors()) != 1 || !strings.Contains(failing.Errors()[0], "lexer: read error: disco") {
+		t.Errorf("errors = %q, want a read error", failing.Errors())
+	}
+}
+
+// words lee todos los tokens de l hasta el EOF y devuelve sus clases y palabras.
+func words(l *lexer.Lexer) (classes []tk.TokenClass, words []string) {
+	for tok := l.Ensambladora(); tok.Type != tk.EOF; tok = l.Ensambladora() {
+		classes = append(classes, tok.Type)
+		words = append(words, tok.Word)
+	}
+	return classes, words
+}
+
+// TestStringLiterals comprueba las tres formas de cadena: cruda, multilínea
+// y con escapes.
+func TestStringLiterals(t *testing.T) {
+	l := lexer.New("`{\"id\": 1, \"tags\": [\"a\\n\"]}` \"\"\"\nPrimera línea\n\tsegunda\"\"\" \"dice \\\"hola\\\"\\u{21}\"")
+	classes, got := words(l)
+	wantClasses := []tk.TokenClass{tk.STRING, tk.MULTILINE_STRING, tk.STRING}
+	want := []string{`{"id": 1, "tags": ["a\n"]}`, "Primera línea\n\tsegunda", `dice "hola"!`}
+	if fmt.Sprint(classes) != fmt.Sprint(wantClasses) || fmt.Sprintf("%q", got) != fmt.Sprintf("%q", want) {
+		t.Errorf("tokens = %v %q, want %v %q", classes, got, wantClasses, want)
+	}
+	if len(l.Errors()) != 0 {
+		t.Errorf("errors = %q", l.Errors())
+	}
+
+	l = lexer.New("fact order note \"sin cerrar;\nfact order label x;")
+	words(l)
+	if errs := l.Errors(); len(errs) != 1 || !strings.HasPrefix(errs[0], "Line 1, Column 17: lexer: unterminated literal") {
+		t.Errorf("errors = %q, want an unterminated literal at 1:17", errs)
+	}
+}
+
+// TestCharLiterals comprueba que un literal de carácter tiene exactamente uno.
+func TestCharLiterals(t *testing.T) {
+	l := lexer.New(`'a' 'ñ' 'n` + "\u0303" + `' '\n'`)
+	classes, got := words(l)
+	if fmt.Sprint(classes) != "[CHAR CHAR CHAR CHAR]" || len(l.Errors()) != 0 {
+		t.Errorf("tokens = %v %q, errors %q", classes, got, l.Errors())
+	}
+
+	l = lexer.New("'ab' '' x")
+	classes, got = words(l)
+	if fmt.Sprint(classes) != "[ILLEGAL ILLEGAL IDENTIFIER]" || fmt.Sprintf("%q", got) != `["'ab'" "''" "x"]` {
+		t.Errorf("tokens = %v %q, want two illegal literals and x", classes, got)
+	}
+	wantErrors := []string{
+		"Line 1, Column 1: lexer: character literal must hold exactly one character: 'ab'",
+		"Line 1, Column 6: lexer: character literal must hold exactly one character: ''",
+	}
+	if fmt.Sprintf("%q", l.Errors()) != fmt.Sprintf("%q", wantErrors) {
+		t.Errorf("errors = %q, want %q", l.Errors(), wantErrors)
+	}
+}
+
+// TestUnicodeIdentifiers comprueba que los identificadores admiten letras
+// Unicode y se normalizan a NFC: 'n' + U+0303 y 'ñ' son la misma palabra.
+func TestUnicodeIdentifiers(t *testing.T) {
+	classes, got := words(lexer.New("nin\u0303o ubicación año λ_1"))
+	want := []string{"niño", "ubicación", "año", "λ_1"}
+	if fmt.Sprint(classes) != "[IDENTIFIER IDENTIFIER IDENTIFIER IDENTIFIER]" || fmt.Sprintf("%q", got) != fmt.Sprintf("%q", want) {
+		t.Errorf("tokens = %v %q, want identifiers %q", classes, got, want)
+	}
+}
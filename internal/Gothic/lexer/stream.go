@@ -0,0 +1,70 @@
+// lexer/stream.go
+package lexer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/devicemxl/nexusl/internal/Gothic/diag"
+)
+
+// Lectura por flujo
+//
+// NewReader lee la entrada de un io.Reader en bloques en vez de exigir el
+// programa entero en memoria. Input pasa a ser una ventana deslizante:
+// ReadChar la rellena cuando quedan menos de streamLookahead bytes por
+// delante y Ensambladora descarta lo ya leído antes de cada token. La ventana
+// solo crece con el token en curso (una cadena multilínea larga, por ejemplo).
+//
+// Line y Column se cuentan al leer, así que no dependen de la ventana;
+// Position, ReadPosition y LineStartPos son offsets dentro de ella.
+
+const (
+	streamChunk     = 64 << 10 // Tamaño mínimo de cada lectura.
+	streamLookahead = 4 << 10  // Bytes que deben quedar por delante de ReadPosition.
+)
+
+// ErrRead indica que el io.Reader devolvió un error distinto de io.EOF.
+var ErrRead = errors.New("lexer: read error")
+
+// NewReader crea un lexer que lee la entrada de r por bloques.
+func NewReader(r io.Reader) *Lexer {
+	l := &Lexer{Line: 1, reader: r}
+	l.ReadChar() // Carga el primer carácter y actualiza Line/Column
+	return l
+}
+
+// fill añade a la ventana el siguiente bloque de la entrada. Lee al menos
+// streamChunk bytes, o tantos como ya tenga la ventana, para que el coste de
+// copiarla quede amortizado. Al llegar al final deja de leer; un error de
+// lectura queda en l.Diagnostics y se trata como el final de la entrada.
+func (l *Lexer) fill() {
+	if l.readErr != nil {
+		return
+	}
+	buf := make([]byte, max(streamChunk, len(l.Input)))
+	n, err := io.ReadAtLeast(l.reader, buf, streamLookahead)
+	l.Input += string(buf[:n])
+	switch {
+	case err == nil:
+	case errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF):
+		l.readErr = io.EOF
+	default:
+		l.readErr = err
+		at := diag.Position{Line: l.Line, Column: l.Column + 1}
+		l.report(diag.Wrap(diag.ReadError, diag.Span{Start: at, End: at}, fmt.Errorf("%w: %w", ErrRead, err)))
+	}
+}
+
+// compact descarta de la ventana lo que está antes del carácter actual.
+func (l *Lexer) compact() {
+	if l.reader == nil {
+		return
+	}
+	shift := min(l.Position, len(l.Input))
+	l.Input = l.Input[shift:]
+	l.Position -= shift
+	l.ReadPosition -= shift
+	l.LineStartPos -= shift
+}
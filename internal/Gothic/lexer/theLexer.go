@@ -2,7 +2,28 @@
 package lexer
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/devicemxl/nexusl/internal/Gothic/diag"
 	tk "github.com/devicemxl/nexusl/internal/Gothic/token"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Errores léxicos. Los tokens ILLEGAL llevan el texto problemático y el
+// diagnóstico correspondiente queda en Lexer.Diagnostics.
+var (
+	ErrUnterminated        = errors.New("lexer: unterminated literal")
+	ErrUnterminatedComment = errors.New("lexer: unterminated comment")
+	ErrInvalidEscape       = errors.New("lexer: invalid escape sequence")
+	ErrUnexpectedChar      = errors.New("lexer: unexpected character")
+	ErrUnknownLanguage     = errors.New("lexer: unknown language")
+	ErrInvalidChar         = errors.New("lexer: character literal must hold exactly one character")
 )
 
 // Lexer representa la instancia del analizador léxico
@@ -10,10 +31,34 @@ type Lexer struct {
 	Input        string
 	Position     int  // current position in input (points to current char)
 	ReadPosition int  // current reading position in input (after current char)
-	Ch           byte // current char under examination
+	Ch           rune // current char under examination (decoded from UTF-8)
 	Line         int  // current line number (1-based)
-	Column       int  // current column number (1-based)
+	Column       int  // current column number (1-based, in runes)
 	LineStartPos int  // Position of the start of the current line in Input string (0-indexed)
+
+	Diagnostics []diag.Diagnostic // Errores léxicos
+
+	// Vocabulary decide qué palabras son palabras clave; nil usa
+	// tk.DefaultVocabulary() en el momento de leer cada palabra. Un pragma
+	// //nexusl:lang al principio del archivo lo reemplaza (ver trivia.go).
+	Vocabulary *tk.Vocabulary
+	sawToken   bool // Ya se leyó un token: los pragmas dejan de valer
+
+	// Posición de l.Ch. Coincide con Line/Column salvo cuando l.Ch es '\n',
+	// que ReadChar ya cuenta como el inicio de la línea siguiente.
+	chLine   int
+	chColumn int
+
+	// Línea y columna del token en curso, fijadas por Ensambladora: un token
+	// puede terminar en una línea posterior (cadenas multilínea) o justo antes
+	// de un '\n' que ReadChar ya contó.
+	tokenLine   int
+	tokenColumn int
+
+	// Entrada por flujo (ver NewReader): Input es entonces una ventana que se
+	// rellena desde reader y se descarta por delante a medida que avanza.
+	reader  io.Reader
+	readErr error
 }
 
 // SkipWhitespace avanza el lexer sobre los caracteres de espacio en blanco.
@@ -24,12 +69,24 @@ func (l *Lexer) SkipWhitespace() {
 	}
 }
 
-// LookupIdent verifica si la palabra es una palabra clave o un identificador.
+// LookupIdent verifica si la palabra es una palabra clave o un identificador
+// según el vocabulario por defecto.
 func LookupIdent(ident string) tk.TokenClass {
-	if tok, ok := tk.Keywords[ident]; ok {
-		return tok
+	return tk.LookupIdent(ident)
+}
+
+// ActiveVocabulary devuelve el vocabulario con que el lexer lee las palabras:
+// Vocabulary, o el vocabulario por defecto si es nil.
+func (l *Lexer) ActiveVocabulary() *tk.Vocabulary {
+	if l.Vocabulary != nil {
+		return l.Vocabulary
 	}
-	return tk.IDENTIFIER // Cambié de TK.TOKEN a TK.IDENTIFIER si no es una palabra clave.
+	return tk.DefaultVocabulary()
+}
+
+// lookupIdent es LookupIdent con el vocabulario del lexer.
+func (l *Lexer) lookupIdent(ident string) tk.TokenClass {
+	return l.ActiveVocabulary().Class(ident)
 }
 
 // New crea e inicializa un nuevo lexer
@@ -47,19 +104,27 @@ func New(input string) *Lexer {
 	return l
 }
 
-// ReadChar es el método que avanza el lexer un carácter
+// ReadChar es el método que avanza el lexer un carácter. Decodifica la
+// entrada como UTF-8: Position y ReadPosition son offsets en bytes, mientras
+// que Column cuenta runas. Una secuencia inválida se lee como
+// utf8.RuneError de un byte.
 func (l *Lexer) ReadChar() {
+	if l.reader != nil && len(l.Input)-l.ReadPosition < streamLookahead {
+		l.fill()
+	}
+	size := 1
 	if l.ReadPosition >= len(l.Input) {
 		l.Ch = 0 // EOF
 	} else {
-		l.Ch = l.Input[l.ReadPosition]
+		l.Ch, size = utf8.DecodeRuneInString(l.Input[l.ReadPosition:])
 	}
 
 	// Actualiza Position para que apunte al carácter que acabamos de cargar en l.Ch
 	l.Position = l.ReadPosition
-	l.ReadPosition++
+	l.ReadPosition += size
 
 	// Lógica de actualización de línea y columna
+	l.chLine, l.chColumn = l.Line, l.Column+1
 	if l.Ch == '\n' {
 		l.Line++
 		l.Column = 0                    // Reinicia la columna para la nueva línea (se hará 1 en la próxima lectura de carácter)
@@ -71,38 +136,78 @@ func (l *Lexer) ReadChar() {
 }
 
 // NewToken crea un nuevo token con el tipo, literal y la posición de inicio del token.
-// La columna se calcula basándose en tokenStartPos (la Position del lexer cuando el token comenzó a ser leído).
+// La línea y la columna son las que Ensambladora registró al empezar el token,
+// cuya Position es tokenStartPos.
 func (l *Lexer) NewToken(tokenType tk.TokenClass, literal string, tokenStartPos int) tk.Token {
-	// La columna del token se calcula usando la posición de inicio del token (tokenStartPos)
-	// y la posición de inicio de la línea (l.LineStartPos).
-	// Se suma 1 porque las columnas suelen ser 1-based.
-	columnForToken := (tokenStartPos - l.LineStartPos) + 1
-	return tk.Token{Type: tokenType, Word: literal, Line: l.Line, Column: columnForToken}
+	if l.reader != nil {
+		literal = strings.Clone(literal) // No retener la ventana entera por una subcadena
+	}
+	return tk.Token{Type: tokenType, Word: literal, Line: l.tokenLine, Column: l.tokenColumn}
+}
+
+// markTokenStart recuerda la línea y la columna en las que empieza el token actual.
+func (l *Lexer) markTokenStart() {
+	l.tokenLine, l.tokenColumn = l.Line, l.Column
 }
 
 // peekChar devuelve el próximo carácter sin avanzar la posición
-func (l *Lexer) peekChar() byte {
+func (l *Lexer) peekChar() rune {
 	if l.ReadPosition >= len(l.Input) {
 		return 0
 	}
-	return l.Input[l.ReadPosition]
+	ch, _ := utf8.DecodeRuneInString(l.Input[l.ReadPosition:])
+	return ch
+}
+
+// IsLetter verifica si una runa puede empezar un identificador: una letra
+// Unicode ('a', 'ñ', 'ó', 'λ') o '_'.
+func IsLetter(ch rune) bool {
+	return unicode.IsLetter(ch) || ch == '_'
 }
 
-// IsLetter verifica si un byte es una letra (para identificadores).
-func IsLetter(ch byte) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+// isIdentifierPart verifica si una runa puede continuar un identificador:
+// además de letras, dígitos Unicode y marcas combinantes (una 'n' seguida de
+// U+0303 se normaliza después a 'ñ').
+func isIdentifierPart(ch rune) bool {
+	return IsLetter(ch) || unicode.IsDigit(ch) || unicode.Is(unicode.Mn, ch)
 }
 
-// isDigit verifica si un byte es un dígito.
-// isDigit verifica si un byte es un dígito.
-func isDigit(ch byte) bool {
+// isDigit verifica si una runa es un dígito ASCII (los números solo usan estos).
+func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
 
-// ReadNumber lee un número completo (entero, flotante o complejo).
+// ReadNumber lee un número completo (entero, flotante, decimal o complejo).
 // Determina el tipo de número y lo devuelve como string.
+//
+// El sufijo 'd' marca un decimal exacto ('19.99d', '100d'), que no admite exponente.
+// Un número seguido de 'i' es imaginario ('3i', '3.14i', '1e-3i') y un real
+// seguido sin espacios de un signo y un imaginario ('2+3i', '2.5-0.5i') es un
+// único literal complejo. Con espacios ('2 + 3i') son tres tokens que el
+// parser no une: en '(* 2 -3i)' el '-3i' es otro argumento.
 func (l *Lexer) ReadNumber() (string, tk.TokenClass) {
 	position := l.Position
+	tokenType := l.readReal()
+
+	if l.Ch == 'i' && !isIdentifierPart(l.peekChar()) {
+		l.ReadChar() // Consume la 'i'
+		return l.Input[position:l.Position], tk.COMPLEX
+	}
+	if l.Ch == 'd' && !isIdentifierPart(l.peekChar()) && !strings.ContainsAny(l.Input[position:l.Position], "eE") {
+		l.ReadChar() // Consume la 'd'
+		return l.Input[position:l.Position], tk.DECIMAL
+	}
+	if (l.Ch == '+' || l.Ch == '-') && imaginaryLength(l.Input[l.ReadPosition:]) > 0 {
+		for n := imaginaryLength(l.Input[l.ReadPosition:]); n >= 0; n-- {
+			l.ReadChar() // Consume el signo y la parte imaginaria
+		}
+		return l.Input[position:l.Position], tk.COMPLEX
+	}
+	return l.Input[position:l.Position], tokenType
+}
+
+// readReal lee la parte entera, la fracción y el exponente de un número.
+func (l *Lexer) readReal() tk.TokenClass {
 	tokenType := tk.INTEGER // Asumimos entero por defecto
 
 	// Leer parte entera
@@ -119,95 +224,204 @@ func (l *Lexer) ReadNumber() (string, tk.TokenClass) {
 		}
 	}
 
-	// Comprobar si es un número complejo (contiene 'i' al final, asumiendo formato Go/Python)
-	// Opcional: Podrías querer soportar 2+3i, 3i, etc. Por ahora, solo detecta 'i' al final de un número.
-	// Para 2+3i, necesitarías una fase de parsing que combine INTEGER/FLOAT + PLUS + INTEGER/FLOAT + I
-	if l.Ch == 'i' { // Asumiendo 'i' como sufijo para números imaginarios puros como '3i' o '3.14i'
-		// Esto clasifica números como '10i' o '3.14i' como COMPLEX.
-		// Si tu gramática soporta '2 + 3i' como un solo token complejo, esto sería más complejo.
-		tokenType = tk.COMPLEX // Es un número complejo (imaginario puro en esta implementación)
-		l.ReadChar()           // Consume la 'i'
-	} else if l.Ch == 'E' || l.Ch == 'e' { // Notación científica (ej. 1e5, 1.2e-3)
-		// Solo si no es ya un número complejo puro. Un número complejo como 3e+2i es más avanzado.
-		// Aquí detectamos 'e' o 'E' para números flotantes en notación científica.
-		// Asegúrate de que no es la 'e' de un identificador.
-		l.ReadChar() // Consume 'E' o 'e'
-		if l.Ch == '+' || l.Ch == '-' {
-			l.ReadChar() // Consume '+' o '-'
-		}
-		for isDigit(l.Ch) {
+	// Notación científica (ej. 1e5, 1.2e-3); la 'e' solo cuenta si le sigue el exponente.
+	if (l.Ch == 'E' || l.Ch == 'e') && exponentLength(l.Input[l.Position:]) > 0 {
+		for n := exponentLength(l.Input[l.Position:]); n > 0; n-- {
 			l.ReadChar()
 		}
 		tokenType = tk.FLOAT // Un número con notación científica es un flotante
 	}
+	return tokenType
+}
 
-	return l.Input[position:l.Position], tokenType
+// exponentLength devuelve la longitud del exponente ('e5', 'E-3') al inicio de s, o 0.
+func exponentLength(s string) int {
+	if len(s) == 0 || (s[0] != 'e' && s[0] != 'E') {
+		return 0
+	}
+	i := 1
+	if i < len(s) && (s[i] == '+' || s[i] == '-') {
+		i++
+	}
+	digits := i
+	for i < len(s) && isDigit(rune(s[i])) {
+		i++
+	}
+	if i == digits {
+		return 0
+	}
+	return i
 }
 
-// ReadIdentifier lee un identificador completo.
+// imaginaryLength devuelve la longitud del imaginario sin signo ('3i', '0.5i',
+// '1e-3i') al inicio de s, o 0 si s no empieza por uno.
+func imaginaryLength(s string) int {
+	i := 0
+	for i < len(s) && isDigit(rune(s[i])) {
+		i++
+	}
+	if i == 0 {
+		return 0
+	}
+	if i+1 < len(s) && s[i] == '.' && isDigit(rune(s[i+1])) {
+		i++
+		for i < len(s) && isDigit(rune(s[i])) {
+			i++
+		}
+	}
+	i += exponentLength(s[i:])
+	if i >= len(s) || s[i] != 'i' {
+		return 0
+	}
+	if next, _ := utf8.DecodeRuneInString(s[i+1:]); isIdentifierPart(next) {
+		return 0
+	}
+	return i + 1
+}
+
+// ReadIdentifier lee un identificador completo y lo normaliza a NFC, de modo
+// que 'niño' escrito con la ñ precompuesta o como n + U+0303 es el mismo
+// nombre (y el mismo ds.Symbol).
 func (l *Lexer) ReadIdentifier() string {
 	position := l.Position
-	for IsLetter(l.Ch) || isDigit(l.Ch) {
+	for isIdentifierPart(l.Ch) {
 		l.ReadChar()
 	}
-	return l.Input[position:l.Position]
+	return norm.NFC.String(l.Input[position:l.Position])
 }
 
-// readStringLiteral lee un literal de cadena (entre comillas dobles).
-// Se asume que l.Ch ya está en el carácter *después* de la comilla de apertura.
-// Esta función lee hasta la comilla de cierre y avanza el lexer hasta después de ella.
-func (l *Lexer) readStringLiteral() string {
-	start := l.Position // La posición actual es donde empieza el contenido del string
-
+// readDelimited lee el contenido de un literal hasta el delimitador de cierre
+// delim y deja el lexer después de él. Se asume que l.Ch ya está en el primer
+// carácter del contenido. Si multiline es falso, un salto de línea termina el
+// literal sin cerrarlo; si escapes es verdadero, interpreta las secuencias de
+// escape. Ante un escape inválido sigue leyendo hasta el cierre para que el
+// siguiente token empiece donde corresponde.
+func (l *Lexer) readDelimited(delim string, multiline, escapes bool) (string, error) {
+	var sb strings.Builder
+	var err error
 	for {
-		l.ReadChar() // Avanza al siguiente carácter
-
-		// Si es la comilla de cierre o EOF
-		if l.Ch == '"' || l.Ch == 0 {
-			break
+		switch {
+		case l.Ch == 0 || (l.Ch == '\n' && !multiline):
+			return sb.String(), fmt.Errorf("%w: missing closing %s", ErrUnterminated, delim)
+		case strings.HasPrefix(l.Input[l.Position:], delim):
+			for i := 0; i < len(delim); i++ {
+				l.ReadChar() // Consume el delimitador de cierre
+			}
+			return sb.String(), err
+		case l.Ch == '\\' && escapes:
+			if e := l.readEscape(&sb); e != nil && err == nil {
+				err = e
+			}
+		default:
+			sb.WriteRune(l.Ch)
+			l.ReadChar()
 		}
-		// TODO: Implementar lógica para caracteres de escape si los necesitas
-		// Ejemplo simple para '\\' y '\"':
-		// if l.Ch == '\\' {
-		// 	l.ReadChar() // Consume el backslash
-		// 	// Podrías añadir un switch aquí para diferentes caracteres de escape
-		// }
 	}
+}
 
-	// El literal es desde 'start' hasta l.Position (que es la comilla de cierre)
-	literal := l.Input[start:l.Position]
-
-	// Si no es EOF, consume la comilla de cierre
-	if l.Ch == '"' {
-		l.ReadChar() // Consume la comilla de cierre
+// readEscape interpreta la secuencia de escape que empieza en la barra invertida y
+// escribe su valor en sb: \n, \t, \r, \0, \\, \", \' y \u{XXXX} (de 1 a 6
+// dígitos hexadecimales). No consume un salto de línea ni el EOF.
+func (l *Lexer) readEscape(sb *strings.Builder) error {
+	l.ReadChar() // Consume el '\\'
+	switch l.Ch {
+	case 'n':
+		sb.WriteByte('\n')
+	case 't':
+		sb.WriteByte('\t')
+	case 'r':
+		sb.WriteByte('\r')
+	case '0':
+		sb.WriteByte(0)
+	case '\\', '"', '\'':
+		sb.WriteRune(l.Ch)
+	case 'u':
+		return l.readUnicodeEscape(sb)
+	case 0, '\n':
+		return fmt.Errorf("%w: incomplete escape sequence", ErrInvalidEscape)
+	default:
+		err := fmt.Errorf("%w: \\%c", ErrInvalidEscape, l.Ch)
+		l.ReadChar()
+		return err
 	}
-
-	return literal
+	l.ReadChar() // Consume el carácter escapado
+	return nil
 }
 
-// readCharLiteral lee un literal de caracter (entre comillas simples).
-// Se asume que l.Ch ya está en el carácter *después* de la comilla de apertura.
-// Esta función lee hasta la comilla de cierre y avanza el lexer hasta después de ella.
-func (l *Lexer) readCharLiteral() string {
-	start := l.Position // La posición actual es donde empieza el contenido del caracter
+// readUnicodeEscape lee '{XXXX}' después de \u y escribe el punto de código.
+func (l *Lexer) readUnicodeEscape(sb *strings.Builder) error {
+	if l.peekChar() != '{' {
+		l.ReadChar() // Consume la 'u'
+		return fmt.Errorf("%w: \\u must be followed by {hex}", ErrInvalidEscape)
+	}
+	l.ReadChar() // Consume la 'u'
+	l.ReadChar() // Consume la '{'
+	start := l.Position
+	for isHexDigit(l.Ch) {
+		l.ReadChar()
+	}
+	digits := l.Input[start:l.Position]
+	if l.Ch != '}' {
+		return fmt.Errorf("%w: \\u{%s without closing }", ErrInvalidEscape, digits)
+	}
+	l.ReadChar() // Consume la '}'
+	code, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil || len(digits) > 6 || !utf8.ValidRune(rune(code)) {
+		return fmt.Errorf("%w: \\u{%s} is not a valid code point", ErrInvalidEscape, digits)
+	}
+	sb.WriteRune(rune(code))
+	return nil
+}
 
-	for {
-		l.ReadChar() // Avanza al siguiente carácter
+// isHexDigit verifica si una runa es un dígito hexadecimal.
+func isHexDigit(ch rune) bool {
+	return isDigit(ch) || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
+}
 
-		// Si es la comilla simple de cierre o EOF
-		if l.Ch == '\'' || l.Ch == 0 {
-			break
-		}
-		// TODO: Implementar lógica para caracteres de escape si los necesitas
+// literalToken crea el token de un literal delimitado por delim que empezó en
+// tokenStartPos. Si la lectura falló devuelve un token ILLEGAL con el texto
+// leído y registra el diagnóstico en l.Diagnostics.
+func (l *Lexer) literalToken(tokenType tk.TokenClass, literal string, err error, tokenStartPos int, delim string) tk.Token {
+	if err == nil {
+		return l.NewToken(tokenType, literal, tokenStartPos)
 	}
+	tok := l.NewToken(tk.ILLEGAL, l.Input[tokenStartPos:l.Position], tokenStartPos)
+	tok.EndLine, tok.EndColumn = l.chLine, l.chColumn
+	if errors.Is(err, ErrInvalidEscape) {
+		l.report(diag.Wrap(diag.InvalidEscape, diag.TokenSpan(tok), err).
+			WithNote(`valid escapes are \n \t \r \0 \\ \" \' and \u{XXXX}`))
+		return tok
+	}
+	if errors.Is(err, ErrInvalidChar) {
+		l.report(diag.Wrap(diag.InvalidChar, diag.TokenSpan(tok), err).
+			WithNote(`use a string "..." for text`))
+		return tok
+	}
+	d := diag.Wrap(diag.UnterminatedLiteral, diag.TokenSpan(tok), err).
+		WithFix("close the literal", diag.PointAfter(tok), delim)
+	if delim == `"` && l.Ch == '\n' {
+		d = d.WithNote(`a "..." string cannot span lines; use """...""" or a raw string`)
+	}
+	l.report(d)
+	return tok
+}
 
-	// El literal es desde 'start' hasta l.Position (que es la comilla de cierre)
-	literal := l.Input[start:l.Position]
+// illegal crea un token ILLEGAL para el carácter actual, registra el diagnóstico y lo consume.
+func (l *Lexer) illegal(tokenStartPos int) tk.Token {
+	tok := l.NewToken(tk.ILLEGAL, string(l.Ch), tokenStartPos)
+	tok.EndLine, tok.EndColumn = tok.Line, tok.Column+1
+	l.report(diag.Wrap(diag.UnexpectedChar, diag.TokenSpan(tok), fmt.Errorf("%w %q", ErrUnexpectedChar, l.Ch)))
+	l.ReadChar() // Consume el carácter ilegal
+	return tok
+}
 
-	// Si no es EOF, consume la comilla de cierre
-	if l.Ch == '\'' {
-		l.ReadChar() // Consume la comilla de cierre
-	}
+// report registra un diagnóstico léxico.
+func (l *Lexer) report(d diag.Diagnostic) {
+	lexerLog.Debug("diagnostic", "code", d.Code, "line", d.Span.Start.Line, "column", d.Span.Start.Column, "msg", d.Message)
+	l.Diagnostics = append(l.Diagnostics, d)
+}
 
-	return literal
+// Errors devuelve el texto de los diagnósticos léxicos.
+func (l *Lexer) Errors() []string {
+	return diag.Strings(l.Diagnostics)
 }
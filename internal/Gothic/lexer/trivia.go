@@ -0,0 +1,140 @@
+// lexer/trivia.go
+package lexer
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/devicemxl/nexusl/internal/Gothic/diag"
+	tk "github.com/devicemxl/nexusl/internal/Gothic/token"
+)
+
+// Comentarios
+//
+// El lexer reconoce los comentarios en su propia máquina de estados, de modo
+// que un '//' o un '#' dentro de un literal ("http://x.org", "#1") no corta la
+// línea. Los comentarios no son tokens: se guardan como trivia del token
+// siguiente (Leading) o, si están en la misma línea, del anterior (Trailing).
+//
+//	// comentario de línea       SINGLE_LINE_COMMENT
+//	# comentario de línea        COMMENT_INLINE
+//	/* comentario de bloque */   COMMENT_MULTI_LINE
+//	/// documentación            DOC_COMMENT
+//
+// Un comentario '//nexusl:lang es' antes del primer token es un pragma: el
+// resto del archivo se lee con el vocabulario de ese idioma (ver
+// token.Language), de modo que 'hecho' o 'tiene' son palabras clave.
+
+// LanguagePragma es el prefijo del pragma de idioma.
+const LanguagePragma = "//nexusl:lang"
+
+// PragmaLanguage devuelve el idioma de un comentario pragma de idioma.
+func PragmaLanguage(comment string) (string, bool) {
+	rest, ok := strings.CutPrefix(comment, LanguagePragma)
+	if !ok || (rest != "" && rest[0] != ' ' && rest[0] != '\t') {
+		return "", false
+	}
+	return strings.TrimSpace(rest), true
+}
+
+// commentClass devuelve la clase del comentario que empieza en l.Ch, o "" si no empieza ninguno.
+func (l *Lexer) commentClass() tk.TokenClass {
+	rest := l.Input[l.Position:]
+	switch {
+	case l.Ch == 0:
+		return ""
+	case strings.HasPrefix(rest, "///"):
+		return tk.DOC_COMMENT
+	case strings.HasPrefix(rest, "//"):
+		return tk.SINGLE_LINE_COMMENT
+	case strings.HasPrefix(rest, "/*"):
+		return tk.COMMENT_MULTI_LINE
+	case l.Ch == '#':
+		return tk.COMMENT_INLINE
+	}
+	return ""
+}
+
+// readTrivia salta espacios y comentarios y devuelve los comentarios leídos.
+func (l *Lexer) readTrivia() []tk.Trivia {
+	var trivia []tk.Trivia
+	for {
+		l.SkipWhitespace()
+		class := l.commentClass()
+		if class == "" {
+			return trivia
+		}
+		c := l.readComment(class)
+		if !l.sawToken && class == tk.SINGLE_LINE_COMMENT {
+			l.pragma(c)
+		}
+		trivia = append(trivia, c)
+	}
+}
+
+// pragma aplica un pragma de idioma; cualquier otro comentario se ignora.
+func (l *Lexer) pragma(c tk.Trivia) {
+	lang, ok := PragmaLanguage(c.Text)
+	if !ok {
+		return
+	}
+	v, ok := tk.Language(lang)
+	if !ok {
+		span := diag.Span{
+			Start: diag.Position{Line: c.Line, Column: c.Column},
+			End:   diag.Position{Line: c.Line, Column: c.Column + utf8.RuneCountInString(c.Text)},
+		}
+		l.report(diag.Wrap(diag.UnknownLanguage, span, fmt.Errorf("%w: %q", ErrUnknownLanguage, lang)).
+			WithNote("known languages: %s", strings.Join(tk.Languages(), ", ")))
+		return
+	}
+	l.Vocabulary = v
+}
+
+// readTrailingTrivia lee los comentarios que siguen al token en su misma línea.
+func (l *Lexer) readTrailingTrivia() []tk.Trivia {
+	var trivia []tk.Trivia
+	for {
+		for l.Ch == ' ' || l.Ch == '\t' {
+			l.ReadChar()
+		}
+		class := l.commentClass()
+		if class == "" {
+			return trivia
+		}
+		trivia = append(trivia, l.readComment(class))
+		if class != tk.COMMENT_MULTI_LINE {
+			return trivia // Un comentario de línea llega hasta el '\n'
+		}
+	}
+}
+
+// readComment lee un comentario de la clase dada. Un comentario de bloque sin
+// cerrar llega hasta el EOF y deja un diagnóstico en l.Diagnostics.
+func (l *Lexer) readComment(class tk.TokenClass) tk.Trivia {
+	start := l.Position
+	trivia := tk.Trivia{Type: class, Line: l.Line, Column: l.Column}
+	if class == tk.COMMENT_MULTI_LINE {
+		l.ReadChar() // Consume '/'
+		l.ReadChar() // Consume '*'
+		for l.Ch != 0 && !strings.HasPrefix(l.Input[l.Position:], "*/") {
+			l.ReadChar()
+		}
+		if l.Ch == 0 {
+			start := diag.Position{Line: trivia.Line, Column: trivia.Column}
+			end := diag.Position{Line: l.chLine, Column: l.chColumn}
+			l.report(diag.Wrap(diag.UnterminatedComment, diag.Span{Start: start, End: end}, fmt.Errorf("%w: missing closing */", ErrUnterminatedComment)).
+				WithFix("close the comment", diag.Span{Start: end, End: end}, "*/"))
+		} else {
+			l.ReadChar() // Consume '*'
+			l.ReadChar() // Consume '/'
+		}
+	} else {
+		for l.Ch != 0 && l.Ch != '\n' {
+			l.ReadChar()
+		}
+	}
+	trivia.Text = strings.Clone(strings.TrimRight(l.Input[start:l.Position], "\r"))
+	return trivia
+}
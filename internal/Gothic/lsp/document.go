@@ -0,0 +1,163 @@
+// Gothic/lsp/document.go
+package lsp
+
+import (
+	"strings"
+	"unicode/utf16"
+
+	"github.com/devicemxl/nexusl/internal/Gothic/ast"
+	"github.com/devicemxl/nexusl/internal/Gothic/diag"
+	"github.com/devicemxl/nexusl/internal/Gothic/lexer"
+	"github.com/devicemxl/nexusl/internal/Gothic/metamodel"
+	"github.com/devicemxl/nexusl/internal/Gothic/parser"
+	"github.com/devicemxl/nexusl/internal/Gothic/token"
+)
+
+// document es un archivo abierto en el editor, ya analizado.
+type document struct {
+	uri     string
+	version int
+	text    string
+	lines   []string // Líneas de text, sin el salto de línea
+
+	program *ast.Program
+	diags   []diag.Diagnostic
+	tokens  []token.Token // Todos los tokens, con su trivia; el último es EOF
+	// vocabulary es el vocabulario con que se leyó, el del pragma
+	// //nexusl:lang si lo tiene.
+	vocabulary *token.Vocabulary
+	// declared guarda, por nombre, el sujeto del primer 'fact' que lo usa:
+	// es la declaración a la que lleva "ir a la definición".
+	declared map[string]*ast.FactStatement
+}
+
+// newDocument analiza text.
+func newDocument(uri string, version int, text string, mm *metamodel.MetamodelDefinitions) *document {
+	d := &document{uri: uri, version: version, text: text, lines: strings.Split(text, "\n")}
+	p := parser.New(lexer.New(text), mm)
+	d.program = p.ParseProgram()
+	d.diags = p.Diagnostics()
+
+	l := lexer.New(text)
+	for {
+		tok := l.Ensambladora()
+		d.tokens = append(d.tokens, tok)
+		if tok.Type == token.EOF {
+			break
+		}
+	}
+	d.vocabulary = l.ActiveVocabulary()
+
+	d.declared = map[string]*ast.FactStatement{}
+	for _, stmt := range d.program.Statements {
+		fs, ok := stmt.(*ast.FactStatement)
+		if !ok {
+			continue
+		}
+		if id, ok := fs.Subject.(*ast.Identifier); ok {
+			if _, seen := d.declared[id.Value]; !seen {
+				d.declared[id.Value] = fs
+			}
+		}
+	}
+	return d
+}
+
+// declaration devuelve el identificador que declara name, si lo hay.
+func (d *document) declaration(name string) (*ast.Identifier, bool) {
+	fs, ok := d.declared[name]
+	if !ok {
+		return nil, false
+	}
+	return fs.Subject.(*ast.Identifier), true
+}
+
+// isDeclaration indica si t es el sujeto que declara su nombre.
+func (d *document) isDeclaration(t token.Token) bool {
+	id, ok := d.declaration(t.Word)
+	return ok && id.Token.Line == t.Line && id.Token.Column == t.Column
+}
+
+// position convierte una posición del código (línea y columna en runas,
+// 1-based) en una del LSP.
+func (d *document) position(line, column int) Position {
+	if line < 1 || line > len(d.lines) {
+		return Position{Line: max(line-1, 0)}
+	}
+	units, runes := 0, 0
+	for _, r := range d.lines[line-1] {
+		if runes >= column-1 {
+			break
+		}
+		units += utf16.RuneLen(r)
+		runes++
+	}
+	return Position{Line: line - 1, Character: units}
+}
+
+// sourcePosition convierte una posición del LSP en línea y columna del código.
+func (d *document) sourcePosition(p Position) (line, column int) {
+	line, column = p.Line+1, 1
+	if p.Line < 0 || p.Line >= len(d.lines) {
+		return line, column
+	}
+	units := 0
+	for _, r := range d.lines[p.Line] {
+		if units >= p.Character {
+			break
+		}
+		units += utf16.RuneLen(r)
+		column++
+	}
+	return line, column
+}
+
+// tokenRange es el rango de un token en el LSP.
+func (d *document) tokenRange(t token.Token) Range {
+	return Range{Start: d.position(t.Line, t.Column), End: d.position(t.EndLine, t.EndColumn)}
+}
+
+// spanRange es el rango de un diagnóstico en el LSP.
+func (d *document) spanRange(s diag.Span) Range {
+	end := s.End
+	if end.Line == 0 {
+		end = diag.Position{Line: s.Start.Line, Column: s.Start.Column + 1}
+	}
+	return Range{Start: d.position(s.Start.Line, s.Start.Column), End: d.position(end.Line, end.Column)}
+}
+
+// tokenAt devuelve el token que contiene la posición p.
+func (d *document) tokenAt(p Position) (token.Token, bool) {
+	line, column := d.sourcePosition(p)
+	for _, t := range d.tokens {
+		if t.Type == token.EOF || t.Line > line {
+			break
+		}
+		after := t.Line < line || t.Column <= column
+		before := t.EndLine > line || (t.EndLine == line && column <= t.EndColumn)
+		if after && before {
+			return t, true
+		}
+	}
+	return token.Token{}, false
+}
+
+// diagnostics convierte los diagnósticos del parser a los del LSP. Las notas
+// se añaden al mensaje.
+func (d *document) diagnostics() []Diagnostic {
+	out := []Diagnostic{}
+	for _, dg := range d.diags {
+		msg := dg.Message
+		for _, note := range dg.Notes {
+			msg += "\nnote: " + note
+		}
+		out = append(out, Diagnostic{
+			Range:    d.spanRange(dg.Span),
+			Severity: int(dg.Severity),
+			Code:     dg.Code,
+			Source:   "nexusl",
+			Message:  msg,
+		})
+	}
+	return out
+}
@@ -0,0 +1,142 @@
+// Gothic/lsp/semantic.go
+package lsp
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/devicemxl/nexusl/internal/Gothic/token"
+)
+
+// Tipos y modificadores de los tokens semánticos; su índice en estas listas
+// es el que viaja en SemanticTokens.Data.
+var (
+	semanticTypes     = []string{"keyword", "variable", "string", "number", "comment", "operator"}
+	semanticModifiers = []string{"declaration", "documentation"}
+)
+
+const (
+	semKeyword = iota
+	semVariable
+	semString
+	semNumber
+	semComment
+	semOperator
+)
+
+const (
+	modDeclaration = 1 << iota
+	modDocumentation
+)
+
+// delimiters son los signos de puntuación que no se resaltan.
+var delimiters = map[token.TokenClass]bool{
+	token.SEMICOLON: true, token.COMMA: true, token.LPAREN: true, token.RPAREN: true,
+	token.LBRACKET: true, token.RBRACKET: true, token.LCURLY: true, token.RCURLY: true,
+}
+
+// semanticClass clasifica un token según su token.TokenClass y el vocabulario
+// del documento.
+func semanticClass(t token.Token, vocab *token.Vocabulary) (int, bool) {
+	switch t.Type {
+	case token.EOF, token.ILLEGAL:
+		return 0, false
+	case token.IDENTIFIER, token.VARIABLE:
+		return semVariable, true
+	case token.STRING, token.MULTILINE_STRING, token.CHAR:
+		return semString, true
+	case token.INTEGER, token.FLOAT, token.DECIMAL, token.COMPLEX:
+		return semNumber, true
+	}
+	if class, ok := vocab.Lookup(t.Word); ok && class == t.Type {
+		return semKeyword, true
+	}
+	if r, _ := utf8.DecodeRuneInString(string(t.Type)); !unicode.IsLetter(r) && !delimiters[t.Type] {
+		return semOperator, true
+	}
+	return 0, false
+}
+
+// semanticItem es un tramo resaltado, dentro de una sola línea.
+type semanticItem struct {
+	line, char, length, kind, mods int
+}
+
+// semanticTokens codifica los tokens y comentarios del documento como pide
+// el LSP: cinco enteros por tramo, con la línea y el carácter relativos al
+// tramo anterior. Los tramos de varias líneas se parten en una por línea.
+func (d *document) semanticTokens() SemanticTokens {
+	var items []semanticItem
+	add := func(line, column, endLine, endColumn, kind, mods int) {
+		start := d.position(line, column)
+		end := d.position(endLine, endColumn)
+		for l := start.Line; l <= end.Line && l < len(d.lines); l++ {
+			from, to := 0, utf16Len(d.lines[l])
+			if l == start.Line {
+				from = start.Character
+			}
+			if l == end.Line {
+				to = end.Character
+			}
+			if to > from {
+				items = append(items, semanticItem{l, from, to - from, kind, mods})
+			}
+		}
+	}
+	comment := func(c token.Trivia) {
+		mods := 0
+		if c.Type == token.DOC_COMMENT {
+			mods = modDocumentation
+		}
+		lines := strings.Split(c.Text, "\n")
+		endLine := c.Line + len(lines) - 1
+		endColumn := utf8.RuneCountInString(lines[len(lines)-1]) + 1
+		if len(lines) == 1 {
+			endColumn += c.Column - 1
+		}
+		add(c.Line, c.Column, endLine, endColumn, semComment, mods)
+	}
+	for _, t := range d.tokens {
+		for _, c := range t.Leading {
+			comment(c)
+		}
+		if kind, ok := semanticClass(t, d.vocabulary); ok {
+			mods := 0
+			if kind == semVariable && d.isDeclaration(t) {
+				mods = modDeclaration
+			}
+			add(t.Line, t.Column, t.EndLine, t.EndColumn, kind, mods)
+		}
+		for _, c := range t.Trailing {
+			comment(c)
+		}
+	}
+	slices.SortStableFunc(items, func(a, b semanticItem) int {
+		return cmp.Or(cmp.Compare(a.line, b.line), cmp.Compare(a.char, b.char))
+	})
+
+	data := make([]int, 0, 5*len(items))
+	prevLine, prevChar := 0, 0
+	for _, it := range items {
+		deltaChar := it.char
+		if it.line == prevLine {
+			deltaChar -= prevChar
+		}
+		data = append(data, it.line-prevLine, deltaChar, it.length, it.kind, it.mods)
+		prevLine, prevChar = it.line, it.char
+	}
+	return SemanticTokens{Data: data}
+}
+
+// utf16Len es la longitud de s en unidades UTF-16.
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
@@ -0,0 +1,367 @@
+// Gothic/lsp/server.go
+//
+// # Servidor del Language Server Protocol
+//
+// Server atiende a un editor por JSON-RPC sobre un par io.Reader/io.Writer
+// (la entrada y la salida estándar en 'lsp'). Cada documento abierto se
+// vuelve a analizar completo con el lexer y el parser en cada cambio, y el
+// servidor ofrece:
+//
+//   - diagnósticos, publicados tras cada cambio;
+//   - tokens semánticos a partir de token.TokenClass;
+//   - hover con el ds.Symbol (Thing, LogicalType) y sus vecinos por embedding;
+//   - completado de palabras clave, de scopes y predicados de definitions.db
+//     y de los símbolos declarados;
+//   - ir a la definición de un símbolo declarado (el sujeto del primer
+//     'fact' que lo usa, en cualquier documento abierto);
+//   - formateo del documento (ver el paquete format).
+//
+// Las peticiones se atienden en orden, una a una.
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/devicemxl/nexusl/ds"
+	"github.com/devicemxl/nexusl/internal/Gothic/ast"
+	"github.com/devicemxl/nexusl/internal/Gothic/diag"
+	"github.com/devicemxl/nexusl/internal/Gothic/format"
+	"github.com/devicemxl/nexusl/internal/Gothic/metamodel"
+	"github.com/devicemxl/nexusl/internal/Gothic/token"
+)
+
+// ErrExitWithoutShutdown indica que el cliente envió 'exit' sin 'shutdown'.
+var ErrExitWithoutShutdown = errors.New("lsp: exit without shutdown")
+
+// hoverNeighbours es la cantidad de vecinos por embedding que muestra el hover.
+const hoverNeighbours = 3
+
+// Server es un servidor LSP para archivos .nxl.
+type Server struct {
+	mm          *metamodel.MetamodelDefinitions
+	conn        *conn
+	docs        map[string]*document
+	initialized bool
+	shutdown    bool
+}
+
+// NewServer crea un servidor que consulta los scopes y predicados en mm.
+func NewServer(mm *metamodel.MetamodelDefinitions) *Server {
+	return &Server{mm: mm, docs: map[string]*document{}}
+}
+
+// Serve atiende los mensajes de r y escribe las respuestas en w hasta recibir
+// 'exit' o el fin de r. Devuelve nil si la sesión terminó con 'shutdown'.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.conn = newConn(r, w)
+	for {
+		msg, err := s.conn.read()
+		var rerr *rpcError
+		switch {
+		case errors.As(err, &rerr):
+			if err := s.conn.reply(nil, nil, rerr); err != nil {
+				return err
+			}
+			continue
+		case errors.Is(err, io.EOF):
+			if s.shutdown {
+				return nil
+			}
+			return io.ErrUnexpectedEOF
+		case err != nil:
+			return err
+		}
+		if msg.Method == "exit" {
+			if s.shutdown {
+				return nil
+			}
+			return ErrExitWithoutShutdown
+		}
+		result, err := s.handle(msg)
+		if msg.ID == nil {
+			continue // Las notificaciones no tienen respuesta
+		}
+		if err := s.conn.reply(msg.ID, result, err); err != nil {
+			return err
+		}
+	}
+}
+
+// handle atiende un mensaje y devuelve el resultado de la petición.
+func (s *Server) handle(msg *message) (any, error) {
+	if !s.initialized && msg.Method != "initialize" {
+		if msg.ID == nil {
+			return nil, nil
+		}
+		return nil, &rpcError{Code: codeNotInitialized, Message: "server not initialized"}
+	}
+	switch msg.Method {
+	case "initialize":
+		s.initialized = true
+		return s.initialize(), nil
+	case "initialized", "$/cancelRequest", "$/setTrace":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var p DidOpenTextDocumentParams
+		if err := unmarshal(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		return nil, s.update(p.TextDocument.URI, p.TextDocument.Version, p.TextDocument.Text)
+	case "textDocument/didChange":
+		var p DidChangeTextDocumentParams
+		if err := unmarshal(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		if len(p.ContentChanges) == 0 {
+			return nil, nil
+		}
+		text := p.ContentChanges[len(p.ContentChanges)-1].Text
+		return nil, s.update(p.TextDocument.URI, p.TextDocument.Version, text)
+	case "textDocument/didClose":
+		var p DidCloseTextDocumentParams
+		if err := unmarshal(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		delete(s.docs, p.TextDocument.URI)
+		return nil, s.conn.notify("textDocument/publishDiagnostics",
+			PublishDiagnosticsParams{URI: p.TextDocument.URI, Diagnostics: []Diagnostic{}})
+	case "textDocument/semanticTokens/full":
+		var p DocumentParams
+		doc, err := s.document(msg.Params, &p.TextDocument, &p)
+		if err != nil {
+			return nil, err
+		}
+		return doc.semanticTokens(), nil
+	case "textDocument/hover":
+		var p TextDocumentPositionParams
+		doc, err := s.document(msg.Params, &p.TextDocument, &p)
+		if err != nil {
+			return nil, err
+		}
+		return s.hover(doc, p.Position), nil
+	case "textDocument/completion":
+		var p TextDocumentPositionParams
+		doc, err := s.document(msg.Params, &p.TextDocument, &p)
+		if err != nil {
+			return nil, err
+		}
+		return s.completion(doc), nil
+	case "textDocument/definition":
+		var p TextDocumentPositionParams
+		doc, err := s.document(msg.Params, &p.TextDocument, &p)
+		if err != nil {
+			return nil, err
+		}
+		return s.definition(doc, p.Position), nil
+	case "textDocument/formatting":
+		var p DocumentParams
+		doc, err := s.document(msg.Params, &p.TextDocument, &p)
+		if err != nil {
+			return nil, err
+		}
+		return s.formatting(doc), nil
+	}
+	if msg.ID == nil {
+		return nil, nil
+	}
+	return nil, &rpcError{Code: codeMethodNotFound, Message: "method not found: " + msg.Method}
+}
+
+// unmarshal decodifica los parámetros de una petición.
+func unmarshal(raw json.RawMessage, v any) error {
+	if err := json.Unmarshal(raw, v); err != nil {
+		return &rpcError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// document decodifica los parámetros en params y devuelve el documento
+// abierto que nombra id.
+func (s *Server) document(raw json.RawMessage, id *TextDocumentIdentifier, params any) (*document, error) {
+	if err := unmarshal(raw, params); err != nil {
+		return nil, err
+	}
+	doc, ok := s.docs[id.URI]
+	if !ok {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "document not open: " + id.URI}
+	}
+	return doc, nil
+}
+
+func (s *Server) initialize() any {
+	return map[string]any{
+		"capabilities": map[string]any{
+			"textDocumentSync":           1, // Full
+			"hoverProvider":              true,
+			"completionProvider":         map[string]any{},
+			"definitionProvider":         true,
+			"documentFormattingProvider": true,
+			"semanticTokensProvider": map[string]any{
+				"legend": SemanticTokensLegend{TokenTypes: semanticTypes, TokenModifiers: semanticModifiers},
+				"full":   true,
+			},
+		},
+		"serverInfo": map[string]any{"name": "nexusl"},
+	}
+}
+
+// update analiza la nueva versión de un documento y publica sus diagnósticos.
+func (s *Server) update(uri string, version int, text string) error {
+	doc := newDocument(uri, version, text, s.mm)
+	s.docs[uri] = doc
+	return s.conn.notify("textDocument/publishDiagnostics",
+		PublishDiagnosticsParams{URI: uri, Version: version, Diagnostics: doc.diagnostics()})
+}
+
+// hover describe el símbolo bajo el cursor: su declaración en los documentos
+// abiertos y, si es un símbolo del sistema, su Thing, su LogicalType y los
+// símbolos más cercanos por embedding.
+func (s *Server) hover(doc *document, pos Position) *Hover {
+	tok, ok := doc.tokenAt(pos)
+	if !ok || tok.Word == "" {
+		return nil
+	}
+	var parts []string
+	if sym, ok := ds.LookupSymbolByPublicName(doc.vocabulary.Canonical(tok.Word)); ok {
+		parts = append(parts, fmt.Sprintf("**%s** — Thing: `%s`, LogicalType: `%s`", tok.Word, sym.Thing, sym.LogicalType))
+		if near := ds.NearestSymbols(sym, hoverNeighbours); len(near) > 0 {
+			names := make([]string, len(near))
+			for i, n := range near {
+				names[i] = fmt.Sprintf("`%s` (%.3f)", n.Symbol.PublicName, n.Similarity)
+			}
+			parts = append(parts, "Nearest by embedding: "+strings.Join(names, ", "))
+		}
+	}
+	if tok.Type == token.IDENTIFIER {
+		if declDoc, id, ok := s.declaration(doc, tok.Word); ok {
+			if len(parts) == 0 {
+				parts = append(parts, fmt.Sprintf("**%s**", tok.Word))
+			}
+			parts = append(parts, fmt.Sprintf("Declared at line %d: `%s`", id.Token.Line, declDoc.declared[tok.Word].String()))
+			if text := declDoc.declared[tok.Word].Doc; text != "" {
+				parts = append(parts, text)
+			}
+		}
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+	r := doc.tokenRange(tok)
+	return &Hover{Contents: MarkupContent{Kind: "markdown", Value: strings.Join(parts, "\n\n")}, Range: &r}
+}
+
+// declaration busca la declaración de name, primero en doc y luego en los
+// demás documentos abiertos (en orden de URI, para que sea estable).
+func (s *Server) declaration(doc *document, name string) (*document, *ast.Identifier, bool) {
+	if id, ok := doc.declaration(name); ok {
+		return doc, id, true
+	}
+	uris := make([]string, 0, len(s.docs))
+	for uri := range s.docs {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	for _, uri := range uris {
+		if id, ok := s.docs[uri].declaration(name); ok {
+			return s.docs[uri], id, true
+		}
+	}
+	return nil, nil, false
+}
+
+// definition devuelve dónde se declara el identificador bajo el cursor.
+func (s *Server) definition(doc *document, pos Position) []Location {
+	tok, ok := doc.tokenAt(pos)
+	if !ok || tok.Type != token.IDENTIFIER {
+		return []Location{}
+	}
+	declDoc, id, ok := s.declaration(doc, tok.Word)
+	if !ok {
+		return []Location{}
+	}
+	return []Location{{URI: declDoc.uri, Range: declDoc.tokenRange(id.Token)}}
+}
+
+// completion ofrece las palabras clave, los scopes y predicados del sistema
+// y los símbolos declarados en los documentos abiertos; el editor filtra por
+// lo que ya se escribió. Las palabras clave son las del idioma del documento.
+func (s *Server) completion(doc *document) CompletionList {
+	var items []CompletionItem
+	seen := map[string]bool{}
+	addItem := func(it CompletionItem) {
+		if !seen[it.Label] {
+			seen[it.Label] = true
+			items = append(items, it)
+		}
+	}
+	for _, sym := range s.mm.Scopes() {
+		addItem(CompletionItem{Label: sym.PublicName, Kind: CompletionModule, Detail: string(sym.Thing)})
+	}
+	for _, sym := range s.mm.Predicates() {
+		addItem(CompletionItem{Label: sym.PublicName, Kind: CompletionFunction, Detail: string(sym.Thing)})
+	}
+	for _, kw := range doc.vocabulary.Words() {
+		addItem(CompletionItem{Label: kw, Kind: CompletionKeyword, Detail: "keyword"})
+	}
+	var declared []string
+	for _, doc := range s.docs {
+		for name := range doc.declared {
+			declared = append(declared, name)
+		}
+	}
+	slices.Sort(declared)
+	for _, name := range declared {
+		addItem(CompletionItem{Label: name, Kind: CompletionVariable, Detail: "declared symbol"})
+	}
+	return CompletionList{Items: items}
+}
+
+// formatting reemplaza el documento por su versión formateada; no cambia
+// nada si tiene errores de sintaxis o si el resultado no tiene los mismos
+// tokens y comentarios que el original: un fallo del formateador no debe
+// borrar código en el editor.
+func (s *Server) formatting(doc *document) []TextEdit {
+	out, diags := format.Source(doc.text, s.mm, format.Options{})
+	if diag.HasErrors(diags) || out == doc.text || !sameTokens(doc, newDocument(doc.uri, doc.version, out, s.mm)) {
+		return []TextEdit{}
+	}
+	last := len(doc.lines) - 1
+	end := Position{Line: last, Character: utf16Len(doc.lines[last])}
+	return []TextEdit{{Range: Range{End: end}, NewText: out}}
+}
+
+// sameTokens indica si a y b tienen los mismos tokens y los mismos
+// comentarios, sin contar espacios ni posiciones.
+func sameTokens(a, b *document) bool {
+	if len(a.tokens) != len(b.tokens) {
+		return false
+	}
+	for i, t := range a.tokens {
+		u := b.tokens[i]
+		if t.Type != u.Type || t.Word != u.Word || !sameTrivia(t.Leading, u.Leading) || !sameTrivia(t.Trailing, u.Trailing) {
+			return false
+		}
+	}
+	return true
+}
+
+func sameTrivia(a, b []token.Trivia) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if strings.TrimRight(a[i].Text, " \t\r") != strings.TrimRight(b[i].Text, " \t\r") {
+			return false
+		}
+	}
+	return true
+}
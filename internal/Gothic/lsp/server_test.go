@@ -0,0 +1,280 @@
+package lsp_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/devicemxl/nexusl/ds"
+	"github.com/devicemxl/nexusl/internal/Gothic/lsp"
+	"github.com/devicemxl/nexusl/internal/Gothic/metamodel"
+)
+
+// rpc es un mensaje JSON-RPC visto desde el cliente.
+type rpc struct {
+	ID     *int            `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// client habla con un lsp.Server en la misma goroutine de prueba, por tuberías.
+type client struct {
+	t        *testing.T
+	w        io.Writer
+	incoming chan rpc
+	notes    []rpc
+	nextID   int
+	done     chan error
+}
+
+func startServer(t *testing.T) *client {
+	t.Helper()
+	// El scope 'fact' y los predicados vienen normalmente de definitions.db.
+	for name, def := range map[string]struct {
+		thing ds.ThingType
+		emb   []float32
+	}{
+		"fact": {ds.TripletScopeType, []float32{0.10, 0.20, 0.30}},
+		"is":   {ds.PredicateType, []float32{0.11, 0.22, 0.33}},
+		"has":  {ds.PredicateType, []float32{0.44, 0.55, 0.66}},
+		"do":   {ds.PredicateType, []float32{0.77, 0.88, 0.99}},
+	} {
+		sym, ok := ds.LookupSymbolByPublicName(name)
+		if !ok {
+			sym = ds.NewSymbolWithPublicName(name, def.thing)
+		}
+		sym.Embedding = def.emb
+	}
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	c := &client{t: t, w: inW, incoming: make(chan rpc, 16), done: make(chan error, 1)}
+	go func() {
+		c.done <- lsp.NewServer(metamodel.NewMetamodelFacade()).Serve(inR, outW)
+		outW.Close()
+	}()
+	go func() {
+		defer close(c.incoming)
+		r := bufio.NewReader(outR)
+		for {
+			header, err := textproto.NewReader(r).ReadMIMEHeader()
+			if err != nil {
+				return
+			}
+			n, _ := strconv.Atoi(header.Get("Content-Length"))
+			body := make([]byte, n)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return
+			}
+			var msg rpc
+			if err := json.Unmarshal(body, &msg); err != nil {
+				t.Errorf("bad message from server: %v", err)
+				return
+			}
+			c.incoming <- msg
+		}
+	}()
+	return c
+}
+
+func (c *client) send(msg map[string]any) {
+	msg["jsonrpc"] = "2.0"
+	body, _ := json.Marshal(msg)
+	fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+func (c *client) notify(method string, params any) {
+	c.send(map[string]any{"method": method, "params": params})
+}
+
+// call envía una petición y decodifica su resultado en result; las
+// notificaciones que lleguen mientras tanto quedan en c.notes.
+func (c *client) call(method string, params, result any) {
+	c.t.Helper()
+	c.nextID++
+	c.send(map[string]any{"id": c.nextID, "method": method, "params": params})
+	for msg := range c.incoming {
+		if msg.ID == nil {
+			c.notes = append(c.notes, msg)
+			continue
+		}
+		if *msg.ID != c.nextID {
+			c.t.Fatalf("%s: response to request %d, want %d", method, *msg.ID, c.nextID)
+		}
+		if msg.Error != nil {
+			c.t.Fatalf("%s: error %d: %s", method, msg.Error.Code, msg.Error.Message)
+		}
+		if result != nil {
+			if err := json.Unmarshal(msg.Result, result); err != nil {
+				c.t.Fatalf("%s: decoding result %s: %v", method, msg.Result, err)
+			}
+		}
+		return
+	}
+	c.t.Fatalf("%s: server closed the connection", method)
+}
+
+const testURI = "file:///casa.nxl"
+
+const testDoc = "/// Un coche.\n" +
+	"fact Car is symbol;\n" +
+	"fact Car has wheels;\n" +
+	"fact Bike is;\n"
+
+func TestServerSession(t *testing.T) {
+	c := startServer(t)
+
+	var init struct {
+		Capabilities struct {
+			SemanticTokensProvider struct {
+				Legend lsp.SemanticTokensLegend `json:"legend"`
+			} `json:"semanticTokensProvider"`
+		} `json:"capabilities"`
+	}
+	c.call("initialize", map[string]any{"capabilities": map[string]any{}}, &init)
+	legend := init.Capabilities.SemanticTokensProvider.Legend
+	if len(legend.TokenTypes) == 0 || legend.TokenTypes[0] != "keyword" {
+		t.Fatalf("semantic token legend = %+v", legend)
+	}
+	c.notify("initialized", map[string]any{})
+	c.notify("textDocument/didOpen", map[string]any{"textDocument": lsp.TextDocumentItem{
+		URI: testURI, LanguageID: "nexusl", Version: 1, Text: testDoc,
+	}})
+
+	t.Run("diagnostics", func(t *testing.T) {
+		// La respuesta a una petición cualquiera garantiza que la notificación ya llegó.
+		c.call("textDocument/hover", position(0, 0), nil)
+		var pub lsp.PublishDiagnosticsParams
+		if len(c.notes) != 1 || c.notes[0].Method != "textDocument/publishDiagnostics" {
+			t.Fatalf("notifications = %+v", c.notes)
+		}
+		json.Unmarshal(c.notes[0].Params, &pub)
+		want := []lsp.Diagnostic{{
+			Range:    lsp.Range{Start: lsp.Position{Line: 3, Character: 12}, End: lsp.Position{Line: 3, Character: 13}},
+			Severity: lsp.SeverityError,
+			Code:     "P0001",
+			Source:   "nexusl",
+			Message:  `Unexpected token ; (";") when expecting an expression.`,
+		}}
+		if pub.URI != testURI || !reflect.DeepEqual(pub.Diagnostics, want) {
+			t.Errorf("diagnostics = %+v, want %+v", pub, want)
+		}
+	})
+
+	t.Run("semantic tokens", func(t *testing.T) {
+		var toks lsp.SemanticTokens
+		c.call("textDocument/semanticTokens/full", map[string]any{"textDocument": map[string]any{"uri": testURI}}, &toks)
+		want := []int{
+			0, 0, 13, 4, 2, // /// Un coche.  comment, documentation
+			1, 0, 4, 0, 0, //  fact            keyword
+			0, 5, 3, 1, 1, //  Car             variable, declaration
+			0, 4, 2, 0, 0, //  is              keyword
+			0, 3, 6, 0, 0, //  symbol          keyword
+			1, 0, 4, 0, 0, //  fact
+			0, 5, 3, 1, 0, //  Car             variable
+		}
+		if len(toks.Data) < len(want) || !reflect.DeepEqual(toks.Data[:len(want)], want) {
+			t.Errorf("data = %v, want prefix %v", toks.Data, want)
+		}
+	})
+
+	t.Run("hover", func(t *testing.T) {
+		var h lsp.Hover
+		c.call("textDocument/hover", position(1, 10), &h)
+		for _, want := range []string{"**is** — Thing: `Predicate`", "Nearest by embedding: `fact` (1.000), `has`"} {
+			if !strings.Contains(h.Contents.Value, want) {
+				t.Errorf("hover on 'is' = %q, want it to contain %q", h.Contents.Value, want)
+			}
+		}
+		c.call("textDocument/hover", position(2, 6), &h)
+		want := "**Car**\n\nDeclared at line 2: `fact Car is symbol;`\n\nUn coche."
+		if h.Contents.Value != want {
+			t.Errorf("hover on 'Car' = %q, want %q", h.Contents.Value, want)
+		}
+	})
+
+	t.Run("definition", func(t *testing.T) {
+		var locs []lsp.Location
+		c.call("textDocument/definition", position(2, 7), &locs)
+		want := []lsp.Location{{URI: testURI, Range: lsp.Range{
+			Start: lsp.Position{Line: 1, Character: 5}, End: lsp.Position{Line: 1, Character: 8},
+		}}}
+		if !reflect.DeepEqual(locs, want) {
+			t.Errorf("definition = %+v, want %+v", locs, want)
+		}
+	})
+
+	t.Run("completion", func(t *testing.T) {
+		var list lsp.CompletionList
+		c.call("textDocument/completion", position(3, 0), &list)
+		kinds := map[string]int{}
+		for _, it := range list.Items {
+			kinds[it.Label] = it.Kind
+		}
+		want := map[string]int{
+			"fact":    lsp.CompletionModule,
+			"has":     lsp.CompletionFunction,
+			"retract": lsp.CompletionKeyword,
+			"Car":     lsp.CompletionVariable,
+		}
+		for label, kind := range want {
+			if kinds[label] != kind {
+				t.Errorf("completion %q has kind %d, want %d", label, kinds[label], kind)
+			}
+		}
+	})
+
+	t.Run("formatting", func(t *testing.T) {
+		c.notify("textDocument/didChange", map[string]any{
+			"textDocument":   map[string]any{"uri": testURI, "version": 2},
+			"contentChanges": []map[string]any{{"text": "fact  Car is symbol;\nfact Bike has wheels;"}},
+		})
+		var edits []lsp.TextEdit
+		c.call("textDocument/formatting", map[string]any{"textDocument": map[string]any{"uri": testURI}}, &edits)
+		want := []lsp.TextEdit{{
+			Range:   lsp.Range{End: lsp.Position{Line: 1, Character: 21}},
+			NewText: "fact Car  is  symbol;\nfact Bike has wheels;\n",
+		}}
+		if !reflect.DeepEqual(edits, want) {
+			t.Errorf("formatting = %+v, want %+v", edits, want)
+		}
+
+		// Un único comentario delante de la sentencia no la absorbe.
+		c.notify("textDocument/didChange", map[string]any{
+			"textDocument":   map[string]any{"uri": testURI, "version": 3},
+			"contentChanges": []map[string]any{{"text": "// c\nfact  Car is symbol;"}},
+		})
+		c.call("textDocument/formatting", map[string]any{"textDocument": map[string]any{"uri": testURI}}, &edits)
+		want = []lsp.TextEdit{{
+			Range:   lsp.Range{End: lsp.Position{Line: 1, Character: 20}},
+			NewText: "// c\nfact Car is symbol;\n",
+		}}
+		if !reflect.DeepEqual(edits, want) {
+			t.Errorf("formatting with one leading comment = %+v, want %+v", edits, want)
+		}
+	})
+
+	c.call("shutdown", nil, nil)
+	c.notify("exit", nil)
+	if err := <-c.done; err != nil {
+		t.Errorf("Serve() = %v, want nil after shutdown", err)
+	}
+}
+
+func position(line, char int) map[string]any {
+	return map[string]any{
+		"textDocument": map[string]any{"uri": testURI},
+		"position":     lsp.Position{Line: line, Character: char},
+	}
+}
@@ -3,29 +3,80 @@ package main
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/devicemxl/nexusl/ds"                  // Para llamar a LoadSystemDefinitionsFromDB
 	"github.com/devicemxl/nexusl/internal/Gothic/ast" // Asegúrate de importar ast
+	"github.com/devicemxl/nexusl/internal/Gothic/diag"
 	"github.com/devicemxl/nexusl/internal/Gothic/lexer"
+	"github.com/devicemxl/nexusl/internal/Gothic/lsp"
 	"github.com/devicemxl/nexusl/internal/Gothic/metamodel"
 	"github.com/devicemxl/nexusl/internal/Gothic/parser"
-	// Asegúrate de importar token
+	"github.com/devicemxl/nexusl/internal/Gothic/token"
+	"github.com/devicemxl/nexusl/logging"
 )
 
 func main() {
 	dbPath := "./db/definitions.db" // Asegúrate de que esta ruta sea correcta
+	if env := os.Getenv("NEXUSL_DB"); env != "" {
+		dbPath = env // El servidor LSP suele arrancar fuera del repositorio
+	}
 
 	// 1. Cargar las definiciones del sistema desde la DB
 	err := ds.LoadSystemDefinitionsFromDB(dbPath)
 	if err != nil {
-		fmt.Printf("Error loading system definitions from DB: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error loading system definitions from DB: %v\n", err)
 		return
 	}
-	fmt.Println("System definitions loaded from DB.")
 
 	// 2. Crear el facade del metamodelo que usará los símbolos cargados
 	mm := metamodel.NewMetamodelFacade()
 
+	// 3. El lexer reconoce los scopes y predicados de la DB, no solo los del
+	// mapa token.Keywords; las diferencias entre ambos se registran.
+	token.SetDefaultVocabulary(mm.Vocabulary())
+	// Los idiomas con alias en la DB (keyword_aliases) se eligen por archivo
+	// con el pragma //nexusl:lang o con 'fmt -lang'.
+	if langs, err := mm.Languages(); err != nil {
+		logging.Logger(logging.DB).Warn("keyword aliases ignored", "err", err)
+	} else {
+		for name, v := range langs {
+			token.SetLanguage(name, v)
+		}
+	}
+	drifts := mm.CheckVocabulary()
+	for _, d := range drifts {
+		logging.Logger(logging.DB).Info("vocabulary drift", "word", d.Word, "keyword", d.Keyword, "thing", d.Thing)
+	}
+
+	// Subcomandos: 'fmt' formatea archivos (ver fmtcmd.go), 'run' los ejecuta
+	// (ver runcmd.go), 'vocab' comprueba el vocabulario y 'lsp' atiende a un
+	// editor por la entrada y la salida estándar.
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "fmt":
+			os.Exit(runFmt(os.Args[2:], mm))
+		case "run":
+			os.Exit(runRun(os.Args[2:], mm))
+		case "vocab":
+			// 'vocab' informa de las diferencias entre token.Keywords y la DB.
+			for _, d := range drifts {
+				fmt.Println(d)
+			}
+			if len(drifts) > 0 {
+				os.Exit(1)
+			}
+			return
+		case "lsp":
+			if err := lsp.NewServer(mm).Serve(os.Stdin, os.Stdout); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	fmt.Println("System definitions loaded from DB.")
+
 	input := `fact Car is symbol;` // Tu entrada de prueba
 
 	l := lexer.New(input)
@@ -33,11 +84,9 @@ func main() {
 
 	program := p.ParseProgram()
 
-	if len(p.Errors()) != 0 {
+	if diags := p.Diagnostics(); len(diags) != 0 {
 		fmt.Println("Parser errors:")
-		for _, msg := range p.Errors() {
-			fmt.Printf("  %s\n", msg)
-		}
+		diag.RenderAll(os.Stdout, "input", input, diags)
 		return
 	}
 
@@ -44,3 +44,13 @@ func (mm *MetamodelDefinitions) LookupPredicate(name string) (*ds.Symbol, bool)
 func NewTestMetamodel() *MetamodelDefinitions { ... }
 func LoadDefinitionsFromBytes(data []byte) (*MetamodelDefinitions, error) { ... }
 */
+
+// Scopes devuelve los scopes de tripleta definidos, ordenados por nombre.
+func (mm *MetamodelDefinitions) Scopes() []*ds.Symbol {
+	return ds.SymbolsOfThing(ds.TripletScopeType)
+}
+
+// Predicates devuelve los predicados definidos, ordenados por nombre.
+func (mm *MetamodelDefinitions) Predicates() []*ds.Symbol {
+	return ds.SymbolsOfThing(ds.PredicateType)
+}
@@ -0,0 +1,128 @@
+package metamodel_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/devicemxl/nexusl/ds"
+	"github.com/devicemxl/nexusl/internal/Gothic/ast"
+	"github.com/devicemxl/nexusl/internal/Gothic/lexer"
+	"github.com/devicemxl/nexusl/internal/Gothic/metamodel"
+	"github.com/devicemxl/nexusl/internal/Gothic/parser"
+	"github.com/devicemxl/nexusl/internal/Gothic/token"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// define registra los símbolos que normalmente vienen de definitions.db.
+func define() {
+	for name, thing := range map[string]ds.ThingType{
+		"fact": ds.TripletScopeType, "rule": ds.TripletScopeType, "logic": ds.TripletScopeType,
+		"is": ds.PredicateType, "has": ds.PredicateType, "owns": ds.PredicateType,
+	} {
+		if _, ok := ds.LookupSymbolByPublicName(name); !ok {
+			ds.NewSymbolWithPublicName(name, thing)
+		}
+	}
+}
+
+func TestVocabulary(t *testing.T) {
+	define()
+	v := metamodel.NewMetamodelFacade().Vocabulary()
+	for word, want := range map[string]token.TokenClass{
+		"fact":   token.FACT,
+		"rule":   token.FACT, // la base manda: 'rule' es un scope
+		"logic":  token.FACT,
+		"is":     token.IS,
+		"owns":   token.PREDICATE,
+		"symbol": token.SYMBOL,
+		"nil":    token.NIL,
+		"robot":  token.IDENTIFIER,
+	} {
+		if got := v.Class(word); got != want {
+			t.Errorf("Vocabulary class of %q = %q, want %q", word, got, want)
+		}
+	}
+
+	// Un verbo nuevo de la base se parsea sin recompilar.
+	l := lexer.New("logic robot owns battery;")
+	l.Vocabulary = v
+	p := parser.New(l, metamodel.NewMetamodelFacade())
+	prog := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	stmt, ok := prog.Statements[0].(*ast.FactStatement)
+	if !ok || stmt.Scope.PublicName != "logic" || stmt.Predicate.String() != "owns" {
+		t.Errorf("statement = %#v, want a 'logic' fact with predicate 'owns'", prog.Statements[0])
+	}
+}
+
+func TestCheckVocabulary(t *testing.T) {
+	define()
+	var got []string
+	for _, d := range metamodel.NewMetamodelFacade().CheckVocabulary() {
+		got = append(got, d.String())
+	}
+	want := []string{
+		`"do" is keyword DO but not in the database`,
+		`"how" is keyword HOW but not in the database`,
+		`"logic" is a TripletScope in the database but not a keyword`,
+		`"owns" is a Predicate in the database but not a keyword`,
+		`"rule" is a TripletScope in the database but keyword RULE`,
+		`"when" is keyword WHEN but not in the database`,
+		`"where" is keyword WHERE but not in the database`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CheckVocabulary() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestLanguages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "definitions.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		`CREATE TABLE system_symbols (public_name TEXT PRIMARY KEY, thing TEXT NOT NULL, embedding_data TEXT)`,
+		`INSERT INTO system_symbols VALUES ('fact', 'TripletScope', ''), ('rule', 'TripletScope', ''), ('has', 'Predicate', '')`,
+		`CREATE TABLE keyword_aliases (language TEXT NOT NULL, alias TEXT NOT NULL, keyword TEXT NOT NULL, PRIMARY KEY (language, alias))`,
+		`INSERT INTO keyword_aliases VALUES ('es', 'hecho', 'fact'), ('es', 'regla', 'rule'), ('es', 'tiene', 'has')`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+	if err := ds.LoadSystemDefinitionsFromDB(path); err != nil {
+		t.Fatal(err)
+	}
+
+	mm := metamodel.NewMetamodelFacade()
+	langs, err := mm.Languages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	es, ok := langs["es"]
+	if !ok || len(langs) != 1 {
+		t.Fatalf("Languages() = %v, want only es", langs)
+	}
+	if es.Class("regla") != token.FACT || es.Canonical("regla") != "rule" {
+		t.Errorf("'regla' is %q (%q), want a scope alias of 'rule'", es.Class("regla"), es.Canonical("regla"))
+	}
+
+	// El AST usa las palabras canónicas: el programa significa lo mismo en los dos idiomas.
+	l := lexer.New("regla ?x tiene y if ?x tiene z;")
+	l.Vocabulary = es
+	p := parser.New(l, mm)
+	prog := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	stmt := prog.Statements[0].(*ast.RuleStatement)
+	if stmt.Scope.PublicName != "rule" || stmt.Head.Predicate.String() != "has" || stmt.Body[0].Predicate.String() != "has" {
+		t.Errorf("statement = %s with scope %s, want the rule scope and predicate 'has'", stmt, stmt.Scope.PublicName)
+	}
+}
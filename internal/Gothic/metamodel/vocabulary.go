@@ -0,0 +1,100 @@
+// Gothic/metamodel/vocabulary.go
+package metamodel
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/devicemxl/nexusl/ds"
+	"github.com/devicemxl/nexusl/internal/Gothic/token"
+)
+
+// Vocabulary construye el vocabulario del lexer a partir de los símbolos
+// cargados: parte de token.Keywords y le añade, o corrige, los scopes y los
+// predicados de la base. Un TripletScope abre una sentencia de tripleta
+// (token.ScopeClass); un predicado conserva su clase si ya es de predicado
+// (IS, HAS...) y si no pasa a ser token.PREDICATE. La base manda sobre el mapa
+// cuando no coinciden; CheckVocabulary informa de esas diferencias.
+func (mm *MetamodelDefinitions) Vocabulary() *token.Vocabulary {
+	v := token.NewVocabulary(token.Keywords)
+	for _, sym := range mm.Scopes() {
+		v.Set(sym.PublicName, token.ScopeClass)
+	}
+	for _, sym := range mm.Predicates() {
+		if class, ok := v.Lookup(sym.PublicName); !ok || !token.IsPredicateClass(class) {
+			v.Set(sym.PublicName, token.PREDICATE)
+		}
+	}
+	return v
+}
+
+// Drift es una palabra en la que token.Keywords y la base de definiciones no
+// coinciden. Keyword está vacío si la palabra no está en el mapa y Thing si
+// no está en la base.
+type Drift struct {
+	Word    string
+	Keyword token.TokenClass
+	Thing   ds.ThingType
+}
+
+func (d Drift) String() string {
+	switch {
+	case d.Keyword == "":
+		return fmt.Sprintf("%q is a %s in the database but not a keyword", d.Word, d.Thing)
+	case d.Thing == "":
+		return fmt.Sprintf("%q is keyword %s but not in the database", d.Word, d.Keyword)
+	default:
+		return fmt.Sprintf("%q is a %s in the database but keyword %s", d.Word, d.Thing, d.Keyword)
+	}
+}
+
+// CheckVocabulary compara token.Keywords con los scopes y predicados de la
+// base y devuelve las diferencias ordenadas por palabra: símbolos de la base
+// que el mapa no conoce o clasifica de otra manera, y palabras clave de scope
+// o de predicado que la base no define.
+func (mm *MetamodelDefinitions) CheckVocabulary() []Drift {
+	var drifts []Drift
+	inDB := make(map[string]bool)
+	check := func(sym *ds.Symbol, fits func(token.TokenClass) bool) {
+		inDB[sym.PublicName] = true
+		if class, ok := token.Keywords[sym.PublicName]; !ok || !fits(class) {
+			drifts = append(drifts, Drift{Word: sym.PublicName, Keyword: class, Thing: sym.Thing})
+		}
+	}
+	for _, sym := range mm.Scopes() {
+		check(sym, func(c token.TokenClass) bool { return c == token.ScopeClass })
+	}
+	for _, sym := range mm.Predicates() {
+		check(sym, token.IsPredicateClass)
+	}
+	for word, class := range token.Keywords {
+		if !inDB[word] && (class == token.ScopeClass || token.IsPredicateClass(class)) {
+			drifts = append(drifts, Drift{Word: word, Keyword: class})
+		}
+	}
+	sort.Slice(drifts, func(i, j int) bool { return drifts[i].Word < drifts[j].Word })
+	return drifts
+}
+
+// Languages construye el vocabulario de cada idioma con alias en la base
+// (tabla keyword_aliases): el de Vocabulary más sus alias. Falla si un alias
+// nombra una palabra clave que no existe o choca con otra palabra clave.
+func (mm *MetamodelDefinitions) Languages() (map[string]*token.Vocabulary, error) {
+	base := mm.Vocabulary()
+	byLanguage := make(map[string]map[string]string)
+	for _, a := range ds.KeywordAliases() {
+		if byLanguage[a.Language] == nil {
+			byLanguage[a.Language] = make(map[string]string)
+		}
+		byLanguage[a.Language][a.Alias] = a.Keyword
+	}
+	langs := make(map[string]*token.Vocabulary, len(byLanguage))
+	for lang, aliases := range byLanguage {
+		v, err := base.WithAliases(aliases)
+		if err != nil {
+			return nil, fmt.Errorf("metamodel: language %q: %w", lang, err)
+		}
+		langs[lang] = v
+	}
+	return langs, nil
+}
@@ -2,16 +2,25 @@
 package parser
 
 import (
+	"context"
 	"fmt"
+	"iter"
+	"log/slog"
+	"math/big"
 	"strconv"
 
 	"github.com/devicemxl/nexusl/ds"
 	"github.com/devicemxl/nexusl/internal/Gothic/ast"
+	"github.com/devicemxl/nexusl/internal/Gothic/diag"
 	"github.com/devicemxl/nexusl/internal/Gothic/lexer"
 	"github.com/devicemxl/nexusl/internal/Gothic/metamodel"
 	"github.com/devicemxl/nexusl/internal/Gothic/token" // Ensure this is imported correctly
+	"github.com/devicemxl/nexusl/logging"
 )
 
+// parserLog es el logger del subsistema parser (ver el paquete logging).
+var parserLog = logging.Logger(logging.Parser)
+
 // Parser holds the lexer, current token, peek token, and errors.
 type Parser struct {
 	l         *lexer.Lexer
@@ -19,7 +28,9 @@ type Parser struct {
 
 	curToken  token.Token
 	peekToken token.Token
-	errors    []string
+	diags     []diag.Diagnostic
+
+	trail []token.Token // Tokens de la sentencia en curso que ya dejó atrás nextToken
 }
 
 // New creates a new Parser instance.
@@ -27,21 +38,32 @@ func New(l *lexer.Lexer, mm *metamodel.MetamodelDefinitions) *Parser {
 	p := &Parser{
 		l:         l,
 		metamodel: mm,
-		errors:    []string{},
 	}
 	// Read two tokens, so curToken and peekToken are both set.
-
 	p.nextToken()
-	fmt.Printf("inside-DEBUG: parseExpression called. Current Token: Type=%s, Word=%q, Line=%d, Col=%d\n", p.curToken.Type, p.curToken.Word, p.curToken.Line, p.curToken.Column) // DEPURAR
-
 	p.nextToken()
-	fmt.Printf("inside-DEBUG: parseExpression called. Current Token: Type=%s, Word=%q, Line=%d, Col=%d\n", p.curToken.Type, p.curToken.Word, p.curToken.Line, p.curToken.Column) // DEPURAR
 	// <--- This will initialize both curToken (FACT) and peekToken (Car)
 	return p
 }
 
+// canonical devuelve la palabra clave que escribe word en el idioma del lexer
+// ('hecho' -> 'fact'); las demás palabras no cambian.
+func (p *Parser) canonical(word string) string {
+	return p.l.ActiveVocabulary().Canonical(word)
+}
+
+// trace registra en debug la regla que se está parseando y el token actual.
+func (p *Parser) trace(rule string) {
+	if !parserLog.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	parserLog.Debug(rule, "type", p.curToken.Type, "word", p.curToken.Word,
+		"line", p.curToken.Line, "column", p.curToken.Column)
+}
+
 // nextToken advances the parser's current and peek tokens.
 func (p *Parser) nextToken() {
+	p.trail = append(p.trail, p.curToken)
 	p.curToken = p.peekToken
 	p.peekToken = p.l.Ensambladora() // Get the next token from the lexer
 }
@@ -51,38 +73,120 @@ func (p *Parser) ParseProgram() *ast.Program {
 	program := &ast.Program{}
 	program.Statements = []ast.Statement{}
 
-	for p.curToken.Type != token.EOF {
-		stmt := p.parseStatement()
-		if stmt != nil {
-			program.Statements = append(program.Statements, stmt)
+	for stmt := range p.Statements() {
+		program.Statements = append(program.Statements, stmt)
+	}
+	return program
+}
+
+// Statements devuelve un iterador que parsea las sentencias una a una, a medida
+// que el lexer lee la entrada, sin construir el ast.Program: con un lexer de
+// NewReader un volcado de hechos se procesa en memoria constante. Una
+// sentencia con errores se entrega como *ast.BadStatement y sus errores
+// quedan en Errors(). Si el consumidor corta la iteración, la siguiente
+// continúa donde se quedó.
+func (p *Parser) Statements() iter.Seq[ast.Statement] {
+	return func(yield func(ast.Statement) bool) {
+		for p.curToken.Type != token.EOF {
+			p.trail = p.trail[:0]
+			stmt := p.parseStatement()
+			if stmt == nil {
+				stmt = p.synchronize()
+			}
+			// Una sentencia correcta deja curToken en su ';'; una rota, en su ';' o
+			// justo en el inicio de la siguiente.
+			if p.curTokenIs(token.SEMICOLON) {
+				p.nextToken()
+			}
+			if !yield(stmt) {
+				return
+			}
 		}
-		// The statement parsing functions are responsible for advancing `p.curToken`
-		// to the token *after* the statement's end (e.g., after the semicolon).
-		// We don't call
-		p.nextToken()
-		fmt.Printf("inside-DEBUG: parseExpression called. Current Token: Type=%s, Word=%q, Line=%d, Col=%d\n", p.curToken.Type, p.curToken.Word, p.curToken.Line, p.curToken.Column) // DEPURAR
-		//here unconditionally, as parseStatement should handle it.
-		// However, if parseStatement returns nil (due to error), we need to advance to avoid infinite loop.
-		if stmt == nil {
-			// If statement parsing failed, we need to advance to prevent infinite loop
-			// by skipping the current problematic token.
-
-			p.nextToken()
-			fmt.Printf("inside-DEBUG: parseExpression called. Current Token: Type=%s, Word=%q, Line=%d, Col=%d\n", p.curToken.Type, p.curToken.Word, p.curToken.Line, p.curToken.Column) // DEPURAR
-			// Skip the token that caused the error
+	}
+}
+
+// statementStart son las palabras clave que empiezan una sentencia: puntos de
+// sincronización tras un error, además del ';'.
+var statementStart = map[token.TokenClass]bool{
+	token.FACT:       true,
+	token.RULE:       true,
+	token.RETRACT:    true,
+	token.EXPLAIN:    true,
+	token.TRACE:      true,
+	token.TABLE:      true,
+	token.SIMPLIFY:   true,
+	token.EXPAND:     true,
+	token.DERIVE:     true,
+	token.SUBSTITUTE: true,
+	token.EQ_SOLVE:   true,
+}
+
+// synchronize recupera el parser tras una sentencia rota (recuperación en
+// modo pánico): descarta tokens hasta el ';' que la cierra o hasta la palabra
+// clave que empieza la siguiente, sin consumirla. Así solo se informa el
+// primer error de cada sentencia y no la cascada que provocaría seguir
+// parseando desde el token equivocado. Devuelve la sentencia como
+// *ast.BadStatement con todos sus tokens.
+func (p *Parser) synchronize() *ast.BadStatement {
+	for !p.curTokenIs(token.SEMICOLON) && !p.curTokenIs(token.EOF) {
+		if statementStart[p.curToken.Type] && len(p.trail) > 0 {
+			break // Empieza la siguiente sentencia
 		}
+		p.nextToken()
 	}
-	return program
+	tokens := append([]token.Token{}, p.trail...)
+	if p.curTokenIs(token.SEMICOLON) {
+		tokens = append(tokens, p.curToken)
+	}
+	if len(tokens) == 0 {
+		tokens = append(tokens, p.curToken) // Solo puede pasar en EOF
+	}
+	return &ast.BadStatement{Token: tokens[0], Tokens: tokens}
 }
 
 // parseStatement tries to parse a single statement.
 func (p *Parser) parseStatement() ast.Statement {
-	fmt.Printf("inside-DEBUG: parseStatement called. Current Token: Type=%s, Word=%q, Line=%d, Col=%d\n",
-		p.curToken.Type, p.curToken.Word, p.curToken.Line, p.curToken.Column) // <-- Added DEBUG here
+	p.trace("parseStatement")
 
 	switch p.curToken.Type {
-	case token.FACT:
-		return p.parseFactStatement()
+	case token.FACT, token.RULE:
+		// Los scopes de la base comparten la clase de 'fact'; el de las reglas
+		// tiene su propia forma.
+		if p.canonical(p.curToken.Word) == ruleScope {
+			if stmt := p.parseRuleStatement(); stmt != nil {
+				return stmt
+			}
+			return nil
+		}
+		if stmt := p.parseFactStatement(); stmt != nil {
+			return stmt
+		}
+		return nil
+	case token.RETRACT:
+		if stmt := p.parseRetractStatement(); stmt != nil {
+			return stmt
+		}
+		return nil
+	case token.EXPLAIN:
+		if stmt := p.parseExplainStatement(); stmt != nil {
+			return stmt
+		}
+		return nil
+	case token.TRACE:
+		if stmt := p.parseTraceStatement(); stmt != nil {
+			return stmt
+		}
+		return nil
+	case token.TABLE:
+		if stmt := p.parseTableStatement(); stmt != nil {
+			return stmt
+		}
+		return nil
+	case token.SIMPLIFY, token.EXPAND, token.DERIVE, token.SUBSTITUTE, token.EQ_SOLVE:
+		if stmt := p.parseAlgebraStatement(); stmt != nil {
+			return stmt
+		}
+		return nil
 	default:
 		p.noCurTokenError(token.FACT) // Report that we expected 'fact' keyword
 		return nil
@@ -93,14 +197,12 @@ func (p *Parser) parseStatement() ast.Statement {
 func (p *Parser) parseFactStatement() *ast.FactStatement {
 	factToken := p.curToken // Capturamos el token 'fact' (Type=FACT_KEYWORD)
 
-	factScopeSymbol, ok := p.metamodel.LookupScope(factToken.Word)
-	if !ok || factScopeSymbol.Thing != ds.TripletScopeType {
-		p.errors = append(p.errors, fmt.Sprintf("Line %d, Column %d: Unknown or invalid scope '%s'", factToken.Line, factToken.Column, factToken.Word))
+	factScopeSymbol, ok := p.lookupScope(factToken)
+	if !ok {
 		return nil
 	}
 
 	p.nextToken()
-	fmt.Printf("inside-DEBUG: parseExpression called. Current Token: Type=%s, Word=%q, Line=%d, Col=%d\n", p.curToken.Type, p.curToken.Word, p.curToken.Line, p.curToken.Column) // DEPURAR
 	// Consume 'fact'. curToken ahora es 'Car'
 
 	// Sujeto
@@ -110,7 +212,6 @@ func (p *Parser) parseFactStatement() *ast.FactStatement {
 	}
 
 	p.nextToken()
-	fmt.Printf("inside-DEBUG: parseExpression called. Current Token: Type=%s, Word=%q, Line=%d, Col=%d\n", p.curToken.Type, p.curToken.Word, p.curToken.Line, p.curToken.Column) // DEPURAR
 	// Consumes 'Car'. curToken ahora es 'is'
 
 	// Predicado
@@ -120,7 +221,6 @@ func (p *Parser) parseFactStatement() *ast.FactStatement {
 	}
 
 	p.nextToken()
-	fmt.Printf("inside-DEBUG: parseExpression called. Current Token: Type=%s, Word=%q, Line=%d, Col=%d\n", p.curToken.Type, p.curToken.Word, p.curToken.Line, p.curToken.Column) // DEPURAR
 	// Consumes 'is'. curToken ahora es 'symbol'
 
 	// Objeto
@@ -130,7 +230,6 @@ func (p *Parser) parseFactStatement() *ast.FactStatement {
 	}
 
 	p.nextToken()
-	fmt.Printf("inside-DEBUG: parseExpression called. Current Token: Type=%s, Word=%q, Line=%d, Col=%d\n", p.curToken.Type, p.curToken.Word, p.curToken.Line, p.curToken.Column) // DEPURAR
 	// Consumes 'symbol'. curToken ahora es ';'
 
 	// Esperar y consumir el punto y coma final
@@ -139,12 +238,11 @@ func (p *Parser) parseFactStatement() *ast.FactStatement {
 		return nil
 	}
 
-	p.nextToken()
-	fmt.Printf("inside-DEBUG: parseExpression called. Current Token: Type=%s, Word=%q, Line=%d, Col=%d\n", p.curToken.Type, p.curToken.Word, p.curToken.Line, p.curToken.Column) // DEPURAR
-	// Consume ';'. curToken ahora es EOF (or the start of next statement)
+	// curToken queda en ';': ParseProgram avanza al inicio de la siguiente sentencia.
 
 	return &ast.FactStatement{
 		Token:     factToken,
+		Doc:       ast.DocComment(factToken),
 		Scope:     factScopeSymbol,
 		Subject:   subject,
 		Predicate: predicate,
@@ -152,31 +250,219 @@ func (p *Parser) parseFactStatement() *ast.FactStatement {
 	}
 }
 
+// lookupScope busca en el metamodelo el scope que abre la sentencia tok y
+// registra un error si no es un TripletScope.
+func (p *Parser) lookupScope(tok token.Token) (*ds.Symbol, bool) {
+	scope, ok := p.metamodel.LookupScope(p.canonical(tok.Word))
+	if !ok || scope.Thing != ds.TripletScopeType {
+		p.report(diag.Errorf(diag.UnknownScope, diag.TokenSpan(tok), "Unknown or invalid scope '%s'", tok.Word).
+			WithNote("the scope must be defined in the system definitions database"))
+		return nil, false
+	}
+	return scope, true
+}
+
+// ruleScope es el scope de las reglas.
+const ruleScope = "rule"
+
+// parseRuleStatement parsea 'rule Cabeza if Condición and Condición ...;',
+// donde la cabeza y las condiciones son tripletas. Deja curToken en el ';'.
+func (p *Parser) parseRuleStatement() *ast.RuleStatement {
+	stmt := &ast.RuleStatement{Token: p.curToken, Doc: ast.DocComment(p.curToken)}
+	var ok bool
+	if stmt.Scope, ok = p.lookupScope(stmt.Token); !ok {
+		return nil
+	}
+	stmt.Head = &ast.Triple{}
+	if !p.parseParts(&stmt.Head.Subject, &stmt.Head.Predicate, &stmt.Head.Object) {
+		return nil
+	}
+	// La primera condición va tras 'if' y las demás tras 'and'; sin
+	// condiciones no hay regla, así que falta el 'if' y no el ';'.
+	for connective := token.IF; len(stmt.Body) == 0 || !p.peekTokenIs(token.SEMICOLON); connective = token.AND_GATE {
+		if !p.peekTokenIs(connective) {
+			if connective == token.IF {
+				p.peekError(token.IF)
+			} else {
+				p.peekError(token.SEMICOLON)
+			}
+			return nil
+		}
+		p.nextToken()
+		cond := &ast.Triple{Token: p.curToken}
+		if !p.parseParts(&cond.Subject, &cond.Predicate, &cond.Object) {
+			return nil
+		}
+		stmt.Body = append(stmt.Body, cond)
+	}
+	p.nextToken()
+	return stmt
+}
+
+// parseRetractStatement parsea una declaración 'retract Subject Predicate Object;'.
+// Deja curToken en el ';' final, igual que parseFactStatement.
+func (p *Parser) parseRetractStatement() *ast.RetractStatement {
+	stmt := &ast.RetractStatement{Token: p.curToken, Doc: ast.DocComment(p.curToken)}
+	if !p.parseTriple(&stmt.Subject, &stmt.Predicate, &stmt.Object) {
+		return nil
+	}
+	return stmt
+}
+
+// parseExplainStatement parsea una declaración 'explain Subject Predicate Object;'.
+func (p *Parser) parseExplainStatement() *ast.ExplainStatement {
+	stmt := &ast.ExplainStatement{Token: p.curToken, Doc: ast.DocComment(p.curToken)}
+	if !p.parseTriple(&stmt.Subject, &stmt.Predicate, &stmt.Object) {
+		return nil
+	}
+	return stmt
+}
+
+// parseTraceStatement parsea una declaración 'trace Predicate;'.
+func (p *Parser) parseTraceStatement() *ast.TraceStatement {
+	stmt := &ast.TraceStatement{Token: p.curToken, Doc: ast.DocComment(p.curToken)}
+	p.nextToken()
+	if stmt.Predicate = p.parseExpression(); stmt.Predicate == nil {
+		return nil
+	}
+	if !p.expectPeek(token.SEMICOLON) {
+		return nil
+	}
+	return stmt
+}
+
+// parseTableStatement parsea una declaración 'table Predicate/Arity;'.
+func (p *Parser) parseTableStatement() *ast.TableStatement {
+	stmt := &ast.TableStatement{Token: p.curToken, Doc: ast.DocComment(p.curToken)}
+	p.nextToken()
+	if stmt.Predicate = p.parseExpression(); stmt.Predicate == nil {
+		return nil
+	}
+	if !p.expectPeek(token.DIVIDE) || !p.expectPeek(token.INTEGER) {
+		return nil
+	}
+	arity := p.parseIntegerLiteral(p.curToken)
+	if arity == nil {
+		return nil
+	}
+	stmt.Arity = arity
+	if !p.expectPeek(token.SEMICOLON) {
+		return nil
+	}
+	return stmt
+}
+
+// algebraArity es la cantidad de argumentos de cada operación algebraica.
+var algebraArity = map[token.TokenClass]int{
+	token.SIMPLIFY:   1,
+	token.EXPAND:     1,
+	token.DERIVE:     2,
+	token.SUBSTITUTE: 3,
+	token.EQ_SOLVE:   2,
+}
+
+// parseAlgebraStatement parsea 'simplify E;', 'derive E X;' y las demás
+// operaciones algebraicas. Deja curToken en el ';' final.
+func (p *Parser) parseAlgebraStatement() *ast.AlgebraStatement {
+	stmt := &ast.AlgebraStatement{Token: p.curToken, Doc: ast.DocComment(p.curToken)}
+	for !p.peekTokenIs(token.SEMICOLON) && !p.peekTokenIs(token.EOF) {
+		p.nextToken()
+		arg := p.parseExpression()
+		if arg == nil {
+			return nil
+		}
+		stmt.Arguments = append(stmt.Arguments, arg)
+	}
+	if !p.expectPeek(token.SEMICOLON) {
+		return nil
+	}
+	if want := algebraArity[stmt.Token.Type]; len(stmt.Arguments) != want {
+		p.report(diag.Errorf(diag.WrongArity, diag.TokenSpan(stmt.Token), "%s expects %d arguments, got %d",
+			stmt.Token.Word, want, len(stmt.Arguments)))
+		return nil
+	}
+	return stmt
+}
+
+// parseSExpression parsea '(operador argumentos...)' y deja curToken en el ')'.
+func (p *Parser) parseSExpression() *ast.SExpression {
+	expr := &ast.SExpression{Token: p.curToken}
+	p.nextToken()
+	switch p.curToken.Type {
+	case token.RPAREN, token.SEMICOLON, token.EOF:
+		p.report(diag.Errorf(diag.ExpectedToken, diag.TokenSpan(p.curToken), "Expected an operator after '(', got %s (%q)",
+			p.curToken.Type, p.curToken.Word))
+		return nil
+	}
+	expr.Operator = p.curToken
+	for !p.peekTokenIs(token.RPAREN) {
+		if p.peekTokenIs(token.EOF) || p.peekTokenIs(token.SEMICOLON) {
+			p.peekError(token.RPAREN)
+			return nil
+		}
+		p.nextToken()
+		arg := p.parseExpression()
+		if arg == nil {
+			return nil
+		}
+		expr.Arguments = append(expr.Arguments, arg)
+	}
+	p.nextToken()
+	return expr
+}
+
+// parseTriple parsea 'Subject Predicate Object;' a continuación de la palabra
+// clave actual y deja curToken en el ';'.
+func (p *Parser) parseTriple(subject, predicate, object *ast.Expression) bool {
+	return p.parseParts(subject, predicate, object) && p.expectPeek(token.SEMICOLON)
+}
+
+// parseParts parsea una expresión por parte a continuación del token actual
+// y deja curToken en el último token de la última.
+func (p *Parser) parseParts(parts ...*ast.Expression) bool {
+	for _, part := range parts {
+		p.nextToken()
+		if *part = p.parseExpression(); *part == nil {
+			return false
+		}
+	}
+	return true
+}
+
 // parseExpression es la función principal que decide qué tipo de expresión parsear
 func (p *Parser) parseExpression() ast.Expression {
-	fmt.Printf("DEBUG: parseExpression called. Current Token: Type=%s, Word=%q, Line=%d, Col=%d\n",
-		p.curToken.Type, p.curToken.Word, p.curToken.Line, p.curToken.Column)
+	p.trace("parseExpression")
 
 	switch p.curToken.Type {
 	case token.IDENTIFIER:
 		return p.parseIdentifier()
-	case token.STRING:
+	case token.VARIABLE:
+		return &ast.Variable{Token: p.curToken, Name: p.curToken.Word[1:]}
+	case token.STRING, token.MULTILINE_STRING:
 		return p.parseStringLiteral()
-	case token.INTEGER:
-		return p.parseIntegerLiteral()
-	case token.FLOAT:
-		return p.parseFloatLiteral()
+	case token.ILLEGAL:
+		// El lexer ya registró el error (literal sin cerrar, escape inválido...).
+		return nil
+	case token.INTEGER, token.FLOAT, token.DECIMAL, token.COMPLEX, token.MINUS:
+		return p.parseNumber()
 	case token.BOOLEAN:
 		return p.parseBooleanLiteral()
-	case token.IS: // This should be "IS" (uppercase)
-		// If 'is' is a keyword token, treat it as an identifier for the AST
-		return &ast.Identifier{Token: p.curToken, Value: p.curToken.Word}
+	case token.IS, token.HAS, token.DO, token.HOW, token.WHERE, token.WHEN, token.PREDICATE:
+		// Los predicados del modelo de tripletas son palabras clave (PREDICATE si
+		// vienen solo de la base); en el AST son identificadores con la palabra
+		// canónica, aunque se escribieran con un alias ('tiene' -> 'has').
+		return &ast.Identifier{Token: p.curToken, Value: p.canonical(p.curToken.Word)}
+	case token.LPAREN:
+		if expr := p.parseSExpression(); expr != nil {
+			return expr
+		}
+		return nil
 	case token.SYMBOL: // This should be "SYMBOL" (uppercase)
 		// Treat "symbol" as an identifier in the AST for now.
-		return &ast.Identifier{Token: p.curToken, Value: p.curToken.Word}
+		return &ast.Identifier{Token: p.curToken, Value: p.canonical(p.curToken.Word)}
 	default:
-		p.errors = append(p.errors, fmt.Sprintf("Line %d, Column %d: Unexpected token %s (%q) when expecting an expression.",
-			p.curToken.Line, p.curToken.Column, p.curToken.Type, p.curToken.Word))
+		p.report(diag.Errorf(diag.UnexpectedToken, diag.TokenSpan(p.curToken), "Unexpected token %s (%q) when expecting an expression.",
+			p.curToken.Type, p.curToken.Word))
 		return nil
 	}
 }
@@ -186,36 +472,101 @@ func (p *Parser) parseIdentifier() *ast.Identifier {
 	return &ast.Identifier{Token: p.curToken, Value: p.curToken.Word}
 }
 
-// parseStringLiteral parsea un token STRING y lo convierte en un nodo *ast.StringLiteral.
+// parseStringLiteral parsea un token STRING o MULTILINE_STRING y lo convierte en un nodo *ast.StringLiteral.
 func (p *Parser) parseStringLiteral() *ast.StringLiteral {
 	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Word}
 }
 
-// parseIntegerLiteral parsea un token INT y lo convierte en un nodo *ast.IntegerLiteral.
-func (p *Parser) parseIntegerLiteral() *ast.IntegerLiteral {
-	val, err := strconv.ParseInt(p.curToken.Word, 0, 64)
+// parseNumber parsea un literal numérico con signo opcional. Un complejo
+// 'a±bi' solo se escribe sin espacios ('2+3i', '-1.5-0.5i'): el lexer lo lee
+// como un único token COMPLEX. Con espacios son expresiones distintas, así
+// que '(* 2 -3i)' tiene dos argumentos. Deja curToken en el último token del
+// literal.
+func (p *Parser) parseNumber() ast.Expression {
+	tok := p.curToken
+	tok.Word = ""
+	if p.curTokenIs(token.MINUS) {
+		tok.Word = "-"
+		p.nextToken()
+	}
+	switch p.curToken.Type {
+	case token.INTEGER, token.FLOAT, token.DECIMAL, token.COMPLEX:
+	default:
+		p.report(diag.Errorf(diag.UnexpectedToken, diag.TokenSpan(p.curToken), "Expected a number after '-', got %s (%q)",
+			p.curToken.Type, p.curToken.Word))
+		return nil
+	}
+	tok.Type = p.curToken.Type
+	tok.Word += p.curToken.Word
+	// El literal acaba donde acaba su último token.
+	tok.EndLine, tok.EndColumn, tok.Trailing = p.curToken.EndLine, p.curToken.EndColumn, p.curToken.Trailing
+
+	switch tok.Type {
+	case token.INTEGER:
+		if lit := p.parseIntegerLiteral(tok); lit != nil {
+			return lit
+		}
+	case token.FLOAT:
+		if lit := p.parseFloatLiteral(tok); lit != nil {
+			return lit
+		}
+	case token.DECIMAL:
+		if lit := p.parseDecimalLiteral(tok); lit != nil {
+			return lit
+		}
+	default:
+		if lit := p.parseComplexLiteral(tok); lit != nil {
+			return lit
+		}
+	}
+	return nil
+}
+
+// parseIntegerLiteral convierte un token INT en un nodo *ast.IntegerLiteral.
+// El valor no tiene límite de tamaño: un número de serie de 20 cifras es un
+// entero. Siempre es decimal: los ceros a la izquierda ('0089') no lo hacen octal.
+func (p *Parser) parseIntegerLiteral(tok token.Token) *ast.IntegerLiteral {
+	val, ok := new(big.Int).SetString(tok.Word, 10)
+	if !ok {
+		p.report(diag.Errorf(diag.InvalidNumber, diag.TokenSpan(tok), "Could not parse %q as integer", tok.Word))
+		return nil
+	}
+	return &ast.IntegerLiteral{Token: tok, Value: val}
+}
+
+// parseDecimalLiteral convierte un token DECIMAL ('19.99d') en un nodo *ast.DecimalLiteral.
+func (p *Parser) parseDecimalLiteral(tok token.Token) *ast.DecimalLiteral {
+	val, err := ds.ParseDecimal(tok.Word)
 	if err != nil {
-		p.errors = append(p.errors, fmt.Sprintf("Line %d, Column %d: Could not parse %q as integer: %v",
-			p.curToken.Line, p.curToken.Column, p.curToken.Word, err))
+		p.report(diag.Errorf(diag.InvalidNumber, diag.TokenSpan(tok), "Could not parse %q as decimal: %v", tok.Word, err))
 		return nil
 	}
-	return &ast.IntegerLiteral{Token: p.curToken, Value: val}
+	return &ast.DecimalLiteral{Token: tok, Value: val}
 }
 
-// parseFloatLiteral parsea un token FLOAT y lo convierte en un nodo *ast.FloatLiteral.
-func (p *Parser) parseFloatLiteral() *ast.FloatLiteral {
-	val, err := strconv.ParseFloat(p.curToken.Word, 64)
+// parseFloatLiteral convierte un token FLOAT en un nodo *ast.FloatLiteral.
+func (p *Parser) parseFloatLiteral(tok token.Token) *ast.FloatLiteral {
+	val, err := strconv.ParseFloat(tok.Word, 64)
 	if err != nil {
-		p.errors = append(p.errors, fmt.Sprintf("Line %d, Column %d: Could not parse %q as float: %v",
-			p.curToken.Line, p.curToken.Column, p.curToken.Word, err))
+		p.report(diag.Errorf(diag.InvalidNumber, diag.TokenSpan(tok), "Could not parse %q as float: %v", tok.Word, err))
 		return nil
 	}
-	return &ast.FloatLiteral{Token: p.curToken, Value: val}
+	return &ast.FloatLiteral{Token: tok, Value: val}
+}
+
+// parseComplexLiteral convierte un token COMPLEX ('3i', '2+3i') en un nodo *ast.ComplexLiteral.
+func (p *Parser) parseComplexLiteral(tok token.Token) *ast.ComplexLiteral {
+	val, err := ds.ParseComplex(tok.Word)
+	if err != nil {
+		p.report(diag.Errorf(diag.InvalidNumber, diag.TokenSpan(tok), "Could not parse %q as complex: %v", tok.Word, err))
+		return nil
+	}
+	return &ast.ComplexLiteral{Token: tok, Value: val}
 }
 
 // parseBooleanLiteral parsea un token BOOLEAN y lo convierte en un nodo *ast.BooleanLiteral.
 func (p *Parser) parseBooleanLiteral() *ast.BooleanLiteral {
-	val := (p.curToken.Word == "true")
+	val := (p.canonical(p.curToken.Word) == "true")
 	return &ast.BooleanLiteral{Token: p.curToken, Value: val}
 }
 
@@ -230,10 +581,7 @@ func (p *Parser) peekTokenIs(t token.TokenClass) bool {
 
 func (p *Parser) expectPeek(t token.TokenClass) bool {
 	if p.peekTokenIs(t) {
-
 		p.nextToken()
-		fmt.Printf("inside-DEBUG: parseExpression called. Current Token: Type=%s, Word=%q, Line=%d, Col=%d\n", p.curToken.Type, p.curToken.Word, p.curToken.Line, p.curToken.Column) // DEPURAR
-
 		return true
 	} else {
 		p.peekError(t)
@@ -241,18 +589,42 @@ func (p *Parser) expectPeek(t token.TokenClass) bool {
 	}
 }
 
+// Diagnostics devuelve los diagnósticos léxicos y sintácticos, ordenados por posición.
+func (p *Parser) Diagnostics() []diag.Diagnostic {
+	diags := append(append([]diag.Diagnostic{}, p.l.Diagnostics...), p.diags...)
+	diag.Sort(diags)
+	return diags
+}
+
+// Errors devuelve el texto de los diagnósticos: "Line L, Column C: mensaje".
 func (p *Parser) Errors() []string {
-	return p.errors
+	return diag.Strings(p.Diagnostics())
+}
+
+// report registra un diagnóstico sintáctico.
+func (p *Parser) report(d diag.Diagnostic) {
+	p.diags = append(p.diags, d)
 }
 
 func (p *Parser) peekError(t token.TokenClass) {
-	msg := fmt.Sprintf("Expected next token to be %s, got %s instead (%q)",
+	d := diag.Errorf(diag.ExpectedToken, diag.TokenSpan(p.peekToken), "Expected next token to be %s, got %s instead (%q)",
 		t, p.peekToken.Type, p.peekToken.Word)
-	p.errors = append(p.errors, msg)
+	p.report(withInsertFix(d, t, p.curToken))
 }
 
 func (p *Parser) noCurTokenError(t token.TokenClass) {
-	msg := fmt.Sprintf("Expected current token to be %s, got %s instead (%q)",
+	d := diag.Errorf(diag.ExpectedToken, diag.TokenSpan(p.curToken), "Expected current token to be %s, got %s instead (%q)",
 		t, p.curToken.Type, p.curToken.Word)
-	p.errors = append(p.errors, msg)
+	if len(p.trail) > 0 {
+		d = withInsertFix(d, t, p.trail[len(p.trail)-1])
+	}
+	p.report(d)
+}
+
+// withInsertFix sugiere insertar el ';' o el ')' que falta justo después de prev.
+func withInsertFix(d diag.Diagnostic, t token.TokenClass, prev token.Token) diag.Diagnostic {
+	if t != token.SEMICOLON && t != token.RPAREN {
+		return d
+	}
+	return d.WithFix(fmt.Sprintf("insert '%s'", t), diag.PointAfter(prev), string(t))
 }
@@ -0,0 +1,270 @@
+package parser_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/devicemxl/nexusl/ds"
+	"github.com/devicemxl/nexusl/internal/Gothic/ast"
+	"github.com/devicemxl/nexusl/internal/Gothic/lexer"
+	"github.com/devicemxl/nexusl/internal/Gothic/metamodel"
+	"github.com/devicemxl/nexusl/internal/Gothic/parser"
+)
+
+// newParser crea un parser sobre l. Los scopes 'fact' y 'rule' vienen
+// normalmente de la base de definiciones; aquí se registran si faltan.
+func newParser(l *lexer.Lexer) *parser.Parser {
+	for _, name := range []string{"fact", "rule"} {
+		if _, ok := ds.LookupSymbolByPublicName(name); !ok {
+			ds.NewSymbolWithPublicName(name, ds.TripletScopeType)
+		}
+	}
+	return parser.New(l, metamodel.NewMetamodelFacade())
+}
+
+// kinds devuelve los tipos de las sentencias, separados por espacios.
+func kinds(program *ast.Program) string {
+	var out []string
+	for _, stmt := range program.Statements {
+		out = append(out, fmt.Sprintf("%T", stmt))
+	}
+	return strings.Join(out, " ")
+}
+
+func TestStringLiterals(t *testing.T) {
+	src := "fact order payload `{\"id\": 1, \"tags\": [\"a\\n\"]}`;\n" +
+		"fact order note \"\"\"\nPrimera línea\n\tsegunda\"\"\";\n" +
+		"fact order label \"dice \\\"hola\\\"\\u{21}\";"
+	p := newParser(lexer.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	want := []string{`{"id": 1, "tags": ["a\n"]}`, "Primera línea\n\tsegunda", `dice "hola"!`}
+	for i, stmt := range program.Statements {
+		lit, ok := stmt.(*ast.FactStatement).Object.(*ast.StringLiteral)
+		if !ok || lit.Value != want[i] {
+			t.Errorf("object %d = %#v, want the string %q", i, stmt.(*ast.FactStatement).Object, want[i])
+		}
+	}
+}
+
+func TestIntegerLiterals(t *testing.T) {
+	p := newParser(lexer.New("fact item serial 0089;\nfact item serial 010;\nfact item serial -000123456789012345678901;"))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	for i, want := range []string{"89", "10", "-123456789012345678901"} {
+		lit, ok := program.Statements[i].(*ast.FactStatement).Object.(*ast.IntegerLiteral)
+		if !ok || lit.Value.String() != want {
+			t.Errorf("serial %d = %v, want %s", i, program.Statements[i].(*ast.FactStatement).Object, want)
+		}
+	}
+}
+
+// TestComplexLiterals comprueba que 'a±bi' solo es un literal sin espacios:
+// con espacios, el signo empieza otro argumento de la expresión S.
+func TestComplexLiterals(t *testing.T) {
+	p := newParser(lexer.New("simplify (* 2 -3);\nsimplify (* 2 -3i);\nfact v phasor 1+2i;"))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	for i, want := range []string{"-3", "(0-3i)"} {
+		args := program.Statements[i].(*ast.AlgebraStatement).Arguments[0].(*ast.SExpression).Arguments
+		if len(args) != 2 {
+			t.Errorf("statement %d: arguments = %v, want 2", i, args)
+			continue
+		}
+		var got string
+		switch a := args[1].(type) {
+		case *ast.IntegerLiteral:
+			got = a.Value.String()
+		case *ast.ComplexLiteral:
+			got = fmt.Sprint(a.Value)
+		}
+		if got != want {
+			t.Errorf("statement %d: second argument = %#v, want %s", i, args[1], want)
+		}
+	}
+	lit, ok := program.Statements[2].(*ast.FactStatement).Object.(*ast.ComplexLiteral)
+	if !ok || lit.Value != 1+2i {
+		t.Errorf("object = %#v, want the complex 1+2i", program.Statements[2].(*ast.FactStatement).Object)
+	}
+}
+
+func TestDocComments(t *testing.T) {
+	src := "// no es documentación\n" +
+		"/// Site es la web pública.\n" +
+		"/// Se consulta a diario.\n" +
+		"fact Site url \"http://x.org\"; # url canónica\n" +
+		"/// suelto\n" +
+		"\n" +
+		"fact Tag name \"#1\";"
+	p := newParser(lexer.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	if len(program.Statements) != 2 {
+		t.Fatalf("statements = %d, want 2", len(program.Statements))
+	}
+	site := program.Statements[0].(*ast.FactStatement)
+	if site.Doc != "Site es la web pública.\nSe consulta a diario." {
+		t.Errorf("Doc = %q", site.Doc)
+	}
+	if got := site.Object.String(); got != `"http://x.org"` {
+		t.Errorf("object = %s, want \"http://x.org\"", got)
+	}
+	tag := program.Statements[1].(*ast.FactStatement)
+	if tag.Doc != "" {
+		t.Errorf("Doc = %q, want none (blank line before the statement)", tag.Doc)
+	}
+	if got := tag.Object.String(); got != `"#1"` {
+		t.Errorf("object = %s, want \"#1\"", got)
+	}
+}
+
+func TestStreamingStatements(t *testing.T) {
+	const n = 5000
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, "fact item%d weight %d;\n", i, i)
+	}
+	p := newParser(lexer.NewReader(strings.NewReader(sb.String())))
+	count := 0
+	var last ast.Statement
+	for stmt := range p.Statements() {
+		count++
+		last = stmt
+	}
+	if len(p.Errors()) != 0 {
+		t.Errorf("parser errors: %v", p.Errors())
+	}
+	if count != n {
+		t.Errorf("statements = %d, want %d", count, n)
+	}
+	if want := fmt.Sprintf("fact item%d weight %d;", n-1, n-1); last == nil || last.String() != want {
+		t.Errorf("last statement = %v, want %s", last, want)
+	}
+}
+
+func TestParserRecovery(t *testing.T) {
+	src := "fact a weight 1;\n" +
+		"fact b weight;\n" +
+		"explain c d e f;\n" +
+		"fact d weight 4\n" +
+		"trace weight;\n" +
+		"fact e weight \"sin cerrar;\n" +
+		"fact f weight 6;"
+	p := newParser(lexer.New(src))
+	program := p.ParseProgram()
+
+	wantErrors := []string{
+		"Line 2, Column 14: Unexpected token ;",
+		"Line 3, Column 15: Expected next token to be ;",
+		"Line 5, Column 1: Expected current token to be ;",
+		"Line 6, Column 15: lexer: unterminated literal",
+	}
+	errs := p.Errors()
+	if len(errs) != len(wantErrors) {
+		t.Fatalf("errors = %q, want %d", errs, len(wantErrors))
+	}
+	for i, want := range wantErrors {
+		if !strings.HasPrefix(errs[i], want) {
+			t.Errorf("error %d = %q, want prefix %q", i, errs[i], want)
+		}
+	}
+
+	wantKinds := "*ast.FactStatement *ast.BadStatement *ast.BadStatement *ast.BadStatement " +
+		"*ast.TraceStatement *ast.BadStatement *ast.FactStatement"
+	if got := kinds(program); got != wantKinds {
+		t.Fatalf("statements = %s\nwant %s", got, wantKinds)
+	}
+	if got := program.Statements[3].String(); got != "fact d weight 4" {
+		t.Errorf("bad statement = %q, want %q", got, "fact d weight 4")
+	}
+	if got := program.Statements[2].String(); got != "explain c d e f ;" {
+		t.Errorf("bad statement = %q, want %q", got, "explain c d e f ;")
+	}
+}
+
+// TestRecoveryPoints comprueba que cada clase de error deja una sola
+// BadStatement, con un solo diagnóstico, y que la sentencia siguiente se
+// parsea bien.
+func TestRecoveryPoints(t *testing.T) {
+	tests := []struct {
+		name, src string
+		bad       string // Texto de la BadStatement
+		err       string // Prefijo del único error
+	}{
+		{"unknown statement start", "foo a b c;\nfact a b c;",
+			"foo a b c ;", "Line 1, Column 1: Expected current token to be FACT"},
+		{"stray semicolon", ";\nfact a b c;",
+			";", "Line 1, Column 1: Expected current token to be FACT"},
+		{"unterminated parenthesis", "simplify (+ x 1;\nfact a b c;",
+			"simplify ( + x 1 ;", "Line 1, Column 16: Expected next token to be )"},
+		{"unterminated parenthesis before a statement", "simplify (+ x 1\nfact a b c;",
+			"simplify ( + x 1", "Line 2, Column 1: Unexpected token FACT"},
+		{"illegal token", "fact a $ c;\nfact a b c;",
+			"fact a $ c ;", "Line 1, Column 8: lexer: unexpected character '$'"},
+		{"table without arity", "table path;\nfact a b c;",
+			"table path ;", "Line 1, Column 11: Expected next token to be /"},
+		{"rule without conditions", "rule ?d is unsafe;\nfact a b c;",
+			"rule ?d is unsafe ;", "Line 1, Column 18: Expected next token to be IF"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newParser(lexer.New(tt.src))
+			program := p.ParseProgram()
+			if got := kinds(program); got != "*ast.BadStatement *ast.FactStatement" {
+				t.Fatalf("statements = %s, want a bad statement and a fact", got)
+			}
+			if got := program.Statements[0].String(); got != tt.bad {
+				t.Errorf("bad statement = %q, want %q", got, tt.bad)
+			}
+			if errs := p.Errors(); len(errs) != 1 || !strings.HasPrefix(errs[0], tt.err) {
+				t.Errorf("errors = %q, want one with prefix %q", errs, tt.err)
+			}
+		})
+	}
+
+	// EOF en medio de una sentencia: una BadStatement con lo que había.
+	p := newParser(lexer.New("fact a weight 1;\nfact a b"))
+	program := p.ParseProgram()
+	if got := kinds(program); got != "*ast.FactStatement *ast.BadStatement" {
+		t.Fatalf("statements = %s, want a fact and a bad statement", got)
+	}
+	if got := program.Statements[1].String(); got != "fact a b" {
+		t.Errorf("bad statement = %q, want %q", got, "fact a b")
+	}
+	if errs := p.Errors(); len(errs) != 1 || !strings.HasPrefix(errs[0], "Line 2, Column 9: Unexpected token EOF") {
+		t.Errorf("errors = %q, want an unexpected EOF at 2:9", errs)
+	}
+}
+
+// TestStatementsResume comprueba que cortar la iteración de Statements no
+// pierde sentencias: la siguiente iteración sigue donde se quedó, también
+// justo después de una sentencia rota.
+func TestStatementsResume(t *testing.T) {
+	p := newParser(lexer.New("fact a b 1;\nfact a b;\nfact a b 3;\ntrace b;"))
+	var got []string
+	for range 3 {
+		for stmt := range p.Statements() {
+			got = append(got, stmt.String())
+			break
+		}
+	}
+	for stmt := range p.Statements() {
+		got = append(got, stmt.String())
+	}
+	want := []string{"fact a b 1;", "fact a b ;", "fact a b 3;", "trace b;"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("statements = %q, want %q", got, want)
+	}
+	for range p.Statements() {
+		t.Error("Statements yielded a statement after EOF")
+	}
+}
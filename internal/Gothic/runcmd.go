@@ -0,0 +1,88 @@
+// Gothic/runcmd.go
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/devicemxl/nexusl/internal/Gothic/diag"
+	"github.com/devicemxl/nexusl/internal/Gothic/lexer"
+	"github.com/devicemxl/nexusl/internal/Gothic/metamodel"
+	"github.com/devicemxl/nexusl/internal/Gothic/parser"
+	"github.com/devicemxl/nexusl/internal/Gothic/runtime"
+	prologo "github.com/devicemxl/nexusl/internal/proloGo"
+)
+
+// runRun implementa 'run [-json] [-debug] [archivos]': ejecuta las sentencias de cada
+// archivo en orden sobre una misma base de conocimiento, de modo que un
+// archivo ve los hechos y reglas de los anteriores. Sin archivos lee la
+// entrada estándar. Las sentencias se leen por flujo y la ejecución se
+// detiene en el primer error. Con -json los diagnósticos de cada archivo se
+// escriben como en 'fmt -json'. Con -debug las consultas corren bajo el
+// depurador paso a paso de proloGo, que lee sus órdenes de la entrada
+// estándar; por eso -debug pide archivos. Devuelve el código de salida: 1
+// si hubo errores.
+func runRun(args []string, mm *metamodel.MetamodelDefinitions) int {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print diagnostics as JSON")
+	debug := flags.Bool("debug", false, "step through queries with the debugger")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *debug && flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "run: -debug reads its commands from standard input and needs source files")
+		return 2
+	}
+	in, err := runtime.New(prologo.NewKnowledgeBase(), os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if *debug {
+		prologo.NewDebugger(os.Stdin, os.Stdout).Attach(in.Solver)
+	}
+	if flags.NArg() == 0 {
+		return runSource(in, "<stdin>", os.Stdin, *asJSON, mm)
+	}
+	for _, name := range flags.Args() {
+		f, err := os.Open(name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		code := runSource(in, name, f, *asJSON, mm)
+		f.Close()
+		if code != 0 {
+			return code
+		}
+	}
+	return 0
+}
+
+// runSource ejecuta las sentencias leídas de r y escribe sus diagnósticos.
+func runSource(in *runtime.Interpreter, name string, r io.Reader, asJSON bool, mm *metamodel.MetamodelDefinitions) int {
+	p := parser.New(lexer.NewReader(r), mm)
+	err := in.RunStatements(p.Statements())
+	diags := p.Diagnostics()
+	// Una sentencia rota ya tiene sus diagnósticos del parser.
+	var d diag.Diagnostic
+	if errors.As(err, &d) && !errors.Is(err, runtime.ErrBadStatement) {
+		diags = append(diags, d)
+	}
+	switch {
+	case asJSON:
+		diag.WriteJSON(os.Stderr, diags)
+	case len(diags) > 0:
+		// El fragmento de código de los diagnósticos sale del archivo; la
+		// entrada estándar ya se consumió y se muestran sin él.
+		src, _ := os.ReadFile(name)
+		diag.RenderAll(os.Stderr, name, string(src), diags)
+	}
+	if err != nil || diag.HasErrors(diags) {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,342 @@
+// Gothic/runtime/runtime.go
+//
+// Ejecuta las sentencias de un programa nexusL contra el motor lógico:
+//
+//	fact S P O;         afirma el hecho base P(S, O) y sus consecuencias
+//	rule H if C and D;  agrega la regla H :- C, D y la dispara (?x es una variable)
+//	retract S P O;      lo quita, junto con lo que se derivó de él
+//	explain S P O;      demuestra P(S, O) y escribe su árbol de prueba
+//	trace P;            escribe los puertos Call/Exit/Redo/Fail de las llamadas a P
+//	table P/2;          resuelve P con tabulación (reglas recursivas por la izquierda)
+//	simplify E;         escribe la forma canónica de la expresión E
+//	expand E;           la escribe desarrollada
+//	derive E X;         escribe la derivada de E respecto de X
+//	substitute E X V;   escribe E con X reemplazada por V
+//	eq_solve Eq X;      escribe las raíces de la ecuación, una por línea: x = 3
+//
+// Las expresiones se escriben con el operador primero, (+ x (* 2 y)), y los
+// resultados en notación infija (ver internal/proloGo/algebra.go). Los
+// literales complejos (3i, 2+3i, -1.5-0.5i) son constantes complex128, los
+// enteros no tienen límite de tamaño y el sufijo d marca un decimal exacto
+// (19.99d); simplify evalúa las expresiones numéricas con complejos o decimales.
+//
+// Los hechos pasan por un TMS (ver internal/proloGo/tms.go), así que las
+// inferencias materializadas nunca sobreviven a sus premisas.
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"strconv"
+
+	"github.com/devicemxl/nexusl/ds"
+	"github.com/devicemxl/nexusl/internal/Gothic/ast"
+	"github.com/devicemxl/nexusl/internal/Gothic/diag"
+	"github.com/devicemxl/nexusl/internal/Gothic/token"
+	prologo "github.com/devicemxl/nexusl/internal/proloGo"
+)
+
+var ErrUnsupported = errors.New("runtime: unsupported statement")
+
+// factScope es el único scope de tripletas que se afirma como hecho.
+const factScope = "fact"
+
+// ErrBadStatement indica que la sentencia tiene errores de sintaxis (ver parser.Errors).
+var ErrBadStatement = errors.New("runtime: statement has syntax errors")
+
+// Interpreter ejecuta sentencias sobre una base de conocimiento.
+type Interpreter struct {
+	KB     *prologo.KnowledgeBase
+	TMS    *prologo.TMS
+	Solver *prologo.Solver
+	Out    io.Writer // Destino de las explicaciones y de la traza.
+}
+
+// New crea un intérprete sobre kb que escribe en out.
+func New(kb *prologo.KnowledgeBase, out io.Writer) (*Interpreter, error) {
+	tms, err := prologo.NewTMS(kb)
+	if err != nil {
+		return nil, err
+	}
+	return &Interpreter{KB: kb, TMS: tms, Solver: prologo.NewSolver(kb), Out: out}, nil
+}
+
+// Run ejecuta las sentencias del programa en orden y se detiene en el primer error.
+func (in *Interpreter) Run(program *ast.Program) error {
+	for _, stmt := range program.Statements {
+		if err := in.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunStatements ejecuta las sentencias a medida que las entrega seq (ver
+// parser.Statements) y se detiene en el primer error. A diferencia de Run no
+// necesita el programa entero en memoria.
+func (in *Interpreter) RunStatements(seq iter.Seq[ast.Statement]) error {
+	for stmt := range seq {
+		if err := in.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Exec ejecuta una sentencia. Un error es un diag.Diagnostic situado en la
+// palabra clave de la sentencia que envuelve el error de origen (errors.Is
+// sigue funcionando con ErrUnsupported, ErrBadStatement y los del motor).
+func (in *Interpreter) Exec(stmt ast.Statement) error {
+	if err := in.exec(stmt); err != nil {
+		code := diag.RuntimeFailed
+		switch {
+		case errors.Is(err, ErrUnsupported):
+			code = diag.Unsupported
+		case errors.Is(err, ErrBadStatement):
+			code = diag.BadStatement
+		}
+		return diag.Wrap(code, diag.TokenSpan(statementToken(stmt)), err)
+	}
+	return nil
+}
+
+// statementToken devuelve el primer token de una sentencia.
+func statementToken(stmt ast.Statement) token.Token {
+	switch s := stmt.(type) {
+	case *ast.FactStatement:
+		return s.Token
+	case *ast.RuleStatement:
+		return s.Token
+	case *ast.RetractStatement:
+		return s.Token
+	case *ast.ExplainStatement:
+		return s.Token
+	case *ast.TraceStatement:
+		return s.Token
+	case *ast.TableStatement:
+		return s.Token
+	case *ast.AlgebraStatement:
+		return s.Token
+	case *ast.BadStatement:
+		return s.Token
+	}
+	return token.Token{}
+}
+
+func (in *Interpreter) exec(stmt ast.Statement) error {
+	switch s := stmt.(type) {
+	case *ast.FactStatement:
+		if s.Scope != nil && s.Scope.PublicName != factScope {
+			return fmt.Errorf("%w: scope %s", ErrUnsupported, s.Scope.PublicName)
+		}
+		goal, err := tripleGoal(s.Subject, s.Predicate, s.Object)
+		if err != nil {
+			return err
+		}
+		_, err = in.TMS.Assert(goal)
+		return err
+	case *ast.RuleStatement:
+		return in.rule(s)
+	case *ast.RetractStatement:
+		goal, err := tripleGoal(s.Subject, s.Predicate, s.Object)
+		if err != nil {
+			return err
+		}
+		_, err = in.TMS.Retract(goal)
+		return err
+	case *ast.ExplainStatement:
+		goal, err := tripleGoal(s.Subject, s.Predicate, s.Object)
+		if err != nil {
+			return err
+		}
+		return in.explain(goal)
+	case *ast.TraceStatement:
+		// Los predicados del lenguaje son tripletas: P(S, O).
+		if in.Solver.Tracer == nil {
+			in.Solver.Tracer = &prologo.TraceWriter{Out: in.Out}
+		}
+		in.Solver.Trace(prologo.Indicator(predicateName(s.Predicate), 2))
+		return nil
+	case *ast.TableStatement:
+		arity, ok := s.Arity.(*ast.IntegerLiteral)
+		if !ok || !arity.Value.IsInt64() || arity.Value.Sign() < 0 {
+			return fmt.Errorf("%w: arity %s", ErrUnsupported, s.Arity)
+		}
+		in.KB.Table(predicateName(s.Predicate), int(arity.Value.Int64()))
+		return nil
+	case *ast.AlgebraStatement:
+		return in.algebra(s)
+	case *ast.BadStatement:
+		return fmt.Errorf("%w: %s", ErrBadStatement, s.String())
+	}
+	return fmt.Errorf("%w: %s", ErrUnsupported, stmt.String())
+}
+
+// rule agrega la regla s a la base y a la red. Las variables con el mismo
+// nombre son la misma en toda la regla.
+func (in *Interpreter) rule(s *ast.RuleStatement) error {
+	vars := make(map[string]*ds.Symbol)
+	head, err := tripleTerm(s.Head, vars)
+	if err != nil {
+		return err
+	}
+	body := make([]*ds.Symbol, len(s.Body))
+	for i, c := range s.Body {
+		if body[i], err = tripleTerm(c, vars); err != nil {
+			return err
+		}
+	}
+	return in.TMS.AddRule(head, body...)
+}
+
+// explain escribe la prueba de goal, o "no." si no se puede demostrar.
+func (in *Interpreter) explain(goal *ds.Symbol) error {
+	proofs, err := in.Solver.Explain(goal)
+	if err != nil {
+		return err
+	}
+	if proofs == nil {
+		_, err = fmt.Fprintf(in.Out, "%s: no.\n", prologo.Format(goal, nil))
+		return err
+	}
+	for _, p := range proofs {
+		if _, err := io.WriteString(in.Out, p.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// algebra ejecuta una operación de álgebra simbólica y escribe el resultado.
+func (in *Interpreter) algebra(s *ast.AlgebraStatement) error {
+	args := make([]*ds.Symbol, len(s.Arguments))
+	for i, a := range s.Arguments {
+		t, err := term(a, nil)
+		if err != nil {
+			return err
+		}
+		args[i] = t
+	}
+	var out *ds.Symbol
+	var err error
+	// La clase del token, no la palabra: puede ser un alias ('simplificar').
+	switch s.Token.Type {
+	case token.SIMPLIFY:
+		out, err = prologo.Simplify(args[0])
+	case token.EXPAND:
+		out, err = prologo.Expand(args[0])
+	case token.DERIVE:
+		out, err = prologo.Derive(args[0], args[1])
+	case token.SUBSTITUTE:
+		out, err = prologo.Substitute(args[0], args[1], args[2])
+	case token.EQ_SOLVE:
+		return in.eqSolve(args[0], args[1])
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupported, s.String())
+	}
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(in.Out, prologo.FormatExpr(out))
+	return err
+}
+
+// eqSolve escribe las raíces de eq en x, o que no tiene solución.
+func (in *Interpreter) eqSolve(eq, x *ds.Symbol) error {
+	roots, err := prologo.EqSolve(eq, x)
+	if err != nil {
+		return err
+	}
+	name := prologo.FormatExpr(x)
+	if len(roots) == 0 {
+		_, err = fmt.Fprintf(in.Out, "%s: no solution.\n", name)
+		return err
+	}
+	for _, r := range roots {
+		if _, err := fmt.Fprintf(in.Out, "%s = %s\n", name, prologo.FormatExpr(r)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// operators traduce los operadores del lenguaje a los functores del motor.
+var operators = map[string]string{
+	"**": "^",
+	"==": "=",
+}
+
+// tripleGoal traduce la tripleta S P O, sin variables, al término P(S, O).
+func tripleGoal(subject, predicate, object ast.Expression) (*ds.Symbol, error) {
+	return tripleTerm(&ast.Triple{Subject: subject, Predicate: predicate, Object: object}, nil)
+}
+
+// tripleTerm traduce una tripleta de una regla al término P(S, O); vars
+// guarda las variables de la regla por nombre.
+func tripleTerm(t *ast.Triple, vars map[string]*ds.Symbol) (*ds.Symbol, error) {
+	s, err := term(t.Subject, vars)
+	if err != nil {
+		return nil, err
+	}
+	o, err := term(t.Object, vars)
+	if err != nil {
+		return nil, err
+	}
+	return prologo.Compound(predicateName(t.Predicate), s, o), nil
+}
+
+// predicateName devuelve el nombre canónico de un predicado: el parser deja
+// en el Identifier la palabra clave aunque se escribiera con un alias
+// ('tiene' -> 'has').
+func predicateName(e ast.Expression) string {
+	if id, ok := e.(*ast.Identifier); ok {
+		return id.Value
+	}
+	return e.TokenLiteral()
+}
+
+// term traduce una expresión del AST a un término. Las variables solo valen
+// en las reglas: con vars nil son un error.
+func term(e ast.Expression, vars map[string]*ds.Symbol) (*ds.Symbol, error) {
+	switch x := e.(type) {
+	case *ast.Variable:
+		if vars == nil {
+			return nil, fmt.Errorf("%w: variable %s outside a rule", ErrUnsupported, x)
+		}
+		if _, ok := vars[x.Name]; !ok {
+			vars[x.Name] = prologo.Var(x.Name)
+		}
+		return vars[x.Name], nil
+	case *ast.Identifier:
+		return prologo.Atom(x.Value), nil
+	case *ast.StringLiteral:
+		return prologo.Atom(x.Value), nil
+	case *ast.IntegerLiteral:
+		return prologo.Number(x.Value), nil
+	case *ast.DecimalLiteral:
+		return prologo.Number(x.Value), nil
+	case *ast.FloatLiteral:
+		return prologo.Number(x.Value), nil
+	case *ast.ComplexLiteral:
+		return prologo.Number(x.Value), nil
+	case *ast.BooleanLiteral:
+		return prologo.Atom(strconv.FormatBool(x.Value)), nil
+	case *ast.SExpression:
+		args := make([]*ds.Symbol, len(x.Arguments))
+		for i, a := range x.Arguments {
+			t, err := term(a, vars)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = t
+		}
+		name := x.Operator.Word
+		if op, ok := operators[name]; ok {
+			name = op
+		}
+		return prologo.Compound(name, args...), nil
+	}
+	return nil, fmt.Errorf("%w: expression %s", ErrUnsupported, e.String())
+}
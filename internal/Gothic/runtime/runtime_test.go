@@ -0,0 +1,321 @@
+package runtime_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/devicemxl/nexusl/ds"
+	"github.com/devicemxl/nexusl/internal/Gothic/ast"
+	"github.com/devicemxl/nexusl/internal/Gothic/lexer"
+	"github.com/devicemxl/nexusl/internal/Gothic/metamodel"
+	"github.com/devicemxl/nexusl/internal/Gothic/parser"
+	"github.com/devicemxl/nexusl/internal/Gothic/runtime"
+	"github.com/devicemxl/nexusl/internal/Gothic/token"
+	prologo "github.com/devicemxl/nexusl/internal/proloGo"
+)
+
+func TestExplainAndRetract(t *testing.T) {
+	kb := prologo.NewKnowledgeBase()
+	x := prologo.Var("X")
+	kb.AddRule(prologo.Compound("is", x, prologo.Atom("unsafe")), prologo.Compound("is", x, prologo.Atom("open")))
+	var out strings.Builder
+	in, err := runtime.New(kb, &out)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := in.TMS.Assert(prologo.Compound("is", prologo.Atom("door"), prologo.Atom("open"))); err != nil {
+		t.Fatalf("Assert: %v", err)
+	}
+
+	p := parser.New(lexer.New("explain door is unsafe;\nretract door is open;\ntrace is;\nexplain door is unsafe;"), nil)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	if err := in.Run(program); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	want := "is(door, unsafe)  by is(X, unsafe) :- is(X, open).  {X = door}\n" +
+		"  is(door, open)  [fact]\n" +
+		" Call: (1) is(door, unsafe)\n" +
+		"  Call: (2) is(door, open)\n" +
+		"  Fail: (2) is(door, open)\n" +
+		" Fail: (1) is(door, unsafe)\n" +
+		"is(door, unsafe): no.\n"
+	if out.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", out.String(), want)
+	}
+	if in.TMS.Believed(prologo.Compound("is", prologo.Atom("door"), prologo.Atom("unsafe"))) {
+		t.Error("is(door, unsafe) is still believed after the retraction")
+	}
+}
+
+func TestAlgebraStatements(t *testing.T) {
+	var out strings.Builder
+	in, err := runtime.New(prologo.NewKnowledgeBase(), &out)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	src := "simplify (+ x (- y x));\n" +
+		"expand (** (+ x 1) 2);\n" +
+		"derive (* x x) x;\n" +
+		"substitute (+ x y) x 10;\n" +
+		"eq_solve (= (^ x 2) (+ x 6)) x;\n" +
+		"eq_solve (+ (* x x) 1) x;"
+	p := parser.New(lexer.New(src), nil)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	if err := in.Run(program); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	want := "y\nx^2 + 2*x + 1\n2*x\ny + 10\nx = -2\nx = 3\nx: no solution.\n"
+	if out.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", out.String(), want)
+	}
+
+	p = parser.New(lexer.New("derive (* x x);"), nil)
+	p.ParseProgram()
+	if len(p.Errors()) == 0 {
+		t.Error("derive with one argument should be a parse error")
+	}
+}
+
+func TestComplexLiterals(t *testing.T) {
+	var out strings.Builder
+	in, err := runtime.New(prologo.NewKnowledgeBase(), &out)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	src := "fact v1 phasor 2+3i;\n" +
+		"fact v2 phasor -1.5-0.5i;\n" +
+		"explain v1 phasor 2+3i;\n" +
+		"explain v2 phasor -1.5-0.5i;\n" +
+		"simplify (* 2+3i 1-1i);\n" +
+		"simplify (- 3i);"
+	// El scope 'fact' viene normalmente de la base de definiciones.
+	if _, ok := ds.LookupSymbolByPublicName("fact"); !ok {
+		ds.NewSymbolWithPublicName("fact", ds.TripletScopeType)
+	}
+	mm := metamodel.NewMetamodelFacade()
+	p := parser.New(lexer.New(src), mm)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	if err := in.Run(program); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	want := "phasor(v1, 2+3i)  [fact]\n" +
+		"phasor(v2, -1.5-0.5i)  [fact]\n" +
+		"5+1i\n" +
+		"-3i\n"
+	if out.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", out.String(), want)
+	}
+
+	p = parser.New(lexer.New("fact v3 phasor 2 + x;"), mm)
+	p.ParseProgram()
+	if len(p.Errors()) == 0 {
+		t.Error("2 + x should be a parse error")
+	}
+}
+
+func TestExactNumbers(t *testing.T) {
+	var out strings.Builder
+	in, err := runtime.New(prologo.NewKnowledgeBase(), &out)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	src := "fact item42 serial 12345678901234567890;\n" +
+		"fact item42 price 19.99d;\n" +
+		"explain item42 serial 12345678901234567890;\n" +
+		"explain item42 serial 12345678901234567891;\n" +
+		"simplify (* 19.99d 3);\n" +
+		"simplify (- 0.10d 0.3d);\n" +
+		"simplify (+ 99999999999999999999 1);\n" +
+		"simplify (/ 10 4);"
+	if _, ok := ds.LookupSymbolByPublicName("fact"); !ok {
+		ds.NewSymbolWithPublicName("fact", ds.TripletScopeType)
+	}
+	// Por el camino de los archivos grandes: sentencia a sentencia.
+	p := parser.New(lexer.NewReader(strings.NewReader(src)), metamodel.NewMetamodelFacade())
+	if err := in.RunStatements(p.Statements()); err != nil {
+		t.Fatalf("RunStatements: %v", err)
+	}
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	want := "serial(item42, 12345678901234567890)  [fact]\n" +
+		"serial(item42, 12345678901234567891): no.\n" +
+		"59.97d\n" +
+		"-0.20d\n" +
+		"100000000000000000000\n" +
+		"5/2\n"
+	if out.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestBadStatement(t *testing.T) {
+	if _, ok := ds.LookupSymbolByPublicName("fact"); !ok {
+		ds.NewSymbolWithPublicName("fact", ds.TripletScopeType)
+	}
+	p := parser.New(lexer.New("fact a weight 1;\nfact b weight;"), metamodel.NewMetamodelFacade())
+	program := p.ParseProgram()
+	in, err := runtime.New(prologo.NewKnowledgeBase(), &strings.Builder{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := in.Exec(program.Statements[0]); err != nil {
+		t.Errorf("Exec(fact) = %v", err)
+	}
+	if err := in.Exec(program.Statements[1]); !errors.Is(err, runtime.ErrBadStatement) {
+		t.Errorf("Exec(bad) = %v, want ErrBadStatement", err)
+	}
+}
+
+func TestRules(t *testing.T) {
+	for _, name := range []string{"fact", "rule"} {
+		if _, ok := ds.LookupSymbolByPublicName(name); !ok {
+			ds.NewSymbolWithPublicName(name, ds.TripletScopeType)
+		}
+	}
+	var out strings.Builder
+	in, err := runtime.New(prologo.NewKnowledgeBase(), &out)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	src := "fact door1 is door;\n" +
+		"rule ?d is unsafe if ?d is door and ?d has alarm_off;\n" +
+		"fact door1 has alarm_off;\n" +
+		"fact door2 is door;\n" +
+		"explain door2 is unsafe;"
+	p := parser.New(lexer.New(src), metamodel.NewMetamodelFacade())
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	rule, ok := program.Statements[1].(*ast.RuleStatement)
+	if !ok {
+		t.Fatalf("statement 2 is %T, want *ast.RuleStatement", program.Statements[1])
+	}
+	if got := rule.String(); got != "rule ?d is unsafe if ?d is door and ?d has alarm_off;" {
+		t.Errorf("rule = %q", got)
+	}
+	if err := in.Run(program); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if out.String() != "is(door2, unsafe): no.\n" {
+		t.Errorf("output = %q", out.String())
+	}
+
+	unsafe := prologo.Compound("is", prologo.Atom("door1"), prologo.Atom("unsafe"))
+	if !in.TMS.Believed(unsafe) {
+		t.Fatal("is(door1, unsafe) is not believed")
+	}
+	// Se numera como en NetworkFromKB: es la segunda cláusula de is/2.
+	if rules := in.TMS.Network.Rules(); len(rules) != 1 || rules[0].Name != "is/2#2" {
+		t.Errorf("network rules = %v, want is/2#2", rules)
+	}
+	if _, err := in.TMS.Retract(prologo.Compound("has", prologo.Atom("door1"), prologo.Atom("alarm_off"))); err != nil {
+		t.Fatalf("Retract: %v", err)
+	}
+	if in.TMS.Believed(unsafe) {
+		t.Error("is(door1, unsafe) is still believed after retracting its premise")
+	}
+
+	// Una regla sin condiciones no es una regla, y las variables solo valen en ellas.
+	p = parser.New(lexer.New("rule door is unsafe;"), metamodel.NewMetamodelFacade())
+	if stmt := p.ParseProgram().Statements[0]; !strings.Contains(fmt.Sprint(p.Errors()), "Expected next token to be IF") {
+		t.Errorf("rule without conditions: %T, errors %q", stmt, p.Errors())
+	}
+	p = parser.New(lexer.New("fact ?d is door;"), metamodel.NewMetamodelFacade())
+	if err := in.Run(p.ParseProgram()); !errors.Is(err, runtime.ErrUnsupported) {
+		t.Errorf("fact with a variable: err = %v, want ErrUnsupported", err)
+	}
+}
+
+// TestTable comprueba que 'table' hace terminar una regla recursiva por la
+// izquierda que sin tabulación agota la profundidad de resolución.
+func TestTable(t *testing.T) {
+	for _, name := range []string{"fact", "rule"} {
+		if _, ok := ds.LookupSymbolByPublicName(name); !ok {
+			ds.NewSymbolWithPublicName(name, ds.TripletScopeType)
+		}
+	}
+	rules := "fact a edge b;\n" +
+		"fact b edge c;\n" +
+		"rule ?x path ?y if ?x edge ?y;\n" +
+		"rule ?x path ?z if ?x path ?y and ?y edge ?z;\n" +
+		"explain a path d;"
+	for _, tabled := range []bool{false, true} {
+		src := rules
+		if tabled {
+			src = "table path/2;\n" + rules
+		}
+		var out strings.Builder
+		in, err := runtime.New(prologo.NewKnowledgeBase(), &out)
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		p := parser.New(lexer.New(src), metamodel.NewMetamodelFacade())
+		err = in.RunStatements(p.Statements())
+		if len(p.Errors()) != 0 {
+			t.Fatalf("parser errors: %v", p.Errors())
+		}
+		switch {
+		case !tabled && err == nil:
+			t.Error("left recursion without table: want a resolution error")
+		case tabled && err != nil:
+			t.Errorf("left recursion with table: %v", err)
+		case tabled && out.String() != "path(a, d): no.\n":
+			t.Errorf("output = %q", out.String())
+		}
+		if got := in.KB.Tabled("path", 2); got != tabled {
+			t.Errorf("Tabled(path, 2) = %v, want %v", got, tabled)
+		}
+	}
+}
+
+// TestAliases comprueba que las sentencias escritas con alias se ejecutan con
+// las palabras canónicas: 'hecho D tiene X' afirma has(D, X).
+func TestAliases(t *testing.T) {
+	if _, ok := ds.LookupSymbolByPublicName("fact"); !ok {
+		ds.NewSymbolWithPublicName("fact", ds.TripletScopeType)
+	}
+	es, err := token.DefaultVocabulary().WithAliases(map[string]string{
+		"hecho": "fact", "explicar": "explain", "tiene": "has", "simplificar": "simplify",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token.SetLanguage("es", es)
+	defer token.SetLanguage("es", nil)
+
+	var out strings.Builder
+	in, err := runtime.New(prologo.NewKnowledgeBase(), &out)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	src := "//nexusl:lang es\n" +
+		"hecho door1 tiene alarm_off;\n" +
+		"explicar door1 tiene alarm_off;\n" +
+		"simplificar (+ x x);"
+	p := parser.New(lexer.New(src), metamodel.NewMetamodelFacade())
+	if err := in.RunStatements(p.Statements()); err != nil {
+		t.Fatalf("RunStatements: %v", err)
+	}
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	if !in.TMS.Believed(prologo.Compound("has", prologo.Atom("door1"), prologo.Atom("alarm_off"))) {
+		t.Error("has(door1, alarm_off) is not believed")
+	}
+	if want := "has(door1, alarm_off)  [fact]\n2*x\n"; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
@@ -6,7 +6,7 @@ var Keywords = map[string]TokenClass{
 	// Emptiness and Uncertainty
 	// ----------------------
 	// Keywords representing concepts of absence, lack of information, or indeterminate states.
-	"mil":     NIL,
+	"nil":     NIL,
 	"maybe":   MAYBE,
 	"unknown": UNKNOWN,
 	//
@@ -289,6 +289,8 @@ var Keywords = map[string]TokenClass{
 	"fail":        FAIL,
 	"collect_all": COLLECT_ALL,
 	"trace":       TRACE,
+	"explain":     EXPLAIN,
+	"table":       TABLE,
 	// ======================================================== #
 	// Symbolic Computation
 	// ======================================================== #
@@ -1,6 +1,7 @@
 package token_test // Nota: Cambiado a package token_test para testing externo
 
 import (
+	"errors"
 	"testing"
 
 	. "github.com/devicemxl/nexusl/internal/Gothic/token" // Importa tu paquete token
@@ -17,7 +18,7 @@ func TestLookupIdent(t *testing.T) {
 		{"and", AND_GATE}, // Asumiendo que "and" es la palabra clave para AND_GATE
 		{"or", OR_GATE},
 		{"not", NOT_GATE},
-		{"mil", NIL},     // Aquí "mil" es el literal para NIL
+		{"nil", NIL},     // "nil" es el literal para NIL
 		{"maybe", MAYBE}, // Aquí "maybe" es el literal para MAYBE
 		{"func", FUNC},
 		{"let", LET},
@@ -55,6 +56,63 @@ func TestLookupIdent(t *testing.T) {
 	}
 }
 
+func TestDefaultVocabulary(t *testing.T) {
+	v := NewVocabulary(Keywords)
+	v.Set("owns", PREDICATE)
+	SetDefaultVocabulary(v)
+	defer SetDefaultVocabulary(nil)
+
+	if got := LookupIdent("owns"); got != PREDICATE {
+		t.Errorf("LookupIdent(\"owns\") = %q with the new vocabulary, want PREDICATE", got)
+	}
+	if _, ok := Keywords["owns"]; ok {
+		t.Errorf("Set modified Keywords")
+	}
+	SetDefaultVocabulary(nil)
+	if got := LookupIdent("owns"); got != IDENTIFIER {
+		t.Errorf("LookupIdent(\"owns\") = %q after the reset, want IDENTIFIER", got)
+	}
+}
+
+func TestVocabularyAliases(t *testing.T) {
+	es, err := DefaultVocabulary().WithAliases(map[string]string{"hecho": "fact", "tiene": "has", "posee": "has"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for word, want := range map[string]TokenClass{"hecho": FACT, "fact": FACT, "tiene": HAS, "posee": HAS, "coche": IDENTIFIER} {
+		if got := es.Class(word); got != want {
+			t.Errorf("Class(%q) = %q, want %q", word, got, want)
+		}
+	}
+	if got := es.Canonical("tiene"); got != "has" {
+		t.Errorf("Canonical(\"tiene\") = %q, want \"has\"", got)
+	}
+	if got := es.Spelling("has"); got != "posee" {
+		t.Errorf("Spelling(\"has\") = %q, want the first alias \"posee\"", got)
+	}
+	if got := es.Spelling("is"); got != "is" {
+		t.Errorf("Spelling(\"is\") = %q, want \"is\"", got)
+	}
+	if _, err := es.WithAliases(map[string]string{"x": "nope"}); !errors.Is(err, ErrUnknownKeyword) {
+		t.Errorf("alias of an unknown keyword: err = %v", err)
+	}
+	if _, err := es.WithAliases(map[string]string{"is": "has"}); !errors.Is(err, ErrAliasConflict) {
+		t.Errorf("alias that is a keyword: err = %v", err)
+	}
+
+	SetLanguage("es", es)
+	defer SetLanguage("es", nil)
+	if v, ok := Language("es"); !ok || v != es {
+		t.Errorf("Language(\"es\") = %v, %v", v, ok)
+	}
+	if v, ok := Language(DefaultLanguage); !ok || v != DefaultVocabulary() {
+		t.Errorf("Language(DefaultLanguage) is not the default vocabulary")
+	}
+	if got := Languages(); len(got) != 2 || got[0] != "en" || got[1] != "es" {
+		t.Errorf("Languages() = %v", got)
+	}
+}
+
 // Puedes añadir más tests aquí si es necesario para otros aspectos de tus tokens,
 // como la creación de tokens, si creas una función NewToken.
 /*
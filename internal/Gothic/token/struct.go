@@ -17,19 +17,39 @@ type TokenClass string
 type Token struct {
 	// Aquí es se usan las constantes TokenClass (como "ILLEGAL", "IDENTIFIER", "MAY",
 	// etc.). Es el identificador principal de qué tipo de elemento sintáctico es.
-	Type TokenClass
+	Type TokenClass `json:"type"`
 	// Este campo es crucial. Contendrá el "lexema" o la secuencia de caracteres real
 	// que el lexer encontró en el código fuente que corresponde a este token. Por
 	// ejemplo, si el Type es IDENTIFIER, Word podría ser "robot". Si el Type es
 	// NUMBER, Word podría ser "0.80".
-	Word string
+	Word string `json:"word"`
 	// Importantísimo para el manejo de errores. Cuando el parser encuentre un error,
 	// se podra decirle al usuario exactamente en qué línea del código ocurrió.
-	Line int
+	Line int `json:"line"`
 	// Complemento de Line. Indica la posición precisa (columna) dentro de esa línea
 	// donde comienza el token. Esto hace que los mensajes de error sean muy específicos
 	// y útiles para la depuración.
-	Column int
+	Column int `json:"column"`
+	// Posición justo después del último carácter del token (el fin exclusivo de
+	// su tramo). Con Line y Column delimita el texto exacto que subrayan los
+	// diagnósticos.
+	EndLine   int `json:"endLine"`
+	EndColumn int `json:"endColumn"`
+	// Comentarios que el lexer encontró antes del token (Leading) y después de él
+	// en la misma línea (Trailing). El parser los ignora, pero las herramientas
+	// (formateador, documentación) los recuperan de aquí.
+	Leading  []Trivia `json:"leading,omitempty"`
+	Trailing []Trivia `json:"trailing,omitempty"`
+}
+
+// Trivia es un comentario del código fuente. Type es SINGLE_LINE_COMMENT,
+// COMMENT_INLINE, COMMENT_MULTI_LINE o DOC_COMMENT y Text incluye los
+// delimitadores ("// nota", "/* nota */").
+type Trivia struct {
+	Type   TokenClass `json:"type"`
+	Text   string     `json:"text"`
+	Line   int        `json:"line"`
+	Column int        `json:"column"`
 }
 
 // String devuelve una representación en cadena del Token.
@@ -39,10 +59,8 @@ func (t Token) String() string {
 		t.Type, t.Word, t.Line, t.Column)
 }
 
-// LookupIdent determines whether a given identifier is a keyword or a generic identifier.
+// LookupIdent determines whether a given identifier is a keyword or a generic identifier,
+// según el vocabulario por defecto (ver DefaultVocabulary).
 func LookupIdent(ident string) TokenClass {
-	if tok, ok := Keywords[ident]; ok {
-		return tok
-	}
-	return IDENTIFIER
+	return DefaultVocabulary().Class(ident)
 }
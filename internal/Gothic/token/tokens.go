@@ -54,6 +54,9 @@ const (
 	FLOAT   TokenClass = "FLOAT" // Purpose: Represents a floating-point number (decimal literal).
 	// Context: Used for numerical values with fractional components.
 	// Syntax/Example: 3.14, 0.001, -9.87
+	DECIMAL TokenClass = "DECIMAL" // Purpose: Represents an exact decimal literal, marked with the suffix d.
+	// Context: Used for quantities that must not be rounded, such as money.
+	// Syntax/Example: 19.99d, 100d, -0.50d
 	//
 	// Primitive Textual and Boolean Types
 	// ----------------------
@@ -210,6 +213,13 @@ const (
 	// Context: Used to assert *where* something is or where an action takes place.
 	// Syntax/Example: robot IS:LOCATED WHERE:(kitchen IS:position(x 10 y 20));
 	//
+	// Domain Predicates
+	// ----------------------
+	// Verbs declared as Predicate in the system definitions database but not built into the lexer.
+	PREDICATE TokenClass = "PREDICATE" // Purpose: A domain-specific predicate loaded from the vocabulary.
+	// Context: Lets a knowledge base add its own verbs without recompiling; the parser treats it like IS or HAS.
+	// Syntax/Example: fact robot owns battery; (with 'owns' registered as a Predicate)
+	//
 	// ======================================================== #
 	// Logical Predicates
 	// ======================================================== #
@@ -546,6 +556,9 @@ const (
 	// Context: Used to represent placeholders in patterns that the inference engine will attempt to bind to concrete values. Also triggers a search.
 	// Syntax/Example: (QUERY (robot location ?L)), (FOR_ALL (?X) (robot HAS (color ?X)))
 	// Note: While 'VAR_LOGIC' could be a keyword, 'QUERY' is more idiomatic for declarative logic programming (e.g., Prolog).
+	VARIABLE TokenClass = "VARIABLE" // Purpose: A logical variable: '?' followed by a name.
+	// Context: Placeholder in the triplets of a rule; every occurrence of the same name in a rule is the same variable.
+	// Syntax/Example: rule ?d is unsafe if ?d is door and ?d has alarm_off;
 	//
 	// Domain Definition and Set Operations
 	// ----------------------
@@ -627,6 +640,12 @@ const (
 	// 					TRACE (QUERY (robot HAS (location ?where)));
 	// 					Activar el trazado para todas las reglas dentro de un bloque
 	// 					RULE:{DO (TRACE ON) AND (run_diagnosis_rules) AND (TRACE OFF)};
+	EXPLAIN TokenClass = "EXPLAIN" // Purpose: Demuestra una consulta y muestra su árbol de prueba: cada meta, la cláusula usada y su unificador.
+	// Context: Permite a un operador entender por qué el agente llegó a una conclusión (ver BECAUSE).
+	// Syntax/Example:	EXPLAIN door IS unsafe;
+	TABLE TokenClass = "TABLE" // Purpose: Declara un predicado tabulado: sus respuestas se memorizan y las reglas recursivas por la izquierda terminan.
+	// Context: Los predicados del modelo de tripletas tienen aridad 2 (P(S, O)).
+	// Syntax/Example:	TABLE path/2;
 	//
 	// ======================================================== #
 	// Symbolic Computation
@@ -921,6 +940,11 @@ const (
 							multi-line
 	   				comment block. */
 	COMMENT_MULTI_LINE TokenClass = "/*"
+	// DOC_COMMENT
+	// Purpose: Marks a documentation comment.
+	// Context: Consecutive `///` lines right before a statement are kept in the AST as its documentation.
+	// Syntax/Example: /// Car is the vehicle used by the robot.
+	DOC_COMMENT TokenClass = "///"
 	//
 	// ======================================================== #
 	// Operators and Delimiters
@@ -0,0 +1,198 @@
+// Gothic/token/vocabulary.go
+//
+// Vocabulario del lexer: qué palabras son palabras clave y de qué clase. El
+// mapa Keywords es el vocabulario de arranque; el metamodelo construye otro a
+// partir de los scopes y predicados de la base de definiciones y lo instala
+// con SetDefaultVocabulary, de modo que un verbo nuevo en la base se reconoce
+// sin recompilar.
+//
+// Cada idioma tiene su propio vocabulario: el de arranque más unos alias (en
+// español, 'hecho' por 'fact' o 'tiene' por 'has'). Un alias tiene la clase de
+// la palabra clave a la que sustituye, y Canonical devuelve esa palabra, que
+// es la que usan el metamodelo y el AST.
+package token
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultLanguage es el idioma de Keywords y del vocabulario por defecto.
+const DefaultLanguage = "en"
+
+// Errores de WithAliases.
+var (
+	ErrUnknownKeyword = errors.New("token: alias of an unknown keyword")
+	ErrAliasConflict  = errors.New("token: alias is already a keyword")
+)
+
+// ScopeClass es la clase de las palabras que abren una sentencia de tripleta
+// ('fact', y cualquier TripletScope de la base que no tenga otra).
+const ScopeClass = FACT
+
+// predicateClasses son las clases que el parser acepta como predicado.
+var predicateClasses = map[TokenClass]bool{
+	IS: true, HAS: true, DO: true, HOW: true, WHERE: true, WHEN: true, PREDICATE: true,
+}
+
+// IsPredicateClass indica si c es una clase de predicado del modelo de tripletas.
+func IsPredicateClass(c TokenClass) bool {
+	return predicateClasses[c]
+}
+
+// Vocabulary asocia palabras a su clase de token. Un Vocabulary no se
+// modifica después de pasarlo al lexer; Set es solo para construirlo.
+type Vocabulary struct {
+	words     map[string]TokenClass
+	canonical map[string]string // alias -> palabra clave
+	spelling  map[string]string // palabra clave -> alias
+}
+
+// NewVocabulary devuelve un vocabulario con una copia de words.
+func NewVocabulary(words map[string]TokenClass) *Vocabulary {
+	return &Vocabulary{words: maps.Clone(words)}
+}
+
+// Set asocia word a class, reemplazando la clase anterior si la había.
+func (v *Vocabulary) Set(word string, class TokenClass) {
+	if v.words == nil {
+		v.words = make(map[string]TokenClass)
+	}
+	v.words[word] = class
+}
+
+// Lookup devuelve la clase de word y si es una palabra clave.
+func (v *Vocabulary) Lookup(word string) (TokenClass, bool) {
+	class, ok := v.words[word]
+	return class, ok
+}
+
+// Class devuelve la clase de word, o IDENTIFIER si no es una palabra clave.
+func (v *Vocabulary) Class(word string) TokenClass {
+	if class, ok := v.words[word]; ok {
+		return class
+	}
+	return IDENTIFIER
+}
+
+// WithAliases devuelve una copia de v con los alias dados (alias -> palabra
+// clave de v). Si una palabra clave tiene varios alias, Spelling usa el primero
+// en orden alfabético.
+func (v *Vocabulary) WithAliases(aliases map[string]string) (*Vocabulary, error) {
+	out := &Vocabulary{
+		words:     maps.Clone(v.words),
+		canonical: maps.Clone(v.canonical),
+		spelling:  maps.Clone(v.spelling),
+	}
+	if out.words == nil {
+		out.words = make(map[string]TokenClass)
+	}
+	if out.canonical == nil {
+		out.canonical = make(map[string]string)
+		out.spelling = make(map[string]string)
+	}
+	for _, alias := range slices.Sorted(maps.Keys(aliases)) {
+		keyword := v.Canonical(aliases[alias])
+		class, ok := v.words[keyword]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q -> %q", ErrUnknownKeyword, alias, keyword)
+		}
+		if _, taken := v.words[alias]; taken && v.Canonical(alias) != keyword {
+			return nil, fmt.Errorf("%w: %q", ErrAliasConflict, alias)
+		}
+		out.words[alias] = class
+		out.canonical[alias] = keyword
+		if _, ok := out.spelling[keyword]; !ok {
+			out.spelling[keyword] = alias
+		}
+	}
+	return out, nil
+}
+
+// Canonical devuelve la palabra clave de la que word es alias, o word si no
+// es un alias.
+func (v *Vocabulary) Canonical(word string) string {
+	if keyword, ok := v.canonical[word]; ok {
+		return keyword
+	}
+	return word
+}
+
+// Spelling devuelve cómo se escribe la palabra clave keyword en este
+// vocabulario: su alias si lo tiene, o ella misma.
+func (v *Vocabulary) Spelling(keyword string) string {
+	if alias, ok := v.spelling[keyword]; ok {
+		return alias
+	}
+	return keyword
+}
+
+// Words devuelve las palabras del vocabulario, ordenadas.
+func (v *Vocabulary) Words() []string {
+	return slices.Sorted(maps.Keys(v.words))
+}
+
+var defaultVocabulary atomic.Pointer[Vocabulary]
+
+func init() {
+	defaultVocabulary.Store(NewVocabulary(Keywords))
+}
+
+// DefaultVocabulary devuelve el vocabulario que usan LookupIdent y los lexers
+// sin vocabulario propio. Hasta que alguien llame a SetDefaultVocabulary es
+// el mapa Keywords.
+func DefaultVocabulary() *Vocabulary {
+	return defaultVocabulary.Load()
+}
+
+// SetDefaultVocabulary reemplaza el vocabulario por defecto; nil vuelve a Keywords.
+func SetDefaultVocabulary(v *Vocabulary) {
+	if v == nil {
+		v = NewVocabulary(Keywords)
+	}
+	defaultVocabulary.Store(v)
+}
+
+var (
+	languagesMu sync.RWMutex
+	languages   = map[string]*Vocabulary{}
+)
+
+// SetLanguage registra el vocabulario del idioma name; nil lo quita.
+func SetLanguage(name string, v *Vocabulary) {
+	languagesMu.Lock()
+	defer languagesMu.Unlock()
+	if v == nil {
+		delete(languages, name)
+		return
+	}
+	languages[name] = v
+}
+
+// Language devuelve el vocabulario del idioma name. DefaultLanguage, si no se
+// registró otro, es el vocabulario por defecto.
+func Language(name string) (*Vocabulary, bool) {
+	languagesMu.RLock()
+	v, ok := languages[name]
+	languagesMu.RUnlock()
+	if !ok && name == DefaultLanguage {
+		return DefaultVocabulary(), true
+	}
+	return v, ok
+}
+
+// Languages devuelve los idiomas registrados y DefaultLanguage, ordenados.
+func Languages() []string {
+	languagesMu.RLock()
+	defer languagesMu.RUnlock()
+	names := slices.Collect(maps.Keys(languages))
+	if _, ok := languages[DefaultLanguage]; !ok {
+		names = append(names, DefaultLanguage)
+	}
+	slices.Sort(names)
+	return names
+}
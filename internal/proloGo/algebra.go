@@ -0,0 +1,1178 @@
+// /nexusl/internal/proloGo/algebra.go
+// .
+// Álgebra simbólica
+// .
+// Corresponde a las palabras clave EXPR, SIMPLIFY, EXPAND, DERIVE,
+// SUBSTITUTE y EQ_SOLVE. Una expresión es un término (ds.StructureTerm)
+// con los operadores +, -, *, /, ^ (o **), el menos unario y las funciones
+// sqrt, exp, ln, log, sin, cos y tan; los átomos y las variables libres son
+// las incógnitas, y los números son enteros de cualquier tamaño, racionales
+// o flotantes. Una expresión con constantes complejas o decimales ha de ser
+// numérica: simplify y expand la evalúan (ver arith.go).
+// .
+// Internamente cada expresión pasa a una forma canónica: sumas y productos
+// aplanados, con los términos semejantes reunidos, los coeficientes exactos
+// (racionales) al frente y las potencias de una misma base combinadas. Al
+// salir se reescribe con restas, cocientes y raíces, de modo que el
+// resultado vuelve a ser una expresión válida:
+// .
+//
+//	simplify(E, S)             S es la forma canónica de E
+//	expand(E, S)               además distribuye productos y potencias enteras
+//	derive(E, X, D)            D es la derivada de E respecto de X
+//	substitute(E, X, V, S)     reemplaza X por V en E y simplifica
+//	eq_solve(L = R, X, Sols)   raíces de una ecuación de grado 1 o 2 en X
+//	expr(E)                    E es una expresión algebraica
+//
+// .
+// Los mismos servicios están disponibles desde Go (Simplify, Expand, Derive,
+// Substitute, EqSolve) y FormatExpr escribe una expresión en notación infija.
+// .
+package prologo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/devicemxl/nexusl/ds"
+)
+
+var (
+	ErrNotExpression     = errors.New("prologo: term is not an algebraic expression")
+	ErrNotDifferentiable = errors.New("prologo: cannot differentiate function")
+	ErrUnsolvable        = errors.New("prologo: cannot solve equation")
+)
+
+// maxExpandPower es el mayor exponente con el que expand desarrolla una potencia de una suma.
+const maxExpandPower = 64
+
+func init() {
+	unary := func(op func(*ds.Symbol) (*ds.Symbol, error)) Builtin {
+		return func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int {
+			out, err := op(Resolve(args[0], s.Env))
+			if err != nil {
+				return s.fail(err)
+			}
+			return s.unifyThen(args[1], out, k)
+		}
+	}
+	RegisterBuiltin("simplify", 2, unary(Simplify))
+	RegisterBuiltin("expand", 2, unary(Expand))
+	RegisterBuiltin("derive", 3, func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int {
+		out, err := Derive(Resolve(args[0], s.Env), Resolve(args[1], s.Env))
+		if err != nil {
+			return s.fail(err)
+		}
+		return s.unifyThen(args[2], out, k)
+	})
+	RegisterBuiltin("substitute", 4, func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int {
+		out, err := Substitute(Resolve(args[0], s.Env), Resolve(args[1], s.Env), Resolve(args[2], s.Env))
+		if err != nil {
+			return s.fail(err)
+		}
+		return s.unifyThen(args[3], out, k)
+	})
+	RegisterBuiltin("eq_solve", 3, func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int {
+		roots, err := EqSolve(Resolve(args[0], s.Env), Resolve(args[1], s.Env))
+		if err != nil {
+			return s.fail(err)
+		}
+		return s.unifyThen(args[2], List(roots...), k)
+	})
+	RegisterBuiltin("expr", 1, func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int {
+		if _, err := fromTerm(Resolve(args[0], s.Env)); err != nil {
+			return 0
+		}
+		return k()
+	})
+}
+
+// Simplify devuelve la forma canónica de la expresión e.
+func Simplify(e *ds.Symbol) (*ds.Symbol, error) {
+	if needsEval(e) {
+		return Eval(e, nil)
+	}
+	x, err := fromTerm(e)
+	if err != nil {
+		return nil, err
+	}
+	return result(x, e)
+}
+
+// Expand devuelve e con los productos distribuidos sobre las sumas y las
+// potencias enteras de sumas desarrolladas.
+func Expand(e *ds.Symbol) (*ds.Symbol, error) {
+	if needsEval(e) {
+		return Eval(e, nil)
+	}
+	x, err := fromTerm(e)
+	if err != nil {
+		return nil, err
+	}
+	return result(expand(x), e)
+}
+
+// Derive devuelve la derivada de e respecto de la incógnita x.
+func Derive(e, x *ds.Symbol) (*ds.Symbol, error) {
+	ex, err := fromTerm(e)
+	if err != nil {
+		return nil, err
+	}
+	v, err := unknown(x)
+	if err != nil {
+		return nil, err
+	}
+	d, err := derive(ex, v)
+	if err != nil {
+		return nil, err
+	}
+	return result(d, e)
+}
+
+// Substitute reemplaza en e cada aparición de la subexpresión x por value y simplifica.
+func Substitute(e, x, value *ds.Symbol) (*ds.Symbol, error) {
+	ex, err := fromTerm(e)
+	if err != nil {
+		return nil, err
+	}
+	xx, err := fromTerm(x)
+	if err != nil {
+		return nil, err
+	}
+	vx, err := fromTerm(value)
+	if err != nil {
+		return nil, err
+	}
+	return result(substitute(ex, xx.key(), vx), e)
+}
+
+// result convierte la forma canónica x, calculada a partir de e, en un
+// término. Si la simplificación dejó un divisor nulo (x/0, 0^-1) devuelve
+// ErrDivisionByZero, igual que la evaluación aritmética.
+func result(x *expr, e *ds.Symbol) (*ds.Symbol, error) {
+	if x.divByZero() {
+		return nil, fmt.Errorf("%w: %s", ErrDivisionByZero, FormatExpr(e))
+	}
+	return x.term(), nil
+}
+
+// EqSolve devuelve las raíces de la ecuación eq en la incógnita x. eq es
+// L = R (o L == R, equal(L, R)); cualquier otra expresión se iguala a 0.
+// Resuelve ecuaciones polinómicas de grado 1 y 2 con coeficientes simbólicos;
+// si el discriminante es un número negativo no hay raíces reales y el
+// resultado es vacío.
+func EqSolve(eq, x *ds.Symbol) ([]*ds.Symbol, error) {
+	eq = ds.Deref(eq)
+	lhs, rhs := eq, Int(0)
+	if name, arity, _ := Functor(eq); arity == 2 && (name == "=" || name == "==" || name == "equal") {
+		lhs, rhs = Args(eq)[0], Args(eq)[1]
+	}
+	l, err := fromTerm(lhs)
+	if err != nil {
+		return nil, err
+	}
+	r, err := fromTerm(rhs)
+	if err != nil {
+		return nil, err
+	}
+	v, err := unknown(x)
+	if err != nil {
+		return nil, err
+	}
+	roots, err := solvePolynomial(expand(simpSum(l, simpProd(numExpr(intNum(-1)), r))), v)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, FormatExpr(eq))
+	}
+	out := make([]*ds.Symbol, len(roots))
+	for i, root := range roots {
+		out[i] = root.term()
+	}
+	return out, nil
+}
+
+// unknown convierte x en una incógnita: un átomo o una variable libre.
+func unknown(x *ds.Symbol) (*expr, error) {
+	v, err := fromTerm(x)
+	if err != nil {
+		return nil, err
+	}
+	if v.kind != exSym {
+		return nil, fmt.Errorf("%w: %s is not an unknown", ErrNotExpression, FormatExpr(x))
+	}
+	return v, nil
+}
+
+// number es un coeficiente: un racional exacto o, si intervino un
+// flotante, un float64.
+type number struct {
+	rat   *big.Rat // nil si es flotante.
+	float float64
+}
+
+func intNum(n int64) number     { return number{rat: big.NewRat(n, 1)} }
+func ratNum(r *big.Rat) number  { return number{rat: r} }
+func floatNum(f float64) number { return number{float: f} }
+func (a number) isFloat() bool  { return a.rat == nil }
+func (a number) isZero() bool   { return a.sign() == 0 }
+func (a number) neg() number    { return a.mul(intNum(-1)) }
+
+func (a number) isOne() bool {
+	if a.isFloat() {
+		return a.float == 1
+	}
+	return a.rat.IsInt() && a.rat.Num().IsInt64() && a.rat.Num().Int64() == 1
+}
+
+func (a number) String() string {
+	if a.isFloat() {
+		return strconv.FormatFloat(a.float, 'g', -1, 64)
+	}
+	return a.rat.RatString()
+}
+
+// value devuelve el número como float64.
+func (a number) value() float64 {
+	if a.isFloat() {
+		return a.float
+	}
+	f, _ := a.rat.Float64()
+	return f
+}
+
+func (a number) sign() int {
+	if a.isFloat() {
+		switch {
+		case a.float > 0:
+			return 1
+		case a.float < 0:
+			return -1
+		}
+		return 0
+	}
+	return a.rat.Sign()
+}
+
+func (a number) add(b number) number {
+	if a.isFloat() || b.isFloat() {
+		return floatNum(a.value() + b.value())
+	}
+	return ratNum(new(big.Rat).Add(a.rat, b.rat))
+}
+
+func (a number) mul(b number) number {
+	if a.isFloat() || b.isFloat() {
+		return floatNum(a.value() * b.value())
+	}
+	return ratNum(new(big.Rat).Mul(a.rat, b.rat))
+}
+
+// pow devuelve a^b si el resultado es exacto (o flotante) y está definido.
+func (a number) pow(b number) (number, bool) {
+	if a.isFloat() || b.isFloat() {
+		f := math.Pow(a.value(), b.value())
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return number{}, false
+		}
+		return floatNum(f), true
+	}
+	p, q := b.rat.Num(), b.rat.Denom()
+	if !p.IsInt64() || !q.IsInt64() || p.CmpAbs(big.NewInt(1024)) > 0 {
+		return number{}, false
+	}
+	if a.isZero() && p.Sign() < 0 {
+		return number{}, false
+	}
+	// Raíz q-ésima exacta del numerador y del denominador.
+	num, ok := intRoot(a.rat.Num(), q.Int64())
+	if !ok {
+		return number{}, false
+	}
+	den, ok := intRoot(a.rat.Denom(), q.Int64())
+	if !ok {
+		return number{}, false
+	}
+	n := p.Int64()
+	if n < 0 {
+		num, den, n = den, num, -n
+	}
+	e := big.NewInt(n)
+	r := new(big.Rat).SetFrac(new(big.Int).Exp(num, e, nil), new(big.Int).Exp(den, e, nil))
+	return ratNum(r), true
+}
+
+// intRoot devuelve la raíz q-ésima entera de x, si es exacta.
+func intRoot(x *big.Int, q int64) (*big.Int, bool) {
+	if q == 1 {
+		return x, true
+	}
+	if x.Sign() < 0 {
+		if q%2 == 0 {
+			return nil, false
+		}
+		r, ok := intRoot(new(big.Int).Neg(x), q)
+		if !ok {
+			return nil, false
+		}
+		return r.Neg(r), true
+	}
+	f, _ := new(big.Float).SetInt(x).Float64()
+	guess := int64(math.Round(math.Pow(f, 1/float64(q))))
+	for _, c := range []int64{guess - 1, guess, guess + 1} {
+		r := big.NewInt(c)
+		if c >= 0 && new(big.Int).Exp(r, big.NewInt(q), nil).Cmp(x) == 0 {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+// maxRootFactor acota la búsqueda de factores en extractRoot.
+const maxRootFactor = 1 << 16
+
+// extractRoot saca de la raíz los factores exactos de un entero positivo: si
+// exp = p/q y base = k^q·rest, devuelve k^p y rest.
+func extractRoot(base, exp number) (outside, rest number, ok bool) {
+	if base.isFloat() || exp.isFloat() || !base.rat.IsInt() || base.rat.Sign() <= 0 || exp.rat.IsInt() {
+		return number{}, number{}, false
+	}
+	q := exp.rat.Denom().Int64()
+	n := new(big.Int).Set(base.rat.Num())
+	k := big.NewInt(1)
+	for c := int64(2); c < maxRootFactor; c++ {
+		pow := new(big.Int).Exp(big.NewInt(c), big.NewInt(q), nil)
+		if pow.Cmp(n) > 0 {
+			break
+		}
+		for new(big.Int).Mod(n, pow).Sign() == 0 {
+			n.Div(n, pow)
+			k.Mul(k, big.NewInt(c))
+		}
+	}
+	if k.Cmp(big.NewInt(1)) == 0 {
+		return number{}, number{}, false
+	}
+	outside, ok = ratNum(new(big.Rat).SetInt(k)).pow(ratNum(new(big.Rat).SetInt(exp.rat.Num())))
+	return outside, ratNum(new(big.Rat).SetInt(n)), ok
+}
+
+// term devuelve el número como constante: entera (de cualquier tamaño),
+// flotante o el cociente p/q.
+func (a number) term() *ds.Symbol {
+	if a.isFloat() {
+		return floatTerm(a.float)
+	}
+	if a.rat.IsInt() {
+		return Number(a.rat.Num())
+	}
+	return Compound("/", Number(a.rat.Num()), Number(a.rat.Denom()))
+}
+
+// floatTerm devuelve la constante flotante f.
+func floatTerm(f float64) *ds.Symbol {
+	return constant(strconv.FormatFloat(f, 'g', -1, 64), f)
+}
+
+// needsEval indica si t contiene constantes complejas o decimales, que la
+// forma canónica no representa: esas expresiones se evalúan.
+func needsEval(t *ds.Symbol) bool {
+	t = ds.Deref(t)
+	if v, ok := numberValue(t); ok {
+		return rank(v) == rankComplex || rank(v) == rankDecimal
+	}
+	for _, a := range Args(t) {
+		if needsEval(a) {
+			return true
+		}
+	}
+	return false
+}
+
+// exprKind es la clase de un nodo de la forma canónica.
+type exprKind int
+
+const (
+	exNum  exprKind = iota // Número.
+	exSym                  // Incógnita: átomo o variable.
+	exFunc                 // Función aplicada: sin(x).
+	exPow                  // Potencia: args[0]^args[1].
+	exProd                 // Producto de args, con el coeficiente numérico primero.
+	exSum                  // Suma de args, con la constante al final.
+)
+
+// expr es un nodo de la forma canónica.
+type expr struct {
+	kind exprKind
+	num  number     // exNum.
+	name string     // exFunc.
+	sym  *ds.Symbol // exSym.
+	args []*expr
+}
+
+func numExpr(n number) *expr { return &expr{kind: exNum, num: n} }
+
+// key identifica la expresión: dos expresiones canónicas son iguales si sus claves lo son.
+func (e *expr) key() string {
+	switch e.kind {
+	case exNum:
+		return "#" + e.num.String()
+	case exSym:
+		if e.sym.LogicalType == ds.LT_Variable {
+			return fmt.Sprintf("_%d", e.sym.ID)
+		}
+		return e.sym.PublicName
+	}
+	parts := make([]string, len(e.args))
+	for i, a := range e.args {
+		parts[i] = a.key()
+	}
+	op := map[exprKind]string{exPow: "^", exProd: "*", exSum: "+"}[e.kind]
+	if e.kind == exFunc {
+		op = e.name
+	}
+	return op + "(" + strings.Join(parts, ",") + ")"
+}
+
+// fromTerm traduce un término a la forma canónica.
+func fromTerm(t *ds.Symbol) (*expr, error) {
+	t = ds.Deref(t)
+	switch t.LogicalType {
+	case ds.LT_Variable:
+		return &expr{kind: exSym, sym: t}, nil
+	case ds.LT_Constant:
+		if v, ok := numberValue(t); ok && !isComplex(v) {
+			if f, ok := v.(float64); ok {
+				return numExpr(floatNum(f)), nil
+			}
+			return numExpr(ratNum(toRat(v))), nil
+		}
+		if _, ok := t.Value.(string); ok {
+			return &expr{kind: exSym, sym: t}, nil
+		}
+	case ds.LT_Structure:
+		name, arity, _ := Functor(t)
+		args := make([]*expr, arity)
+		for i, a := range Args(t) {
+			x, err := fromTerm(a)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = x
+		}
+		switch {
+		case name == "expr" && arity == 1:
+			return args[0], nil
+		case name == "+" && arity == 1:
+			return args[0], nil
+		case name == "-" && arity == 1:
+			return simpProd(numExpr(intNum(-1)), args[0]), nil
+		case name == "+":
+			return simpSum(args...), nil
+		case name == "-" && arity == 2:
+			return simpSum(args[0], simpProd(numExpr(intNum(-1)), args[1])), nil
+		case name == "*":
+			return simpProd(args...), nil
+		case name == "/" && arity == 2:
+			return simpProd(args[0], simpPow(args[1], numExpr(intNum(-1)))), nil
+		case (name == "^" || name == "**") && arity == 2:
+			return simpPow(args[0], args[1]), nil
+		case name == "sqrt" && arity == 1:
+			return simpPow(args[0], numExpr(ratNum(big.NewRat(1, 2)))), nil
+		case arity == 1 && name != "":
+			return simpFunc(name, args...), nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNotExpression, Format(t, nil))
+}
+
+// splitCoef separa el coeficiente numérico de un término de una suma. rest
+// es nil si el término es una constante.
+func splitCoef(t *expr) (coef number, rest *expr) {
+	switch {
+	case t.kind == exNum:
+		return t.num, nil
+	case t.kind == exProd && t.args[0].kind == exNum:
+		if len(t.args) == 2 {
+			return t.args[0].num, t.args[1]
+		}
+		return t.args[0].num, &expr{kind: exProd, args: t.args[1:]}
+	}
+	return intNum(1), t
+}
+
+// withCoef devuelve coef·rest ya en forma canónica.
+func withCoef(coef number, rest *expr) *expr {
+	switch {
+	case rest == nil:
+		return numExpr(coef)
+	case coef.isOne():
+		return rest
+	case rest.kind == exProd:
+		return &expr{kind: exProd, args: append([]*expr{numExpr(coef)}, rest.args...)}
+	}
+	return &expr{kind: exProd, args: []*expr{numExpr(coef), rest}}
+}
+
+// powParts separa un factor en base y exponente.
+func powParts(f *expr) (base, exp *expr) {
+	if f.kind == exPow {
+		return f.args[0], f.args[1]
+	}
+	return f, numExpr(intNum(1))
+}
+
+// degree es el grado total de un término en sus incógnitas, para ordenar las sumas.
+func degree(t *expr) float64 {
+	_, rest := splitCoef(t)
+	if rest == nil {
+		return 0
+	}
+	factors := []*expr{rest}
+	if rest.kind == exProd {
+		factors = rest.args
+	}
+	d := 0.0
+	for _, f := range factors {
+		base, exp := powParts(f)
+		if base.kind == exSym && exp.kind == exNum {
+			d += exp.num.value()
+		}
+	}
+	return d
+}
+
+// lessFactor ordena los factores de un producto por su base y luego por su exponente.
+func lessFactor(a, b *expr) bool {
+	ab, ae := powParts(a)
+	bb, be := powParts(b)
+	if ab.kind != bb.kind {
+		return ab.kind < bb.kind
+	}
+	if ka, kb := ab.key(), bb.key(); ka != kb {
+		return ka < kb
+	}
+	return ae.key() < be.key()
+}
+
+// simpSum suma términos canónicos reuniendo los semejantes. Los términos
+// quedan de mayor a menor grado y la constante al final.
+func simpSum(terms ...*expr) *expr {
+	type group struct {
+		coef number
+		rest *expr
+	}
+	constant := intNum(0)
+	groups := make(map[string]*group)
+	var order []string
+	var visit func(t *expr)
+	visit = func(t *expr) {
+		if t.kind == exSum {
+			for _, a := range t.args {
+				visit(a)
+			}
+			return
+		}
+		coef, rest := splitCoef(t)
+		if rest == nil {
+			constant = constant.add(coef)
+			return
+		}
+		k := rest.key()
+		if g, ok := groups[k]; ok {
+			g.coef = g.coef.add(coef)
+			return
+		}
+		groups[k] = &group{coef: coef, rest: rest}
+		order = append(order, k)
+	}
+	for _, t := range terms {
+		visit(t)
+	}
+
+	var out []*expr
+	for _, k := range order {
+		if g := groups[k]; !g.coef.isZero() {
+			out = append(out, withCoef(g.coef, g.rest))
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if di, dj := degree(out[i]), degree(out[j]); di != dj {
+			return di > dj
+		}
+		_, ri := splitCoef(out[i])
+		_, rj := splitCoef(out[j])
+		return ri.key() < rj.key()
+	})
+	if !constant.isZero() {
+		out = append(out, numExpr(constant))
+	}
+	switch len(out) {
+	case 0:
+		return numExpr(constant)
+	case 1:
+		return out[0]
+	}
+	return &expr{kind: exSum, args: out}
+}
+
+// simpProd multiplica factores canónicos: junta los coeficientes y suma los
+// exponentes de las potencias de una misma base.
+func simpProd(factors ...*expr) *expr {
+	type group struct {
+		base *expr
+		exps []*expr
+	}
+	coef := intNum(1)
+	groups := make(map[string]*group)
+	var order []string
+	var visit func(f *expr)
+	visit = func(f *expr) {
+		switch f.kind {
+		case exProd:
+			for _, a := range f.args {
+				visit(a)
+			}
+			return
+		case exNum:
+			coef = coef.mul(f.num)
+			return
+		}
+		base, exp := powParts(f)
+		k := base.key()
+		if g, ok := groups[k]; ok {
+			g.exps = append(g.exps, exp)
+			return
+		}
+		groups[k] = &group{base: base, exps: []*expr{exp}}
+		order = append(order, k)
+	}
+	for _, f := range factors {
+		visit(f)
+	}
+	if coef.isZero() {
+		return numExpr(coef)
+	}
+
+	var out []*expr
+	distributed := false
+	for _, k := range order {
+		g := groups[k]
+		switch p := simpPow(g.base, simpSum(g.exps...)); p.kind {
+		case exNum:
+			coef = coef.mul(p.num)
+		case exProd:
+			// Una potencia entera de un producto se distribuye en sus factores,
+			// que pueden compartir base con otros: se vuelven a juntar.
+			distributed = true
+			out = append(out, p.args...)
+		default:
+			out = append(out, p)
+		}
+	}
+	if distributed {
+		return simpProd(append(out, numExpr(coef))...)
+	}
+	if coef.isZero() {
+		return numExpr(coef)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return lessFactor(out[i], out[j]) })
+	if !coef.isOne() {
+		out = append([]*expr{numExpr(coef)}, out...)
+	}
+	switch len(out) {
+	case 0:
+		return numExpr(coef)
+	case 1:
+		return out[0]
+	}
+	return &expr{kind: exProd, args: out}
+}
+
+// simpPow eleva una base canónica a un exponente canónico.
+func simpPow(base, exp *expr) *expr {
+	if exp.kind == exNum {
+		switch {
+		case exp.num.isZero():
+			return numExpr(intNum(1))
+		case exp.num.isOne():
+			return base
+		}
+	}
+	switch base.kind {
+	case exNum:
+		if base.num.isZero() && exp.kind == exNum && exp.num.sign() > 0 {
+			return base
+		}
+		if base.num.isOne() {
+			return base
+		}
+		if exp.kind == exNum {
+			if n, ok := base.num.pow(exp.num); ok {
+				return numExpr(n)
+			}
+			if k, rest, ok := extractRoot(base.num, exp.num); ok {
+				// √8 = 2·√2
+				return simpProd(numExpr(k), simpPow(numExpr(rest), exp))
+			}
+		}
+	case exPow:
+		// (b^e)^n = b^(e·n) para n entero.
+		if exp.kind == exNum && !exp.num.isFloat() && exp.num.rat.IsInt() {
+			return simpPow(base.args[0], simpProd(base.args[1], exp))
+		}
+	case exProd:
+		if exp.kind == exNum && !exp.num.isFloat() && exp.num.rat.IsInt() {
+			factors := make([]*expr, len(base.args))
+			for i, f := range base.args {
+				factors[i] = simpPow(f, exp)
+			}
+			return simpProd(factors...)
+		}
+	}
+	return &expr{kind: exPow, args: []*expr{base, exp}}
+}
+
+// mathFuncs son las funciones que se evalúan con argumentos flotantes.
+var mathFuncs = map[string]func(float64) float64{
+	"exp": math.Exp,
+	"ln":  math.Log,
+	"log": math.Log,
+	"sin": math.Sin,
+	"cos": math.Cos,
+	"tan": math.Tan,
+}
+
+// simpFunc aplica una función a argumentos canónicos.
+func simpFunc(name string, args ...*expr) *expr {
+	if len(args) == 1 {
+		a := args[0]
+		if fn, ok := mathFuncs[name]; ok && a.kind == exNum {
+			if a.num.isFloat() {
+				if f := fn(a.num.value()); !math.IsNaN(f) && !math.IsInf(f, 0) {
+					return numExpr(floatNum(f))
+				}
+			}
+			// Los valores exactos conocidos.
+			switch {
+			case a.num.isZero() && (name == "sin" || name == "tan"):
+				return numExpr(intNum(0))
+			case a.num.isZero() && (name == "cos" || name == "exp"):
+				return numExpr(intNum(1))
+			case a.num.isOne() && (name == "ln" || name == "log"):
+				return numExpr(intNum(0))
+			}
+		}
+		if a.kind == exFunc {
+			switch {
+			case (name == "ln" || name == "log") && a.name == "exp":
+				return a.args[0]
+			case name == "exp" && (a.name == "ln" || a.name == "log"):
+				return a.args[0]
+			}
+		}
+	}
+	return &expr{kind: exFunc, name: name, args: args}
+}
+
+// rebuild vuelve a simplificar un nodo con los argumentos dados.
+func rebuild(e *expr, args []*expr) *expr {
+	switch e.kind {
+	case exSum:
+		return simpSum(args...)
+	case exProd:
+		return simpProd(args...)
+	case exPow:
+		return simpPow(args[0], args[1])
+	case exFunc:
+		return simpFunc(e.name, args...)
+	}
+	return e
+}
+
+// expand distribuye los productos sobre las sumas y desarrolla las potencias
+// enteras de sumas.
+func expand(e *expr) *expr {
+	switch e.kind {
+	case exNum, exSym:
+		return e
+	}
+	args := make([]*expr, len(e.args))
+	for i, a := range e.args {
+		args[i] = expand(a)
+	}
+	switch e.kind {
+	case exProd:
+		return distribute(args)
+	case exPow:
+		base, exp := args[0], args[1]
+		if base.kind == exSum && exp.kind == exNum && !exp.num.isFloat() && exp.num.rat.IsInt() {
+			n := exp.num.rat.Num()
+			if n.IsInt64() && n.Int64() >= -maxExpandPower && n.Int64() <= maxExpandPower {
+				k := n.Int64()
+				factors := make([]*expr, 0, max(k, -k))
+				for range max(k, -k) {
+					factors = append(factors, base)
+				}
+				if k < 0 {
+					return simpPow(distribute(factors), numExpr(intNum(-1)))
+				}
+				return distribute(factors)
+			}
+		}
+	}
+	return rebuild(e, args)
+}
+
+// distribute multiplica factores ya desarrollados y reparte el producto
+// sobre las sumas.
+func distribute(factors []*expr) *expr {
+	terms := []*expr{numExpr(intNum(1))}
+	for _, f := range factors {
+		addends := []*expr{f}
+		if f.kind == exSum {
+			addends = f.args
+		}
+		next := make([]*expr, 0, len(terms)*len(addends))
+		for _, t := range terms {
+			for _, a := range addends {
+				next = append(next, simpProd(t, a))
+			}
+		}
+		terms = next
+	}
+	return simpSum(terms...)
+}
+
+// contains indica si la expresión e depende de la incógnita x.
+func contains(e, x *expr) bool {
+	if e.kind == exSym {
+		return e.key() == x.key()
+	}
+	for _, a := range e.args {
+		if contains(a, x) {
+			return true
+		}
+	}
+	return false
+}
+
+// derive deriva e respecto de la incógnita x.
+func derive(e, x *expr) (*expr, error) {
+	zero, one := numExpr(intNum(0)), numExpr(intNum(1))
+	if !contains(e, x) {
+		return zero, nil
+	}
+	switch e.kind {
+	case exSym:
+		return one, nil
+	case exSum:
+		terms := make([]*expr, len(e.args))
+		for i, a := range e.args {
+			d, err := derive(a, x)
+			if err != nil {
+				return nil, err
+			}
+			terms[i] = d
+		}
+		return simpSum(terms...), nil
+	case exProd:
+		// (f·g·h)' = f'·g·h + f·g'·h + f·g·h'
+		var terms []*expr
+		for i, f := range e.args {
+			d, err := derive(f, x)
+			if err != nil {
+				return nil, err
+			}
+			factors := append([]*expr{d}, e.args[:i]...)
+			terms = append(terms, simpProd(append(factors, e.args[i+1:]...)...))
+		}
+		return simpSum(terms...), nil
+	case exPow:
+		base, exp := e.args[0], e.args[1]
+		db, err := derive(base, x)
+		if err != nil {
+			return nil, err
+		}
+		if !contains(exp, x) {
+			// (u^n)' = n·u^(n-1)·u'
+			return simpProd(exp, simpPow(base, simpSum(exp, numExpr(intNum(-1)))), db), nil
+		}
+		de, err := derive(exp, x)
+		if err != nil {
+			return nil, err
+		}
+		// (u^v)' = u^v·(v'·ln u + v·u'/u)
+		inner := simpSum(simpProd(de, simpFunc("ln", base)), simpProd(exp, db, simpPow(base, numExpr(intNum(-1)))))
+		return simpProd(e, inner), nil
+	case exFunc:
+		u := e.args[0]
+		du, err := derive(u, x)
+		if err != nil {
+			return nil, err
+		}
+		var outer *expr
+		switch e.name {
+		case "exp":
+			outer = e
+		case "ln", "log":
+			outer = simpPow(u, numExpr(intNum(-1)))
+		case "sin":
+			outer = simpFunc("cos", u)
+		case "cos":
+			outer = simpProd(numExpr(intNum(-1)), simpFunc("sin", u))
+		case "tan":
+			outer = simpPow(simpFunc("cos", u), numExpr(intNum(-2)))
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrNotDifferentiable, e.name)
+		}
+		return simpProd(outer, du), nil
+	}
+	return zero, nil
+}
+
+// substitute reemplaza las subexpresiones con clave k por value.
+func substitute(e *expr, k string, value *expr) *expr {
+	if e.key() == k {
+		return value
+	}
+	if len(e.args) == 0 {
+		return e
+	}
+	args := make([]*expr, len(e.args))
+	for i, a := range e.args {
+		args[i] = substitute(a, k, value)
+	}
+	return rebuild(e, args)
+}
+
+// solvePolynomial devuelve las raíces de p = 0 en x, con p ya desarrollado.
+func solvePolynomial(p, x *expr) ([]*expr, error) {
+	terms := []*expr{p}
+	if p.kind == exSum {
+		terms = p.args
+	}
+	coefs := make(map[int64][]*expr)
+	for _, t := range terms {
+		factors := []*expr{t}
+		if t.kind == exProd {
+			factors = t.args
+		}
+		var deg int64
+		var rest []*expr
+		for _, f := range factors {
+			base, exp := powParts(f)
+			if !contains(f, x) {
+				rest = append(rest, f)
+				continue
+			}
+			if base.key() != x.key() || exp.kind != exNum || exp.num.isFloat() || !exp.num.rat.IsInt() || deg != 0 {
+				return nil, fmt.Errorf("%w: not a polynomial in %s", ErrUnsolvable, FormatExpr(x.term()))
+			}
+			deg = exp.num.rat.Num().Int64()
+		}
+		if deg < 0 || deg > 2 {
+			return nil, fmt.Errorf("%w: degree %d in %s", ErrUnsolvable, deg, FormatExpr(x.term()))
+		}
+		coefs[deg] = append(coefs[deg], simpProd(rest...))
+	}
+	a, b, c := simpSum(coefs[2]...), simpSum(coefs[1]...), simpSum(coefs[0]...)
+	isZero := func(e *expr) bool { return e.kind == exNum && e.num.isZero() }
+	minusOne := numExpr(intNum(-1))
+
+	switch {
+	case !isZero(a):
+		// x = (-b ± √(b² - 4ac)) / 2a
+		disc := expand(simpSum(simpPow(b, numExpr(intNum(2))), simpProd(numExpr(intNum(-4)), a, c)))
+		den := simpPow(simpProd(numExpr(intNum(2)), a), minusOne)
+		if disc.kind == exNum {
+			switch disc.num.sign() {
+			case -1:
+				return nil, nil
+			case 0:
+				return []*expr{simpProd(minusOne, b, den)}, nil
+			}
+		}
+		sqrt := simpPow(disc, numExpr(ratNum(big.NewRat(1, 2))))
+		r1 := expand(simpProd(simpSum(simpProd(minusOne, b), simpProd(minusOne, sqrt)), den))
+		r2 := expand(simpProd(simpSum(simpProd(minusOne, b), sqrt), den))
+		if r1.kind == exNum && r2.kind == exNum && r2.num.value() < r1.num.value() {
+			r1, r2 = r2, r1
+		}
+		return []*expr{r1, r2}, nil
+	case !isZero(b):
+		return []*expr{expand(simpProd(minusOne, c, simpPow(b, minusOne)))}, nil
+	case isZero(c):
+		return nil, fmt.Errorf("%w: every value of %s is a solution", ErrUnsolvable, FormatExpr(x.term()))
+	}
+	return nil, nil
+}
+
+// divByZero indica si e contiene una potencia de base 0 con exponente
+// negativo, es decir, una división por cero.
+func (e *expr) divByZero() bool {
+	if e.kind == exPow && e.args[0].kind == exNum && e.args[0].num.isZero() &&
+		e.args[1].kind == exNum && e.args[1].num.sign() < 0 {
+		return true
+	}
+	for _, a := range e.args {
+		if a.divByZero() {
+			return true
+		}
+	}
+	return false
+}
+
+// term traduce la forma canónica a un término con restas, cocientes y raíces.
+func (e *expr) term() *ds.Symbol {
+	switch e.kind {
+	case exNum:
+		return e.num.term()
+	case exSym:
+		return e.sym
+	case exFunc:
+		args := make([]*ds.Symbol, len(e.args))
+		for i, a := range e.args {
+			args[i] = a.term()
+		}
+		return Compound(e.name, args...)
+	case exPow:
+		base, exp := e.args[0], e.args[1]
+		if exp.kind == exNum && exp.num.sign() < 0 {
+			return Compound("/", Int(1), simpPow(base, numExpr(exp.num.neg())).term())
+		}
+		if exp.kind == exNum && !exp.num.isFloat() && exp.num.rat.Cmp(big.NewRat(1, 2)) == 0 {
+			return Compound("sqrt", base.term())
+		}
+		return Compound("^", base.term(), exp.term())
+	case exSum:
+		out := e.args[0].term()
+		for _, t := range e.args[1:] {
+			if coef, rest := splitCoef(t); coef.sign() < 0 {
+				out = Compound("-", out, withCoef(coef.neg(), rest).term())
+			} else {
+				out = Compound("+", out, t.term())
+			}
+		}
+		return out
+	}
+
+	// Producto: los factores con exponente negativo van al denominador.
+	coef, factors := intNum(1), e.args
+	if factors[0].kind == exNum {
+		coef, factors = factors[0].num, factors[1:]
+	}
+	negative := coef.sign() < 0
+	if negative {
+		coef = coef.neg()
+	}
+	var num, den []*ds.Symbol
+	switch {
+	case coef.isFloat():
+		if !coef.isOne() {
+			num = append(num, coef.term())
+		}
+	default:
+		if p := coef.rat.Num(); !p.IsInt64() || p.Int64() != 1 {
+			num = append(num, numExpr(ratNum(new(big.Rat).SetInt(p))).term())
+		}
+		if q := coef.rat.Denom(); !q.IsInt64() || q.Int64() != 1 {
+			den = append(den, numExpr(ratNum(new(big.Rat).SetInt(q))).term())
+		}
+	}
+	for _, f := range factors {
+		if base, exp := powParts(f); exp.kind == exNum && exp.num.sign() < 0 {
+			den = append(den, simpPow(base, numExpr(exp.num.neg())).term())
+		} else {
+			num = append(num, f.term())
+		}
+	}
+	chain := func(ts []*ds.Symbol) *ds.Symbol {
+		if len(ts) == 0 {
+			return Int(1)
+		}
+		out := ts[0]
+		for _, t := range ts[1:] {
+			out = Compound("*", out, t)
+		}
+		return out
+	}
+	out := chain(num)
+	if len(den) > 0 {
+		out = Compound("/", out, chain(den))
+	}
+	if negative {
+		out = Compound("-", out)
+	}
+	return out
+}
+
+// FormatExpr escribe una expresión en notación infija, con los paréntesis
+// justos: x^2 + 2*x + 1.
+func FormatExpr(t *ds.Symbol) string {
+	s, _ := formatExpr(ds.Deref(t))
+	return s
+}
+
+// Precedencia de los operadores para FormatExpr.
+const (
+	precSum   = 1
+	precProd  = 2
+	precUnary = 3
+	precPow   = 4
+	precAtom  = 5
+)
+
+// formatExpr devuelve el texto de t y la precedencia de su operador principal.
+func formatExpr(t *ds.Symbol) (string, int) {
+	wrap := func(t *ds.Symbol, min int) string {
+		s, p := formatExpr(ds.Deref(t))
+		if p < min {
+			return "(" + s + ")"
+		}
+		return s
+	}
+	switch t.LogicalType {
+	case ds.LT_Constant:
+		if v, ok := numberValue(t); ok && isComplex(v) {
+			// 2+3i se lee como una suma y -3i como un menos unario.
+			switch c := v.(complex128); {
+			case real(c) != 0:
+				return Format(t, nil), precSum
+			case imag(c) < 0:
+				return Format(t, nil), precUnary
+			}
+			return Format(t, nil), precAtom
+		}
+		if n, ok := numericValue(t); ok && n < 0 {
+			return Format(t, nil), precUnary
+		}
+		if v, ok := numberValue(t); ok && rank(v) == rankRat {
+			return Format(t, nil), precProd // La constante 3/4 es un cociente.
+		}
+		return Format(t, nil), precAtom
+	case ds.LT_Structure:
+		name, arity, _ := Functor(t)
+		args := Args(t)
+		switch {
+		case arity == 2 && (name == "+" || name == "-"):
+			return wrap(args[0], precSum) + " " + name + " " + wrap(args[1], precSum+1), precSum
+		case arity == 2 && (name == "*" || name == "/"):
+			return wrap(args[0], precProd) + name + wrap(args[1], precProd+1), precProd
+		case arity == 1 && name == "-":
+			// -(a*b) y (-a)*b valen lo mismo: el producto no necesita paréntesis.
+			return "-" + wrap(args[0], precProd), precUnary
+		case arity == 2 && (name == "^" || name == "**"):
+			return wrap(args[0], precAtom) + "^" + wrap(args[1], precPow), precPow
+		}
+		parts := make([]string, len(args))
+		for i, a := range args {
+			parts[i], _ = formatExpr(ds.Deref(a))
+		}
+		return name + "(" + strings.Join(parts, ", ") + ")", precAtom
+	}
+	return Format(t, nil), precAtom
+}
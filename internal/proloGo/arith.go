@@ -0,0 +1,752 @@
+// /nexusl/internal/proloGo/arith.go
+// .
+// Evaluación aritmética
+// .
+// Evalúa expresiones numéricas ya instanciadas sobre una torre numérica:
+// .
+//
+//	entero      int64, que pasa a *big.Int cuando una operación desborda
+//	decimal     ds.Decimal, exacto y con escala (19.99d)
+//	racional    *big.Rat, exacto (el resultado de 1/3)
+//	flotante    float64
+//	complejo    complex128
+//
+// .
+// Una operación entre dos clases distintas promueve al operando menor a la
+// clase del mayor, y los resultados se normalizan: un *big.Int que cabe en
+// int64 vuelve a int64 y un racional entero pasa a entero. Mientras no
+// intervenga un flotante o un complejo el resultado es exacto.
+// .
+//
+//   - - * /  - unario            / entre enteros da un racional si no es exacta
+//     //, mod                      división entera y módulo (solo enteros)
+//     ^, **                        potencia; exacta con exponente entero
+//     abs, sqrt, exp, ln, sin, cos, min, max, float
+//     re, im, conj, arg            partes, conjugado y argumento de un complejo
+//     polar(R, Theta)              el complejo R·e^(i·Theta), la forma de un fasor
+//     pi, e, i                     constantes
+//
+// .
+// Predicados:
+// .
+//
+//	eval(E, V)                 V es el valor de E (is/2 ya es el predicado de las tripletas)
+//	=:=, =\=                   igualdad numérica; compara complejos por valor
+//	<, >, =<, >=               orden numérico; un operando complejo es un error
+//
+// .
+// La unificación también compara los números por valor: 2, 2.0, 4/2 y 2.00d
+// son el mismo número (ver Unify).
+// .
+package prologo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"math/cmplx"
+
+	"github.com/devicemxl/nexusl/ds"
+)
+
+var (
+	ErrNotNumber      = errors.New("prologo: arithmetic expression is not a number")
+	ErrComplexOrder   = errors.New("prologo: complex numbers are not ordered")
+	ErrDivisionByZero = errors.New("prologo: division by zero")
+)
+
+// maxExactPowerBits limita el tamaño de una potencia exacta; por encima se calcula en flotante.
+const maxExactPowerBits = 1 << 20
+
+func init() {
+	RegisterBuiltin("eval", 2, func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int {
+		v, err := Eval(args[0], s.Env)
+		if err != nil {
+			return s.fail(err)
+		}
+		return s.unifyThen(args[1], v, k)
+	})
+	comparisons := map[string]func(c int) bool{
+		"<":  func(c int) bool { return c < 0 },
+		">":  func(c int) bool { return c > 0 },
+		"=<": func(c int) bool { return c <= 0 },
+		">=": func(c int) bool { return c >= 0 },
+	}
+	for name, holds := range comparisons {
+		holds := holds
+		RegisterBuiltin(name, 2, func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int {
+			c, err := CompareNumbers(args[0], args[1], s.Env)
+			if err != nil {
+				return s.fail(err)
+			}
+			if !holds(c) {
+				return 0
+			}
+			return k()
+		})
+	}
+	equality := func(want bool) Builtin {
+		return func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int {
+			a, err := evalNumber(args[0], s.Env)
+			if err != nil {
+				return s.fail(err)
+			}
+			b, err := evalNumber(args[1], s.Env)
+			if err != nil {
+				return s.fail(err)
+			}
+			if numbersEqual(a, b) != want {
+				return 0
+			}
+			return k()
+		}
+	}
+	RegisterBuiltin("=:=", 2, equality(true))
+	RegisterBuiltin(`=\=`, 2, equality(false))
+}
+
+// Eval evalúa la expresión aritmética t y devuelve su valor como constante.
+func Eval(t *ds.Symbol, env *Environment) (*ds.Symbol, error) {
+	v, err := evalNumber(t, env)
+	if err != nil {
+		return nil, err
+	}
+	return Number(v), nil
+}
+
+// CompareNumbers evalúa a y b y devuelve -1, 0 o 1 según su orden. Los
+// complejos no tienen orden: si alguno lo es devuelve ErrComplexOrder.
+func CompareNumbers(a, b *ds.Symbol, env *Environment) (int, error) {
+	x, err := evalNumber(a, env)
+	if err != nil {
+		return 0, err
+	}
+	y, err := evalNumber(b, env)
+	if err != nil {
+		return 0, err
+	}
+	return compareValues(x, y)
+}
+
+// compareValues ordena dos valores numéricos reales. Los exactos se
+// comparan sin redondeo, también contra un flotante finito.
+func compareValues(x, y interface{}) (int, error) {
+	if isComplex(x) || isComplex(y) {
+		return 0, fmt.Errorf("%w: %s and %s", ErrComplexOrder, formatNumber(x), formatNumber(y))
+	}
+	if p, q, ok := bothInts(x, y); ok {
+		return compareInts64(p, q), nil
+	}
+	p, okP := exactRat(x)
+	q, okQ := exactRat(y)
+	if okP && okQ {
+		return p.Cmp(q), nil
+	}
+	return compareFloats(toFloat(x), toFloat(y)), nil
+}
+
+// numberValue devuelve el valor de una constante numérica, normalizado a
+// int64, *big.Int, ds.Decimal, *big.Rat, float64 o complex128.
+func numberValue(t *ds.Symbol) (interface{}, bool) {
+	if t.LogicalType != ds.LT_Constant {
+		return nil, false
+	}
+	switch n := t.Value.(type) {
+	case int:
+		return int64(n), true
+	case int64:
+		return n, true
+	case *big.Int:
+		return normalize(n), true
+	case ds.Decimal:
+		return n, true
+	case *big.Rat:
+		return normalize(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	case complex64:
+		return complex128(n), true
+	case complex128:
+		return n, true
+	}
+	return nil, false
+}
+
+// sameNumber indica si a y b son constantes numéricas con el mismo valor.
+func sameNumber(a, b *ds.Symbol) bool {
+	x, okX := numberValue(a)
+	y, okY := numberValue(b)
+	return okX && okY && numbersEqual(x, y)
+}
+
+// numberSymbol devuelve la constante con el valor v.
+func Number(v interface{}) *ds.Symbol {
+	switch n := normalize(v).(type) {
+	case int64:
+		return Int(n)
+	case *big.Int:
+		return constant(n.String(), new(big.Int).Set(n))
+	case ds.Decimal:
+		return constant(n.String()+"d", n)
+	case *big.Rat:
+		return constant(n.String(), new(big.Rat).Set(n))
+	case float64:
+		return floatTerm(n)
+	}
+	return complexTerm(v.(complex128))
+}
+
+// complexTerm devuelve la constante compleja c.
+func complexTerm(c complex128) *ds.Symbol {
+	return constant(ds.FormatComplex(c), c)
+}
+
+// formatNumber escribe un valor numérico como lo haría Format con su constante.
+func formatNumber(v interface{}) string {
+	return Number(v).PublicName
+}
+
+// evalNumber evalúa t y devuelve su valor numérico normalizado.
+func evalNumber(t *ds.Symbol, env *Environment) (interface{}, error) {
+	t = Deref(t, env)
+	switch t.LogicalType {
+	case ds.LT_Variable:
+		return nil, fmt.Errorf("%w: in arithmetic expression", ErrInstantiation)
+	case ds.LT_Constant:
+		if v, ok := numberValue(t); ok {
+			return v, nil
+		}
+		switch t.PublicName {
+		case "pi":
+			return math.Pi, nil
+		case "e":
+			return math.E, nil
+		case "i":
+			return complex(0, 1), nil
+		}
+		return nil, fmt.Errorf("%w: %s", ErrNotNumber, Format(t, env))
+	case ds.LT_Structure:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrNotNumber, Format(t, env))
+	}
+
+	name, args := compoundParts(t)
+	vals := make([]interface{}, len(args))
+	for i, a := range args {
+		v, err := evalNumber(a, env)
+		if err != nil {
+			return nil, err
+		}
+		vals[i] = v
+	}
+	var op func([]interface{}) (interface{}, error)
+	switch len(vals) {
+	case 1:
+		if f, ok := unaryOps[name]; ok {
+			op = func(v []interface{}) (interface{}, error) { return f(v[0]) }
+		}
+	case 2:
+		if f, ok := binaryOps[name]; ok {
+			op = func(v []interface{}) (interface{}, error) { return f(v[0], v[1]) }
+		}
+	}
+	if op == nil {
+		return nil, fmt.Errorf("%w: unknown function %s", ErrNotNumber, Indicator(name, len(args)))
+	}
+	v, err := op(vals)
+	if err != nil {
+		return nil, err
+	}
+	return normalize(v), nil
+}
+
+// unaryOps son las funciones aritméticas de un argumento.
+var unaryOps = map[string]func(interface{}) (interface{}, error){
+	"-": func(x interface{}) (interface{}, error) { return negate(x), nil },
+	"+": func(x interface{}) (interface{}, error) { return x, nil },
+	"abs": func(x interface{}) (interface{}, error) {
+		if isComplex(x) {
+			return cmplx.Abs(x.(complex128)), nil
+		}
+		if sign(x) < 0 {
+			return negate(x), nil
+		}
+		return x, nil
+	},
+	"sqrt": func(x interface{}) (interface{}, error) {
+		// La raíz de un real negativo es imaginaria.
+		if !isComplex(x) && sign(x) >= 0 {
+			return math.Sqrt(toFloat(x)), nil
+		}
+		return cmplx.Sqrt(toComplex(x)), nil
+	},
+	"exp": realOrComplex(math.Exp, cmplx.Exp),
+	"ln":  realOrComplex(math.Log, cmplx.Log),
+	"sin": realOrComplex(math.Sin, cmplx.Sin),
+	"cos": realOrComplex(math.Cos, cmplx.Cos),
+	"float": func(x interface{}) (interface{}, error) {
+		if isComplex(x) {
+			return x, nil
+		}
+		return toFloat(x), nil
+	},
+	"re": func(x interface{}) (interface{}, error) {
+		if isComplex(x) {
+			return real(x.(complex128)), nil
+		}
+		return x, nil
+	},
+	"im": func(x interface{}) (interface{}, error) {
+		if isComplex(x) {
+			return imag(x.(complex128)), nil
+		}
+		if _, ok := x.(float64); ok {
+			return 0.0, nil
+		}
+		return int64(0), nil
+	},
+	"conj": func(x interface{}) (interface{}, error) {
+		if isComplex(x) {
+			return cmplx.Conj(x.(complex128)), nil
+		}
+		return x, nil
+	},
+	"arg": func(x interface{}) (interface{}, error) { return cmplx.Phase(toComplex(x)), nil },
+}
+
+// negate devuelve -x en la misma clase de x.
+func negate(x interface{}) interface{} {
+	switch n := x.(type) {
+	case int64:
+		if n == math.MinInt64 {
+			return new(big.Int).Neg(big.NewInt(n))
+		}
+		return -n
+	case *big.Int:
+		return new(big.Int).Neg(n)
+	case ds.Decimal:
+		return ds.Decimal{Unscaled: new(big.Int).Neg(n.Unscaled), Scale: n.Scale}
+	case *big.Rat:
+		return new(big.Rat).Neg(n)
+	case float64:
+		return -n
+	}
+	return -x.(complex128)
+}
+
+// arithOp es una operación binaria con una versión por clase de la torre.
+// ints devuelve false si el resultado no es un int64 exacto, y entonces se
+// repite con *big.Int.
+type arithOp struct {
+	ints      func(a, b int64) (int64, bool)
+	bigs      func(a, b *big.Int) interface{}
+	decimals  func(a, b ds.Decimal) interface{}
+	rats      func(a, b *big.Rat) interface{}
+	floats    func(a, b float64) float64
+	complexes func(a, b complex128) complex128
+}
+
+// apply promueve x e y a su clase común y aplica la operación.
+func (op arithOp) apply(x, y interface{}) interface{} {
+	r := max(rank(x), rank(y))
+	if r == rankInt {
+		if v, ok := op.ints(x.(int64), y.(int64)); ok {
+			return v
+		}
+		r = rankBig
+	}
+	switch r {
+	case rankBig:
+		return op.bigs(toBig(x), toBig(y))
+	case rankDecimal:
+		return op.decimals(toDecimal(x), toDecimal(y))
+	case rankRat:
+		return op.rats(toRat(x), toRat(y))
+	case rankFloat:
+		return op.floats(toFloat(x), toFloat(y))
+	}
+	return op.complexes(toComplex(x), toComplex(y))
+}
+
+var (
+	addOp = arithOp{
+		ints: func(a, b int64) (int64, bool) {
+			s := a + b
+			return s, (s > a) == (b > 0)
+		},
+		bigs: func(a, b *big.Int) interface{} { return new(big.Int).Add(a, b) },
+		decimals: func(a, b ds.Decimal) interface{} {
+			a, b = alignScales(a, b)
+			return ds.Decimal{Unscaled: new(big.Int).Add(a.Unscaled, b.Unscaled), Scale: a.Scale}
+		},
+		rats:      func(a, b *big.Rat) interface{} { return new(big.Rat).Add(a, b) },
+		floats:    func(a, b float64) float64 { return a + b },
+		complexes: func(a, b complex128) complex128 { return a + b },
+	}
+	subOp = arithOp{
+		ints: func(a, b int64) (int64, bool) {
+			d := a - b
+			return d, (d < a) == (b > 0)
+		},
+		bigs: func(a, b *big.Int) interface{} { return new(big.Int).Sub(a, b) },
+		decimals: func(a, b ds.Decimal) interface{} {
+			a, b = alignScales(a, b)
+			return ds.Decimal{Unscaled: new(big.Int).Sub(a.Unscaled, b.Unscaled), Scale: a.Scale}
+		},
+		rats:      func(a, b *big.Rat) interface{} { return new(big.Rat).Sub(a, b) },
+		floats:    func(a, b float64) float64 { return a - b },
+		complexes: func(a, b complex128) complex128 { return a - b },
+	}
+	mulOp = arithOp{
+		ints: func(a, b int64) (int64, bool) {
+			if a == 0 || b == 0 {
+				return 0, true
+			}
+			p := a * b
+			return p, p/b == a && !(a == -1 && b == math.MinInt64) && !(b == -1 && a == math.MinInt64)
+		},
+		bigs: func(a, b *big.Int) interface{} { return new(big.Int).Mul(a, b) },
+		decimals: func(a, b ds.Decimal) interface{} {
+			return ds.Decimal{Unscaled: new(big.Int).Mul(a.Unscaled, b.Unscaled), Scale: a.Scale + b.Scale}
+		},
+		rats:      func(a, b *big.Rat) interface{} { return new(big.Rat).Mul(a, b) },
+		floats:    func(a, b float64) float64 { return a * b },
+		complexes: func(a, b complex128) complex128 { return a * b },
+	}
+	// divOp supone el divisor distinto de cero. Un cociente de decimales
+	// sigue siendo decimal si su expansión es finita.
+	divOp = arithOp{
+		ints: func(a, b int64) (int64, bool) {
+			return a / b, a%b == 0 && !(a == math.MinInt64 && b == -1)
+		},
+		bigs: func(a, b *big.Int) interface{} { return new(big.Rat).SetFrac(a, b) },
+		decimals: func(a, b ds.Decimal) interface{} {
+			q := new(big.Rat).Quo(a.Rat(), b.Rat())
+			if d, ok := ratToDecimal(q, max(a.Scale, b.Scale)); ok {
+				return d
+			}
+			return q
+		},
+		rats:      func(a, b *big.Rat) interface{} { return new(big.Rat).Quo(a, b) },
+		floats:    func(a, b float64) float64 { return a / b },
+		complexes: func(a, b complex128) complex128 { return a / b },
+	}
+)
+
+// binaryOps son las funciones aritméticas de dos argumentos.
+var binaryOps = map[string]func(x, y interface{}) (interface{}, error){
+	"+": func(x, y interface{}) (interface{}, error) { return addOp.apply(x, y), nil },
+	"-": func(x, y interface{}) (interface{}, error) { return subOp.apply(x, y), nil },
+	"*": func(x, y interface{}) (interface{}, error) { return mulOp.apply(x, y), nil },
+	"/": func(x, y interface{}) (interface{}, error) {
+		if isZero(y) {
+			return nil, ErrDivisionByZero
+		}
+		return divOp.apply(x, y), nil
+	},
+	"//": func(x, y interface{}) (interface{}, error) {
+		a, b, err := integerOperands("//", x, y)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).Quo(a, b), nil
+	},
+	"mod": func(x, y interface{}) (interface{}, error) {
+		a, b, err := integerOperands("mod", x, y)
+		if err != nil {
+			return nil, err
+		}
+		m := new(big.Int).Mod(a, b) // Euclídeo: 0 <= m < |b|.
+		if m.Sign() != 0 && b.Sign() < 0 {
+			m.Add(m, b)
+		}
+		return m, nil
+	},
+	"^":   power,
+	"**":  power,
+	"min": func(x, y interface{}) (interface{}, error) { return pick(x, y, -1) },
+	"max": func(x, y interface{}) (interface{}, error) { return pick(x, y, 1) },
+	"polar": func(x, y interface{}) (interface{}, error) {
+		if isComplex(x) || isComplex(y) {
+			return nil, fmt.Errorf("%w: polar expects real modulus and angle", ErrNotNumber)
+		}
+		return cmplx.Rect(toFloat(x), toFloat(y)), nil
+	},
+}
+
+// integerOperands comprueba que x e y sean enteros y el divisor no sea cero.
+func integerOperands(name string, x, y interface{}) (*big.Int, *big.Int, error) {
+	if rank(x) > rankBig || rank(y) > rankBig {
+		return nil, nil, fmt.Errorf("%w: %s expects integers", ErrNotInteger, name)
+	}
+	if isZero(y) {
+		return nil, nil, ErrDivisionByZero
+	}
+	return toBig(x), toBig(y), nil
+}
+
+// power eleva x a y. Con exponente entero la potencia de un número exacto
+// es exacta: 2^100 es un entero, 2^-1 el racional 1/2 y 1.5d^2 el decimal 2.25d.
+func power(x, y interface{}) (interface{}, error) {
+	if rank(x) <= rankRat && rank(y) <= rankBig {
+		base, n := toRat(x), toBig(y)
+		bits := int64(base.Num().BitLen() + base.Denom().BitLen())
+		size := new(big.Int).Mul(big.NewInt(bits), new(big.Int).Abs(n))
+		if size.Cmp(big.NewInt(maxExactPowerBits)) <= 0 {
+			if n.Sign() < 0 && base.Sign() == 0 {
+				return nil, ErrDivisionByZero
+			}
+			e := new(big.Int).Abs(n)
+			if d, ok := x.(ds.Decimal); ok && n.Sign() >= 0 {
+				return ds.Decimal{Unscaled: new(big.Int).Exp(d.Unscaled, e, nil), Scale: d.Scale * int(e.Int64())}, nil
+			}
+			r := new(big.Rat).SetFrac(new(big.Int).Exp(base.Num(), e, nil), new(big.Int).Exp(base.Denom(), e, nil))
+			if n.Sign() < 0 {
+				r.Inv(r)
+			}
+			return r, nil
+		}
+	}
+	if isComplex(x) || isComplex(y) {
+		return cmplx.Pow(toComplex(x), toComplex(y)), nil
+	}
+	return math.Pow(toFloat(x), toFloat(y)), nil
+}
+
+// pick devuelve el menor (sign -1) o el mayor (sign 1) de x e y.
+func pick(x, y interface{}, sign int) (interface{}, error) {
+	c, err := compareValues(x, y)
+	if err != nil {
+		return nil, err
+	}
+	if c == -sign {
+		return y, nil
+	}
+	return x, nil
+}
+
+// realOrComplex elige la versión real o compleja de una función.
+func realOrComplex(f func(float64) float64, c func(complex128) complex128) func(interface{}) (interface{}, error) {
+	return func(x interface{}) (interface{}, error) {
+		if isComplex(x) {
+			return c(x.(complex128)), nil
+		}
+		return f(toFloat(x)), nil
+	}
+}
+
+// numbersEqual compara dos valores numéricos por valor.
+func numbersEqual(x, y interface{}) bool {
+	if isComplex(x) || isComplex(y) {
+		return toComplex(x) == toComplex(y)
+	}
+	if math.IsNaN(toFloat(x)) || math.IsNaN(toFloat(y)) {
+		return false
+	}
+	c, _ := compareValues(x, y)
+	return c == 0
+}
+
+// standardOrder ordena dos valores numéricos para el orden estándar de los
+// términos: los complejos, que no tienen orden aritmético, se comparan por
+// su parte real y luego por la imaginaria.
+func standardOrder(x, y interface{}) int {
+	if !isComplex(x) && !isComplex(y) {
+		c, _ := compareValues(x, y)
+		return c
+	}
+	p, q := toComplex(x), toComplex(y)
+	if c := compareFloats(real(p), real(q)); c != 0 {
+		return c
+	}
+	return compareFloats(imag(p), imag(q))
+}
+
+// Clases de la torre numérica, de menor a mayor.
+const (
+	rankInt = iota
+	rankBig
+	rankDecimal
+	rankRat
+	rankFloat
+	rankComplex
+)
+
+func rank(x interface{}) int {
+	switch x.(type) {
+	case int64:
+		return rankInt
+	case *big.Int:
+		return rankBig
+	case ds.Decimal:
+		return rankDecimal
+	case *big.Rat:
+		return rankRat
+	case float64:
+		return rankFloat
+	}
+	return rankComplex
+}
+
+// normalize devuelve la representación canónica de v: los enteros que caben
+// en int64 como int64 y los racionales enteros como enteros.
+func normalize(v interface{}) interface{} {
+	switch n := v.(type) {
+	case *big.Int:
+		if n.IsInt64() {
+			return n.Int64()
+		}
+	case *big.Rat:
+		if n.IsInt() {
+			return normalize(new(big.Int).Set(n.Num()))
+		}
+	}
+	return v
+}
+
+// alignScales lleva dos decimales a la mayor de sus escalas.
+func alignScales(a, b ds.Decimal) (ds.Decimal, ds.Decimal) {
+	rescale := func(d ds.Decimal, scale int) ds.Decimal {
+		f := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale-d.Scale)), nil)
+		return ds.Decimal{Unscaled: new(big.Int).Mul(d.Unscaled, f), Scale: scale}
+	}
+	if a.Scale < b.Scale {
+		return rescale(a, b.Scale), b
+	}
+	if b.Scale < a.Scale {
+		return a, rescale(b, a.Scale)
+	}
+	return a, b
+}
+
+// ratToDecimal escribe r como decimal de escala al menos minScale, si su
+// expansión decimal es finita (el denominador solo tiene factores 2 y 5).
+func ratToDecimal(r *big.Rat, minScale int) (ds.Decimal, bool) {
+	den := new(big.Int).Set(r.Denom())
+	twos, fives := 0, 0
+	for two := big.NewInt(2); new(big.Int).Mod(den, two).Sign() == 0; twos++ {
+		den.Quo(den, two)
+	}
+	for five := big.NewInt(5); new(big.Int).Mod(den, five).Sign() == 0; fives++ {
+		den.Quo(den, five)
+	}
+	if !den.IsInt64() || den.Int64() != 1 {
+		return ds.Decimal{}, false
+	}
+	scale := max(twos, fives, minScale)
+	n := new(big.Int).Mul(r.Num(), new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil))
+	return ds.Decimal{Unscaled: n.Quo(n, r.Denom()), Scale: scale}, true
+}
+
+// exactRat devuelve el valor exacto de x, incluido el de un flotante finito.
+func exactRat(x interface{}) (*big.Rat, bool) {
+	if f, ok := x.(float64); ok {
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return nil, false
+		}
+		return new(big.Rat).SetFloat64(f), true
+	}
+	if isComplex(x) {
+		return nil, false
+	}
+	return toRat(x), true
+}
+
+func sign(x interface{}) int {
+	switch n := x.(type) {
+	case int64:
+		return compareInts64(n, 0)
+	case float64:
+		return compareFloats(n, 0)
+	case complex128:
+		return compareFloats(real(n), 0)
+	}
+	return toRat(x).Sign()
+}
+
+func bothInts(x, y interface{}) (int64, int64, bool) {
+	a, okA := x.(int64)
+	b, okB := y.(int64)
+	return a, b, okA && okB
+}
+
+func isComplex(x interface{}) bool {
+	_, ok := x.(complex128)
+	return ok
+}
+
+func isZero(x interface{}) bool {
+	if isComplex(x) {
+		return x.(complex128) == 0
+	}
+	return sign(x) == 0
+}
+
+func toBig(x interface{}) *big.Int {
+	if n, ok := x.(int64); ok {
+		return big.NewInt(n)
+	}
+	return x.(*big.Int)
+}
+
+func toDecimal(x interface{}) ds.Decimal {
+	if d, ok := x.(ds.Decimal); ok {
+		return d
+	}
+	return ds.Decimal{Unscaled: toBig(x), Scale: 0}
+}
+
+func toRat(x interface{}) *big.Rat {
+	switch n := x.(type) {
+	case int64:
+		return big.NewRat(n, 1)
+	case *big.Int:
+		return new(big.Rat).SetInt(n)
+	case ds.Decimal:
+		return n.Rat()
+	}
+	return x.(*big.Rat)
+}
+
+func toFloat(x interface{}) float64 {
+	switch n := x.(type) {
+	case int64:
+		return float64(n)
+	case float64:
+		return n
+	case complex128:
+		return real(n)
+	}
+	f, _ := toRat(x).Float64()
+	return f
+}
+
+func toComplex(x interface{}) complex128 {
+	if c, ok := x.(complex128); ok {
+		return c
+	}
+	return complex(toFloat(x), 0)
+}
+
+func compareInts64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
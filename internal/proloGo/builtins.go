@@ -0,0 +1,258 @@
+// /nexusl/internal/proloGo/builtins.go
+// .
+// Predicados de control del resolvedor
+// .
+// Corresponden a las palabras clave CUT, FAIL, NOT_GATE, FOR_ALL, EXIST y
+// COLLECT_ALL del lenguaje (ver internal/Gothic/token/keywords.go), con los
+// nombres de Prolog como alias para portar bases de reglas existentes:
+//
+//	!, cut             corta las alternativas de la cláusula actual
+//	fail, false        falla siempre
+//	not(G), \+(G)      negación por fallo
+//	for_all(C, A)      para toda solución de C se cumple A
+//	exist(G)           G tiene solución (se toma solo la primera)
+//	exist(Vs, G)       igual, con las variables cuantificadas explícitas
+//	call(G)            llama a G; el corte dentro de G es local
+//	','(A, B), ';'(A, B), '->'(C, T)
+//	collect_all(T, G, L), findall/3, bagof/3, setof/3
+//
+// .
+package prologo
+
+import (
+	"sort"
+
+	"github.com/devicemxl/nexusl/ds"
+)
+
+func init() {
+	cut := func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int {
+		if r := k(); r != 0 {
+			return r
+		}
+		return frame
+	}
+	RegisterBuiltin("!", 0, cut)
+	RegisterBuiltin("cut", 0, cut)
+
+	failGoal := func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int { return 0 }
+	RegisterBuiltin("fail", 0, failGoal)
+	RegisterBuiltin("false", 0, failGoal)
+
+	not := func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int {
+		found, r := s.succeeds(args[0])
+		if r != 0 {
+			return r
+		}
+		if found {
+			return 0
+		}
+		return k()
+	}
+	RegisterBuiltin("not", 1, not)
+	RegisterBuiltin(`\+`, 1, not)
+
+	RegisterBuiltin("call", 1, func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int {
+		return s.call(args[0], k)
+	})
+	RegisterBuiltin("for_all", 2, func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int {
+		counterexample := Compound(",", args[0], Compound(`\+`, args[1]))
+		found, r := s.succeeds(counterexample)
+		if r != 0 {
+			return r
+		}
+		if found {
+			return 0
+		}
+		return k()
+	})
+	RegisterBuiltin("exist", 1, func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int {
+		return s.once(args[0], k)
+	})
+	RegisterBuiltin("exist", 2, func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int {
+		return s.once(args[1], k)
+	})
+
+	RegisterBuiltin(",", 2, func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int {
+		return s.solveGoal(args[0], frame, func() int {
+			return s.solveGoal(args[1], frame, k)
+		})
+	})
+	RegisterBuiltin(";", 2, func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int {
+		left := Deref(args[0], s.Env)
+		if name, arity, _ := Functor(left); name == "->" && arity == 2 {
+			cond := Args(left)
+			return s.ifThenElse(cond[0], cond[1], args[1], frame, k)
+		}
+		if r := s.solveGoal(left, frame, k); r != 0 {
+			return r
+		}
+		return s.solveGoal(args[1], frame, k)
+	})
+	RegisterBuiltin("->", 2, func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int {
+		return s.ifThenElse(args[0], args[1], Atom("fail"), frame, k)
+	})
+
+	findall := func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int {
+		results, r := s.collect(args[0], args[1])
+		if r != 0 {
+			return r
+		}
+		return s.unifyThen(args[2], List(results...), k)
+	}
+	RegisterBuiltin("collect_all", 3, findall)
+	RegisterBuiltin("findall", 3, findall)
+	RegisterBuiltin("bagof", 3, func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int {
+		return s.bagof(args[0], args[1], args[2], false, k)
+	})
+	RegisterBuiltin("setof", 3, func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int {
+		return s.bagof(args[0], args[1], args[2], true, k)
+	})
+}
+
+// call prueba goal como un objetivo opaco al corte: un corte dentro de goal
+// solo descarta alternativas de goal.
+func (s *Solver) call(goal *ds.Symbol, k Continuation) int {
+	barrier := s.newFrame()
+	if r := s.solveGoal(goal, barrier, k); r != barrier {
+		return r
+	}
+	return 0
+}
+
+// succeeds indica si goal tiene alguna solución, sin conservar sus ligaduras.
+// r distinto de 0 significa que hay que propagar una detención o un error.
+func (s *Solver) succeeds(goal *ds.Symbol) (found bool, r int) {
+	stop := s.newFrame()
+	mark := s.Env.Mark()
+	r = s.call(goal, func() int { return stop })
+	s.Env.Undo(mark)
+	if r == stop {
+		return true, 0
+	}
+	return false, r
+}
+
+// once continúa con k solo con la primera solución de goal.
+func (s *Solver) once(goal *ds.Symbol, k Continuation) int {
+	return s.call(Compound(",", goal, Atom("!")), k)
+}
+
+// ifThenElse implementa (Cond -> Then ; Else): si Cond tiene solución, se toma
+// la primera y se prueba Then; si no, se prueba Else. Then y Else son
+// transparentes al corte de la cláusula.
+func (s *Solver) ifThenElse(cond, then, els *ds.Symbol, frame int, k Continuation) int {
+	chosen := false
+	barrier := s.newFrame()
+	r := s.solveGoal(cond, barrier, func() int {
+		chosen = true
+		if r := s.solveGoal(then, frame, k); r != 0 {
+			return r
+		}
+		return barrier // Solo cuenta la primera solución de Cond.
+	})
+	if r != 0 && r != barrier {
+		return r
+	}
+	if chosen {
+		return 0
+	}
+	return s.solveGoal(els, frame, k)
+}
+
+// collect devuelve una copia de template por cada solución de goal.
+func (s *Solver) collect(template, goal *ds.Symbol) ([]*ds.Symbol, int) {
+	var results []*ds.Symbol
+	mark := s.Env.Mark()
+	r := s.call(goal, func() int {
+		results = append(results, rename(Resolve(template, s.Env), make(map[ds.SymbolID]*ds.Symbol)))
+		return 0
+	})
+	s.Env.Undo(mark)
+	return results, r
+}
+
+// bagof agrupa las soluciones por los valores de las variables libres de goal
+// (las que no aparecen en template ni están cuantificadas con V^Goal) y
+// ofrece cada grupo como una solución distinta. Falla si no hay soluciones.
+// Con set, cada grupo se ordena y se eliminan los duplicados (setof).
+func (s *Solver) bagof(template, goal, result *ds.Symbol, set bool, k Continuation) int {
+	bound := make(map[ds.SymbolID]bool)
+	for _, v := range Variables(template, s.Env) {
+		bound[v.ID] = true
+	}
+	goal = Deref(goal, s.Env)
+	for {
+		name, arity, _ := Functor(goal)
+		if name != "^" || arity != 2 {
+			break
+		}
+		args := Args(goal)
+		for _, v := range Variables(args[0], s.Env) {
+			bound[v.ID] = true
+		}
+		goal = Deref(args[1], s.Env)
+	}
+	var free []*ds.Symbol
+	for _, v := range Variables(goal, s.Env) {
+		if !bound[v.ID] {
+			free = append(free, v)
+		}
+	}
+	witness := List(free...)
+
+	pairs, r := s.collect(Compound("-", witness, template), goal)
+	if r != 0 || len(pairs) == 0 {
+		return r
+	}
+
+	// Agrupa por testigo en orden de primera aparición.
+	type group struct {
+		witness *ds.Symbol
+		items   []*ds.Symbol
+	}
+	var groups []*group
+	for _, p := range pairs {
+		w, item := Args(p)[0], Args(p)[1]
+		var g *group
+		for _, candidate := range groups {
+			if variant(candidate.witness, w) {
+				g = candidate
+				break
+			}
+		}
+		if g == nil {
+			g = &group{witness: w}
+			groups = append(groups, g)
+		}
+		g.items = append(g.items, item)
+	}
+	if set {
+		sort.SliceStable(groups, func(i, j int) bool { return CompareTerms(groups[i].witness, groups[j].witness) < 0 })
+	}
+
+	for _, g := range groups {
+		items := g.items
+		if set {
+			items = sortUnique(items)
+		}
+		r := s.unifyThen(Compound("-", witness, result), Compound("-", g.witness, List(items...)), k)
+		if r != 0 {
+			return r
+		}
+	}
+	return 0
+}
+
+// sortUnique ordena términos con el orden estándar y elimina los repetidos.
+func sortUnique(items []*ds.Symbol) []*ds.Symbol {
+	sorted := append([]*ds.Symbol(nil), items...)
+	sort.SliceStable(sorted, func(i, j int) bool { return CompareTerms(sorted[i], sorted[j]) < 0 })
+	var out []*ds.Symbol
+	for _, item := range sorted {
+		if len(out) == 0 || CompareTerms(out[len(out)-1], item) != 0 {
+			out = append(out, item)
+		}
+	}
+	return out
+}
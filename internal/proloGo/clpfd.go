@@ -0,0 +1,837 @@
+// /nexusl/internal/proloGo/clpfd.go
+// .
+// Restricciones sobre dominios finitos: CLP(FD)
+// .
+// Corresponde a las palabras clave DOMAIN, CONSTRAINT, SOLVE,
+// REIFY_CONSTRAINT y REIFY_DOMAIN. Cada variable restringida lleva el
+// atributo "clpfd" (ver Environment.PutAttr) con su dominio y los
+// propagadores que la observan. Todo cambio queda en el trail, así que el
+// retroceso del resolvedor deshace también las podas.
+// .
+// Al ligar una variable restringida, el gancho del atributo comprueba que el
+// valor esté en su dominio y despierta sus propagadores; al achicarse un
+// dominio se despiertan los propagadores de esa variable, hasta el punto fijo.
+// .
+//
+//	domain(Vs, Lo, Hi), domain(Vs, Values)   dominio de una variable o lista
+//	X #= Y, #\=, #<, #=<, #>, #>=             aritmética lineal
+//	all_different(Vs), all_distinct(Vs)      valores distintos dos a dos
+//	element(I, List, V)                       V es el elemento I (desde 1) de List
+//	B #<==> C, reify(C, B), reify_constraint(C, B)
+//	                                          B es 1 si se cumple C y 0 si no
+//	reify_domain(X, Lo, Hi, B)                B es 1 si X está en Lo..Hi
+//	constraint(C)                             impone C
+//	label(Vs), solve(Vs)                      enumera valores, primero la izquierda
+//	labeling(Opts, Vs), solve(Opts, Vs)       con opciones: leftmost, ff, min, max
+//	                                          (variable) y up, down (valores)
+//
+// .
+// Los propagadores de la aritmética mantienen la consistencia de cotas;
+// all_different poda los valores ya tomados y falla si no alcanzan los
+// valores para todas las variables.
+// .
+package prologo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/devicemxl/nexusl/ds"
+)
+
+var (
+	ErrNotLinear       = errors.New("prologo: constraint is not a linear integer expression")
+	ErrNotInteger      = errors.New("prologo: expected an integer")
+	ErrUnboundedDomain = errors.New("prologo: cannot label a variable without a finite domain")
+	ErrFDOverflow      = errors.New("prologo: constraint coefficient out of range")
+)
+
+// fdAttr es el nombre del atributo de las variables de dominio finito.
+const fdAttr = "clpfd"
+
+// fdVar es el valor del atributo fdAttr.
+type fdVar struct {
+	dom   Domain
+	props []*propagator
+}
+
+// propagator poda los dominios de sus variables. Devuelve false si la
+// restricción ya no puede cumplirse.
+type propagator struct {
+	run func(env *Environment) bool
+}
+
+// fdQueue es la cola de propagadores pendientes de un entorno.
+type fdQueue struct {
+	pending []*propagator
+	queued  map[*propagator]bool
+	running bool
+}
+
+func init() {
+	RegisterAttrHook(fdAttr, fdHook)
+
+	RegisterBuiltin("domain", 3, func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int {
+		lo, okLo := intValue(Deref(args[1], s.Env))
+		hi, okHi := intValue(Deref(args[2], s.Env))
+		if !okLo || !okHi {
+			return s.fail(fmt.Errorf("%w: domain bounds", ErrNotInteger))
+		}
+		return s.restrict(args[0], Range(lo, hi), k)
+	})
+	RegisterBuiltin("domain", 2, func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int {
+		items, ok := listElements(args[1], s.Env)
+		if !ok {
+			return s.fail(fmt.Errorf("%w: domain values must be a list", ErrInstantiation))
+		}
+		d := Domain{}
+		for _, item := range items {
+			n, ok := intValue(Deref(item, s.Env))
+			if !ok {
+				return s.fail(fmt.Errorf("%w: domain value %s", ErrNotInteger, Format(item, s.Env)))
+			}
+			d = d.Union(Range(n, n))
+		}
+		return s.restrict(args[0], d, k)
+	})
+
+	for _, op := range []string{"#=", `#\=`, "#<", "#=<", "#>", "#>="} {
+		RegisterBuiltin(op, 2, func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int {
+			l, err := parseRelation(Compound(op, args...), s.Env)
+			if err != nil {
+				return s.fail(err)
+			}
+			return s.post(&propagator{run: l.propagate}, l.vars(), k)
+		})
+	}
+	RegisterBuiltin("constraint", 1, func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int {
+		return s.call(args[0], k)
+	})
+
+	allDifferent := func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int {
+		vars, ok := listElements(args[0], s.Env)
+		if !ok {
+			return s.fail(fmt.Errorf("%w: all_different expects a list", ErrInstantiation))
+		}
+		return s.post(&propagator{run: func(env *Environment) bool { return propagateAllDifferent(env, vars) }}, vars, k)
+	}
+	RegisterBuiltin("all_different", 1, allDifferent)
+	RegisterBuiltin("all_distinct", 1, allDifferent)
+
+	RegisterBuiltin("element", 3, func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int {
+		list, ok := listElements(args[1], s.Env)
+		if !ok {
+			return s.fail(fmt.Errorf("%w: element expects a list", ErrInstantiation))
+		}
+		index, value := args[0], args[2]
+		p := &propagator{run: func(env *Environment) bool { return propagateElement(env, index, list, value) }}
+		return s.post(p, append([]*ds.Symbol{index, value}, list...), k)
+	})
+
+	reify := func(s *Solver, c, b *ds.Symbol, k Continuation) int {
+		l, err := parseRelation(c, s.Env)
+		if err != nil {
+			return s.fail(err)
+		}
+		p := &propagator{run: func(env *Environment) bool { return propagateReified(env, l, b) }}
+		return s.post(p, append(l.vars(), b), k)
+	}
+	RegisterBuiltin("#<==>", 2, func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int {
+		if _, arity, _ := Functor(Deref(args[0], s.Env)); arity == 2 {
+			return reify(s, args[0], args[1], k)
+		}
+		return reify(s, args[1], args[0], k)
+	})
+	for _, name := range []string{"reify", "reify_constraint"} {
+		RegisterBuiltin(name, 2, func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int {
+			return reify(s, args[0], args[1], k)
+		})
+	}
+	RegisterBuiltin("reify_domain", 4, func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int {
+		lo, okLo := intValue(Deref(args[1], s.Env))
+		hi, okHi := intValue(Deref(args[2], s.Env))
+		if !okLo || !okHi {
+			return s.fail(fmt.Errorf("%w: domain bounds", ErrNotInteger))
+		}
+		x, b, d := args[0], args[3], Range(lo, hi)
+		p := &propagator{run: func(env *Environment) bool { return propagateReifiedDomain(env, x, d, b) }}
+		return s.post(p, []*ds.Symbol{x, b}, k)
+	})
+
+	label := func(s *Solver, opts, vars *ds.Symbol, k Continuation) int {
+		o, err := parseLabeling(opts, s.Env)
+		if err != nil {
+			return s.fail(err)
+		}
+		items, ok := listElements(vars, s.Env)
+		if !ok {
+			items = []*ds.Symbol{vars}
+		}
+		return s.label(items, o, k)
+	}
+	for _, name := range []string{"label", "solve"} {
+		RegisterBuiltin(name, 1, func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int {
+			return label(s, ds.NullSymbol, args[0], k)
+		})
+	}
+	for _, name := range []string{"labeling", "solve"} {
+		RegisterBuiltin(name, 2, func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int {
+			return label(s, args[0], args[1], k)
+		})
+	}
+}
+
+// Domain devuelve el dominio actual de term: el de su atributo si es una
+// variable restringida, FullDomain si es una variable libre, o el valor si
+// es un entero.
+func (s *Solver) Domain(term *ds.Symbol) (Domain, bool) {
+	return fdDomain(s.Env, term)
+}
+
+// restrict achica el dominio de una variable, o de cada una de una lista, y continúa con k.
+func (s *Solver) restrict(vars *ds.Symbol, d Domain, k Continuation) int {
+	items, ok := listElements(vars, s.Env)
+	if !ok {
+		items = []*ds.Symbol{vars}
+	}
+	mark := s.Env.Mark()
+	r := 0
+	if fdNarrowAll(s.Env, items, d) && fdRun(s.Env) {
+		r = k()
+	}
+	s.Env.Undo(mark)
+	return r
+}
+
+// post agrega el propagador p a las variables de vars, lo ejecuta hasta el
+// punto fijo y, si la restricción puede cumplirse, continúa con k.
+func (s *Solver) post(p *propagator, vars []*ds.Symbol, k Continuation) int {
+	mark := s.Env.Mark()
+	r := 0
+	if fdPost(s.Env, p, vars) {
+		r = k()
+	}
+	s.Env.Undo(mark)
+	return r
+}
+
+// fdDomain devuelve el dominio de t en env.
+func fdDomain(env *Environment, t *ds.Symbol) (Domain, bool) {
+	t = Deref(t, env)
+	if n, ok := intValue(t); ok {
+		return Range(n, n), true
+	}
+	if t.LogicalType != ds.LT_Variable {
+		return Domain{}, false
+	}
+	return fdGet(env, t).dom, true
+}
+
+// fdGet devuelve el atributo de la variable v, o uno con FullDomain.
+func fdGet(env *Environment, v *ds.Symbol) *fdVar {
+	if attr, ok := env.GetAttr(v, fdAttr); ok {
+		return attr.(*fdVar)
+	}
+	return &fdVar{dom: FullDomain()}
+}
+
+// fdNarrow achica el dominio de t a su intersección con d. Si queda un solo
+// valor, liga la variable. Devuelve false si el dominio queda vacío.
+func fdNarrow(env *Environment, t *ds.Symbol, d Domain) bool {
+	t = Deref(t, env)
+	if n, ok := intValue(t); ok {
+		return d.Contains(n)
+	}
+	if t.LogicalType != ds.LT_Variable {
+		return false
+	}
+	cur := fdGet(env, t)
+	nd := cur.dom.Intersect(d)
+	switch {
+	case nd.Empty():
+		return false
+	case nd.Equal(cur.dom):
+		return true
+	}
+	env.PutAttr(t, fdAttr, &fdVar{dom: nd, props: cur.props})
+	if n, ok := nd.Singleton(); ok {
+		return Bind(t, Int(n), env) == nil
+	}
+	fdSchedule(env, cur.props)
+	return true
+}
+
+// fdNarrowAll achica el dominio de cada término de ts.
+func fdNarrowAll(env *Environment, ts []*ds.Symbol, d Domain) bool {
+	for _, t := range ts {
+		if !fdNarrow(env, t, d) {
+			return false
+		}
+	}
+	return true
+}
+
+// fdPost agrega p a los propagadores de las variables libres de vars y lo
+// ejecuta con los que despierte.
+func fdPost(env *Environment, p *propagator, vars []*ds.Symbol) bool {
+	seen := make(map[ds.SymbolID]bool)
+	for _, v := range vars {
+		for _, x := range Variables(v, env) {
+			if seen[x.ID] {
+				continue
+			}
+			seen[x.ID] = true
+			cur := fdGet(env, x)
+			props := append(append([]*propagator(nil), cur.props...), p)
+			env.PutAttr(x, fdAttr, &fdVar{dom: cur.dom, props: props})
+		}
+	}
+	fdSchedule(env, []*propagator{p})
+	return fdRun(env)
+}
+
+// fdSchedule pone en la cola los propagadores que no estén ya en ella.
+func fdSchedule(env *Environment, props []*propagator) {
+	q := &env.fd
+	if q.queued == nil {
+		q.queued = make(map[*propagator]bool)
+	}
+	for _, p := range props {
+		if !q.queued[p] {
+			q.queued[p] = true
+			q.pending = append(q.pending, p)
+		}
+	}
+}
+
+// fdRun ejecuta los propagadores pendientes hasta vaciar la cola. Si ya se
+// está propagando, no hace nada: el ciclo en curso atenderá lo agregado.
+func fdRun(env *Environment) bool {
+	q := &env.fd
+	if q.running {
+		return true
+	}
+	q.running = true
+	defer func() { q.running = false }()
+	for len(q.pending) > 0 {
+		p := q.pending[0]
+		q.pending = q.pending[1:]
+		delete(q.queued, p)
+		if !p.run(env) {
+			q.pending, q.queued = nil, nil
+			return false
+		}
+	}
+	return true
+}
+
+// fdHook es el gancho del atributo: valida la ligadura de una variable
+// restringida y despierta sus propagadores.
+func fdHook(env *Environment, v, value *ds.Symbol) bool {
+	attr := fdGet(env, v)
+	if value.LogicalType == ds.LT_Variable {
+		other := fdGet(env, value)
+		nd := attr.dom.Intersect(other.dom)
+		if nd.Empty() {
+			return false
+		}
+		props := append(append([]*propagator(nil), other.props...), attr.props...)
+		env.PutAttr(value, fdAttr, &fdVar{dom: nd, props: props})
+		if n, ok := nd.Singleton(); ok && Bind(value, Int(n), env) != nil {
+			return false
+		}
+		fdSchedule(env, props)
+		return fdRun(env)
+	}
+	n, ok := intValue(value)
+	if !ok || !attr.dom.Contains(n) {
+		return false
+	}
+	fdSchedule(env, attr.props)
+	return fdRun(env)
+}
+
+// linOp es la relación de una restricción lineal con 0.
+type linOp int
+
+const (
+	linLE linOp = iota // Σ ≤ 0
+	linEQ              // Σ = 0
+	linNE              // Σ ≠ 0
+)
+
+// linTerm es el término a·x de una suma lineal.
+type linTerm struct {
+	a int64
+	x *ds.Symbol
+}
+
+// linear es la restricción Σ aᵢ·xᵢ + c op 0. Los coeficientes y c tienen
+// magnitud menor que math.MaxInt64, así que negarlos no desborda; las cotas
+// de la suma, que sí pueden hacerlo, se calculan con big.Int.
+type linear struct {
+	terms []linTerm
+	c     int64
+	op    linOp
+}
+
+// parseRelation traduce X #= Y (y las demás relaciones) a una restricción lineal.
+func parseRelation(rel *ds.Symbol, env *Environment) (*linear, error) {
+	rel = Deref(rel, env)
+	name, arity, _ := Functor(rel)
+	if arity != 2 {
+		return nil, fmt.Errorf("%w: %s", ErrNotLinear, Format(rel, env))
+	}
+	args := Args(rel)
+	left, right := args[0], args[1]
+	var op linOp
+	var offset int64
+	switch name {
+	case "#=":
+		op = linEQ
+	case `#\=`:
+		op = linNE
+	case "#=<":
+		op = linLE
+	case "#<":
+		op, offset = linLE, 1
+	case "#>=":
+		op, left, right = linLE, right, left
+	case "#>":
+		op, left, right, offset = linLE, right, left, 1
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrNotLinear, Format(rel, env))
+	}
+	l := &linear{op: op, c: offset}
+	coef := make(map[ds.SymbolID]int)
+	if err := l.add(left, 1, coef, env); err != nil {
+		return nil, err
+	}
+	if err := l.add(right, -1, coef, env); err != nil {
+		return nil, err
+	}
+	kept := l.terms[:0]
+	for _, t := range l.terms {
+		if t.a != 0 {
+			kept = append(kept, t)
+		}
+	}
+	l.terms = kept
+	if !fdFits(l.c) {
+		return nil, fmt.Errorf("%w: %s", ErrFDOverflow, Format(rel, env))
+	}
+	for _, t := range l.terms {
+		if !fdFits(t.a) {
+			return nil, fmt.Errorf("%w: %s", ErrFDOverflow, Format(rel, env))
+		}
+	}
+	return l, nil
+}
+
+// fdFits indica si n se puede usar como coeficiente de una restricción lineal.
+func fdFits(n int64) bool {
+	return n > -math.MaxInt64 && n < math.MaxInt64
+}
+
+// add suma k·e a la restricción. coef indica la posición del término de cada variable.
+func (l *linear) add(e *ds.Symbol, k int64, coef map[ds.SymbolID]int, env *Environment) error {
+	e = Deref(e, env)
+	if n, ok := intValue(e); ok {
+		kn, ok := mulOp.ints(k, n)
+		if ok {
+			l.c, ok = addOp.ints(l.c, kn)
+		}
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrFDOverflow, Format(e, env))
+		}
+		return nil
+	}
+	if e.LogicalType == ds.LT_Variable {
+		if i, ok := coef[e.ID]; ok {
+			var sum bool
+			if l.terms[i].a, sum = addOp.ints(l.terms[i].a, k); !sum {
+				return fmt.Errorf("%w: %s", ErrFDOverflow, Format(e, env))
+			}
+		} else {
+			coef[e.ID] = len(l.terms)
+			l.terms = append(l.terms, linTerm{a: k, x: e})
+		}
+		return nil
+	}
+	name, arity, _ := Functor(e)
+	args := Args(e)
+	switch {
+	case name == "+" && arity == 2:
+		if err := l.add(args[0], k, coef, env); err != nil {
+			return err
+		}
+		return l.add(args[1], k, coef, env)
+	case name == "-" && arity == 2:
+		if err := l.add(args[0], k, coef, env); err != nil {
+			return err
+		}
+		return l.add(args[1], -k, coef, env)
+	case name == "-" && arity == 1:
+		return l.add(args[0], -k, coef, env)
+	case name == "*" && arity == 2:
+		for i, arg := range args {
+			if n, ok := intValue(Deref(arg, env)); ok {
+				kn, ok := mulOp.ints(k, n)
+				if !ok {
+					return fmt.Errorf("%w: %s", ErrFDOverflow, Format(e, env))
+				}
+				return l.add(args[1-i], kn, coef, env)
+			}
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrNotLinear, Format(e, env))
+}
+
+// vars devuelve las variables de la restricción.
+func (l *linear) vars() []*ds.Symbol {
+	out := make([]*ds.Symbol, len(l.terms))
+	for i, t := range l.terms {
+		out[i] = t.x
+	}
+	return out
+}
+
+// negate devuelve la restricción contraria.
+func (l *linear) negate() *linear {
+	switch l.op {
+	case linEQ:
+		return &linear{terms: l.terms, c: l.c, op: linNE}
+	case linNE:
+		return &linear{terms: l.terms, c: l.c, op: linEQ}
+	}
+	// ¬(Σ + c ≤ 0) es Σ + c ≥ 1, es decir -Σ - c + 1 ≤ 0.
+	neg := make([]linTerm, len(l.terms))
+	for i, t := range l.terms {
+		neg[i] = linTerm{a: -t.a, x: t.x}
+	}
+	return &linear{terms: neg, c: 1 - l.c, op: linLE}
+}
+
+// bounds devuelve el menor y el mayor valor posibles de Σ aᵢ·xᵢ + c.
+func (l *linear) bounds(env *Environment) (lo, hi *big.Int, ok bool) {
+	lo, hi = big.NewInt(l.c), big.NewInt(l.c)
+	for _, t := range l.terms {
+		d, ok := fdDomain(env, t.x)
+		if !ok || d.Empty() {
+			return nil, nil, false
+		}
+		least, most := d.Min(), d.Max()
+		if t.a < 0 {
+			least, most = most, least
+		}
+		lo.Add(lo, mulBig(t.a, least))
+		hi.Add(hi, mulBig(t.a, most))
+	}
+	return lo, hi, true
+}
+
+// mulBig devuelve a·b sin desbordar.
+func mulBig(a, b int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(a), big.NewInt(b))
+}
+
+// entailment indica si la restricción se cumple o se incumple con seguridad.
+func (l *linear) entailment(env *Environment) (entailed, disentailed bool) {
+	lo, hi, ok := l.bounds(env)
+	if !ok {
+		return false, true
+	}
+	switch l.op {
+	case linLE:
+		return hi.Sign() <= 0, lo.Sign() > 0
+	case linEQ:
+		return lo.Sign() == 0 && hi.Sign() == 0, lo.Sign() > 0 || hi.Sign() < 0
+	}
+	return lo.Sign() > 0 || hi.Sign() < 0, lo.Sign() == 0 && hi.Sign() == 0
+}
+
+// propagate poda los dominios para mantener la consistencia de cotas.
+func (l *linear) propagate(env *Environment) bool {
+	switch l.op {
+	case linLE:
+		return propagateLE(env, l.terms, l.c)
+	case linEQ:
+		neg := make([]linTerm, len(l.terms))
+		for i, t := range l.terms {
+			neg[i] = linTerm{a: -t.a, x: t.x}
+		}
+		return propagateLE(env, l.terms, l.c) && propagateLE(env, neg, -l.c)
+	}
+	// Σ ≠ 0 solo poda cuando queda una variable libre.
+	var free *linTerm
+	sum := big.NewInt(l.c)
+	for i, t := range l.terms {
+		x := Deref(t.x, env)
+		if n, ok := intValue(x); ok {
+			sum.Add(sum, mulBig(t.a, n))
+			continue
+		}
+		if free != nil {
+			return true
+		}
+		free = &l.terms[i]
+	}
+	if free == nil {
+		return sum.Sign() != 0
+	}
+	// El valor prohibido es -sum/a, si es entero y puede estar en el dominio.
+	q, r := new(big.Int).QuoRem(sum.Neg(sum), big.NewInt(free.a), new(big.Int))
+	if r.Sign() != 0 || !q.IsInt64() {
+		return true
+	}
+	d, _ := fdDomain(env, free.x)
+	return fdNarrow(env, free.x, d.Remove(q.Int64()))
+}
+
+// propagateLE poda los dominios para que Σ aᵢ·xᵢ + c ≤ 0 pueda cumplirse.
+// Las cuentas se hacen con big.Int: aᵢ·xᵢ desborda int64 con coeficientes
+// grandes aunque los dominios estén acotados en ±FDSup.
+func propagateLE(env *Environment, terms []linTerm, c int64) bool {
+	mins := make([]*big.Int, len(terms))
+	total := big.NewInt(c)
+	for i, t := range terms {
+		d, ok := fdDomain(env, t.x)
+		if !ok || d.Empty() {
+			return false
+		}
+		if t.a > 0 {
+			mins[i] = mulBig(t.a, d.Min())
+		} else {
+			mins[i] = mulBig(t.a, d.Max())
+		}
+		total.Add(total, mins[i])
+	}
+	if total.Sign() > 0 {
+		return false
+	}
+	bound, a := new(big.Int), new(big.Int)
+	for i, t := range terms {
+		// aᵢ·xᵢ ≤ -(total - minᵢ). Con un divisor positivo, Div redondea hacia -∞.
+		bound.Sub(mins[i], total)
+		var d Domain
+		if t.a > 0 {
+			d = Range(-FDSup, clampFD(bound.Div(bound, a.SetInt64(t.a))))
+		} else {
+			// ⌈bound/a⌉ = -⌊bound/-a⌋
+			d = Range(clampFD(bound.Neg(bound.Div(bound, a.SetInt64(-t.a)))), FDSup)
+		}
+		if !fdNarrow(env, t.x, d) {
+			return false
+		}
+	}
+	return true
+}
+
+// propagateAllDifferent quita los valores ya tomados de las demás variables
+// y falla si entre todas no tienen valores suficientes.
+func propagateAllDifferent(env *Environment, vars []*ds.Symbol) bool {
+	taken := make(map[int64]bool)
+	for _, v := range vars {
+		if n, ok := intValue(Deref(v, env)); ok {
+			if taken[n] {
+				return false
+			}
+			taken[n] = true
+		}
+	}
+	union := Domain{}
+	for _, v := range vars {
+		x := Deref(v, env)
+		if _, ok := intValue(x); ok {
+			continue
+		}
+		d, ok := fdDomain(env, x)
+		if !ok {
+			return false
+		}
+		for n := range taken {
+			d = d.Remove(n)
+		}
+		if !fdNarrow(env, x, d) {
+			return false
+		}
+		union = union.Union(d)
+	}
+	return union.Size() >= int64(len(vars)-len(taken))
+}
+
+// propagateElement mantiene V = List[I], con I desde 1.
+func propagateElement(env *Environment, index *ds.Symbol, list []*ds.Symbol, value *ds.Symbol) bool {
+	if !fdNarrow(env, index, Range(1, int64(len(list)))) {
+		return false
+	}
+	di, _ := fdDomain(env, index)
+	dv, ok := fdDomain(env, value)
+	if !ok {
+		return false
+	}
+	possible, union := Domain{}, Domain{}
+	for i, more := di.Min(), true; more; i, more = di.Next(i) {
+		de, ok := fdDomain(env, list[i-1])
+		if !ok {
+			continue
+		}
+		if common := de.Intersect(dv); !common.Empty() {
+			possible = possible.Union(Range(i, i))
+			union = union.Union(common)
+		}
+	}
+	if !fdNarrow(env, index, possible) || !fdNarrow(env, value, union) {
+		return false
+	}
+	if i, ok := intValue(Deref(index, env)); ok {
+		dv, _ := fdDomain(env, value)
+		de, _ := fdDomain(env, list[i-1])
+		return fdNarrow(env, list[i-1], dv) && fdNarrow(env, value, de)
+	}
+	return true
+}
+
+// propagateReified mantiene B ⇔ l, con B en 0..1.
+func propagateReified(env *Environment, l *linear, b *ds.Symbol) bool {
+	if !fdNarrow(env, b, Range(0, 1)) {
+		return false
+	}
+	if v, ok := intValue(Deref(b, env)); ok {
+		if v == 1 {
+			return l.propagate(env)
+		}
+		return l.negate().propagate(env)
+	}
+	entailed, disentailed := l.entailment(env)
+	switch {
+	case entailed:
+		return fdNarrow(env, b, Range(1, 1))
+	case disentailed:
+		return fdNarrow(env, b, Range(0, 0))
+	}
+	return true
+}
+
+// propagateReifiedDomain mantiene B ⇔ X ∈ d, con B en 0..1.
+func propagateReifiedDomain(env *Environment, x *ds.Symbol, d Domain, b *ds.Symbol) bool {
+	if !fdNarrow(env, b, Range(0, 1)) {
+		return false
+	}
+	if v, ok := intValue(Deref(b, env)); ok {
+		if v == 1 {
+			return fdNarrow(env, x, d)
+		}
+		return fdNarrow(env, x, d.Complement())
+	}
+	dx, ok := fdDomain(env, x)
+	if !ok {
+		return false
+	}
+	switch common := dx.Intersect(d); {
+	case common.Equal(dx):
+		return fdNarrow(env, b, Range(1, 1))
+	case common.Empty():
+		return fdNarrow(env, b, Range(0, 0))
+	}
+	return true
+}
+
+// labelOptions son las estrategias de enumeración.
+type labelOptions struct {
+	selection string // leftmost, ff, min o max.
+	down      bool   // Valores de mayor a menor.
+}
+
+// parseLabeling lee la lista de opciones de labeling/2.
+func parseLabeling(opts *ds.Symbol, env *Environment) (labelOptions, error) {
+	o := labelOptions{selection: "leftmost"}
+	items, ok := listElements(opts, env)
+	if !ok {
+		return o, fmt.Errorf("%w: labeling options must be a list", ErrInstantiation)
+	}
+	for _, item := range items {
+		switch name := atomName(Deref(item, env)); name {
+		case "leftmost", "ff", "first_fail", "min", "max":
+			if name == "first_fail" {
+				name = "ff"
+			}
+			o.selection = name
+		case "up":
+			o.down = false
+		case "down":
+			o.down = true
+		default:
+			return o, fmt.Errorf("prologo: unknown labeling option %s", name)
+		}
+	}
+	return o, nil
+}
+
+// label asigna valores a las variables de vars con la estrategia o, y
+// continúa con k por cada asignación que cumpla las restricciones.
+func (s *Solver) label(vars []*ds.Symbol, o labelOptions, k Continuation) int {
+	var pick *ds.Symbol
+	var best Domain
+	for _, v := range vars {
+		x := Deref(v, s.Env)
+		if _, ok := intValue(x); ok {
+			continue
+		}
+		d, ok := fdDomain(s.Env, x)
+		if !ok {
+			return s.fail(fmt.Errorf("%w: cannot label %s", ErrNotInteger, Format(x, s.Env)))
+		}
+		better := pick == nil
+		if !better {
+			switch o.selection {
+			case "ff":
+				better = d.Size() < best.Size()
+			case "min":
+				better = d.Min() < best.Min()
+			case "max":
+				better = d.Max() > best.Max()
+			}
+		}
+		if better {
+			pick, best = x, d
+		}
+	}
+	if pick == nil {
+		return k()
+	}
+	if best.Min() == -FDSup || best.Max() == FDSup {
+		return s.fail(fmt.Errorf("%w: %s", ErrUnboundedDomain, Format(pick, s.Env)))
+	}
+
+	next := func() int { return s.label(vars, o, k) }
+	if o.down {
+		for v, more := best.Max(), true; more; v, more = best.Prev(v) {
+			if r := s.unifyThen(pick, Int(v), next); r != 0 {
+				return r
+			}
+		}
+		return 0
+	}
+	for v, more := best.Min(), true; more; v, more = best.Next(v) {
+		if r := s.unifyThen(pick, Int(v), next); r != 0 {
+			return r
+		}
+	}
+	return 0
+}
+
+// clampFD convierte n a int64 acotándolo a ±(FDSup+1): fuera de ±FDSup da
+// igual el valor exacto, pero un extremo más allá del otro sigue vaciando el
+// dominio en Range.
+func clampFD(n *big.Int) int64 {
+	switch {
+	case n.Cmp(big.NewInt(FDSup)) > 0:
+		return FDSup + 1
+	case n.Cmp(big.NewInt(-FDSup)) < 0:
+		return -FDSup - 1
+	}
+	return n.Int64()
+}
@@ -0,0 +1,138 @@
+// /nexusl/internal/proloGo/debugger.go
+// .
+// Depurador paso a paso
+// .
+// Debugger es un Tracer que se detiene en los puertos y lee órdenes, una
+// por línea, de un io.Reader: la consola en un REPL o un guion en las
+// pruebas. Avanzando paso a paso (creep) se detiene en cada puerto; con
+// leap solo en la llamada a un predicado con punto de ruptura.
+//
+//	(línea vacía), c, creep   seguir hasta el próximo puerto
+//	l, leap                   seguir hasta el próximo punto de ruptura
+//	s, skip                   en Call: ejecutar la caja sin detenerse dentro
+//	f, fail                   forzar el fallo de la caja
+//	a, abort                  abortar la consulta
+//	b, bindings               mostrar el valor de las variables del objetivo
+//	break name/arity          poner un punto de ruptura
+//	nobreak name/arity        quitarlo
+//	h, ?                      mostrar la ayuda
+//
+// .
+// Cuando la entrada se acaba, el depurador deja de detenerse.
+// .
+package prologo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// debuggerHelp es el texto de la orden h.
+const debuggerHelp = `c creep, l leap, s skip, f fail, a abort, b bindings, break P/N, nobreak P/N`
+
+// Debugger es un depurador paso a paso para un Solver.
+type Debugger struct {
+	in          *bufio.Scanner
+	out         io.Writer
+	breakpoints map[string]bool
+	leaping     bool
+	done        bool // La entrada se acabó.
+}
+
+// NewDebugger crea un depurador que lee órdenes de in y escribe en out.
+// Empieza avanzando paso a paso.
+func NewDebugger(in io.Reader, out io.Writer) *Debugger {
+	return &Debugger{in: bufio.NewScanner(in), out: out, breakpoints: make(map[string]bool)}
+}
+
+// Attach instala el depurador en s y activa el trazado de todos los predicados.
+func (d *Debugger) Attach(s *Solver) {
+	s.Tracer = d
+	s.Trace()
+}
+
+// Break pone un punto de ruptura en el predicado ind (como "parent/2").
+func (d *Debugger) Break(ind string) {
+	d.breakpoints[ind] = true
+}
+
+// Unbreak quita el punto de ruptura de ind.
+func (d *Debugger) Unbreak(ind string) {
+	delete(d.breakpoints, ind)
+}
+
+// Breakpoints devuelve los puntos de ruptura en orden alfabético.
+func (d *Debugger) Breakpoints() []string {
+	out := make([]string, 0, len(d.breakpoints))
+	for ind := range d.breakpoints {
+		out = append(out, ind)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Trace muestra el evento y, si corresponde detenerse, lee órdenes hasta
+// recibir una que reanude la consulta.
+func (d *Debugger) Trace(ev TraceEvent) TraceAction {
+	if d.done {
+		return TraceContinue
+	}
+	if d.leaping {
+		if ev.Port != PortCall || !d.breakpoints[ev.Indicator] {
+			return TraceContinue
+		}
+		d.leaping = false
+	}
+	fmt.Fprintf(d.out, "%s%s ?\n", strings.Repeat(" ", ev.Depth), ev)
+	for {
+		if !d.in.Scan() {
+			d.done = true
+			return TraceContinue
+		}
+		fields := strings.Fields(d.in.Text())
+		cmd := ""
+		if len(fields) > 0 {
+			cmd = fields[0]
+		}
+		switch cmd {
+		case "", "c", "creep":
+			return TraceContinue
+		case "l", "leap":
+			d.leaping = true
+			return TraceContinue
+		case "s", "skip":
+			if ev.Port == PortCall {
+				return TraceSkip
+			}
+			return TraceContinue
+		case "f", "fail":
+			return TraceFail
+		case "a", "abort":
+			return TraceAbort
+		case "b", "bindings":
+			if len(ev.Bindings) == 0 {
+				fmt.Fprintln(d.out, "no bindings")
+			}
+			for _, b := range ev.Bindings {
+				fmt.Fprintf(d.out, "%s = %s\n", b.Var, Format(b.Value, nil))
+			}
+		case "break", "nobreak":
+			if len(fields) != 2 {
+				fmt.Fprintf(d.out, "usage: %s name/arity\n", cmd)
+				continue
+			}
+			if cmd == "break" {
+				d.Break(fields[1])
+			} else {
+				d.Unbreak(fields[1])
+			}
+		case "h", "?":
+			fmt.Fprintln(d.out, debuggerHelp)
+		default:
+			fmt.Fprintf(d.out, "unknown command %q (h for help)\n", cmd)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+// /nexusl/internal/proloGo/domain.go
+// .
+// Dominios finitos de enteros
+// .
+// Un Domain es una unión de intervalos cerrados, disjuntos y ordenados.
+// Es inmutable: cada operación devuelve un dominio nuevo, así que el trail
+// puede guardar el anterior para deshacerlo al retroceder (ver clpfd.go).
+// Las variables sin dominio declarado tienen FullDomain, acotado en
+// ±FDSup para que la aritmética de cotas no desborde.
+// .
+package prologo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FDSup es la mayor magnitud que puede tomar una variable de dominio finito.
+const FDSup int64 = 1 << 40
+
+// interval es el intervalo cerrado Lo..Hi.
+type interval struct {
+	Lo, Hi int64
+}
+
+// Domain es un conjunto finito de enteros.
+type Domain struct {
+	ivs []interval
+}
+
+// FullDomain devuelve -FDSup..FDSup.
+func FullDomain() Domain {
+	return Domain{ivs: []interval{{-FDSup, FDSup}}}
+}
+
+// Range devuelve el dominio lo..hi, vacío si lo > hi.
+func Range(lo, hi int64) Domain {
+	if lo > hi {
+		return Domain{}
+	}
+	return Domain{ivs: []interval{{max(lo, -FDSup), min(hi, FDSup)}}}
+}
+
+// Values devuelve el dominio formado por los valores dados.
+func Values(values ...int64) Domain {
+	d := Domain{}
+	for _, v := range values {
+		d = d.Union(Range(v, v))
+	}
+	return d
+}
+
+// Empty indica si el dominio no tiene valores.
+func (d Domain) Empty() bool {
+	return len(d.ivs) == 0
+}
+
+// Min devuelve el menor valor. El dominio no debe estar vacío.
+func (d Domain) Min() int64 {
+	return d.ivs[0].Lo
+}
+
+// Max devuelve el mayor valor. El dominio no debe estar vacío.
+func (d Domain) Max() int64 {
+	return d.ivs[len(d.ivs)-1].Hi
+}
+
+// Size devuelve la cantidad de valores.
+func (d Domain) Size() int64 {
+	var n int64
+	for _, iv := range d.ivs {
+		n += iv.Hi - iv.Lo + 1
+	}
+	return n
+}
+
+// Singleton devuelve el único valor del dominio, si tiene uno solo.
+func (d Domain) Singleton() (int64, bool) {
+	if len(d.ivs) == 1 && d.ivs[0].Lo == d.ivs[0].Hi {
+		return d.ivs[0].Lo, true
+	}
+	return 0, false
+}
+
+// Contains indica si v pertenece al dominio.
+func (d Domain) Contains(v int64) bool {
+	for _, iv := range d.ivs {
+		if v < iv.Lo {
+			return false
+		}
+		if v <= iv.Hi {
+			return true
+		}
+	}
+	return false
+}
+
+// Equal indica si los dos dominios tienen los mismos valores.
+func (d Domain) Equal(o Domain) bool {
+	if len(d.ivs) != len(o.ivs) {
+		return false
+	}
+	for i := range d.ivs {
+		if d.ivs[i] != o.ivs[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Intersect devuelve los valores que están en los dos dominios.
+func (d Domain) Intersect(o Domain) Domain {
+	var out []interval
+	i, j := 0, 0
+	for i < len(d.ivs) && j < len(o.ivs) {
+		lo, hi := max(d.ivs[i].Lo, o.ivs[j].Lo), min(d.ivs[i].Hi, o.ivs[j].Hi)
+		if lo <= hi {
+			out = append(out, interval{lo, hi})
+		}
+		if d.ivs[i].Hi < o.ivs[j].Hi {
+			i++
+		} else {
+			j++
+		}
+	}
+	return Domain{ivs: out}
+}
+
+// Union devuelve los valores que están en alguno de los dos dominios.
+func (d Domain) Union(o Domain) Domain {
+	all := make([]interval, 0, len(d.ivs)+len(o.ivs))
+	i, j := 0, 0
+	for i < len(d.ivs) || j < len(o.ivs) {
+		var next interval
+		if j >= len(o.ivs) || (i < len(d.ivs) && d.ivs[i].Lo <= o.ivs[j].Lo) {
+			next = d.ivs[i]
+			i++
+		} else {
+			next = o.ivs[j]
+			j++
+		}
+		if n := len(all); n > 0 && next.Lo <= all[n-1].Hi+1 {
+			all[n-1].Hi = max(all[n-1].Hi, next.Hi)
+			continue
+		}
+		all = append(all, next)
+	}
+	return Domain{ivs: all}
+}
+
+// Complement devuelve los valores de FullDomain que no están en d.
+func (d Domain) Complement() Domain {
+	var out []interval
+	lo := -FDSup
+	for _, iv := range d.ivs {
+		if iv.Lo > lo {
+			out = append(out, interval{lo, iv.Lo - 1})
+		}
+		lo = iv.Hi + 1
+	}
+	if lo <= FDSup {
+		out = append(out, interval{lo, FDSup})
+	}
+	return Domain{ivs: out}
+}
+
+// Remove devuelve el dominio sin el valor v.
+func (d Domain) Remove(v int64) Domain {
+	return d.Intersect(Range(v, v).Complement())
+}
+
+// Next devuelve el menor valor del dominio mayor que v.
+func (d Domain) Next(v int64) (int64, bool) {
+	for _, iv := range d.ivs {
+		if v < iv.Lo {
+			return iv.Lo, true
+		}
+		if v < iv.Hi {
+			return v + 1, true
+		}
+	}
+	return 0, false
+}
+
+// Prev devuelve el mayor valor del dominio menor que v.
+func (d Domain) Prev(v int64) (int64, bool) {
+	for i := len(d.ivs) - 1; i >= 0; i-- {
+		iv := d.ivs[i]
+		if v > iv.Hi {
+			return iv.Hi, true
+		}
+		if v > iv.Lo {
+			return v - 1, true
+		}
+	}
+	return 0, false
+}
+
+// String devuelve el dominio con la notación 1..3 \/ 5; vacío es "{}".
+func (d Domain) String() string {
+	if d.Empty() {
+		return "{}"
+	}
+	parts := make([]string, len(d.ivs))
+	for i, iv := range d.ivs {
+		if iv.Lo == iv.Hi {
+			parts[i] = fmt.Sprint(iv.Lo)
+		} else {
+			parts[i] = fmt.Sprintf("%d..%d", iv.Lo, iv.Hi)
+		}
+	}
+	return strings.Join(parts, ` \/ `)
+}
@@ -0,0 +1,169 @@
+// /nexusl/internal/proloGo/kb.go
+// .
+// Base de conocimiento del resolvedor
+// .
+// Las cláusulas se agrupan por indicador de predicado (name/arity) y se
+// prueban en el orden en que se agregaron. Un hecho es una cláusula sin
+// cuerpo; además puede llevar un valor de verdad distinto de verdadero
+// (false, maybe, unknown) para el razonamiento trivaluado (ver truth.go).
+// .
+package prologo
+
+import (
+	"fmt"
+
+	"github.com/devicemxl/nexusl/ds"
+)
+
+// Clause es una cláusula de Horn: Head :- Body.
+type Clause struct {
+	Head  *ds.Symbol
+	Body  []*ds.Symbol
+	Truth ds.TruthValue // Solo las cláusulas verdaderas participan en la resolución SLD.
+}
+
+// IsFact indica si la cláusula no tiene cuerpo.
+func (c *Clause) IsFact() bool {
+	return len(c.Body) == 0
+}
+
+// String devuelve la cláusula en notación Prolog.
+func (c *Clause) String() string {
+	s := Format(c.Head, nil)
+	for i, goal := range c.Body {
+		if i == 0 {
+			s += " :- "
+		} else {
+			s += ", "
+		}
+		s += Format(goal, nil)
+	}
+	if c.Truth != ds.TV_True {
+		s += " [" + c.Truth.String() + "]"
+	}
+	return s + "."
+}
+
+// KnowledgeBase guarda las cláusulas de un programa.
+type KnowledgeBase struct {
+	clauses    map[string][]*Clause
+	order      []string        // Indicadores en orden de definición.
+	tabled     map[string]bool // Predicados declarados con Table (ver tabling.go).
+	generation int             // Aumenta con cada cambio; invalida las tablas de respuestas.
+}
+
+// NewKnowledgeBase crea una base de conocimiento vacía.
+func NewKnowledgeBase() *KnowledgeBase {
+	return &KnowledgeBase{clauses: make(map[string][]*Clause), tabled: make(map[string]bool)}
+}
+
+// Add agrega una cláusula al final de su predicado.
+func (kb *KnowledgeBase) Add(c *Clause) error {
+	name, arity, ok := Functor(c.Head)
+	if !ok || name == "" {
+		return fmt.Errorf("prologo: clause head must be an atom or a structure: %s", Format(c.Head, nil))
+	}
+	key := Indicator(name, arity)
+	if _, exists := kb.clauses[key]; !exists {
+		kb.order = append(kb.order, key)
+	}
+	kb.clauses[key] = append(kb.clauses[key], c)
+	kb.generation++
+	return nil
+}
+
+// AddFact agrega un hecho verdadero.
+func (kb *KnowledgeBase) AddFact(head *ds.Symbol) error {
+	return kb.Add(&Clause{Head: head, Truth: ds.TV_True})
+}
+
+// AddFactWithTruth agrega un hecho con un valor de verdad explícito, por
+// ejemplo TV_False para registrar que se sabe que algo no ocurre.
+func (kb *KnowledgeBase) AddFactWithTruth(head *ds.Symbol, v ds.TruthValue) error {
+	return kb.Add(&Clause{Head: head, Truth: v})
+}
+
+// AddRule agrega la regla head :- body.
+func (kb *KnowledgeBase) AddRule(head *ds.Symbol, body ...*ds.Symbol) error {
+	return kb.Add(&Clause{Head: head, Body: body, Truth: ds.TV_True})
+}
+
+// AddTriplet agrega la tripleta (S P O) como el hecho P(S, O). Su valor de
+// verdad se deriva del grado fermateano de la tripleta, si lo tiene.
+func (kb *KnowledgeBase) AddTriplet(t *ds.Triplet) error {
+	term, err := tripletTerm(t)
+	if err != nil {
+		return err
+	}
+	return kb.AddFactWithTruth(term, t.Degree().Value())
+}
+
+// Clauses devuelve las cláusulas del predicado name/arity.
+func (kb *KnowledgeBase) Clauses(name string, arity int) []*Clause {
+	return kb.clauses[Indicator(name, arity)]
+}
+
+// Defined indica si existe al menos una cláusula para name/arity.
+func (kb *KnowledgeBase) Defined(name string, arity int) bool {
+	_, ok := kb.clauses[Indicator(name, arity)]
+	return ok
+}
+
+// Predicates devuelve los indicadores definidos en orden de definición.
+func (kb *KnowledgeBase) Predicates() []string {
+	return append([]string(nil), kb.order...)
+}
+
+// Table declara que las llamadas a name/arity se resuelven con tabulación:
+// sus respuestas se memorizan y las reglas recursivas por la izquierda terminan.
+func (kb *KnowledgeBase) Table(name string, arity int) {
+	kb.tabled[Indicator(name, arity)] = true
+	kb.generation++
+}
+
+// Tabled indica si name/arity se declaró con Table.
+func (kb *KnowledgeBase) Tabled(name string, arity int) bool {
+	return kb.tabled[Indicator(name, arity)]
+}
+
+// Generation devuelve un contador que cambia cada vez que se modifica la base.
+func (kb *KnowledgeBase) Generation() int {
+	return kb.generation
+}
+
+// Retract quita los hechos verdaderos que son variantes de head y devuelve
+// cuántos quitó.
+func (kb *KnowledgeBase) Retract(head *ds.Symbol) int {
+	name, arity, ok := Functor(head)
+	if !ok {
+		return 0
+	}
+	key := Indicator(name, arity)
+	kept := kb.clauses[key][:0]
+	removed := 0
+	for _, c := range kb.clauses[key] {
+		if c.IsFact() && c.Truth == ds.TV_True && variant(c.Head, head) {
+			removed++
+			continue
+		}
+		kept = append(kept, c)
+	}
+	if removed > 0 {
+		kb.clauses[key] = kept
+		kb.generation++
+	}
+	return removed
+}
+
+// tripletTerm traduce la tripleta (S P O) al término P(S, O).
+func tripletTerm(t *ds.Triplet) (*ds.Symbol, error) {
+	pred, ok := t.Predicate.(*ds.Symbol)
+	if !ok || pred.PublicName == "" {
+		return nil, fmt.Errorf("prologo: triplet predicate must be a named symbol: %s", t)
+	}
+	obj, ok := t.Object.(*ds.Symbol)
+	if !ok {
+		return nil, fmt.Errorf("prologo: triplet object must be a symbol: %s", t)
+	}
+	return Compound(pred.PublicName, t.Subject, obj), nil
+}
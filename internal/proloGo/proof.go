@@ -0,0 +1,198 @@
+// /nexusl/internal/proloGo/proof.go
+// .
+// Árboles de prueba
+// .
+// SolveWithProofs entrega, junto con cada solución, el árbol que la
+// demuestra: por cada objetivo, la cláusula que se usó, el unificador (el
+// valor de cada variable de la cláusula) y las pruebas de su cuerpo.
+// .
+// Mientras se resuelve, el árbol se construye sobre la marcha y se recorta
+// al retroceder, igual que las ligaduras del trail; al llegar a una solución
+// se copia con los valores resueltos. Las conectivas (',', ';', '->', call,
+// true y el corte) no forman nodos propios: sus objetivos cuelgan del nodo
+// que las contiene. Los predicados tabulados se registran como una hoja que
+// responde desde su tabla, y lo que se prueba dentro de not, for_all o
+// collect_all no queda en el árbol, porque se deshace al terminar.
+// .
+package prologo
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/devicemxl/nexusl/ds"
+)
+
+// ProofKind dice cómo se demostró un objetivo.
+type ProofKind int
+
+const (
+	ProofClause  ProofKind = iota // Con una cláusula de la base.
+	ProofBuiltin                  // Con un predicado predefinido.
+	ProofTable                    // Con una respuesta tabulada.
+)
+
+// String devuelve el nombre del tipo de prueba.
+func (k ProofKind) String() string {
+	switch k {
+	case ProofBuiltin:
+		return "builtin"
+	case ProofTable:
+		return "table"
+	}
+	return "clause"
+}
+
+// Binding es el valor de una variable de la cláusula usada.
+type Binding struct {
+	Var   string
+	Value *ds.Symbol
+}
+
+// Proof es la demostración de un objetivo.
+type Proof struct {
+	Goal     *ds.Symbol
+	Kind     ProofKind
+	Clause   *Clause   // Solo con ProofClause.
+	Unifier  []Binding // Variables de Clause en orden de aparición.
+	Children []*Proof
+}
+
+// proofNode es un nodo del árbol en construcción.
+type proofNode struct {
+	goal     *ds.Symbol
+	kind     ProofKind
+	clause   *Clause
+	fresh    map[ds.SymbolID]*ds.Symbol // Renombrado de las variables de clause.
+	children []*proofNode
+}
+
+// transparentGoals son los indicadores que no forman nodo propio.
+var transparentGoals = map[string]bool{
+	",/2": true, ";/2": true, "->/2": true, "call/1": true,
+	"true/0": true, "!/0": true, "cut/0": true,
+}
+
+// SolveWithProofs es como Solve, pero también entrega una prueba por cada
+// objetivo de goals.
+func (s *Solver) SolveWithProofs(goals []*ds.Symbol, yield func(Solution, []*Proof) bool) error {
+	prev := s.proof
+	root := &proofNode{}
+	s.proof = root
+	defer func() { s.proof = prev }()
+	return s.Solve(goals, func(sol Solution) bool {
+		proofs := make([]*Proof, len(root.children))
+		for i, child := range root.children {
+			proofs[i] = s.snapshot(child)
+		}
+		return yield(sol, proofs)
+	})
+}
+
+// Explain devuelve las pruebas de la primera solución de goals, o nil si no tiene.
+func (s *Solver) Explain(goals ...*ds.Symbol) ([]*Proof, error) {
+	var proofs []*Proof
+	err := s.SolveWithProofs(goals, func(_ Solution, p []*Proof) bool {
+		proofs = p
+		return false
+	})
+	return proofs, err
+}
+
+// traceGoal prueba goal con run y, si se están registrando pruebas, le abre
+// un nodo bajo el actual. El nodo se quita al agotar sus alternativas.
+func (s *Solver) traceGoal(goal *ds.Symbol, kind ProofKind, k Continuation, run func(Continuation) int) int {
+	parent := s.proof
+	if parent == nil {
+		return run(k)
+	}
+	node := &proofNode{goal: goal, kind: kind}
+	n := len(parent.children)
+	parent.children = append(parent.children, node)
+	s.proof = node
+	r := run(func() int {
+		s.proof = parent
+		r := k()
+		s.proof = node
+		return r
+	})
+	s.proof = parent
+	parent.children = parent.children[:n]
+	return r
+}
+
+// snapshot copia el nodo con los valores actuales del entorno.
+func (s *Solver) snapshot(n *proofNode) *Proof {
+	p := &Proof{Goal: Resolve(n.goal, s.Env), Kind: n.kind, Clause: n.clause}
+	if n.clause != nil {
+		seen := make(map[ds.SymbolID]bool)
+		for _, t := range append([]*ds.Symbol{n.clause.Head}, n.clause.Body...) {
+			for _, v := range Variables(t, nil) {
+				if seen[v.ID] || n.fresh[v.ID] == nil {
+					continue
+				}
+				seen[v.ID] = true
+				p.Unifier = append(p.Unifier, Binding{Var: v.PublicName, Value: Resolve(n.fresh[v.ID], s.Env)})
+			}
+		}
+	}
+	for _, child := range n.children {
+		p.Children = append(p.Children, s.snapshot(child))
+	}
+	return p
+}
+
+// String devuelve la prueba indentada, un objetivo por línea.
+func (p *Proof) String() string {
+	var b strings.Builder
+	p.write(&b, 0)
+	return b.String()
+}
+
+func (p *Proof) write(b *strings.Builder, depth int) {
+	b.WriteString(strings.Repeat("  ", depth) + Format(p.Goal, nil))
+	switch {
+	case p.Kind != ProofClause:
+		fmt.Fprintf(b, "  [%s]", p.Kind)
+	case p.Clause.IsFact():
+		b.WriteString("  [fact]")
+	default:
+		b.WriteString("  by " + p.Clause.String())
+	}
+	if len(p.Unifier) > 0 {
+		parts := make([]string, len(p.Unifier))
+		for i, u := range p.Unifier {
+			parts[i] = u.Var + " = " + Format(u.Value, nil)
+		}
+		b.WriteString("  {" + strings.Join(parts, ", ") + "}")
+	}
+	b.WriteString("\n")
+	for _, child := range p.Children {
+		child.write(b, depth+1)
+	}
+}
+
+// proofJSON es la forma JSON de una prueba.
+type proofJSON struct {
+	Goal     string            `json:"goal"`
+	Kind     string            `json:"kind"`
+	Clause   string            `json:"clause,omitempty"`
+	Unifier  map[string]string `json:"unifier,omitempty"`
+	Children []*Proof          `json:"children,omitempty"`
+}
+
+// MarshalJSON codifica la prueba con los términos en notación Prolog.
+func (p *Proof) MarshalJSON() ([]byte, error) {
+	out := proofJSON{Goal: Format(p.Goal, nil), Kind: p.Kind.String(), Children: p.Children}
+	if p.Clause != nil {
+		out.Clause = p.Clause.String()
+	}
+	if len(p.Unifier) > 0 {
+		out.Unifier = make(map[string]string, len(p.Unifier))
+		for _, u := range p.Unifier {
+			out.Unifier[u.Var] = Format(u.Value, nil)
+		}
+	}
+	return json.Marshal(out)
+}
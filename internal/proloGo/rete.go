@@ -0,0 +1,601 @@
+// /nexusl/internal/proloGo/rete.go
+// .
+// Encadenamiento hacia adelante: red Rete/TREAT
+// .
+// El resolvedor SLD responde preguntas; la red reacciona a los hechos. Cada
+// regla se compila en una lista de condiciones, y cada condición distinta
+// (salvo renombrado de variables) tiene una memoria alfa con los hechos que
+// la satisfacen. Como en TREAT, no se guardan memorias beta: al llegar un
+// hecho se unen solo las activaciones en las que ese hecho participa,
+// recorriendo las memorias alfa del resto de las condiciones.
+// .
+//   - Assert agrega un hecho base y propaga hasta el punto fijo. Cada
+//     disparo de una regla queda como una justificación (regla + premisas)
+//     de sus conclusiones; un hecho ya presente solo gana la justificación.
+//   - Retract quita un hecho base y, con él, todo lo derivado que se quede
+//     sin un apoyo fundado en hechos base (también los ciclos de reglas que
+//     se apoyaban entre sí).
+//   - MaxDerivations y MaxTermDepth cortan las reglas que generan hechos
+//     sin fin, por ejemplo next(X, s(X)) :- next(_, X).
+//
+// .
+// OnChange recibe cada hecho que aparece o desaparece: es el punto donde un
+// agente externo reacciona a una inferencia nueva.
+// .
+package prologo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/devicemxl/nexusl/ds"
+)
+
+const (
+	// DefaultMaxDerivations limita los hechos derivados en una sola propagación.
+	DefaultMaxDerivations = 100000
+	// DefaultMaxTermDepth limita el anidamiento de los términos derivados.
+	DefaultMaxTermDepth = 64
+)
+
+var (
+	ErrNonGroundFact   = errors.New("prologo: fact is not ground")
+	ErrUnsafeRule      = errors.New("prologo: rule conclusion has variables not bound by its conditions")
+	ErrDerivationLimit = errors.New("prologo: derivation limit exceeded")
+	ErrNotAsserted     = errors.New("prologo: fact was not asserted")
+)
+
+// Rule es una regla de producción: si se cumplen todas las condiciones,
+// se afirman todas las conclusiones.
+type Rule struct {
+	Name        string
+	Conditions  []*ds.Symbol
+	Conclusions []*ds.Symbol
+}
+
+// RuleFromClause convierte la cláusula Head :- Body en la regla Body => Head.
+func RuleFromClause(name string, c *Clause) *Rule {
+	return &Rule{Name: name, Conditions: c.Body, Conclusions: []*ds.Symbol{c.Head}}
+}
+
+// String devuelve la regla como cond, ... => concl, ....
+func (r *Rule) String() string {
+	parts := func(terms []*ds.Symbol) string {
+		out := make([]string, len(terms))
+		for i, t := range terms {
+			out[i] = Format(t, nil)
+		}
+		return strings.Join(out, ", ")
+	}
+	return r.Name + ": " + parts(r.Conditions) + " => " + parts(r.Conclusions)
+}
+
+// Justification es un disparo de una regla: las premisas que satisficieron
+// sus condiciones y los hechos que concluyó.
+type Justification struct {
+	Rule        *Rule
+	Premises    []*Fact
+	Conclusions []*Fact
+	key         string
+}
+
+// Fact es un hecho de la red, base (afirmado con Assert) o derivado.
+type Fact struct {
+	Term     *ds.Symbol
+	Asserted bool
+	// Justifications son los disparos que concluyeron este hecho.
+	Justifications []*Justification
+	consumers      []*Justification // Disparos en los que es premisa.
+	key            string
+}
+
+// Derived indica si el hecho tiene al menos una justificación.
+func (f *Fact) Derived() bool {
+	return len(f.Justifications) > 0
+}
+
+// Triplet devuelve el hecho P(S, O) como la tripleta (S P O) con ámbito fact.
+func (f *Fact) Triplet() (*ds.Triplet, bool) {
+	name, arity, _ := Functor(f.Term)
+	if arity != 2 {
+		return nil, false
+	}
+	args := Args(f.Term)
+	return ds.NewTriplet(args[0], Atom(name), args[1], Atom("fact")), true
+}
+
+// String devuelve el término del hecho.
+func (f *Fact) String() string {
+	return Format(f.Term, nil)
+}
+
+// ChangeKind distingue los hechos que aparecen de los que desaparecen.
+type ChangeKind int
+
+const (
+	FactAdded ChangeKind = iota
+	FactRemoved
+)
+
+// alphaMemory guarda los hechos que satisfacen una condición.
+type alphaMemory struct {
+	pattern *ds.Symbol
+	facts   []*Fact
+	users   []alphaUser // Condiciones de reglas que leen esta memoria.
+}
+
+// alphaUser identifica la condición index de una regla compilada.
+type alphaUser struct {
+	rule  *compiledRule
+	index int
+}
+
+// compiledRule es una regla con una memoria alfa por condición.
+type compiledRule struct {
+	id     int
+	rule   *Rule
+	alphas []*alphaMemory
+}
+
+// Network es una red de encadenamiento hacia adelante.
+type Network struct {
+	MaxDerivations int
+	MaxTermDepth   int
+	// OnChange, si no es nil, se llama con cada hecho agregado o quitado.
+	OnChange func(kind ChangeKind, f *Fact)
+
+	rules   []*compiledRule
+	alphas  map[string]*alphaMemory   // Por clave de variante del patrón.
+	indexed map[string][]*alphaMemory // Por indicador del patrón.
+	facts   map[string]*Fact
+	order   []*Fact
+	fired   map[string]*Justification
+	agenda  []*Fact
+	derived int // Derivaciones de la propagación en curso.
+}
+
+// NewNetwork crea una red con las reglas dadas.
+func NewNetwork(rules ...*Rule) (*Network, error) {
+	n := &Network{
+		MaxDerivations: DefaultMaxDerivations,
+		MaxTermDepth:   DefaultMaxTermDepth,
+		alphas:         make(map[string]*alphaMemory),
+		indexed:        make(map[string][]*alphaMemory),
+		facts:          make(map[string]*Fact),
+		fired:          make(map[string]*Justification),
+	}
+	for _, r := range rules {
+		if err := n.AddRule(r); err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+// NetworkFromKB compila las reglas de kb (sus cláusulas con cuerpo, con el
+// nombre name/arity#n) y afirma sus hechos verdaderos.
+func NetworkFromKB(kb *KnowledgeBase) (*Network, error) {
+	n, err := NewNetwork()
+	if err != nil {
+		return nil, err
+	}
+	var facts []*ds.Symbol
+	for _, key := range kb.Predicates() {
+		for i, c := range kb.clauses[key] {
+			switch {
+			case c.Truth != ds.TV_True:
+			case c.IsFact():
+				facts = append(facts, c.Head)
+			default:
+				if err := n.AddRule(RuleFromClause(fmt.Sprintf("%s#%d", key, i+1), c)); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	for _, f := range facts {
+		if _, err := n.Assert(f); err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+// AddRule compila r y la dispara con los hechos que ya estén en la red.
+// Devuelve ErrUnsafeRule si una conclusión usa variables que ninguna
+// condición liga, porque derivaría hechos no básicos.
+func (n *Network) AddRule(r *Rule) error {
+	if len(r.Conditions) == 0 {
+		return fmt.Errorf("%w: %s has no conditions", ErrUnsafeRule, r.Name)
+	}
+	bound := make(map[ds.SymbolID]bool)
+	for _, c := range r.Conditions {
+		if _, _, ok := Functor(c); !ok {
+			return fmt.Errorf("%w: %s", ErrNotCallable, Format(c, nil))
+		}
+		for _, v := range Variables(c, nil) {
+			bound[v.ID] = true
+		}
+	}
+	for _, c := range r.Conclusions {
+		for _, v := range Variables(c, nil) {
+			if !bound[v.ID] {
+				return fmt.Errorf("%w: %s in %s", ErrUnsafeRule, v.PublicName, r)
+			}
+		}
+	}
+
+	cr := &compiledRule{id: len(n.rules), rule: r, alphas: make([]*alphaMemory, len(r.Conditions))}
+	for i, c := range r.Conditions {
+		a := n.alpha(c)
+		a.users = append(a.users, alphaUser{rule: cr, index: i})
+		cr.alphas[i] = a
+	}
+	n.rules = append(n.rules, cr)
+
+	n.derived = 0
+	for i, a := range cr.alphas {
+		for _, f := range append([]*Fact(nil), a.facts...) {
+			if err := n.join(cr, i, f); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := n.run()
+	return err
+}
+
+// Rules devuelve las reglas de la red en orden de definición.
+func (n *Network) Rules() []*Rule {
+	rules := make([]*Rule, len(n.rules))
+	for i, cr := range n.rules {
+		rules[i] = cr.rule
+	}
+	return rules
+}
+
+// Assert afirma el hecho básico term y propaga sus consecuencias. Devuelve
+// los hechos nuevos, term incluido, en el orden en que aparecieron. Si se
+// alcanza un límite de derivación, devuelve ErrDerivationLimit y la red
+// conserva lo derivado hasta entonces.
+func (n *Network) Assert(term *ds.Symbol) ([]*Fact, error) {
+	term = Resolve(term, nil)
+	if len(Variables(term, nil)) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNonGroundFact, Format(term, nil))
+	}
+	if _, _, ok := Functor(term); !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotCallable, Format(term, nil))
+	}
+	key := variantKey(term, nil)
+	if f, ok := n.facts[key]; ok {
+		f.Asserted = true
+		return nil, nil
+	}
+	f := &Fact{Term: term, Asserted: true, key: key}
+	n.derived = 0
+	n.add(f)
+	return n.run()
+}
+
+// AssertTriplet afirma la tripleta (S P O) como el hecho P(S, O).
+func (n *Network) AssertTriplet(t *ds.Triplet) ([]*Fact, error) {
+	term, err := tripletTerm(t)
+	if err != nil {
+		return nil, err
+	}
+	return n.Assert(term)
+}
+
+// Retract quita el hecho base term. Todo hecho que deje de estar apoyado
+// por una cadena de justificaciones que termine en hechos base también se
+// quita. Devuelve los hechos quitados; si term además era derivado y sigue
+// apoyado, se conserva como derivado y no se quita nada.
+func (n *Network) Retract(term *ds.Symbol) ([]*Fact, error) {
+	f, ok := n.facts[variantKey(term, nil)]
+	if !ok || !f.Asserted {
+		return nil, fmt.Errorf("%w: %s", ErrNotAsserted, Format(term, nil))
+	}
+	f.Asserted = false
+
+	// Los hechos que dependen de f, directa o indirectamente.
+	affected := map[*Fact]bool{f: true}
+	queue := []*Fact{f}
+	for len(queue) > 0 {
+		g := queue[0]
+		queue = queue[1:]
+		for _, j := range g.consumers {
+			for _, c := range j.Conclusions {
+				if !affected[c] {
+					affected[c] = true
+					queue = append(queue, c)
+				}
+			}
+		}
+	}
+
+	// Punto fijo mínimo: un hecho afectado sigue fundado si es base o si
+	// alguna justificación tiene todas sus premisas fundadas.
+	grounded := make(map[*Fact]bool)
+	isGrounded := func(g *Fact) bool { return !affected[g] || grounded[g] }
+	for changed := true; changed; {
+		changed = false
+		for g := range affected {
+			if grounded[g] {
+				continue
+			}
+			ok := g.Asserted
+			for _, j := range g.Justifications {
+				if ok {
+					break
+				}
+				ok = true
+				for _, p := range j.Premises {
+					if !isGrounded(p) {
+						ok = false
+						break
+					}
+				}
+			}
+			if ok {
+				grounded[g] = true
+				changed = true
+			}
+		}
+	}
+
+	var removed []*Fact
+	for _, g := range n.order {
+		if affected[g] && !grounded[g] {
+			removed = append(removed, g)
+		}
+	}
+	for _, g := range removed {
+		n.remove(g)
+	}
+	return removed, nil
+}
+
+// Lookup devuelve el hecho igual a term, si está en la red.
+func (n *Network) Lookup(term *ds.Symbol) (*Fact, bool) {
+	f, ok := n.facts[variantKey(term, nil)]
+	return f, ok
+}
+
+// Facts devuelve los hechos de la red en el orden en que aparecieron.
+func (n *Network) Facts() []*Fact {
+	return append([]*Fact(nil), n.order...)
+}
+
+// Match devuelve los hechos que unifican con pattern.
+func (n *Network) Match(pattern *ds.Symbol) []*Fact {
+	var out []*Fact
+	for _, f := range n.order {
+		if Unify(pattern, f.Term, NewEnvironment()) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// alpha devuelve la memoria alfa de pattern, creándola y llenándola con los
+// hechos existentes si no estaba.
+func (n *Network) alpha(pattern *ds.Symbol) *alphaMemory {
+	key := variantKey(pattern, nil)
+	if a, ok := n.alphas[key]; ok {
+		return a
+	}
+	a := &alphaMemory{pattern: pattern}
+	for _, f := range n.order {
+		if Unify(pattern, f.Term, NewEnvironment()) {
+			a.facts = append(a.facts, f)
+		}
+	}
+	n.alphas[key] = a
+	name, arity, _ := Functor(pattern)
+	ind := Indicator(name, arity)
+	n.indexed[ind] = append(n.indexed[ind], a)
+	return a
+}
+
+// add registra f en la red y en sus memorias alfa, y lo pone en la agenda.
+func (n *Network) add(f *Fact) {
+	n.facts[f.key] = f
+	n.order = append(n.order, f)
+	name, arity, _ := Functor(f.Term)
+	for _, a := range n.indexed[Indicator(name, arity)] {
+		if Unify(a.pattern, f.Term, NewEnvironment()) {
+			a.facts = append(a.facts, f)
+		}
+	}
+	n.agenda = append(n.agenda, f)
+	if n.OnChange != nil {
+		n.OnChange(FactAdded, f)
+	}
+}
+
+// run propaga los hechos de la agenda hasta vaciarla y devuelve los hechos nuevos.
+func (n *Network) run() ([]*Fact, error) {
+	var added []*Fact
+	for len(n.agenda) > 0 {
+		f := n.agenda[0]
+		n.agenda = n.agenda[1:]
+		added = append(added, f)
+		name, arity, _ := Functor(f.Term)
+		for _, a := range n.indexed[Indicator(name, arity)] {
+			if !containsFact(a.facts, f) {
+				continue
+			}
+			for _, u := range a.users {
+				if err := n.join(u.rule, u.index, f); err != nil {
+					n.agenda = nil
+					return added, err
+				}
+			}
+		}
+	}
+	return added, nil
+}
+
+// join busca las activaciones de cr en las que f satisface la condición
+// index, y dispara cada una.
+func (n *Network) join(cr *compiledRule, index int, f *Fact) error {
+	conds := cr.rule.Conditions
+	env := NewEnvironment()
+	if !Unify(conds[index], f.Term, env) {
+		return nil
+	}
+	premises := make([]*Fact, len(conds))
+	premises[index] = f
+	var step func(i int) error
+	step = func(i int) error {
+		if i == len(conds) {
+			return n.fire(cr, premises, env)
+		}
+		if i == index {
+			return step(i + 1)
+		}
+		for _, g := range append([]*Fact(nil), cr.alphas[i].facts...) {
+			mark := env.Mark()
+			if Unify(conds[i], g.Term, env) {
+				premises[i] = g
+				if err := step(i + 1); err != nil {
+					return err
+				}
+			}
+			env.Undo(mark)
+		}
+		return nil
+	}
+	return step(0)
+}
+
+// fire registra un disparo de cr con esas premisas, salvo que ya exista,
+// y agrega sus conclusiones.
+func (n *Network) fire(cr *compiledRule, premises []*Fact, env *Environment) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d", cr.id)
+	for _, p := range premises {
+		b.WriteString("|")
+		b.WriteString(p.key)
+	}
+	key := b.String()
+	if _, ok := n.fired[key]; ok {
+		return nil
+	}
+
+	j := &Justification{Rule: cr.rule, Premises: append([]*Fact(nil), premises...), key: key}
+	for _, c := range cr.rule.Conclusions {
+		term := Resolve(c, env)
+		if termDepth(term) > n.MaxTermDepth {
+			return fmt.Errorf("%w: %s derives a term deeper than %d", ErrDerivationLimit, cr.rule.Name, n.MaxTermDepth)
+		}
+		n.derived++
+		if n.derived > n.MaxDerivations {
+			return fmt.Errorf("%w: more than %d derivations", ErrDerivationLimit, n.MaxDerivations)
+		}
+		k := variantKey(term, nil)
+		g, ok := n.facts[k]
+		if !ok {
+			g = &Fact{Term: term, key: k}
+		}
+		g.Justifications = append(g.Justifications, j)
+		j.Conclusions = append(j.Conclusions, g)
+		if !ok {
+			n.add(g)
+		}
+	}
+	n.fired[key] = j
+	for _, p := range premises {
+		if !containsJustification(p.consumers, j) {
+			p.consumers = append(p.consumers, j)
+		}
+	}
+	return nil
+}
+
+// remove quita f de la red junto con los disparos en los que es premisa.
+func (n *Network) remove(f *Fact) {
+	if _, ok := n.facts[f.key]; !ok {
+		return
+	}
+	delete(n.facts, f.key)
+	n.order = removeFact(n.order, f)
+	for _, a := range n.alphas {
+		a.facts = removeFact(a.facts, f)
+	}
+	for _, j := range f.consumers {
+		delete(n.fired, j.key)
+		for _, p := range j.Premises {
+			if p != f {
+				p.consumers = removeJustification(p.consumers, j)
+			}
+		}
+		for _, c := range j.Conclusions {
+			c.Justifications = removeJustification(c.Justifications, j)
+		}
+	}
+	f.consumers = nil
+	for _, j := range f.Justifications {
+		j.Conclusions = removeFact(j.Conclusions, f)
+	}
+	if n.OnChange != nil {
+		n.OnChange(FactRemoved, f)
+	}
+}
+
+// termDepth devuelve el anidamiento máximo de un término básico.
+func termDepth(t *ds.Symbol) int {
+	t = ds.Deref(t)
+	depth := 0
+	switch t.LogicalType {
+	case ds.LT_List:
+		pair := t.Value.(*ds.ListPair)
+		depth = max(termDepth(pair.Head), termDepth(pair.Tail))
+	case ds.LT_Structure:
+		for _, arg := range t.Value.(*ds.StructureTerm).Args {
+			depth = max(depth, termDepth(arg))
+		}
+	default:
+		return 0
+	}
+	return depth + 1
+}
+
+func containsFact(facts []*Fact, f *Fact) bool {
+	for _, g := range facts {
+		if g == f {
+			return true
+		}
+	}
+	return false
+}
+
+func containsJustification(js []*Justification, j *Justification) bool {
+	for _, x := range js {
+		if x == j {
+			return true
+		}
+	}
+	return false
+}
+
+func removeFact(facts []*Fact, f *Fact) []*Fact {
+	out := facts[:0]
+	for _, g := range facts {
+		if g != f {
+			out = append(out, g)
+		}
+	}
+	return out
+}
+
+func removeJustification(js []*Justification, j *Justification) []*Justification {
+	out := js[:0]
+	for _, x := range js {
+		if x != j {
+			out = append(out, x)
+		}
+	}
+	return out
+}
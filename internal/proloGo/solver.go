@@ -0,0 +1,248 @@
+// /nexusl/internal/proloGo/solver.go
+// .
+// Resolvedor SLD de ProloGo
+// .
+// El resolvedor recorre el árbol de búsqueda en profundidad con
+// continuaciones: probar un objetivo significa llamar a la continuación k
+// una vez por cada solución, y al volver de k se deshacen las ligaduras
+// hechas desde el último punto de control del trail (Environment.Mark/Undo).
+// .
+// Cada llamada a un predicado abre un marco con un identificador propio.
+// Las funciones devuelven 0 cuando agotaron sus alternativas, o el
+// identificador de un marco cuando hay que abandonar la búsqueda hasta él:
+// así se implementan tanto el corte como la detención de una consulta.
+// .
+package prologo
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/devicemxl/nexusl/ds"
+)
+
+// DefaultMaxDepth limita la profundidad de la resolución para que una
+// recursión sin fin termine en error en lugar de agotar la pila.
+const DefaultMaxDepth = 10000
+
+var (
+	ErrDepthExceeded = errors.New("prologo: maximum resolution depth exceeded")
+	ErrInstantiation = errors.New("prologo: goal is an unbound variable")
+	ErrNotCallable   = errors.New("prologo: goal is not callable")
+)
+
+// Continuation es el resto del cómputo tras una solución.
+type Continuation func() int
+
+// Builtin implementa un predicado predefinido. Recibe los argumentos del
+// objetivo, el marco de la cláusula que lo llama (para el corte) y la
+// continuación; devuelve lo mismo que cualquier objetivo (ver arriba).
+type Builtin func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int
+
+// builtins son los predicados predefinidos, por indicador name/arity.
+var builtins = map[string]Builtin{
+	"true/0": func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int {
+		return k()
+	},
+	"=/2": func(s *Solver, args []*ds.Symbol, frame int, k Continuation) int {
+		return s.unifyThen(args[0], args[1], k)
+	},
+}
+
+// RegisterBuiltin agrega o reemplaza un predicado predefinido.
+func RegisterBuiltin(name string, arity int, fn Builtin) {
+	builtins[Indicator(name, arity)] = fn
+}
+
+// Solution asocia el nombre de cada variable de la consulta con su valor.
+type Solution map[string]*ds.Symbol
+
+// Solver resuelve consultas contra una base de conocimiento.
+type Solver struct {
+	KB       *KnowledgeBase
+	Env      *Environment
+	MaxDepth int
+	// KeepTables evita que las tablas de respuestas se descarten cuando
+	// cambia la base de conocimiento (ver tabling.go).
+	KeepTables bool
+
+	tabling tabling
+	frames  int        // Último identificador de marco asignado.
+	depth   int        // Profundidad actual de la resolución.
+	halt    int        // Marco de la consulta en curso; devolverlo la detiene.
+	err     error      // Primer error encontrado; detiene la consulta.
+	proof   *proofNode // Nodo de prueba en curso; nil si no se registran (ver proof.go).
+
+	// Tracer recibe los eventos de los predicados trazados (ver trace.go).
+	Tracer   Tracer
+	traced   map[string]bool
+	traceAll bool
+	skipping int // Cajas saltadas en curso; mientras sea > 0 no hay eventos.
+	level    int // Cajas abiertas (ver boxGoal).
+}
+
+// NewSolver crea un resolvedor para kb.
+func NewSolver(kb *KnowledgeBase) *Solver {
+	return &Solver{KB: kb, Env: NewEnvironment(), MaxDepth: DefaultMaxDepth}
+}
+
+// Solve busca las soluciones de la conjunción goals y llama a yield con cada
+// una, hasta agotarlas o hasta que yield devuelva false. Al terminar, el
+// entorno queda como estaba.
+func (s *Solver) Solve(goals []*ds.Symbol, yield func(Solution) bool) error {
+	var vars []*ds.Symbol
+	seen := make(map[string]bool)
+	for _, goal := range goals {
+		for _, v := range Variables(goal, s.Env) {
+			if v.PublicName != "" && v.PublicName != "_" && !seen[v.PublicName] {
+				seen[v.PublicName] = true
+				vars = append(vars, v)
+			}
+		}
+	}
+
+	prevHalt, prevErr := s.halt, s.err
+	s.halt, s.err = s.newFrame(), nil
+	mark := s.Env.Mark()
+	s.solveGoals(goals, s.halt, func() int {
+		sol := make(Solution, len(vars))
+		for _, v := range vars {
+			sol[v.PublicName] = Resolve(v, s.Env)
+		}
+		if !yield(sol) {
+			return s.halt
+		}
+		return 0
+	})
+	s.Env.Undo(mark)
+	err := s.err
+	s.halt, s.err = prevHalt, prevErr
+	return err
+}
+
+// Prove indica si la conjunción goals tiene al menos una solución.
+func (s *Solver) Prove(goals ...*ds.Symbol) (bool, error) {
+	found := false
+	err := s.Solve(goals, func(Solution) bool {
+		found = true
+		return false
+	})
+	return found, err
+}
+
+// All devuelve todas las soluciones de la conjunción goals.
+func (s *Solver) All(goals ...*ds.Symbol) ([]Solution, error) {
+	var sols []Solution
+	err := s.Solve(goals, func(sol Solution) bool {
+		sols = append(sols, sol)
+		return true
+	})
+	return sols, err
+}
+
+// newFrame reserva un identificador de marco.
+func (s *Solver) newFrame() int {
+	s.frames++
+	return s.frames
+}
+
+// fail registra el primer error y detiene la consulta en curso.
+func (s *Solver) fail(err error) int {
+	if s.err == nil {
+		s.err = err
+	}
+	return s.halt
+}
+
+// solveGoals prueba una conjunción de izquierda a derecha. frame es el marco
+// de la cláusula a la que pertenecen los objetivos.
+func (s *Solver) solveGoals(goals []*ds.Symbol, frame int, k Continuation) int {
+	if len(goals) == 0 {
+		return k()
+	}
+	rest := goals[1:]
+	return s.solveGoal(goals[0], frame, func() int {
+		return s.solveGoals(rest, frame, k)
+	})
+}
+
+// solveGoal prueba un objetivo con los predicados predefinidos o con las cláusulas de la base.
+func (s *Solver) solveGoal(goal *ds.Symbol, frame int, k Continuation) int {
+	goal = Deref(goal, s.Env)
+	if goal.LogicalType == ds.LT_Variable {
+		return s.fail(ErrInstantiation)
+	}
+	name, arity, ok := Functor(goal)
+	if !ok {
+		return s.fail(fmt.Errorf("%w: %s", ErrNotCallable, Format(goal, s.Env)))
+	}
+
+	s.depth++
+	defer func() { s.depth-- }()
+	if s.depth > s.MaxDepth {
+		return s.fail(fmt.Errorf("%w (%d) while solving %s", ErrDepthExceeded, s.MaxDepth, Indicator(name, arity)))
+	}
+
+	ind := Indicator(name, arity)
+	fn, builtin := builtins[ind]
+	if builtin && transparentGoals[ind] {
+		return fn(s, Args(goal), frame, k)
+	}
+	kind, run := ProofClause, func(k Continuation) int {
+		return s.solveClauses(goal, s.KB.Clauses(name, arity), k)
+	}
+	switch {
+	case builtin:
+		kind, run = ProofBuiltin, func(k Continuation) int { return fn(s, Args(goal), frame, k) }
+	case s.KB.Tabled(name, arity):
+		kind, run = ProofTable, func(k Continuation) int { return s.solveTabled(goal, name, arity, k) }
+	}
+	return s.boxGoal(goal, ind, k, func(k Continuation) int {
+		return s.traceGoal(goal, kind, k, run)
+	})
+}
+
+// solveClauses prueba las cláusulas de un predicado en orden. Un corte
+// dentro de una de ellas devuelve el marco de esta llamada, lo que descarta
+// las cláusulas restantes.
+func (s *Solver) solveClauses(goal *ds.Symbol, clauses []*Clause, k Continuation) int {
+	callFrame := s.newFrame()
+	for _, c := range clauses {
+		if c.Truth != ds.TV_True {
+			continue
+		}
+		mark := s.Env.Mark()
+		fresh := make(map[ds.SymbolID]*ds.Symbol)
+		head := rename(c.Head, fresh)
+		if node := s.proof; node != nil {
+			node.clause, node.fresh, node.children = c, fresh, node.children[:0]
+		}
+		r := 0
+		if Unify(goal, head, s.Env) {
+			body := make([]*ds.Symbol, len(c.Body))
+			for i, b := range c.Body {
+				body[i] = rename(b, fresh)
+			}
+			r = s.solveGoals(body, callFrame, k)
+		}
+		s.Env.Undo(mark)
+		if r == callFrame {
+			return 0
+		}
+		if r != 0 {
+			return r
+		}
+	}
+	return 0
+}
+
+// unifyThen unifica a y b y, si lo logra, continúa con k.
+func (s *Solver) unifyThen(a, b *ds.Symbol, k Continuation) int {
+	mark := s.Env.Mark()
+	r := 0
+	if Unify(a, b, s.Env) {
+		r = k()
+	}
+	s.Env.Undo(mark)
+	return r
+}
@@ -0,0 +1,905 @@
+package prologo_test
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/devicemxl/nexusl/ds"
+	prologo "github.com/devicemxl/nexusl/internal/proloGo"
+)
+
+var (
+	atom = prologo.Atom
+	v    = prologo.Var
+	f    = prologo.Compound
+)
+
+func familyKB(t *testing.T) *prologo.KnowledgeBase {
+	t.Helper()
+	kb := prologo.NewKnowledgeBase()
+	kb.AddFact(f("parent", atom("tom"), atom("bob")))
+	kb.AddFact(f("parent", atom("bob"), atom("ann")))
+	kb.AddFact(f("parent", atom("bob"), atom("pat")))
+	x, y, z := v("X"), v("Y"), v("Z")
+	kb.AddRule(f("grandparent", x, z), f("parent", x, y), f("parent", y, z))
+	return kb
+}
+
+func TestSolveRules(t *testing.T) {
+	s := prologo.NewSolver(familyKB(t))
+	sols, err := s.All(f("grandparent", atom("tom"), v("Who")))
+	if err != nil {
+		t.Fatalf("All: %v", err)
+	}
+	var got []string
+	for _, sol := range sols {
+		got = append(got, prologo.Format(sol["Who"], nil))
+	}
+	if len(got) != 2 || got[0] != "ann" || got[1] != "pat" {
+		t.Errorf("grandchildren of tom = %v, want [ann pat]", got)
+	}
+	if len(s.Env.Bindings) != 0 {
+		t.Errorf("Solve left %d bindings behind", len(s.Env.Bindings))
+	}
+}
+
+func TestDepthLimit(t *testing.T) {
+	kb := prologo.NewKnowledgeBase()
+	x := v("X")
+	kb.AddRule(f("loop", x), f("loop", x))
+	s := prologo.NewSolver(kb)
+	s.MaxDepth = 100
+	if _, err := s.Prove(f("loop", atom("a"))); err == nil {
+		t.Fatal("expected a depth error for an infinite recursion")
+	}
+}
+
+func TestConnectives(t *testing.T) {
+	cases := []struct {
+		logic ds.Logic
+		op    string
+		a, b  ds.TruthValue
+		want  ds.TruthValue
+	}{
+		{ds.Kleene, "and", ds.TV_True, ds.TV_Maybe, ds.TV_Maybe},
+		{ds.Kleene, "and", ds.TV_False, ds.TV_Unknown, ds.TV_False},
+		{ds.Kleene, "or", ds.TV_Maybe, ds.TV_Unknown, ds.TV_Unknown},
+		{ds.Kleene, "imply", ds.TV_Maybe, ds.TV_Maybe, ds.TV_Maybe},
+		{ds.Lukasiewicz, "imply", ds.TV_Maybe, ds.TV_Maybe, ds.TV_True},
+		{ds.Lukasiewicz, "imply", ds.TV_True, ds.TV_Unknown, ds.TV_Unknown},
+	}
+	for _, c := range cases {
+		var got ds.TruthValue
+		switch c.op {
+		case "and":
+			got = c.logic.And(c.a, c.b)
+		case "or":
+			got = c.logic.Or(c.a, c.b)
+		case "imply":
+			got = c.logic.Imply(c.a, c.b)
+		}
+		if got != c.want {
+			t.Errorf("%s %s(%s, %s) = %s, want %s", c.logic, c.op, c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestOpenAndClosedWorld(t *testing.T) {
+	kb := familyKB(t)
+	kb.AddFactWithTruth(f("parent", atom("ann"), atom("joe")), ds.TV_False)
+	kb.AddFactWithTruth(f("sensor", atom("door")), ds.TV_Maybe)
+	s := prologo.NewSolver(kb)
+
+	cwa := prologo.QueryOptions{World: prologo.ClosedWorld}
+	owa := prologo.QueryOptions{World: prologo.OpenWorld}
+	cases := []struct {
+		formula *ds.Symbol
+		opts    prologo.QueryOptions
+		want    ds.TruthValue
+	}{
+		{f("parent", atom("tom"), atom("bob")), owa, ds.TV_True},
+		{f("parent", atom("tom"), atom("joe")), cwa, ds.TV_False},
+		{f("parent", atom("tom"), atom("joe")), owa, ds.TV_Unknown},
+		{f("parent", atom("ann"), atom("joe")), owa, ds.TV_False}, // Falso explícito.
+		{f("sensor", atom("door")), cwa, ds.TV_Maybe},
+		{f("not", f("parent", atom("tom"), atom("joe"))), owa, ds.TV_Unknown},
+		{f("or", f("parent", atom("tom"), atom("joe")), f("grandparent", atom("tom"), atom("ann"))), owa, ds.TV_True},
+		{f("and", atom("maybe"), f("parent", atom("tom"), atom("joe"))), owa, ds.TV_Unknown},
+	}
+	for _, c := range cases {
+		got, err := s.Ask(c.formula, c.opts)
+		if err != nil {
+			t.Fatalf("Ask(%s): %v", prologo.Format(c.formula, nil), err)
+		}
+		if got != c.want {
+			t.Errorf("Ask(%s) under %s = %s, want %s", prologo.Format(c.formula, nil), c.opts.World, got, c.want)
+		}
+	}
+}
+
+func names(t *testing.T, list *ds.Symbol) string {
+	t.Helper()
+	return prologo.Format(list, nil)
+}
+
+func TestControlBuiltins(t *testing.T) {
+	kb := familyKB(t)
+	x, y := v("X"), v("Y")
+	// first_child(X, Y) :- parent(X, Y), !.
+	kb.AddRule(f("first_child", x, y), f("parent", x, y), atom("!"))
+	// childless(X) :- person(X), not(parent(X, _)).
+	for _, p := range []string{"tom", "bob", "ann", "pat"} {
+		kb.AddFact(f("person", atom(p)))
+	}
+	c := v("C")
+	kb.AddRule(f("childless", c), f("person", c), f("not", f("parent", c, v("_"))))
+	s := prologo.NewSolver(kb)
+
+	sols, _ := s.All(f("first_child", atom("bob"), v("Who")))
+	if len(sols) != 1 || prologo.Format(sols[0]["Who"], nil) != "ann" {
+		t.Errorf("cut did not prune: %v", sols)
+	}
+	sols, _ = s.All(f("childless", v("Who")))
+	if len(sols) != 2 {
+		t.Errorf("childless = %d solutions, want 2", len(sols))
+	}
+	if ok, _ := s.Prove(atom("fail")); ok {
+		t.Error("fail succeeded")
+	}
+
+	p, q := v("P"), v("Q")
+	forAll := f("for_all", f("parent", atom("bob"), p), f("person", p))
+	if ok, _ := s.Prove(forAll); !ok {
+		t.Error("for_all over bob's children should hold")
+	}
+	if ok, _ := s.Prove(f("for_all", f("person", q), f("parent", q, v("_")))); ok {
+		t.Error("for_all should fail: not every person is a parent")
+	}
+	if sols, _ := s.All(f("exist", f("parent", atom("bob"), v("Any")))); len(sols) != 1 {
+		t.Errorf("exist should succeed once, got %d", len(sols))
+	}
+}
+
+func TestCollectAll(t *testing.T) {
+	kb := familyKB(t)
+	kb.AddFact(f("parent", atom("tom"), atom("liz")))
+	s := prologo.NewSolver(kb)
+
+	ch, par, list := v("Ch"), v("Par"), v("L")
+	sols, err := s.All(f("collect_all", ch, f("parent", v("_"), ch), list))
+	if err != nil || len(sols) != 1 {
+		t.Fatalf("collect_all: %v %v", sols, err)
+	}
+	if got := names(t, sols[0]["L"]); got != "[bob, ann, pat, liz]" {
+		t.Errorf("collect_all = %s", got)
+	}
+
+	// bagof agrupa por la variable libre Par.
+	sols, _ = s.All(f("bagof", ch, f("parent", par, ch), list))
+	if len(sols) != 2 {
+		t.Fatalf("bagof groups = %d, want 2", len(sols))
+	}
+	if got := names(t, sols[0]["L"]); got != "[bob, liz]" {
+		t.Errorf("bagof first group = %s", got)
+	}
+
+	// setof con Par^ cuantificado ordena y elimina duplicados.
+	sols, _ = s.All(f("setof", par, f("^", ch, f("parent", par, ch)), list))
+	if len(sols) != 1 || names(t, sols[0]["L"]) != "[bob, tom]" {
+		t.Errorf("setof = %v", sols)
+	}
+	if ok, _ := s.Prove(f("bagof", ch, f("parent", atom("ann"), ch), list)); ok {
+		t.Error("bagof must fail when there are no solutions")
+	}
+}
+
+func TestTabledLeftRecursion(t *testing.T) {
+	kb := prologo.NewKnowledgeBase()
+	// Un grafo con ciclo: hall -> lab -> office -> hall, y office -> exit.
+	for _, e := range [][2]string{{"hall", "lab"}, {"lab", "office"}, {"office", "hall"}, {"office", "exit"}} {
+		kb.AddFact(f("edge", atom(e[0]), atom(e[1])))
+	}
+	x, y, z := v("X"), v("Y"), v("Z")
+	kb.AddRule(f("reachable", x, z), f("reachable", x, y), f("edge", y, z))
+	a, b := v("A"), v("B")
+	kb.AddRule(f("reachable", a, b), f("edge", a, b))
+	kb.Table("reachable", 2)
+
+	s := prologo.NewSolver(kb)
+	sols, err := s.All(f("reachable", atom("hall"), v("To")))
+	if err != nil {
+		t.Fatalf("tabled query: %v", err)
+	}
+	if len(sols) != 4 {
+		t.Errorf("hall reaches %d places, want 4 (hall, lab, office, exit)", len(sols))
+	}
+	if answers, complete := s.TableAnswers(f("reachable", atom("hall"), v("Any"))); !complete || len(answers) != 4 {
+		t.Errorf("table = %d answers, complete=%v", len(answers), complete)
+	}
+
+	// Un cambio en la base invalida las tablas.
+	kb.AddFact(f("edge", atom("exit"), atom("street")))
+	sols, _ = s.All(f("reachable", atom("hall"), v("To")))
+	if len(sols) != 5 {
+		t.Errorf("after adding an edge hall reaches %d places, want 5", len(sols))
+	}
+
+	// Sin tabulación la misma regla no termina.
+	kb2 := prologo.NewKnowledgeBase()
+	kb2.AddFact(f("edge", atom("a"), atom("b")))
+	kb2.AddRule(f("reachable", x, z), f("reachable", x, y), f("edge", y, z))
+	plain := prologo.NewSolver(kb2)
+	plain.MaxDepth = 200
+	if _, err := plain.All(f("reachable", atom("a"), v("To"))); err == nil {
+		t.Error("expected the untabled left recursion to exceed the depth limit")
+	}
+}
+
+func TestTabledMutualRecursion(t *testing.T) {
+	kb := prologo.NewKnowledgeBase()
+	kb.AddFact(f("link", atom("a"), atom("b")))
+	kb.AddFact(f("link", atom("b"), atom("c")))
+	kb.AddFact(f("link", atom("c"), atom("a")))
+	x, y, z := v("X"), v("Y"), v("Z")
+	// Caminos de longitud par e impar en un ciclo de 3: ambos llegan a todos los nodos.
+	kb.AddRule(f("odd", x, y), f("link", x, y))
+	kb.AddRule(f("odd", x, z), f("even", x, y), f("link", y, z))
+	kb.AddRule(f("even", x, z), f("odd", x, y), f("link", y, z))
+	kb.Table("odd", 2)
+	kb.Table("even", 2)
+
+	s := prologo.NewSolver(kb)
+	for _, pred := range []string{"odd", "even"} {
+		sols, err := s.All(f(pred, atom("a"), v("N")))
+		if err != nil || len(sols) != 3 {
+			t.Errorf("%s(a, N) = %d solutions (%v), want 3", pred, len(sols), err)
+		}
+	}
+}
+
+func TestNetworkIncremental(t *testing.T) {
+	kb := familyKB(t)
+	x, y, z := v("X"), v("Y"), v("Z")
+	kb.AddRule(f("ancestor", x, y), f("parent", x, y))
+	kb.AddRule(f("ancestor", x, z), f("ancestor", x, y), f("parent", y, z))
+	n, err := prologo.NetworkFromKB(kb)
+	if err != nil {
+		t.Fatalf("NetworkFromKB: %v", err)
+	}
+	var added []string
+	n.OnChange = func(kind prologo.ChangeKind, fact *prologo.Fact) {
+		if kind == prologo.FactAdded {
+			added = append(added, fact.String())
+		}
+	}
+
+	if _, ok := n.Lookup(f("grandparent", atom("tom"), atom("ann"))); !ok {
+		t.Fatal("grandparent(tom, ann) was not materialised")
+	}
+	if _, err := n.Assert(f("parent", atom("ann"), atom("joe"))); err != nil {
+		t.Fatalf("Assert: %v", err)
+	}
+	for _, want := range []string{"grandparent(bob, joe)", "ancestor(tom, joe)"} {
+		found := false
+		for _, got := range added {
+			found = found || got == want
+		}
+		if !found {
+			t.Errorf("asserting parent(ann, joe) did not derive %s; got %v", want, added)
+		}
+	}
+
+	fact, _ := n.Lookup(f("grandparent", atom("bob"), atom("joe")))
+	if len(fact.Justifications) != 1 || len(fact.Justifications[0].Premises) != 2 {
+		t.Fatalf("grandparent(bob, joe) justifications = %v", fact.Justifications)
+	}
+
+	removed, err := n.Retract(f("parent", atom("ann"), atom("joe")))
+	if err != nil {
+		t.Fatalf("Retract: %v", err)
+	}
+	if len(removed) != 5 { // parent, grandparent y tres ancestor.
+		t.Errorf("Retract removed %v, want 5 facts", removed)
+	}
+	if _, ok := n.Lookup(f("ancestor", atom("tom"), atom("joe"))); ok {
+		t.Error("ancestor(tom, joe) survived the retraction of its premise")
+	}
+	if _, err := n.Retract(f("grandparent", atom("tom"), atom("ann"))); err == nil {
+		t.Error("retracting a derived fact should fail")
+	}
+}
+
+func TestNetworkCyclicSupport(t *testing.T) {
+	x, y := v("X"), v("Y")
+	n, err := prologo.NewNetwork(
+		&prologo.Rule{Name: "sym", Conditions: []*ds.Symbol{f("link", x, y)}, Conclusions: []*ds.Symbol{f("link", y, x)}},
+	)
+	if err != nil {
+		t.Fatalf("NewNetwork: %v", err)
+	}
+	n.Assert(f("link", atom("a"), atom("b")))
+	n.Assert(f("link", atom("b"), atom("a")))
+	if _, err := n.Retract(f("link", atom("a"), atom("b"))); err != nil {
+		t.Fatalf("Retract: %v", err)
+	}
+	if fact, ok := n.Lookup(f("link", atom("a"), atom("b"))); !ok || fact.Asserted {
+		t.Error("link(a, b) should remain, derived from link(b, a)")
+	}
+	removed, _ := n.Retract(f("link", atom("b"), atom("a")))
+	if len(removed) != 2 || len(n.Facts()) != 0 {
+		t.Errorf("the self-supporting cycle survived: removed %v, left %v", removed, n.Facts())
+	}
+}
+
+func TestNetworkLoopGuard(t *testing.T) {
+	x := v("X")
+	n, err := prologo.NewNetwork(
+		&prologo.Rule{Name: "succ", Conditions: []*ds.Symbol{f("nat", x)}, Conclusions: []*ds.Symbol{f("nat", f("s", x))}},
+	)
+	if err != nil {
+		t.Fatalf("NewNetwork: %v", err)
+	}
+	n.MaxTermDepth = 10
+	if _, err := n.Assert(f("nat", atom("zero"))); !errors.Is(err, prologo.ErrDerivationLimit) {
+		t.Errorf("Assert error = %v, want ErrDerivationLimit", err)
+	}
+	unsafe := &prologo.Rule{Name: "bad", Conditions: []*ds.Symbol{f("nat", x)}, Conclusions: []*ds.Symbol{f("nat", v("Y"))}}
+	if err := n.AddRule(unsafe); !errors.Is(err, prologo.ErrUnsafeRule) {
+		t.Errorf("AddRule error = %v, want ErrUnsafeRule", err)
+	}
+}
+
+func TestTMSSupportCounting(t *testing.T) {
+	kb := prologo.NewKnowledgeBase()
+	x, y := v("X"), v("Y")
+	kb.AddRule(f("unsafe", x), f("open", x))
+	kb.AddRule(f("unsafe", x), f("alarm", y), f("near", y, x))
+	kb.AddFact(f("open", atom("door")))
+	kb.AddFact(f("alarm", atom("smoke")))
+	kb.AddFact(f("near", atom("smoke"), atom("door")))
+	tms, err := prologo.NewTMS(kb)
+	if err != nil {
+		t.Fatalf("NewTMS: %v", err)
+	}
+
+	door := f("unsafe", atom("door"))
+	if got := tms.Supports(door); got != 2 {
+		t.Fatalf("Supports(unsafe(door)) = %d, want 2", got)
+	}
+	why, err := tms.Why(door)
+	if err != nil {
+		t.Fatalf("Why: %v", err)
+	}
+	want := "unsafe(door)\n" +
+		"  because unsafe/1#1:\n" +
+		"    open(door) [asserted]\n" +
+		"  because unsafe/1#2:\n" +
+		"    alarm(smoke) [asserted]\n" +
+		"    near(smoke, door) [asserted]\n"
+	if why.String() != want {
+		t.Errorf("Why(unsafe(door)) =\n%s\nwant\n%s", why, want)
+	}
+
+	if _, err := tms.Retract(f("open", atom("door"))); err != nil {
+		t.Fatalf("Retract: %v", err)
+	}
+	if !tms.Believed(door) || tms.Supports(door) != 1 {
+		t.Errorf("unsafe(door) should keep its alarm support, has %d", tms.Supports(door))
+	}
+	if found, _ := prologo.NewSolver(kb).Prove(f("open", atom("door"))); found {
+		t.Error("open(door) is still in the knowledge base")
+	}
+
+	removed, err := tms.RetractTriplet(ds.NewTriplet(atom("smoke"), atom("alarm_off"), atom("x"), nil))
+	if err == nil || removed != nil {
+		t.Errorf("retracting an unknown triplet: removed %v, err %v", removed, err)
+	}
+	removed, err = tms.RetractTriplet(ds.NewTriplet(atom("smoke"), atom("near"), atom("door"), nil))
+	if err != nil {
+		t.Fatalf("RetractTriplet: %v", err)
+	}
+	if len(removed) != 2 || tms.Believed(door) {
+		t.Errorf("retracting near(smoke, door) removed %v; unsafe(door) believed = %v", removed, tms.Believed(door))
+	}
+	if _, err := tms.Why(door); !errors.Is(err, prologo.ErrNotBelieved) {
+		t.Errorf("Why after retraction: err = %v, want ErrNotBelieved", err)
+	}
+}
+
+func TestProofTree(t *testing.T) {
+	s := prologo.NewSolver(familyKB(t))
+	var proofs [][]*prologo.Proof
+	err := s.SolveWithProofs([]*ds.Symbol{f("grandparent", atom("tom"), v("Who"))}, func(_ prologo.Solution, p []*prologo.Proof) bool {
+		proofs = append(proofs, p)
+		return true
+	})
+	if err != nil {
+		t.Fatalf("SolveWithProofs: %v", err)
+	}
+	if len(proofs) != 2 {
+		t.Fatalf("got %d proofs, want 2", len(proofs))
+	}
+	want := "grandparent(tom, pat)  by grandparent(X, Z) :- parent(X, Y), parent(Y, Z).  {X = tom, Z = pat, Y = bob}\n" +
+		"  parent(tom, bob)  [fact]\n" +
+		"  parent(bob, pat)  [fact]\n"
+	if got := proofs[1][0].String(); got != want {
+		t.Errorf("second proof =\n%s\nwant\n%s", got, want)
+	}
+
+	data, err := json.Marshal(proofs[0][0])
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var decoded struct {
+		Goal     string            `json:"goal"`
+		Unifier  map[string]string `json:"unifier"`
+		Children []struct {
+			Goal string `json:"goal"`
+			Kind string `json:"kind"`
+		} `json:"children"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if decoded.Goal != "grandparent(tom, ann)" || decoded.Unifier["Y"] != "bob" ||
+		len(decoded.Children) != 2 || decoded.Children[1].Goal != "parent(bob, ann)" || decoded.Children[1].Kind != "clause" {
+		t.Errorf("unexpected JSON proof: %s", data)
+	}
+
+	proof, err := s.Explain(f("grandparent", atom("ann"), v("Who")))
+	if err != nil || proof != nil {
+		t.Errorf("Explain of an unprovable goal = %v, %v; want nil", proof, err)
+	}
+}
+
+func TestTracePorts(t *testing.T) {
+	s := prologo.NewSolver(familyKB(t))
+	var out strings.Builder
+	s.Tracer = &prologo.TraceWriter{Out: &out}
+	s.Trace("grandparent/2")
+	if _, err := s.All(f("grandparent", atom("tom"), v("Who"))); err != nil {
+		t.Fatalf("All: %v", err)
+	}
+	want := " Call: (1) grandparent(tom, Who)\n" +
+		" Exit: (1) grandparent(tom, ann)\n" +
+		" Redo: (1) grandparent(tom, ann)\n" +
+		" Exit: (1) grandparent(tom, pat)\n" +
+		" Redo: (1) grandparent(tom, pat)\n" +
+		" Fail: (1) grandparent(tom, Who)\n"
+	if out.String() != want {
+		t.Errorf("trace =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestDebuggerScript(t *testing.T) {
+	s := prologo.NewSolver(familyKB(t))
+	script := strings.Join([]string{
+		"break parent/2", // En Call de grandparent: pone el punto de ruptura...
+		"l",              // ...y salta hasta él.
+		"b",              // En Call de parent(tom, Y): muestra las ligaduras.
+		"s",              // Ejecuta la caja sin entrar.
+		"c",              // Exit de parent(tom, bob).
+		"f",              // Call de parent(bob, Z): fuerza su fallo.
+		"c", "c", "c",
+	}, "\n")
+	var out strings.Builder
+	d := prologo.NewDebugger(strings.NewReader(script), &out)
+	d.Attach(s)
+	sols, err := s.All(f("grandparent", atom("tom"), v("Who")))
+	if err != nil {
+		t.Fatalf("All: %v", err)
+	}
+	if len(sols) != 0 {
+		t.Errorf("forcing parent(bob, Z) to fail should leave no solutions, got %v", sols)
+	}
+	for _, want := range []string{
+		"Call: (1) grandparent(tom, Who) ?\n",
+		"Call: (2) parent(tom, Y) ?\nX = tom\nY = Y\n",
+		"Exit: (2) parent(tom, bob) ?\n",
+		"Call: (2) parent(bob, Z) ?\n",
+		"Fail: (2) parent(bob, Z) ?\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("debugger output lacks %q:\n%s", want, out.String())
+		}
+	}
+	if got := d.Breakpoints(); len(got) != 1 || got[0] != "parent/2" {
+		t.Errorf("Breakpoints() = %v", got)
+	}
+
+	s = prologo.NewSolver(familyKB(t))
+	prologo.NewDebugger(strings.NewReader("a\n"), &out).Attach(s)
+	if _, err := s.Prove(f("grandparent", atom("tom"), v("Who"))); !errors.Is(err, prologo.ErrTraceAborted) {
+		t.Errorf("abort: err = %v, want ErrTraceAborted", err)
+	}
+}
+
+// fdInts devuelve los valores de vars en una solución, separados por espacios.
+func fdInts(sol prologo.Solution, vars ...string) string {
+	parts := make([]string, len(vars))
+	for i, name := range vars {
+		parts[i] = prologo.Format(sol[name], nil)
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestFDLabeling(t *testing.T) {
+	s := prologo.NewSolver(prologo.NewKnowledgeBase())
+	x, y, z := v("X"), v("Y"), v("Z")
+	vars := prologo.List(x, y, z)
+	sols, err := s.All(
+		f("domain", vars, prologo.Int(1), prologo.Int(3)),
+		f("all_different", vars),
+		f("#<", x, y),
+		f("labeling", prologo.List(atom("down")), vars),
+	)
+	if err != nil {
+		t.Fatalf("All: %v", err)
+	}
+	var got []string
+	for _, sol := range sols {
+		got = append(got, fdInts(sol, "X", "Y", "Z"))
+	}
+	if want := "2 3 1,1 3 2,1 2 3"; strings.Join(got, ",") != want {
+		t.Errorf("labeling(down) = %v, want %s", got, want)
+	}
+
+	// Sin domain no hay valores que enumerar.
+	if _, err := s.All(f("#>", x, prologo.Int(0)), f("label", prologo.List(x))); !errors.Is(err, prologo.ErrUnboundedDomain) {
+		t.Errorf("label without domain: err = %v", err)
+	}
+	// La propagación sola fija las variables cuando puede.
+	sols, _ = s.All(f("domain", prologo.List(x, y), prologo.Int(0), prologo.Int(5)), f("#=", f("+", x, y), prologo.Int(10)))
+	if len(sols) != 1 || fdInts(sols[0], "X", "Y") != "5 5" {
+		t.Errorf("X+Y #= 10 in 0..5 = %v", sols)
+	}
+}
+
+func TestFDLargeCoefficients(t *testing.T) {
+	s := prologo.NewSolver(prologo.NewKnowledgeBase())
+	x := v("X")
+	big := prologo.Int(1 << 62)
+	labels := func(goals ...*ds.Symbol) string {
+		t.Helper()
+		goals = append([]*ds.Symbol{f("domain", x, prologo.Int(-5), prologo.Int(5))}, goals...)
+		sols, err := s.All(append(goals, f("label", prologo.List(x)))...)
+		if err != nil {
+			t.Fatalf("All: %v", err)
+		}
+		var got []string
+		for _, sol := range sols {
+			got = append(got, fdInts(sol, "X"))
+		}
+		return strings.Join(got, " ")
+	}
+	// 2^62·X desborda int64 con |X| ≥ 2; las cotas no deben dar la vuelta.
+	if got := labels(f("#>", f("*", big, x), prologo.Int(1))); got != "1 2 3 4 5" {
+		t.Errorf("2^62*X #> 1 = %s, want 1 2 3 4 5", got)
+	}
+	if got := labels(f("#=<", f("*", big, x), f("-", prologo.Int(0), big))); got != "-5 -4 -3 -2 -1" {
+		t.Errorf("2^62*X #=< -2^62 = %s, want -5 -4 -3 -2 -1", got)
+	}
+	if got := labels(f(`#\=`, f("*", big, x), big), f("#>", x, prologo.Int(0))); got != "2 3 4 5" {
+		t.Errorf("2^62*X #\\= 2^62 = %s, want 2 3 4 5", got)
+	}
+	// Un coeficiente que no cabe en int64 es un error, no un número equivocado.
+	if _, err := s.All(f("#=", f("*", big, f("*", prologo.Int(4), x)), prologo.Int(0))); !errors.Is(err, prologo.ErrFDOverflow) {
+		t.Errorf("2^64*X: err = %v, want ErrFDOverflow", err)
+	}
+}
+
+func TestFDSendMoreMoney(t *testing.T) {
+	s := prologo.NewSolver(prologo.NewKnowledgeBase())
+	names := []string{"S", "E", "N", "D", "M", "O", "R", "Y"}
+	vars := make(map[string]*ds.Symbol)
+	var all []*ds.Symbol
+	for _, n := range names {
+		vars[n] = v(n)
+		all = append(all, vars[n])
+	}
+	word := func(letters ...string) *ds.Symbol {
+		var sum *ds.Symbol
+		for _, l := range letters {
+			if sum == nil {
+				sum = vars[l]
+			} else {
+				sum = f("+", f("*", prologo.Int(10), sum), vars[l])
+			}
+		}
+		return sum
+	}
+	list := prologo.List(all...)
+	sols, err := s.All(
+		f("domain", list, prologo.Int(0), prologo.Int(9)),
+		f("all_different", list),
+		f("#\\=", vars["S"], prologo.Int(0)),
+		f("#\\=", vars["M"], prologo.Int(0)),
+		f("#=", f("+", word("S", "E", "N", "D"), word("M", "O", "R", "E")), word("M", "O", "N", "E", "Y")),
+		f("labeling", prologo.List(atom("ff")), list),
+	)
+	if err != nil {
+		t.Fatalf("All: %v", err)
+	}
+	if len(sols) != 1 || fdInts(sols[0], names...) != "9 5 6 7 1 0 8 2" {
+		t.Errorf("SEND+MORE=MONEY = %v", sols)
+	}
+}
+
+func TestFDElementAndReification(t *testing.T) {
+	s := prologo.NewSolver(prologo.NewKnowledgeBase())
+	i, val, x, b := v("I"), v("V"), v("X"), v("B")
+	sols, _ := s.All(
+		f("element", i, prologo.List(prologo.Int(10), prologo.Int(20), prologo.Int(30)), val),
+		f("#>", val, prologo.Int(15)),
+		f("label", prologo.List(i)),
+	)
+	var got []string
+	for _, sol := range sols {
+		got = append(got, fdInts(sol, "I", "V"))
+	}
+	if strings.Join(got, ",") != "2 20,3 30" {
+		t.Errorf("element = %v", got)
+	}
+
+	// B refleja si X #= 2 se cumple, y fijar B impone la restricción o su negación.
+	sols, _ = s.All(f("domain", x, prologo.Int(1), prologo.Int(3)), f("reify_constraint", f("#=", x, prologo.Int(2)), b), f("label", prologo.List(x)))
+	got = nil
+	for _, sol := range sols {
+		got = append(got, fdInts(sol, "X", "B"))
+	}
+	if strings.Join(got, ",") != "1 0,2 1,3 0" {
+		t.Errorf("reify_constraint = %v", got)
+	}
+	sols, _ = s.All(f("domain", x, prologo.Int(1), prologo.Int(3)), f("#<==>", b, f("#=", x, prologo.Int(2))), f("=", b, prologo.Int(1)))
+	if len(sols) != 1 || fdInts(sols[0], "X") != "2" {
+		t.Errorf("B = 1 should fix X = 2: %v", sols)
+	}
+	sols, _ = s.All(f("reify_domain", x, prologo.Int(5), prologo.Int(9), b), f("=", x, prologo.Int(7)))
+	if len(sols) != 1 || fdInts(sols[0], "B") != "1" {
+		t.Errorf("reify_domain = %v", sols)
+	}
+
+	// Un valor fuera del dominio no unifica, y el retroceso deshace las podas.
+	if ok, _ := s.Prove(f("domain", x, prologo.Int(1), prologo.Int(3)), f("=", x, prologo.Int(4))); ok {
+		t.Error("X = 4 must fail with X in 1..3")
+	}
+	if d, _ := s.Domain(x); !d.Equal(prologo.FullDomain()) {
+		t.Errorf("domain after Solve = %s, want the full domain", d)
+	}
+}
+
+func TestAlgebra(t *testing.T) {
+	x, y, n := atom("x"), atom("y"), prologo.Int
+	cases := []struct {
+		name string
+		op   func() (*ds.Symbol, error)
+		want string
+	}{
+		{"simplify", func() (*ds.Symbol, error) { return prologo.Simplify(f("+", x, f("-", y, x))) }, "y"},
+		{"fraction", func() (*ds.Symbol, error) {
+			return prologo.Simplify(f("/", f("*", n(6), x), f("*", n(4), f("^", x, n(3)))))
+		}, "3/(2*x^2)"},
+		{"radicals", func() (*ds.Symbol, error) { return prologo.Simplify(f("*", f("sqrt", n(8)), f("sqrt", n(2)))) }, "4"},
+		{"expand", func() (*ds.Symbol, error) { return prologo.Expand(f("*", f("+", x, y), f("-", x, y))) }, "x^2 - y^2"},
+		{"chain rule", func() (*ds.Symbol, error) {
+			return prologo.Derive(f("+", f("*", n(3), f("^", x, n(3))), f("sin", f("*", n(2), x))), x)
+		}, "9*x^2 + 2*cos(2*x)"},
+		{"quotient", func() (*ds.Symbol, error) { return prologo.Derive(f("/", n(1), x), x) }, "-1/x^2"},
+		{"x^x", func() (*ds.Symbol, error) { return prologo.Derive(f("^", x, x), x) }, "x^x*(ln(x) + 1)"},
+		{"substitute", func() (*ds.Symbol, error) { return prologo.Substitute(f("*", x, f("+", x, y)), x, n(2)) }, "2*(y + 2)"},
+	}
+	for _, c := range cases {
+		got, err := c.op()
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if s := prologo.FormatExpr(got); s != c.want {
+			t.Errorf("%s = %s, want %s", c.name, s, c.want)
+		}
+		// La salida es una expresión válida y ya está en forma canónica.
+		if again, err := prologo.Simplify(got); err != nil || prologo.FormatExpr(again) != c.want {
+			t.Errorf("%s: simplifying %s again gives %v (%v)", c.name, c.want, again, err)
+		}
+	}
+
+	// Un divisor nulo es un error, como en la evaluación aritmética.
+	for name, op := range map[string]func() (*ds.Symbol, error){
+		"x/0":        func() (*ds.Symbol, error) { return prologo.Simplify(f("/", x, n(0))) },
+		"0^-1":       func() (*ds.Symbol, error) { return prologo.Simplify(f("^", n(0), n(-1))) },
+		"expand":     func() (*ds.Symbol, error) { return prologo.Expand(f("/", f("+", x, y), f("-", x, x))) },
+		"substitute": func() (*ds.Symbol, error) { return prologo.Substitute(f("/", x, y), y, n(0)) },
+	} {
+		if got, err := op(); !errors.Is(err, prologo.ErrDivisionByZero) {
+			t.Errorf("%s = %v, %v; want ErrDivisionByZero", name, got, err)
+		}
+	}
+
+	solve := func(eq *ds.Symbol) string {
+		roots, err := prologo.EqSolve(eq, x)
+		if err != nil {
+			return err.Error()
+		}
+		parts := make([]string, len(roots))
+		for i, r := range roots {
+			parts[i] = prologo.FormatExpr(r)
+		}
+		return strings.Join(parts, ", ")
+	}
+	if got := solve(f("=", f("^", x, n(2)), n(2))); got != "-sqrt(2), sqrt(2)" {
+		t.Errorf("x^2 = 2: %s", got)
+	}
+	if got := solve(f("+", f("*", atom("a"), x), atom("b"))); got != "-b/a" {
+		t.Errorf("a*x + b = 0: %s", got)
+	}
+	if _, err := prologo.EqSolve(f("^", x, n(3)), x); !errors.Is(err, prologo.ErrUnsolvable) {
+		t.Errorf("x^3 = 0: err = %v", err)
+	}
+
+	// Desde el resolvedor: derive/3 y eq_solve/3 sobre términos con variables ligadas.
+	s := prologo.NewSolver(prologo.NewKnowledgeBase())
+	e, d := v("E"), v("D")
+	sols, err := s.All(f("=", e, f("^", x, n(2))), f("derive", e, x, d), f("eq_solve", f("=", d, n(4)), x, v("Roots")))
+	if err != nil || len(sols) != 1 {
+		t.Fatalf("derive/eq_solve: %v %v", sols, err)
+	}
+	if d, roots := prologo.FormatExpr(sols[0]["D"]), prologo.Format(sols[0]["Roots"], nil); d != "2*x" || roots != "[2]" {
+		t.Errorf("D = %s, Roots = %s", d, roots)
+	}
+}
+
+func TestComplexArithmetic(t *testing.T) {
+	z := ds.NewComplexSymbol(complex(2, 3))
+	w := ds.NewComplexSymbol(complex(1, -1))
+	cases := []struct {
+		expr *ds.Symbol
+		want string
+	}{
+		{f("+", z, w), "3+2i"},
+		{f("*", z, w), "5+1i"},
+		{f("-", z, prologo.Int(2)), "3i"},
+		{f("conj", z), "2-3i"},
+		{f("abs", ds.NewComplexSymbol(complex(3, 4))), "5"},
+		{f("sqrt", prologo.Int(-4)), "2i"},
+		{f("*", atom("i"), atom("i")), "-1+0i"},
+		{f("/", prologo.Int(7), prologo.Int(2)), "7/2"},
+		{f("^", prologo.Int(2), prologo.Int(10)), "1024"},
+	}
+	for _, c := range cases {
+		got, err := prologo.Eval(c.expr, nil)
+		if err != nil {
+			t.Errorf("%s: %v", prologo.Format(c.expr, nil), err)
+			continue
+		}
+		if s := prologo.Format(got, nil); s != c.want {
+			t.Errorf("%s = %s, want %s", prologo.Format(c.expr, nil), s, c.want)
+		}
+	}
+
+	// Un fasor guardado como hecho unifica por valor con el literal equivalente.
+	kb := prologo.NewKnowledgeBase()
+	kb.AddFact(f("phasor", atom("v1"), z))
+	s := prologo.NewSolver(kb)
+	if ok, err := s.Prove(f("phasor", atom("v1"), ds.NewComplexSymbol(complex(2, 3)))); !ok || err != nil {
+		t.Errorf("phasor(v1, 2+3i) should hold: %v", err)
+	}
+	p, m := v("P"), v("M")
+	sols, err := s.All(f("phasor", atom("v1"), p), f("eval", f("*", p, f("conj", p)), m), f("=:=", m, prologo.Int(13)))
+	if err != nil || len(sols) != 1 {
+		t.Errorf("|P|^2 =:= 13: %v %v", sols, err)
+	}
+	if ok, _ := s.Prove(f("=:=", f("polar", prologo.Int(2), prologo.Int(0)), prologo.Int(2))); !ok {
+		t.Error("polar(2, 0) =:= 2 should hold")
+	}
+	if _, err := s.Prove(f("<", z, w)); !errors.Is(err, prologo.ErrComplexOrder) {
+		t.Errorf("2+3i < 1-1i: err = %v, want ErrComplexOrder", err)
+	}
+	if got, err := prologo.Simplify(f("+", z, w)); err != nil || prologo.FormatExpr(got) != "3+2i" {
+		t.Errorf("simplify(2+3i + 1-1i) = %v (%v)", got, err)
+	}
+}
+
+func TestNumericTower(t *testing.T) {
+	n := prologo.Int
+	big20, _ := new(big.Int).SetString("12345678901234567890", 10)
+	serial := ds.NewIntegerSymbol(big20)
+	price, _ := ds.ParseDecimal("19.99")
+	cases := []struct {
+		expr *ds.Symbol
+		want string
+	}{
+		{f("+", n(math.MaxInt64), n(1)), "9223372036854775808"},
+		{f("-", f("+", n(math.MaxInt64), n(1)), n(1)), "9223372036854775807"},
+		{f("*", serial, n(10)), "123456789012345678900"},
+		{f("/", n(1), n(3)), "1/3"},
+		{f("+", f("/", n(1), n(3)), f("/", n(2), n(3))), "1"},
+		{f("*", ds.NewDecimalSymbol(price), n(3)), "59.97d"},
+		{f("/", ds.NewDecimalSymbol(price), n(2)), "9.995d"},
+		{f("/", ds.NewDecimalSymbol(price), n(3)), "1999/300"},
+		{f("^", n(2), n(100)), "1267650600228229401496703205376"},
+		{f("^", n(2), n(-2)), "1/4"},
+		{f("mod", serial, n(7)), "1"},
+	}
+	for _, c := range cases {
+		got, err := prologo.Eval(c.expr, nil)
+		if err != nil {
+			t.Errorf("%s: %v", prologo.Format(c.expr, nil), err)
+			continue
+		}
+		if s := prologo.Format(got, nil); s != c.want {
+			t.Errorf("%s = %s, want %s", prologo.Format(c.expr, nil), s, c.want)
+		}
+	}
+
+	// La unificación compara por valor, sea cual sea la representación.
+	kb := prologo.NewKnowledgeBase()
+	kb.AddFact(f("serial", atom("item42"), serial))
+	kb.AddFact(f("price", atom("item42"), ds.NewDecimalSymbol(price)))
+	s := prologo.NewSolver(kb)
+	again, _ := new(big.Int).SetString("12345678901234567890", 10)
+	if ok, _ := s.Prove(f("serial", atom("item42"), ds.NewIntegerSymbol(again))); !ok {
+		t.Error("a 20-digit serial should unify with an equal big integer")
+	}
+	if ok, _ := s.Prove(f("serial", atom("item42"), ds.NewIntegerSymbol(new(big.Int).Add(again, big.NewInt(1))))); ok {
+		t.Error("serials that differ in the last digit must not unify")
+	}
+	if ok, _ := s.Prove(f("price", atom("item42"), ds.NewRationalSymbol(big.NewRat(1999, 100)))); !ok {
+		t.Error("19.99d should unify with 1999/100")
+	}
+	if ok, _ := s.Prove(f("=", n(2), ds.NewConstantSymbol("2.0", 2.0))); !ok {
+		t.Error("2 = 2.0 should hold")
+	}
+	if ok, _ := s.Prove(f("=", ds.NewRationalSymbol(big.NewRat(1, 10)), ds.NewConstantSymbol("0.1", 0.1))); ok {
+		t.Error("1/10 and the float 0.1 are different numbers")
+	}
+	if ok, _ := s.Prove(f("<", serial, f("+", serial, n(1)))); !ok {
+		t.Error("comparison must not lose precision on big integers")
+	}
+	if got, err := prologo.Simplify(f("+", f("/", n(1), n(3)), f("/", n(1), n(6)))); err != nil || prologo.FormatExpr(got) != "1/2" {
+		t.Errorf("simplify(1/3 + 1/6) = %v (%v)", got, err)
+	}
+}
+
+func TestTermsStayOutOfSymbolTable(t *testing.T) {
+	scope, ok := ds.LookupSymbolByPublicName("fact")
+	if !ok {
+		scope = ds.NewSymbolWithPublicName("fact", ds.TripletScopeType)
+	}
+	pred, ok := ds.LookupSymbolByPublicName("is")
+	if !ok {
+		pred = ds.NewSymbolWithPublicName("is", ds.PredicateType)
+	}
+	before := len(ds.SymbolsByID)
+
+	kb := prologo.NewKnowledgeBase()
+	kb.AddFact(f("is", atom("fact"), prologo.Int(1)))
+	for range 10 {
+		s := prologo.NewSolver(kb)
+		if _, err := s.All(f("is", atom("fact"), v("N"))); err != nil {
+			t.Fatalf("All: %v", err)
+		}
+		if _, err := s.All(f("findall", v("N"), f("is", atom("fact"), v("N")), v("L"))); err != nil {
+			t.Fatalf("findall: %v", err)
+		}
+		if _, err := prologo.Eval(f("+", prologo.Int(1), prologo.Int(2)), s.Env); err != nil {
+			t.Fatalf("Eval: %v", err)
+		}
+		ds.NewIntegerSymbol(big.NewInt(7))
+		ds.NewRationalSymbol(big.NewRat(1, 3))
+		ds.NewComplexSymbol(complex(2, 3))
+	}
+
+	if got, _ := ds.LookupSymbolByPublicName("fact"); got != scope {
+		t.Errorf("the engine replaced the public symbol %q: %v", "fact", got)
+	}
+	if got, _ := ds.LookupSymbolByPublicName("is"); got != pred {
+		t.Errorf("the engine replaced the public symbol %q: %v", "is", got)
+	}
+	if after := len(ds.SymbolsByID); after != before {
+		t.Errorf("the symbol table grew from %d to %d symbols", before, after)
+	}
+}
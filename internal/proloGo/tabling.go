@@ -0,0 +1,241 @@
+// /nexusl/internal/proloGo/tabling.go
+// .
+// Resolución tabulada (memorización de respuestas)
+// .
+// Con resolución SLD en profundidad, una regla recursiva por la izquierda
+// como ancestor(X, Z) :- ancestor(X, Y), parent(Y, Z) no termina. Los
+// predicados declarados con KnowledgeBase.Table se resuelven de otra forma:
+// .
+//   - Cada llamada se identifica por su variante (la llamada con sus
+//     variables renombradas canónicamente) y tiene una tabla de respuestas.
+//   - La primera llamada evalúa las cláusulas y guarda cada respuesta nueva;
+//     una llamada recursiva a una tabla en evaluación solo consume las
+//     respuestas encontradas hasta ese momento.
+//   - La evaluación se repite hasta que ninguna tabla obtiene respuestas
+//     nuevas (punto fijo). Las tablas que consumieron a otra que seguía en
+//     evaluación forman con ella un componente que se completa a la vez.
+//   - Una tabla completa responde directamente desde memoria.
+//
+// .
+// Las tablas se invalidan solas cuando cambia la base de conocimiento
+// (ver KnowledgeBase.Generation), salvo que KeepTables esté activo, y se
+// pueden descartar a mano con AbolishTables.
+// .
+package prologo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/devicemxl/nexusl/ds"
+)
+
+// tableStatus es el estado de evaluación de una tabla de respuestas.
+type tableStatus int
+
+const (
+	tableEvaluating tableStatus = iota // En la pila de evaluación.
+	tableIncomplete                    // Evaluada, pero depende de una tabla que aún no termina.
+	tableComplete                      // Contiene todas sus respuestas.
+)
+
+// answerTable guarda las respuestas de una variante de llamada.
+type answerTable struct {
+	goal    *ds.Symbol // Copia de la llamada, con sus variables propias.
+	answers []*ds.Symbol
+	seen    map[string]bool // Claves de variante de las respuestas.
+	status  tableStatus
+	index   int // Posición en la pila de evaluación.
+	leader  int // Posición más baja de la pila de la que depende.
+}
+
+// tabling es el estado de tabulación de un Solver.
+type tabling struct {
+	tables     map[string]*answerTable
+	stack      []*answerTable
+	pending    []*answerTable // Tablas incompletas a la espera de su líder.
+	changes    int            // Respuestas agregadas a cualquier tabla.
+	generation int            // Generación de la base con la que se llenaron las tablas.
+}
+
+// AbolishTables descarta todas las tablas de respuestas.
+func (s *Solver) AbolishTables() {
+	s.tabling = tabling{tables: make(map[string]*answerTable), generation: s.KB.Generation()}
+}
+
+// TableAnswers devuelve las respuestas memorizadas para la variante de goal,
+// y si la tabla está completa. Sirve para inspeccionar la tabulación.
+func (s *Solver) TableAnswers(goal *ds.Symbol) ([]*ds.Symbol, bool) {
+	t, ok := s.tabling.tables[variantKey(goal, s.Env)]
+	if !ok {
+		return nil, false
+	}
+	return append([]*ds.Symbol(nil), t.answers...), t.status == tableComplete
+}
+
+// solveTabled resuelve una llamada a un predicado tabulado.
+func (s *Solver) solveTabled(goal *ds.Symbol, name string, arity int, k Continuation) int {
+	tb := &s.tabling
+	if tb.tables == nil || (len(tb.stack) == 0 && !s.KeepTables && tb.generation != s.KB.Generation()) {
+		s.AbolishTables()
+	}
+
+	key := variantKey(goal, s.Env)
+	t, ok := tb.tables[key]
+	switch {
+	case !ok:
+		t = &answerTable{goal: rename(Resolve(goal, s.Env), make(map[ds.SymbolID]*ds.Symbol)), seen: make(map[string]bool)}
+		tb.tables[key] = t
+		if r := s.evaluateTable(t, name, arity); r != 0 {
+			return r
+		}
+	case t.status == tableEvaluating:
+		// Llamada recursiva: todo lo que está encima de t en la pila depende de t.
+		for _, above := range tb.stack[t.index+1:] {
+			above.leader = min(above.leader, t.index)
+		}
+	case t.status == tableIncomplete:
+		if r := s.evaluateTable(t, name, arity); r != 0 {
+			return r
+		}
+	}
+	return s.consumeTable(t, goal, k)
+}
+
+// evaluateTable calcula el punto fijo de las respuestas de t.
+func (s *Solver) evaluateTable(t *answerTable, name string, arity int) int {
+	tb := &s.tabling
+	if t.status == tableIncomplete {
+		for i, p := range tb.pending {
+			if p == t {
+				tb.pending = append(tb.pending[:i], tb.pending[i+1:]...)
+				break
+			}
+		}
+	}
+	t.status = tableEvaluating
+	t.index = len(tb.stack)
+	t.leader = t.index
+	tb.stack = append(tb.stack, t)
+
+	// Las pruebas de la evaluación no corresponden a ninguna solución de la consulta.
+	proof := s.proof
+	s.proof = nil
+	defer func() { s.proof = proof }()
+
+	clauses := s.KB.Clauses(name, arity)
+	for {
+		before := tb.changes
+		call := rename(t.goal, make(map[ds.SymbolID]*ds.Symbol))
+		r := s.solveClauses(call, clauses, func() int {
+			s.addAnswer(t, Resolve(call, s.Env))
+			return 0
+		})
+		if r != 0 {
+			// Solo un error detiene la evaluación: las tablas a medio llenar no sirven.
+			s.AbolishTables()
+			return r
+		}
+		if tb.changes == before {
+			break
+		}
+	}
+	tb.stack = tb.stack[:len(tb.stack)-1]
+
+	if t.leader < t.index {
+		t.status = tableIncomplete
+		tb.pending = append(tb.pending, t)
+		if len(tb.stack) > 0 {
+			top := tb.stack[len(tb.stack)-1]
+			top.leader = min(top.leader, t.leader)
+		}
+		return 0
+	}
+
+	// t es líder de su componente: todo lo que esperaba por él queda completo.
+	t.status = tableComplete
+	kept := tb.pending[:0]
+	for _, p := range tb.pending {
+		if p.leader >= t.index {
+			p.status = tableComplete
+		} else {
+			kept = append(kept, p)
+		}
+	}
+	tb.pending = kept
+	return 0
+}
+
+// addAnswer agrega una respuesta a t si no es variante de otra ya guardada.
+func (s *Solver) addAnswer(t *answerTable, answer *ds.Symbol) {
+	key := variantKey(answer, nil)
+	if t.seen[key] {
+		return
+	}
+	t.seen[key] = true
+	t.answers = append(t.answers, rename(answer, make(map[ds.SymbolID]*ds.Symbol)))
+	s.tabling.changes++
+}
+
+// consumeTable unifica goal con cada respuesta de t y continúa con k. Si t
+// sigue en evaluación, también recorre las respuestas que aparezcan mientras tanto.
+func (s *Solver) consumeTable(t *answerTable, goal *ds.Symbol, k Continuation) int {
+	for i := 0; i < len(t.answers); i++ {
+		if r := s.unifyThen(goal, rename(t.answers[i], make(map[ds.SymbolID]*ds.Symbol)), k); r != 0 {
+			return r
+		}
+	}
+	return 0
+}
+
+// variantKey devuelve una clave igual para dos términos que solo difieren
+// en el nombre de sus variables.
+func variantKey(term *ds.Symbol, env *Environment) string {
+	var b strings.Builder
+	vars := make(map[ds.SymbolID]int)
+	next := 0
+	var walk func(t *ds.Symbol)
+	walk = func(t *ds.Symbol) {
+		if env != nil {
+			t = Deref(t, env)
+		} else {
+			t = ds.Deref(t)
+		}
+		switch t.LogicalType {
+		case ds.LT_Variable:
+			n, ok := vars[t.ID]
+			if !ok {
+				n = next
+				vars[t.ID] = n
+				next++
+			}
+			fmt.Fprintf(&b, "_%d", n)
+		case ds.LT_Anonymous:
+			fmt.Fprintf(&b, "_%d", next) // Cada _ es una variable distinta.
+			next++
+		case ds.LT_List:
+			pair := t.Value.(*ds.ListPair)
+			b.WriteString("[")
+			walk(pair.Head)
+			b.WriteString("|")
+			walk(pair.Tail)
+			b.WriteString("]")
+		case ds.LT_Structure:
+			st := t.Value.(*ds.StructureTerm)
+			fmt.Fprintf(&b, "%q(", st.Functor.PublicName)
+			for i, arg := range st.Args {
+				if i > 0 {
+					b.WriteString(",")
+				}
+				walk(arg)
+			}
+			b.WriteString(")")
+		case ds.LT_Null:
+			b.WriteString("[]")
+		default:
+			fmt.Fprintf(&b, "%q:%v", t.PublicName, t.Value)
+		}
+	}
+	walk(term)
+	return b.String()
+}
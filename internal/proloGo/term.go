@@ -0,0 +1,407 @@
+// /nexusl/internal/proloGo/term.go
+// .
+// Construcción y recorrido de términos para el resolvedor
+// .
+// Los términos son *ds.Symbol: átomos (constantes), variables, listas
+// (ds.ListPair) y estructuras (ds.StructureTerm). Aquí están los
+// constructores cortos que usan el resolvedor y las pruebas, el renombrado
+// de variables de una cláusula y la resolución completa de un término.
+// .
+package prologo
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/devicemxl/nexusl/ds"
+)
+
+// atoms es la tabla de átomos del motor. Es propia: los átomos no se
+// registran en ds.SymbolsByPublicName, donde ocultarían los scopes y
+// predicados cargados de la base de definiciones.
+var atoms sync.Map // string -> *ds.Symbol
+
+// Atom devuelve el átomo con ese nombre; un mismo nombre da siempre el mismo símbolo.
+func Atom(name string) *ds.Symbol {
+	if sym, ok := atoms.Load(name); ok {
+		return sym.(*ds.Symbol)
+	}
+	sym, _ := atoms.LoadOrStore(name, constant(name, name))
+	return sym.(*ds.Symbol)
+}
+
+// Int devuelve la constante entera n.
+func Int(n int64) *ds.Symbol {
+	return constant(strconv.FormatInt(n, 10), n)
+}
+
+// Var crea una variable lógica nueva.
+func Var(name string) *ds.Symbol {
+	s := ds.NewTermSymbol()
+	s.PublicName = name
+	s.LogicalType = ds.LT_Variable
+	return s
+}
+
+// Los términos del motor se crean con ds.NewTermSymbol, sin registrarlos:
+// el resolvedor crea muchos (cada renombrado, cada resultado de Eval) y no
+// deben acumularse en las tablas globales de ds.
+
+// constant crea una constante de nombre name y valor value.
+func constant(name string, value interface{}) *ds.Symbol {
+	s := ds.NewTermSymbol()
+	s.PublicName = name
+	s.Thing = ds.LiteralType
+	s.LogicalType = ds.LT_Constant
+	s.Value = value
+	s.State = ds.Embodied
+	return s
+}
+
+// structure crea la estructura functor(args...).
+func structure(functor *ds.Symbol, args []*ds.Symbol) *ds.Symbol {
+	s := ds.NewTermSymbol()
+	s.PublicName = functor.PublicName
+	s.Thing = ds.PredicateType
+	s.LogicalType = ds.LT_Structure
+	s.Value = &ds.StructureTerm{Functor: functor, Args: args}
+	s.State = ds.Embodied
+	return s
+}
+
+// Compound crea la estructura name(args...). Sin argumentos devuelve el átomo name.
+func Compound(name string, args ...*ds.Symbol) *ds.Symbol {
+	if len(args) == 0 {
+		return Atom(name)
+	}
+	return structure(Atom(name), args)
+}
+
+// List crea una lista a partir de sus elementos, terminada en ds.NullSymbol.
+func List(items ...*ds.Symbol) *ds.Symbol {
+	list := ds.NullSymbol
+	for i := len(items) - 1; i >= 0; i-- {
+		list = ds.NewListSymbol(items[i], list)
+	}
+	return list
+}
+
+// Functor devuelve el nombre y la aridad de un objetivo (átomo o estructura).
+func Functor(goal *ds.Symbol) (string, int, bool) {
+	switch goal.LogicalType {
+	case ds.LT_Constant:
+		return goal.PublicName, 0, true
+	case ds.LT_Structure:
+		st := goal.Value.(*ds.StructureTerm)
+		return st.Functor.PublicName, len(st.Args), true
+	}
+	return "", 0, false
+}
+
+// Indicator devuelve el indicador de predicado name/arity.
+func Indicator(name string, arity int) string {
+	return fmt.Sprintf("%s/%d", name, arity)
+}
+
+// Args devuelve los argumentos de una estructura, o nil si no lo es.
+func Args(term *ds.Symbol) []*ds.Symbol {
+	if term.LogicalType == ds.LT_Structure {
+		return term.Value.(*ds.StructureTerm).Args
+	}
+	return nil
+}
+
+// Resolve devuelve una copia de term con todas las variables ligadas en env
+// sustituidas por su valor. Las variables libres se conservan.
+func Resolve(term *ds.Symbol, env *Environment) *ds.Symbol {
+	term = Deref(term, env)
+	switch term.LogicalType {
+	case ds.LT_List:
+		pair := term.Value.(*ds.ListPair)
+		return ds.NewListSymbol(Resolve(pair.Head, env), Resolve(pair.Tail, env))
+	case ds.LT_Structure:
+		st := term.Value.(*ds.StructureTerm)
+		args := make([]*ds.Symbol, len(st.Args))
+		for i, arg := range st.Args {
+			args[i] = Resolve(arg, env)
+		}
+		return structure(st.Functor, args)
+	}
+	return term
+}
+
+// Variables devuelve, en orden de aparición y sin repetir, las variables libres de term.
+func Variables(term *ds.Symbol, env *Environment) []*ds.Symbol {
+	var vars []*ds.Symbol
+	seen := make(map[ds.SymbolID]bool)
+	var walk func(t *ds.Symbol)
+	walk = func(t *ds.Symbol) {
+		t = Deref(t, env)
+		switch t.LogicalType {
+		case ds.LT_Variable:
+			if !seen[t.ID] {
+				seen[t.ID] = true
+				vars = append(vars, t)
+			}
+		case ds.LT_List:
+			pair := t.Value.(*ds.ListPair)
+			walk(pair.Head)
+			walk(pair.Tail)
+		case ds.LT_Structure:
+			for _, arg := range t.Value.(*ds.StructureTerm).Args {
+				walk(arg)
+			}
+		}
+	}
+	walk(term)
+	return vars
+}
+
+// rename copia term sustituyendo cada variable por una variable nueva.
+// fresh guarda las variables ya renombradas para que una misma variable
+// de la cláusula se renombre siempre igual.
+func rename(term *ds.Symbol, fresh map[ds.SymbolID]*ds.Symbol) *ds.Symbol {
+	switch term.LogicalType {
+	case ds.LT_Variable:
+		v, ok := fresh[term.ID]
+		if !ok {
+			v = Var(term.PublicName)
+			fresh[term.ID] = v
+		}
+		return v
+	case ds.LT_List:
+		pair := term.Value.(*ds.ListPair)
+		return ds.NewListSymbol(rename(pair.Head, fresh), rename(pair.Tail, fresh))
+	case ds.LT_Structure:
+		st := term.Value.(*ds.StructureTerm)
+		args := make([]*ds.Symbol, len(st.Args))
+		for i, arg := range st.Args {
+			args[i] = rename(arg, fresh)
+		}
+		return structure(st.Functor, args)
+	}
+	return term
+}
+
+// Format devuelve la notación Prolog de un término, resolviendo sus ligaduras en env (que puede ser nil).
+func Format(term *ds.Symbol, env *Environment) string {
+	if env != nil {
+		term = Deref(term, env)
+	} else {
+		term = ds.Deref(term)
+	}
+	switch term.LogicalType {
+	case ds.LT_Variable:
+		return term.PublicName
+	case ds.LT_Anonymous:
+		return "_"
+	case ds.LT_Null:
+		return "[]"
+	case ds.LT_List:
+		s := "["
+		for first := true; ; first = false {
+			pair := term.Value.(*ds.ListPair)
+			if !first {
+				s += ", "
+			}
+			s += Format(pair.Head, env)
+			if env != nil {
+				term = Deref(pair.Tail, env)
+			} else {
+				term = ds.Deref(pair.Tail)
+			}
+			if term.LogicalType == ds.LT_Null {
+				return s + "]"
+			}
+			if term.LogicalType != ds.LT_List {
+				return s + " | " + Format(term, env) + "]"
+			}
+		}
+	case ds.LT_Structure:
+		st := term.Value.(*ds.StructureTerm)
+		s := st.Functor.PublicName + "("
+		for i, arg := range st.Args {
+			if i > 0 {
+				s += ", "
+			}
+			s += Format(arg, env)
+		}
+		return s + ")"
+	case ds.LT_Constant:
+		if term.PublicName != "" {
+			return term.PublicName
+		}
+		return fmt.Sprintf("%v", term.Value)
+	}
+	return term.PublicName
+}
+
+// CompareTerms compara dos términos ya resueltos con el orden estándar de
+// Prolog: variables < números < átomos < términos compuestos. Las listas se
+// comparan como el compuesto '.'(Head, Tail) y la lista vacía como el átomo [].
+func CompareTerms(a, b *ds.Symbol) int {
+	a, b = ds.Deref(a), ds.Deref(b)
+	if ra, rb := termRank(a), termRank(b); ra != rb {
+		return compareInts(ra, rb)
+	}
+	switch termRank(a) {
+	case 0:
+		return compareInts(int(a.ID), int(b.ID))
+	case 1:
+		x, _ := numberValue(a)
+		y, _ := numberValue(b)
+		return standardOrder(x, y)
+	case 2:
+		return compareStrings(atomName(a), atomName(b))
+	}
+	nameA, argsA := compoundParts(a)
+	nameB, argsB := compoundParts(b)
+	if c := compareInts(len(argsA), len(argsB)); c != 0 {
+		return c
+	}
+	if c := compareStrings(nameA, nameB); c != 0 {
+		return c
+	}
+	for i := range argsA {
+		if c := CompareTerms(argsA[i], argsB[i]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// variant indica si a y b son iguales salvo por el nombre de sus variables.
+func variant(a, b *ds.Symbol) bool {
+	return variantWith(a, b, make(map[ds.SymbolID]ds.SymbolID), make(map[ds.SymbolID]ds.SymbolID))
+}
+
+func variantWith(a, b *ds.Symbol, ab, ba map[ds.SymbolID]ds.SymbolID) bool {
+	a, b = ds.Deref(a), ds.Deref(b)
+	if a.LogicalType == ds.LT_Variable || b.LogicalType == ds.LT_Variable {
+		if a.LogicalType != b.LogicalType {
+			return false
+		}
+		x, okA := ab[a.ID]
+		y, okB := ba[b.ID]
+		if !okA && !okB {
+			ab[a.ID], ba[b.ID] = b.ID, a.ID
+			return true
+		}
+		return okA && okB && x == b.ID && y == a.ID
+	}
+	if termRank(a) != termRank(b) {
+		return false
+	}
+	if termRank(a) < 3 {
+		return CompareTerms(a, b) == 0
+	}
+	nameA, argsA := compoundParts(a)
+	nameB, argsB := compoundParts(b)
+	if nameA != nameB || len(argsA) != len(argsB) {
+		return false
+	}
+	for i := range argsA {
+		if !variantWith(argsA[i], argsB[i], ab, ba) {
+			return false
+		}
+	}
+	return true
+}
+
+// termRank devuelve la clase del término en el orden estándar.
+func termRank(t *ds.Symbol) int {
+	switch t.LogicalType {
+	case ds.LT_Variable, ds.LT_Anonymous:
+		return 0
+	case ds.LT_Constant:
+		if _, ok := numberValue(t); ok {
+			return 1
+		}
+		return 2
+	case ds.LT_Null:
+		return 2
+	}
+	return 3
+}
+
+// numericValue devuelve el valor de una constante numérica real como float64.
+func numericValue(t *ds.Symbol) (float64, bool) {
+	v, ok := numberValue(t)
+	if !ok || isComplex(v) {
+		return 0, false
+	}
+	return toFloat(v), true
+}
+
+// intValue devuelve el valor de una constante entera.
+func intValue(t *ds.Symbol) (int64, bool) {
+	if t.LogicalType != ds.LT_Constant {
+		return 0, false
+	}
+	switch n := t.Value.(type) {
+	case int:
+		return int64(n), true
+	case int64:
+		return n, true
+	}
+	return 0, false
+}
+
+// listElements devuelve los elementos de una lista cerrada.
+func listElements(t *ds.Symbol, env *Environment) ([]*ds.Symbol, bool) {
+	var items []*ds.Symbol
+	for {
+		t = Deref(t, env)
+		switch t.LogicalType {
+		case ds.LT_Null:
+			return items, true
+		case ds.LT_List:
+			pair := t.Value.(*ds.ListPair)
+			items = append(items, pair.Head)
+			t = pair.Tail
+		default:
+			return nil, false
+		}
+	}
+}
+
+func atomName(t *ds.Symbol) string {
+	if t.LogicalType == ds.LT_Null {
+		return "[]"
+	}
+	if t.PublicName != "" {
+		return t.PublicName
+	}
+	return fmt.Sprintf("%v", t.Value)
+}
+
+// compoundParts devuelve el nombre y los argumentos de una estructura o de un par de lista.
+func compoundParts(t *ds.Symbol) (string, []*ds.Symbol) {
+	if t.LogicalType == ds.LT_List {
+		pair := t.Value.(*ds.ListPair)
+		return ".", []*ds.Symbol{pair.Head, pair.Tail}
+	}
+	st := t.Value.(*ds.StructureTerm)
+	return st.Functor.PublicName, st.Args
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareStrings(a, b string) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,190 @@
+// /nexusl/internal/proloGo/tms.go
+// .
+// Mantenimiento de la verdad basado en justificaciones (JTMS)
+// .
+// TMS mantiene juntas una base de conocimiento y la red de encadenamiento
+// hacia adelante compilada de sus reglas (ver rete.go). Los hechos base se
+// afirman y se retractan en las dos; los derivados viven en la red, cada uno
+// con las justificaciones (regla + premisas) que lo apoyan.
+// .
+//   - Un hecho derivado por varios caminos tiene una justificación por
+//     camino: retractar una premisa solo le quita los apoyos que la usaban,
+//     y el hecho se mantiene mientras le quede alguno (Supports).
+//   - La retracción se propaga en cascada a todo lo que pierde su último
+//     apoyo fundado en hechos base, incluidos los ciclos que se apoyaban
+//     entre sí.
+//   - Why responde por qué se cree un hecho, como un árbol de apoyos que
+//     termina en hechos base.
+//
+// .
+// Corresponde a la palabra clave RETRACT del lenguaje (ver RetractTriplet).
+// .
+package prologo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/devicemxl/nexusl/ds"
+)
+
+var ErrNotBelieved = errors.New("prologo: fact is not believed")
+
+// TMS es una base de conocimiento con mantenimiento de la verdad.
+type TMS struct {
+	KB      *KnowledgeBase
+	Network *Network
+}
+
+// NewTMS compila las reglas de kb y deriva las consecuencias de sus hechos.
+func NewTMS(kb *KnowledgeBase) (*TMS, error) {
+	n, err := NetworkFromKB(kb)
+	if err != nil {
+		return nil, err
+	}
+	return &TMS{KB: kb, Network: n}, nil
+}
+
+// Assert afirma el hecho base term y devuelve los hechos nuevos que produjo.
+func (t *TMS) Assert(term *ds.Symbol) ([]*Fact, error) {
+	if f, ok := t.Network.Lookup(term); !ok || !f.Asserted {
+		if err := t.KB.AddFact(Resolve(term, nil)); err != nil {
+			return nil, err
+		}
+	}
+	return t.Network.Assert(term)
+}
+
+// Retract quita el hecho base term y devuelve los hechos que dejaron de
+// creerse. Un hecho derivado no se puede retractar: hay que retractar sus premisas.
+func (t *TMS) Retract(term *ds.Symbol) ([]*Fact, error) {
+	removed, err := t.Network.Retract(term)
+	if err != nil {
+		return nil, err
+	}
+	t.KB.Retract(term)
+	return removed, nil
+}
+
+// AddRule agrega la regla head :- body a la base y a la red, que la dispara
+// con los hechos que ya se creen. La red la rechaza con ErrUnsafeRule si la
+// cabeza usa variables que el cuerpo no liga; entonces no se agrega a la base.
+func (t *TMS) AddRule(head *ds.Symbol, body ...*ds.Symbol) error {
+	name, arity, ok := Functor(head)
+	if !ok || name == "" {
+		return fmt.Errorf("prologo: clause head must be an atom or a structure: %s", Format(head, nil))
+	}
+	key := Indicator(name, arity)
+	c := &Clause{Head: head, Body: body, Truth: ds.TV_True}
+	if err := t.Network.AddRule(RuleFromClause(fmt.Sprintf("%s#%d", key, len(t.KB.clauses[key])+1), c)); err != nil {
+		return err
+	}
+	return t.KB.Add(c)
+}
+
+// AssertTriplet afirma la tripleta (S P O) como el hecho P(S, O).
+func (t *TMS) AssertTriplet(tr *ds.Triplet) ([]*Fact, error) {
+	term, err := tripletTerm(tr)
+	if err != nil {
+		return nil, err
+	}
+	return t.Assert(term)
+}
+
+// RetractTriplet retracta la tripleta (S P O), como la sentencia RETRACT.
+func (t *TMS) RetractTriplet(tr *ds.Triplet) ([]*Fact, error) {
+	term, err := tripletTerm(tr)
+	if err != nil {
+		return nil, err
+	}
+	return t.Retract(term)
+}
+
+// Believed indica si term está en la red, como hecho base o derivado.
+func (t *TMS) Believed(term *ds.Symbol) bool {
+	_, ok := t.Network.Lookup(term)
+	return ok
+}
+
+// Supports devuelve cuántos apoyos tiene term: uno si es base más uno por
+// cada justificación. Es 0 si no se cree.
+func (t *TMS) Supports(term *ds.Symbol) int {
+	f, ok := t.Network.Lookup(term)
+	if !ok {
+		return 0
+	}
+	n := len(f.Justifications)
+	if f.Asserted {
+		n++
+	}
+	return n
+}
+
+// Belief explica por qué se cree un hecho.
+type Belief struct {
+	Fact     *Fact
+	Asserted bool
+	Supports []*Support
+}
+
+// Support es una justificación con sus premisas explicadas.
+type Support struct {
+	Rule     *Rule
+	Premises []*Belief
+}
+
+// Why devuelve el árbol de apoyos de term. Las justificaciones que pasan por
+// un hecho que ya está en el camino desde la raíz se omiten: son circulares
+// y no explican nada.
+func (t *TMS) Why(term *ds.Symbol) (*Belief, error) {
+	f, ok := t.Network.Lookup(term)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotBelieved, Format(term, nil))
+	}
+	return explainFact(f, make(map[*Fact]bool)), nil
+}
+
+// explainFact arma la explicación de f; path son los hechos que se están
+// explicando por encima de él.
+func explainFact(f *Fact, path map[*Fact]bool) *Belief {
+	b := &Belief{Fact: f, Asserted: f.Asserted}
+	path[f] = true
+	defer delete(path, f)
+next:
+	for _, j := range f.Justifications {
+		for _, p := range j.Premises {
+			if path[p] {
+				continue next
+			}
+		}
+		s := &Support{Rule: j.Rule}
+		for _, p := range j.Premises {
+			s.Premises = append(s.Premises, explainFact(p, path))
+		}
+		b.Supports = append(b.Supports, s)
+	}
+	return b
+}
+
+// String devuelve la explicación indentada, un hecho por línea.
+func (b *Belief) String() string {
+	var sb strings.Builder
+	b.write(&sb, 0)
+	return sb.String()
+}
+
+func (b *Belief) write(sb *strings.Builder, depth int) {
+	indent := strings.Repeat("  ", depth)
+	sb.WriteString(indent + b.Fact.String())
+	if b.Asserted {
+		sb.WriteString(" [asserted]")
+	}
+	sb.WriteString("\n")
+	for _, s := range b.Supports {
+		sb.WriteString(indent + "  because " + s.Rule.Name + ":\n")
+		for _, p := range s.Premises {
+			p.write(sb, depth+2)
+		}
+	}
+}
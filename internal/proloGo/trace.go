@@ -0,0 +1,219 @@
+// /nexusl/internal/proloGo/trace.go
+// .
+// Trazado de la resolución con el modelo de cajas de Byrd
+// .
+// Cada llamada a un predicado es una caja con cuatro puertos:
+// .
+//   - Call: se entra a la caja por primera vez.
+//   - Exit: la caja encontró una solución.
+//   - Redo: se vuelve a la caja a buscar otra solución.
+//   - Fail: la caja no tiene más soluciones.
+//
+// .
+// Si el Solver tiene un Tracer, recibe un TraceEvent en cada puerto de los
+// predicados trazados (ver Solver.Trace; corresponde a la palabra clave
+// TRACE) y decide cómo seguir: continuar, saltar el interior de la caja sin
+// trazarlo, forzar que falle o abortar la consulta. Las conectivas (',',
+// ';', '->', call, true y el corte) no forman cajas propias.
+// .
+// TraceWriter escribe los eventos tal cual; Debugger (ver debugger.go) es un
+// depurador paso a paso.
+// .
+package prologo
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/devicemxl/nexusl/ds"
+)
+
+var ErrTraceAborted = errors.New("prologo: query aborted by the tracer")
+
+// Port es un puerto de la caja de Byrd.
+type Port int
+
+const (
+	PortCall Port = iota
+	PortExit
+	PortRedo
+	PortFail
+)
+
+// String devuelve el nombre del puerto.
+func (p Port) String() string {
+	switch p {
+	case PortExit:
+		return "Exit"
+	case PortRedo:
+		return "Redo"
+	case PortFail:
+		return "Fail"
+	}
+	return "Call"
+}
+
+// TraceEvent describe el paso por un puerto.
+type TraceEvent struct {
+	Port      Port
+	Depth     int        // Cajas abiertas, contando esta.
+	Indicator string     // name/arity del objetivo.
+	Goal      *ds.Symbol // El objetivo con las ligaduras del momento.
+	Bindings  []Binding  // Valor actual de cada variable del objetivo.
+}
+
+// String devuelve el evento como Port: (Depth) Goal.
+func (ev TraceEvent) String() string {
+	return fmt.Sprintf("%s: (%d) %s", ev.Port, ev.Depth, Format(ev.Goal, nil))
+}
+
+// TraceAction es la respuesta de un Tracer a un evento.
+type TraceAction int
+
+const (
+	TraceContinue TraceAction = iota // Seguir normalmente.
+	TraceSkip                        // En Call: no trazar el interior de la caja.
+	TraceFail                        // Hacer que la caja falle ya.
+	TraceAbort                       // Abortar la consulta con ErrTraceAborted.
+)
+
+// Tracer recibe los eventos de los predicados trazados.
+type Tracer interface {
+	Trace(ev TraceEvent) TraceAction
+}
+
+// TraceWriter es un Tracer que escribe una línea por evento.
+type TraceWriter struct {
+	Out io.Writer
+}
+
+// Trace escribe el evento indentado según su profundidad.
+func (w *TraceWriter) Trace(ev TraceEvent) TraceAction {
+	fmt.Fprintf(w.Out, "%s%s\n", strings.Repeat(" ", ev.Depth), ev)
+	return TraceContinue
+}
+
+// Trace activa el trazado de los predicados dados (como "parent/2"); sin
+// argumentos traza todos. Los eventos van a s.Tracer.
+func (s *Solver) Trace(indicators ...string) {
+	if len(indicators) == 0 {
+		s.traceAll = true
+		return
+	}
+	if s.traced == nil {
+		s.traced = make(map[string]bool)
+	}
+	for _, ind := range indicators {
+		s.traced[ind] = true
+	}
+}
+
+// Untrace desactiva el trazado de los predicados dados; sin argumentos lo
+// desactiva por completo.
+func (s *Solver) Untrace(indicators ...string) {
+	if len(indicators) == 0 {
+		s.traceAll, s.traced = false, nil
+		return
+	}
+	for _, ind := range indicators {
+		delete(s.traced, ind)
+	}
+}
+
+// Traced indica si las llamadas a ind generan eventos.
+func (s *Solver) Traced(ind string) bool {
+	return s.Tracer != nil && s.skipping == 0 && (s.traceAll || s.traced[ind])
+}
+
+// boxGoal prueba goal con run dentro de una caja de Byrd. Con un Tracer
+// instalado lleva la cuenta de las cajas abiertas, que es la profundidad
+// que se informa; solo los predicados trazados generan eventos.
+func (s *Solver) boxGoal(goal *ds.Symbol, ind string, k Continuation, run func(Continuation) int) int {
+	if s.Tracer == nil {
+		return run(k)
+	}
+	s.level++
+	depth := s.level
+	defer func() { s.level = depth - 1 }()
+	outside := func(k Continuation) int {
+		s.level = depth - 1
+		r := k()
+		s.level = depth
+		return r
+	}
+	if !s.Traced(ind) {
+		return run(func() int { return outside(k) })
+	}
+	port := func(p Port) TraceAction {
+		return s.Tracer.Trace(TraceEvent{Port: p, Depth: depth, Indicator: ind, Goal: Resolve(goal, s.Env), Bindings: s.bindings(goal)})
+	}
+
+	action := port(PortCall)
+	switch action {
+	case TraceFail:
+		port(PortFail)
+		return 0
+	case TraceAbort:
+		return s.fail(ErrTraceAborted)
+	}
+
+	// Con TraceSkip, lo que ocurre dentro de la caja no se traza, pero sus
+	// propios puertos sí: skipping se suspende mientras la caja está afuera.
+	skip, inside := action == TraceSkip, false
+	enter := func() {
+		if skip && !inside {
+			s.skipping++
+			inside = true
+		}
+	}
+	leave := func() {
+		if inside {
+			s.skipping--
+			inside = false
+		}
+	}
+
+	box := s.newFrame() // Devolverlo desde dentro fuerza el fallo de la caja.
+	enter()
+	r := run(func() int {
+		leave()
+		defer enter()
+		switch port(PortExit) {
+		case TraceFail:
+			return box
+		case TraceAbort:
+			return s.fail(ErrTraceAborted)
+		}
+		if r := outside(k); r != 0 {
+			return r
+		}
+		switch port(PortRedo) {
+		case TraceFail:
+			return box
+		case TraceAbort:
+			return s.fail(ErrTraceAborted)
+		}
+		return 0
+	})
+	leave()
+	if r == box {
+		r = 0
+	}
+	if r == 0 {
+		port(PortFail)
+	}
+	return r
+}
+
+// bindings devuelve el valor actual de las variables con nombre de goal.
+func (s *Solver) bindings(goal *ds.Symbol) []Binding {
+	var out []Binding
+	for _, v := range Variables(goal, nil) {
+		if v.PublicName != "" && v.PublicName != "_" {
+			out = append(out, Binding{Var: v.PublicName, Value: Resolve(v, s.Env)})
+		}
+	}
+	return out
+}
@@ -0,0 +1,125 @@
+// /nexusl/internal/proloGo/truth.go
+// .
+// Consultas trivaluadas: mundo cerrado y mundo abierto
+// .
+// Ask evalúa una fórmula y responde true, false, maybe o unknown.
+// Un objetivo atómico es verdadero si se puede demostrar. Si no, se buscan
+// hechos con valor explícito (false, maybe o unknown) que unifiquen con él;
+// y si tampoco hay, la respuesta depende de la suposición de mundo elegida:
+// con mundo cerrado lo que no se demuestra es falso, con mundo abierto es
+// desconocido. Así un agente con sensores parciales distingue "no" de "no sé".
+// .
+// Las fórmulas combinan objetivos con and/2, or/2, not/1 e imply/2, que se
+// evalúan con las conectivas de la lógica elegida (ds.Kleene o
+// ds.Lukasiewicz). Las variables de cada objetivo atómico se cuantifican
+// existencialmente por separado. Los átomos true, false, maybe y unknown son
+// los valores literales.
+// .
+package prologo
+
+import (
+	"fmt"
+
+	"github.com/devicemxl/nexusl/ds"
+)
+
+// WorldAssumption decide qué responder cuando un objetivo no se puede demostrar.
+type WorldAssumption int
+
+const (
+	ClosedWorld WorldAssumption = iota // Lo que no se demuestra es falso.
+	OpenWorld                          // Lo que no se demuestra es desconocido.
+)
+
+// String devuelve el nombre corto de la suposición (cwa/owa).
+func (w WorldAssumption) String() string {
+	if w == OpenWorld {
+		return "owa"
+	}
+	return "cwa"
+}
+
+// QueryOptions configura una consulta trivaluada.
+type QueryOptions struct {
+	World WorldAssumption
+	Logic ds.Logic
+}
+
+// literalTruth traduce los átomos literales a su valor de verdad.
+var literalTruth = map[string]ds.TruthValue{
+	"true":    ds.TV_True,
+	"false":   ds.TV_False,
+	"maybe":   ds.TV_Maybe,
+	"unknown": ds.TV_Unknown,
+}
+
+// Ask evalúa formula con valores de verdad trivaluados.
+func (s *Solver) Ask(formula *ds.Symbol, opts QueryOptions) (ds.TruthValue, error) {
+	formula = Deref(formula, s.Env)
+	name, arity, ok := Functor(formula)
+	if !ok {
+		return ds.TV_Unknown, fmt.Errorf("%w: %s", ErrNotCallable, Format(formula, s.Env))
+	}
+	args := Args(formula)
+	logic := opts.Logic
+
+	switch {
+	case arity == 0:
+		if v, ok := literalTruth[name]; ok {
+			return v, nil
+		}
+	case name == "not" && arity == 1:
+		v, err := s.Ask(args[0], opts)
+		return logic.Not(v), err
+	case (name == "and" || name == "or" || name == "imply") && arity == 2:
+		a, err := s.Ask(args[0], opts)
+		if err != nil {
+			return ds.TV_Unknown, err
+		}
+		b, err := s.Ask(args[1], opts)
+		if err != nil {
+			return ds.TV_Unknown, err
+		}
+		switch name {
+		case "and":
+			return logic.And(a, b), nil
+		case "or":
+			return logic.Or(a, b), nil
+		}
+		return logic.Imply(a, b), nil
+	}
+	return s.askAtom(formula, name, arity, opts.World)
+}
+
+// askAtom evalúa un objetivo atómico.
+func (s *Solver) askAtom(goal *ds.Symbol, name string, arity int, world WorldAssumption) (ds.TruthValue, error) {
+	proved, err := s.Prove(goal)
+	if err != nil {
+		return ds.TV_Unknown, err
+	}
+	if proved {
+		return ds.TV_True, nil
+	}
+
+	// Entre los hechos explícitos gana el más informativo: false, luego maybe, luego unknown.
+	best, found := ds.TV_Unknown, false
+	for _, c := range s.KB.Clauses(name, arity) {
+		if c.Truth == ds.TV_True {
+			continue
+		}
+		mark := s.Env.Mark()
+		if Unify(goal, rename(c.Head, make(map[ds.SymbolID]*ds.Symbol)), s.Env) {
+			if !found || c.Truth < best {
+				best, found = c.Truth, true
+			}
+		}
+		s.Env.Undo(mark)
+	}
+	if found {
+		return best, nil
+	}
+	if world == OpenWorld {
+		return ds.TV_Unknown, nil
+	}
+	return ds.TV_False, nil
+}
@@ -12,16 +12,42 @@
 package prologo
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"sort"
 
 	"github.com/devicemxl/nexusl/ds"
+	"github.com/devicemxl/nexusl/logging"
 )
 
+// unifyLog es el logger del subsistema unify (ver el paquete logging).
+var unifyLog = logging.Logger(logging.Unify)
+
 // UnificationBinding representa una única ligadura de una variable a un valor dentro de un entorno.
 type UnificationBinding struct {
 	Variable *ds.Symbol // La variable que ha sido ligada
 	OldValue *ds.Symbol // El valor al que estaba ligada ANTES de esta unificación (para deshacer)
 	WasBound bool       // true si la variable ya estaba ligada antes de esta unificación
+	// Attr no está vacío cuando la entrada registra el cambio de un atributo
+	// de Variable (ver PutAttr); OldAttr es su valor anterior y WasBound
+	// indica si existía.
+	Attr    string
+	OldAttr interface{}
+}
+
+// AttrHook se llama cuando se liga una variable que tiene el atributo con
+// que se registró. value ya está desreferenciado y puede ser otra variable.
+// Devolver false hace fallar la unificación.
+type AttrHook func(env *Environment, variable, value *ds.Symbol) bool
+
+// attrHooks son los ganchos de atributos, por nombre de atributo.
+var attrHooks = map[string]AttrHook{}
+
+// RegisterAttrHook asocia un gancho al atributo name.
+func RegisterAttrHook(name string, hook AttrHook) {
+	attrHooks[name] = hook
 }
 
 // Environment representa el entorno de ligaduras para una rama de unificación.
@@ -33,6 +59,11 @@ type Environment struct {
 	// 'trail' es una pila de operaciones de deshacer.
 	// Cada elemento registra el estado anterior de una variable antes de ser ligada.
 	trail []UnificationBinding
+	// attrs guarda los atributos de las variables (variables atribuidas):
+	// datos que un módulo, como CLP(FD), cuelga de una variable libre.
+	attrs map[ds.SymbolID]map[string]interface{}
+	// fd es el estado de propagación de restricciones (ver clpfd.go).
+	fd fdQueue
 }
 
 // --- Métodos de Environment ---
@@ -45,8 +76,12 @@ func NewEnvironment() *Environment {
 	}
 }
 
-// GetBinding obtiene la ligadura de una variable en este entorno.
+// GetBinding obtiene la ligadura de una variable en este entorno. Un
+// entorno nil no tiene ligaduras.
 func (env *Environment) GetBinding(varID ds.SymbolID) (*ds.Symbol, bool) {
+	if env == nil {
+		return nil, false
+	}
 	val, ok := env.Bindings[varID]
 	return val, ok
 }
@@ -70,27 +105,77 @@ func (env *Environment) AddBinding(variable *ds.Symbol, value *ds.Symbol) {
 // Por simplicidad, esta función es un placeholder. En un Prolog real, se pasaría
 // un "punto de elección" o un "estado del trail" para deshacer solo hasta allí.
 func (env *Environment) Backtrack() {
-	fmt.Println("INFO: Backtracking environment (simplified for example).")
-	for _, bind := range env.trail {
+	env.Undo(0)
+	env.Bindings = make(map[ds.SymbolID]*ds.Symbol) // Resetear Bindings (simplificado para ejemplo)
+}
+
+// Mark devuelve un punto de control del trail, para deshacer con Undo solo
+// las ligaduras hechas a partir de él (ver solver.go).
+func (env *Environment) Mark() int {
+	return len(env.trail)
+}
+
+// Undo deshace, en orden inverso, las ligaduras registradas desde mark.
+func (env *Environment) Undo(mark int) {
+	if unifyLog.Enabled(context.Background(), slog.LevelDebug) && len(env.trail) > mark {
+		unifyLog.Debug("undo", "bindings", len(env.trail)-mark)
+	}
+	for i := len(env.trail) - 1; i >= mark; i-- {
+		bind := env.trail[i]
+		if bind.Attr != "" {
+			if bind.WasBound {
+				env.attrs[bind.Variable.ID][bind.Attr] = bind.OldAttr
+			} else {
+				delete(env.attrs[bind.Variable.ID], bind.Attr)
+			}
+			continue
+		}
 		if bind.WasBound {
 			env.Bindings[bind.Variable.ID] = bind.OldValue
 		} else {
 			delete(env.Bindings, bind.Variable.ID)
 		}
 	}
-	env.trail = []UnificationBinding{}              // Limpiar el trail
-	env.Bindings = make(map[ds.SymbolID]*ds.Symbol) // Resetear Bindings (simplificado para ejemplo)
+	env.trail = env.trail[:mark]
+}
+
+// GetAttr devuelve el atributo name de la variable v.
+func (env *Environment) GetAttr(v *ds.Symbol, name string) (interface{}, bool) {
+	if env == nil {
+		return nil, false
+	}
+	val, ok := env.attrs[v.ID][name]
+	return val, ok
+}
+
+// PutAttr asigna el atributo name de la variable v y lo registra en el
+// trail para que Undo lo restaure.
+func (env *Environment) PutAttr(v *ds.Symbol, name string, value interface{}) {
+	if env.attrs == nil {
+		env.attrs = make(map[ds.SymbolID]map[string]interface{})
+	}
+	attrs, ok := env.attrs[v.ID]
+	if !ok {
+		attrs = make(map[string]interface{})
+		env.attrs[v.ID] = attrs
+	}
+	old, wasSet := attrs[name]
+	env.trail = append(env.trail, UnificationBinding{Variable: v, Attr: name, OldAttr: old, WasBound: wasSet})
+	attrs[name] = value
 }
 
 // ApplyBindingsToSymbols recorre las ligaduras en el entorno y las "commit" a los Símbolos originales.
 // Esto se llamaría si una rama de unificación tiene éxito y queremos que las ligaduras persistan globalmente.
 func (env *Environment) ApplyBindingsToSymbols() {
-	fmt.Println("INFO: Applying Bindings to global symbols.")
-	for varID, val := range env.Bindings {
-		if variable, ok := ds.SymbolsByID[varID]; ok { // Asume que ds.SymbolsByID es donde están las variables originales
-			variable.IsBound = true
-			variable.Binding = val
-			variable.State = ds.Embodied
+	// Las variables del motor no están en ds.SymbolsByID: se encuentran en el trail.
+	for _, b := range env.trail {
+		if b.Attr != "" {
+			continue
+		}
+		if val, ok := env.Bindings[b.Variable.ID]; ok {
+			b.Variable.IsBound = true
+			b.Variable.Binding = val
+			b.Variable.State = ds.Embodied
 		}
 	}
 }
@@ -99,8 +184,8 @@ func (env *Environment) ApplyBindingsToSymbols() {
 
 // Deref sigue las ligaduras de un Símbolo en un entorno dado.
 // Primero consulta el entorno de unificación, luego el Symbol.Binding si existe.
+// env puede ser nil para seguir solo las ligaduras globales.
 func Deref(s *ds.Symbol, env *Environment) *ds.Symbol {
-	fmt.Printf("Deref called: s=%s\n", s.String()) // Add this
 	// Si es una variable, primero consulta el entorno actual de unificación
 	if s.LogicalType == ds.LT_Variable {
 		if boundVal, ok := env.GetBinding(s.ID); ok {
@@ -119,8 +204,6 @@ func Deref(s *ds.Symbol, env *Environment) *ds.Symbol {
 // Bind establece una ligadura para una variable DENTRO DEL ENTORNO actual.
 // No modifica directamente el *ds.Symbol a nivel global, lo hace a través del entorno.
 func Bind(variable *ds.Symbol, value *ds.Symbol, env *Environment) error {
-	fmt.Printf("Bind called: variable=%s, value=%s\n", variable.String(), value.String()) // Add this
-
 	if variable.LogicalType != ds.LT_Variable {
 		return fmt.Errorf("attempted to bind a non-variable Symbol: %s", variable.PublicName)
 	}
@@ -137,20 +220,37 @@ func Bind(variable *ds.Symbol, value *ds.Symbol, env *Environment) error {
 	// para ver si 'variable' aparece dentro de ella. Esto es a menudo omitido en Prologs
 	// por rendimiento (por ejemplo, Prolog estándar lo omite por defecto con el "occurs check off").
 
+	if unifyLog.Enabled(context.Background(), slog.LevelDebug) {
+		unifyLog.Debug("bind", "var", variable.PublicName, "value", Format(valDeref, env))
+	}
 	env.AddBinding(variable, valDeref) // Añade la ligadura al entorno, que la registra en el trail
+
+	// Los ganchos de los atributos de la variable pueden rechazar la ligadura.
+	if attrs := env.attrs[variable.ID]; len(attrs) > 0 {
+		names := make([]string, 0, len(attrs))
+		for name := range attrs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if hook, ok := attrHooks[name]; ok && !hook(env, variable, valDeref) {
+				return fmt.Errorf("%w: %s", ErrAttrRejected, name)
+			}
+		}
+	}
 	return nil
 }
 
+// ErrAttrRejected indica que el gancho de un atributo rechazó una ligadura.
+var ErrAttrRejected = errors.New("prologo: binding rejected by attribute hook")
+
 // Unify intenta hacer que dos símbolos 'x' e 'y' sean lógicamente equivalentes
 // realizando ligaduras de variables si es necesario, dentro de un entorno dado.
 // Retorna true si la unificación es exitosa, false en caso contrario.
 func Unify(x, y *ds.Symbol, env *Environment) bool {
 	// 1. Desreferenciar ambos símbolos en el contexto del entorno actual.
-
-	fmt.Printf("Unify called: x=%s, y=%s\n", x.String(), y.String()) // Add this
 	x = Deref(x, env)
 	y = Deref(y, env)
-	fmt.Printf("Unify (dereferenced): x=%s, y=%s\n", x.String(), y.String()) // Add this
 
 	// 2. Casos base de unificación
 	if x == y { // Si los punteros son idénticos después de desreferenciar
@@ -159,19 +259,11 @@ func Unify(x, y *ds.Symbol, env *Environment) bool {
 
 	// 3. Unificación con variables
 	if x.LogicalType == ds.LT_Variable {
-		if err := Bind(x, y, env); err != nil { // Llama a Bind con el entorno
-			fmt.Printf("Error during variable bind for x: %v\n", err)
-			return false
-		}
-		return true
+		return Bind(x, y, env) == nil
 	}
 
 	if y.LogicalType == ds.LT_Variable {
-		if err := Bind(y, x, env); err != nil { // Llama a Bind con el entorno
-			fmt.Printf("Error during variable bind for y: %v\n", err)
-			return false
-		}
-		return true
+		return Bind(y, x, env) == nil
 	}
 
 	// 4. Casos especiales para símbolos predefinidos
@@ -189,8 +281,9 @@ func Unify(x, y *ds.Symbol, env *Environment) bool {
 
 	// 5. Unificación de constantes
 	if x.LogicalType == ds.LT_Constant && y.LogicalType == ds.LT_Constant {
-		// Las constantes unifican si sus valores concretos son iguales.
-		return x.Value == y.Value // Asegúrate de que Value sea comparable.
+		// Las constantes unifican si sus valores concretos son iguales; los
+		// números, si valen lo mismo en cualquier representación (2 = 2.0).
+		return x.Value == y.Value || sameNumber(x, y)
 	}
 
 	// 6. Unificación de listas
@@ -0,0 +1,112 @@
+// /nexusl/internal/trunKV/branches.go
+// .
+// Ramas: create_branch, switch_branch y delete_branch
+// .
+// Cada rama es una línea de razonamiento independiente (una hipótesis, una
+// interpretación alternativa). Las ramas comparten los objetos inmutables, así
+// que crear una rama no copia conocimiento. Ver docs/7_ramas.md.
+// .
+package trunkv
+
+import (
+	"fmt"
+	"sort"
+)
+
+// CreateBranch crea una rama nueva que apunta al commit actual de la rama activa.
+func (s *Store) CreateBranch(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	if name == "" {
+		return fmt.Errorf("trunkv: branch name cannot be empty")
+	}
+	if _, ok := s.refs[name]; ok {
+		return fmt.Errorf("%w: %s", ErrBranchExists, name)
+	}
+	if err := s.setRef(name, s.refs[s.head]); err != nil {
+		return err
+	}
+	return s.sync()
+}
+
+// SwitchBranch activa otra rama y carga su snapshot como estado de trabajo.
+// Falla con ErrDirtyWorkState si hay cambios sin confirmar, para no perderlos.
+func (s *Store) SwitchBranch(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	h, ok := s.refs[name]
+	if !ok && name != s.head {
+		return fmt.Errorf("%w: %s", ErrNoSuchBranch, name)
+	}
+	if s.dirty {
+		return ErrDirtyWorkState
+	}
+	if err := s.loadWorkState(h); err != nil {
+		return err
+	}
+	if err := s.appendRecord(recHead, []byte(name)); err != nil {
+		return err
+	}
+	return s.sync()
+}
+
+// Fork crea una rama a partir del estado actual y la activa.
+// Es el atajo para razonar sobre una hipótesis sin contaminar la rama principal.
+func (s *Store) Fork(name string) error {
+	if err := s.CreateBranch(name); err != nil {
+		return err
+	}
+	return s.SwitchBranch(name)
+}
+
+// DeleteBranch elimina el puntero de una rama. No borra commits ni objetos;
+// los que queden inalcanzables los elimina GC.
+func (s *Store) DeleteBranch(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	if name == s.head {
+		return fmt.Errorf("%w: %s", ErrActiveBranch, name)
+	}
+	if _, ok := s.refs[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrNoSuchBranch, name)
+	}
+	if err := s.appendRecord(recDeleteRef, []byte(name)); err != nil {
+		return err
+	}
+	return s.sync()
+}
+
+// Branches devuelve los nombres de todas las ramas, ordenados.
+func (s *Store) Branches() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, 0, len(s.refs)+1)
+	for name := range s.refs {
+		names = append(names, name)
+	}
+	if _, ok := s.refs[s.head]; !ok {
+		names = append(names, s.head) // Rama activa aún sin commits.
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BranchCommit devuelve el commit al que apunta una rama.
+func (s *Store) BranchCommit(name string) (Hash, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, ok := s.refs[name]
+	if !ok {
+		return ZeroHash, fmt.Errorf("%w: %s", ErrNoSuchBranch, name)
+	}
+	return h, nil
+}
@@ -0,0 +1,413 @@
+// /nexusl/internal/trunKV/codec.go
+// .
+// Serialización de tripletas y símbolos de `ds` para guardarlos como blobs.
+// .
+// El formato es JSON con una etiqueta de tipo por valor, porque Predicate y
+// Object de ds.Triplet son interface{} y pueden contener símbolos, tripletas
+// anidadas, listas o mapas (ver ds/triplet.go).
+// .
+package trunkv
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/devicemxl/nexusl/ds"
+)
+
+// valueRecord es la forma serializada de un valor arbitrario de una tripleta.
+type valueRecord struct {
+	Kind    string                  `json:"kind"`
+	Str     string                  `json:"s,omitempty"`
+	Int     int64                   `json:"i,omitempty"`
+	Float   float64                 `json:"f,omitempty"`
+	Bool    bool                    `json:"b,omitempty"`
+	Symbol  *symbolRecord           `json:"sym,omitempty"`
+	Triplet *tripletRecord          `json:"triplet,omitempty"`
+	Items   []*valueRecord          `json:"items,omitempty"`
+	Map     map[string]*valueRecord `json:"map,omitempty"`
+}
+
+// symbolRecord es la forma serializada de un ds.Symbol.
+type symbolRecord struct {
+	Name        string       `json:"name,omitempty"`
+	Thing       string       `json:"thing"`
+	LogicalType string       `json:"logical"`
+	Value       *valueRecord `json:"value,omitempty"`
+	Embedding   []float32    `json:"embedding,omitempty"`
+}
+
+// tripletRecord es la forma serializada de un ds.Triplet.
+type tripletRecord struct {
+	Scope     *symbolRecord `json:"scope,omitempty"`
+	Subject   *symbolRecord `json:"subject"`
+	Predicate *valueRecord  `json:"predicate"`
+	Object    *valueRecord  `json:"object"`
+	Truth     *ds.Truth     `json:"truth,omitempty"`
+}
+
+// EncodeTriplet serializa una tripleta.
+func EncodeTriplet(t *ds.Triplet) ([]byte, error) {
+	rec, err := tripletToRecord(t)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(rec)
+}
+
+// DecodeTriplet reconstruye una tripleta serializada con EncodeTriplet.
+func DecodeTriplet(data []byte) (*ds.Triplet, error) {
+	rec := &tripletRecord{}
+	if err := json.Unmarshal(data, rec); err != nil {
+		return nil, fmt.Errorf("%w: triplet: %v", ErrCorruptObject, err)
+	}
+	return recordToTriplet(rec)
+}
+
+// EncodeSymbol serializa un símbolo.
+func EncodeSymbol(sym *ds.Symbol) ([]byte, error) {
+	rec, err := symbolToRecord(sym)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(rec)
+}
+
+// DecodeSymbol reconstruye un símbolo serializado con EncodeSymbol.
+func DecodeSymbol(data []byte) (*ds.Symbol, error) {
+	rec := &symbolRecord{}
+	if err := json.Unmarshal(data, rec); err != nil {
+		return nil, fmt.Errorf("%w: symbol: %v", ErrCorruptObject, err)
+	}
+	return recordToSymbol(rec)
+}
+
+func tripletToRecord(t *ds.Triplet) (*tripletRecord, error) {
+	if t == nil || t.Subject == nil {
+		return nil, fmt.Errorf("trunkv: cannot encode a triplet without subject")
+	}
+	rec := &tripletRecord{Truth: t.Truth}
+	var err error
+	if t.Scope != nil {
+		if rec.Scope, err = symbolToRecord(t.Scope); err != nil {
+			return nil, err
+		}
+	}
+	if rec.Subject, err = symbolToRecord(t.Subject); err != nil {
+		return nil, err
+	}
+	if rec.Predicate, err = valueToRecord(t.Predicate); err != nil {
+		return nil, err
+	}
+	if rec.Object, err = valueToRecord(t.Object); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+func recordToTriplet(rec *tripletRecord) (*ds.Triplet, error) {
+	if rec.Subject == nil {
+		return nil, fmt.Errorf("%w: triplet without subject", ErrCorruptObject)
+	}
+	subject, err := recordToSymbol(rec.Subject)
+	if err != nil {
+		return nil, err
+	}
+	var scope *ds.Symbol
+	if rec.Scope != nil {
+		if scope, err = recordToSymbol(rec.Scope); err != nil {
+			return nil, err
+		}
+	}
+	predicate, err := recordToValue(rec.Predicate)
+	if err != nil {
+		return nil, err
+	}
+	object, err := recordToValue(rec.Object)
+	if err != nil {
+		return nil, err
+	}
+	t := ds.NewTriplet(subject, predicate, object, scope)
+	if rec.Truth != nil {
+		if err := t.SetTruth(*rec.Truth); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorruptObject, err)
+		}
+	}
+	return t, nil
+}
+
+func symbolToRecord(sym *ds.Symbol) (*symbolRecord, error) {
+	sym = ds.Deref(sym) // Una variable ligada se guarda por su valor.
+	rec := &symbolRecord{
+		Name:        sym.PublicName,
+		Thing:       string(sym.Thing),
+		LogicalType: sym.LogicalType.String(),
+		Embedding:   sym.Embedding,
+	}
+	switch v := sym.Value.(type) {
+	case nil:
+	case *ds.ListPair:
+		head, err := symbolToRecord(v.Head)
+		if err != nil {
+			return nil, err
+		}
+		tail, err := symbolToRecord(v.Tail)
+		if err != nil {
+			return nil, err
+		}
+		rec.Value = &valueRecord{Kind: "list", Items: []*valueRecord{
+			{Kind: "symbol", Symbol: head}, {Kind: "symbol", Symbol: tail},
+		}}
+	case *ds.StructureTerm:
+		functor, err := symbolToRecord(v.Functor)
+		if err != nil {
+			return nil, err
+		}
+		items := []*valueRecord{{Kind: "symbol", Symbol: functor}}
+		for _, arg := range v.Args {
+			a, err := symbolToRecord(arg)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, &valueRecord{Kind: "symbol", Symbol: a})
+		}
+		rec.Value = &valueRecord{Kind: "structure", Items: items}
+	default:
+		val, err := valueToRecord(v)
+		if err != nil {
+			return nil, err
+		}
+		rec.Value = val
+	}
+	return rec, nil
+}
+
+func recordToSymbol(rec *symbolRecord) (*ds.Symbol, error) {
+	lt, ok := logicalTypes[rec.LogicalType]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown logical type %q", ErrCorruptObject, rec.LogicalType)
+	}
+	switch lt {
+	case ds.LT_Null:
+		return ds.NullSymbol, nil
+	case ds.LT_Anonymous:
+		return ds.AnonymousSymbol, nil
+	}
+
+	// Los símbolos con nombre se internan: si ya existe uno equivalente, se reutiliza.
+	if rec.Name != "" && lt != ds.LT_Variable {
+		if existing, ok := ds.LookupSymbolByPublicName(rec.Name); ok && existing.LogicalType == lt && string(existing.Thing) == rec.Thing {
+			return existing, nil
+		}
+	}
+
+	var value interface{}
+	if rec.Value != nil {
+		switch rec.Value.Kind {
+		case "list", "structure":
+			parts := make([]*ds.Symbol, len(rec.Value.Items))
+			for i, item := range rec.Value.Items {
+				if item.Symbol == nil {
+					return nil, fmt.Errorf("%w: %s element is not a symbol", ErrCorruptObject, rec.Value.Kind)
+				}
+				sym, err := recordToSymbol(item.Symbol)
+				if err != nil {
+					return nil, err
+				}
+				parts[i] = sym
+			}
+			if rec.Value.Kind == "list" {
+				if len(parts) != 2 {
+					return nil, fmt.Errorf("%w: malformed list pair", ErrCorruptObject)
+				}
+				value = &ds.ListPair{Head: parts[0], Tail: parts[1]}
+			} else {
+				if len(parts) == 0 {
+					return nil, fmt.Errorf("%w: structure without functor", ErrCorruptObject)
+				}
+				value = &ds.StructureTerm{Functor: parts[0], Args: parts[1:]}
+			}
+		default:
+			v, err := recordToValue(rec.Value)
+			if err != nil {
+				return nil, err
+			}
+			value = v
+		}
+	}
+
+	var sym *ds.Symbol
+	if lt == ds.LT_Variable {
+		sym = ds.NewVariableSymbol(rec.Name)
+	} else {
+		sym = ds.NewSymbol()
+		if rec.Name != "" {
+			if _, taken := ds.LookupSymbolByPublicName(rec.Name); taken {
+				sym.PublicName = rec.Name // No desplaza al símbolo ya registrado con ese nombre.
+			} else {
+				sym.AssignPublicName(rec.Name)
+			}
+		}
+		sym.LogicalType = lt
+		if value != nil {
+			sym.Value = value
+			sym.State = ds.Embodied
+		}
+	}
+	sym.SetThing(ds.ThingType(rec.Thing))
+	sym.Embedding = rec.Embedding
+	return sym, nil
+}
+
+func valueToRecord(v interface{}) (*valueRecord, error) {
+	switch x := v.(type) {
+	case nil:
+		return &valueRecord{Kind: "nil"}, nil
+	case *ds.Symbol:
+		sym, err := symbolToRecord(x)
+		if err != nil {
+			return nil, err
+		}
+		return &valueRecord{Kind: "symbol", Symbol: sym}, nil
+	case *ds.Triplet:
+		t, err := tripletToRecord(x)
+		if err != nil {
+			return nil, err
+		}
+		return &valueRecord{Kind: "triplet", Triplet: t}, nil
+	case string:
+		return &valueRecord{Kind: "string", Str: x}, nil
+	case bool:
+		return &valueRecord{Kind: "bool", Bool: x}, nil
+	case int:
+		return &valueRecord{Kind: "int", Int: int64(x)}, nil
+	case int64:
+		return &valueRecord{Kind: "int", Int: x}, nil
+	case float32:
+		return &valueRecord{Kind: "float", Float: float64(x)}, nil
+	case float64:
+		return &valueRecord{Kind: "float", Float: x}, nil
+	case complex128:
+		return &valueRecord{Kind: "complex", Str: ds.FormatComplex(x)}, nil
+	case *big.Int:
+		return &valueRecord{Kind: "bigint", Str: x.String()}, nil
+	case *big.Rat:
+		return &valueRecord{Kind: "rational", Str: x.String()}, nil
+	case ds.Decimal:
+		return &valueRecord{Kind: "decimal", Str: x.String()}, nil
+	case []interface{}:
+		items := make([]*valueRecord, len(x))
+		for i, elem := range x {
+			item, err := valueToRecord(elem)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return &valueRecord{Kind: "seq", Items: items}, nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		m := make(map[string]*valueRecord, len(x))
+		for _, k := range keys {
+			item, err := valueToRecord(x[k])
+			if err != nil {
+				return nil, err
+			}
+			m[k] = item
+		}
+		return &valueRecord{Kind: "map", Map: m}, nil
+	default:
+		return nil, fmt.Errorf("trunkv: cannot encode value of type %T", v)
+	}
+}
+
+func recordToValue(rec *valueRecord) (interface{}, error) {
+	if rec == nil {
+		return nil, nil
+	}
+	switch rec.Kind {
+	case "nil":
+		return nil, nil
+	case "symbol":
+		if rec.Symbol == nil {
+			return nil, fmt.Errorf("%w: empty symbol value", ErrCorruptObject)
+		}
+		return recordToSymbol(rec.Symbol)
+	case "triplet":
+		if rec.Triplet == nil {
+			return nil, fmt.Errorf("%w: empty triplet value", ErrCorruptObject)
+		}
+		return recordToTriplet(rec.Triplet)
+	case "string":
+		return rec.Str, nil
+	case "bool":
+		return rec.Bool, nil
+	case "int":
+		return rec.Int, nil
+	case "float":
+		return rec.Float, nil
+	case "complex":
+		c, err := ds.ParseComplex(rec.Str)
+		if err != nil {
+			return nil, fmt.Errorf("%w: complex value %q", ErrCorruptObject, rec.Str)
+		}
+		return c, nil
+	case "bigint":
+		n, ok := new(big.Int).SetString(rec.Str, 10)
+		if !ok {
+			return nil, fmt.Errorf("%w: integer value %q", ErrCorruptObject, rec.Str)
+		}
+		return n, nil
+	case "rational":
+		r, ok := new(big.Rat).SetString(rec.Str)
+		if !ok {
+			return nil, fmt.Errorf("%w: rational value %q", ErrCorruptObject, rec.Str)
+		}
+		return r, nil
+	case "decimal":
+		d, err := ds.ParseDecimal(rec.Str)
+		if err != nil {
+			return nil, fmt.Errorf("%w: decimal value %q", ErrCorruptObject, rec.Str)
+		}
+		return d, nil
+	case "seq":
+		items := make([]interface{}, len(rec.Items))
+		for i, item := range rec.Items {
+			v, err := recordToValue(item)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = v
+		}
+		return items, nil
+	case "map":
+		m := make(map[string]interface{}, len(rec.Map))
+		for k, item := range rec.Map {
+			v, err := recordToValue(item)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = v
+		}
+		return m, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown value kind %q", ErrCorruptObject, rec.Kind)
+	}
+}
+
+// logicalTypes traduce el nombre de un ds.LogicalType (ver LogicalType.String) a su valor.
+var logicalTypes = map[string]ds.LogicalType{
+	ds.LT_Undefined.String(): ds.LT_Undefined,
+	ds.LT_Variable.String():  ds.LT_Variable,
+	ds.LT_Constant.String():  ds.LT_Constant,
+	ds.LT_List.String():      ds.LT_List,
+	ds.LT_Structure.String(): ds.LT_Structure,
+	ds.LT_Anonymous.String(): ds.LT_Anonymous,
+	ds.LT_Null.String():      ds.LT_Null,
+}
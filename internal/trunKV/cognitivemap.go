@@ -0,0 +1,188 @@
+// /nexusl/internal/trunKV/cognitivemap.go
+// .
+// Mapas cognitivos neutrosóficos fermateanos (F-NCM, ver docs/11_FnCognitiveMap.md).
+// .
+// Los conceptos son los sujetos y objetos de las tripletas causales `is`/`do`;
+// cada tripleta aporta una arista sujeto -> objeto cuyo peso se deriva de su
+// grado de verdad: T empuja la activación del destino, F la inhibe e I resta
+// certeza a la relación. La simulación itera las activaciones hasta que dejan
+// de cambiar.
+// .
+package trunkv
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/devicemxl/nexusl/ds"
+)
+
+// CausalPredicates son los predicados que generan aristas en un mapa cognitivo.
+var CausalPredicates = map[string]bool{"is": true, "do": true}
+
+// Edge es una relación causal entre dos conceptos.
+type Edge struct {
+	From  string
+	To    string
+	Truth ds.Truth
+}
+
+// Weight convierte el grado de verdad de la arista en un peso en [-1,1]:
+// la verdad menos la falsedad, atenuada por la indeterminación.
+func (e Edge) Weight() float64 {
+	return (e.Truth.T - e.Truth.F) * (1 - e.Truth.I)
+}
+
+// CognitiveMap es un grafo dirigido y ponderado de conceptos.
+type CognitiveMap struct {
+	concepts []string
+	index    map[string]int
+	edges    map[[2]int][]ds.Truth // Una arista puede estar respaldada por varias tripletas.
+}
+
+// NewCognitiveMap crea un mapa vacío.
+func NewCognitiveMap() *CognitiveMap {
+	return &CognitiveMap{index: make(map[string]int), edges: make(map[[2]int][]ds.Truth)}
+}
+
+// CognitiveMapFromTriplets construye un mapa con las tripletas causales; las demás se ignoran.
+func CognitiveMapFromTriplets(triplets []*ds.Triplet) *CognitiveMap {
+	m := NewCognitiveMap()
+	for _, t := range triplets {
+		pred, ok := t.Predicate.(*ds.Symbol)
+		if !ok || !CausalPredicates[pred.PublicName] {
+			continue
+		}
+		obj, ok := t.Object.(*ds.Symbol)
+		if !ok || obj.PublicName == "" || t.Subject.PublicName == "" {
+			continue
+		}
+		m.AddEdge(t.Subject.PublicName, obj.PublicName, t.Degree())
+	}
+	return m
+}
+
+// CognitiveMap construye el mapa cognitivo del estado de trabajo de la rama activa.
+func (s *Store) CognitiveMap() (*CognitiveMap, error) {
+	triplets, err := s.Triplets()
+	if err != nil {
+		return nil, err
+	}
+	return CognitiveMapFromTriplets(triplets), nil
+}
+
+// AddConcept agrega un concepto si no existe y devuelve su posición.
+func (m *CognitiveMap) AddConcept(name string) int {
+	if i, ok := m.index[name]; ok {
+		return i
+	}
+	m.index[name] = len(m.concepts)
+	m.concepts = append(m.concepts, name)
+	return len(m.concepts) - 1
+}
+
+// AddEdge agrega una relación causal from -> to.
+func (m *CognitiveMap) AddEdge(from, to string, v ds.Truth) {
+	key := [2]int{m.AddConcept(from), m.AddConcept(to)}
+	m.edges[key] = append(m.edges[key], v)
+}
+
+// Concepts devuelve los conceptos en orden de aparición.
+func (m *CognitiveMap) Concepts() []string {
+	return append([]string(nil), m.concepts...)
+}
+
+// Edges devuelve las aristas ordenadas por origen y destino. Cuando varias
+// tripletas respaldan la misma arista, su grado es la media fermateana.
+func (m *CognitiveMap) Edges() []Edge {
+	edges := make([]Edge, 0, len(m.edges))
+	for key, values := range m.edges {
+		v, err := Average(values)
+		if err != nil {
+			v = ds.TruthUnknown // Solo ocurre con valores no fermateanos añadidos a mano.
+		}
+		edges = append(edges, Edge{From: m.concepts[key[0]], To: m.concepts[key[1]], Truth: v})
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
+	})
+	return edges
+}
+
+// SimulationOptions controla la simulación de un mapa cognitivo.
+type SimulationOptions struct {
+	MaxIterations int             // 0 usa 100.
+	Epsilon       float64         // Cambio máximo para considerar que convergió; 0 usa 1e-6.
+	Lambda        float64         // Pendiente de la sigmoide; 0 usa 1.
+	Clamped       map[string]bool // Conceptos cuya activación inicial se mantiene fija.
+}
+
+// Simulation es el resultado de simular un mapa cognitivo.
+type Simulation struct {
+	Activations map[string]float64
+	Iterations  int
+	Converged   bool
+}
+
+// Simulate itera A(t+1) = f(A(t) + A(t)·W), con f sigmoide, a partir de las
+// activaciones iniciales (los conceptos no indicados empiezan en 0), hasta que
+// ninguna activación cambia más de Epsilon o se agotan las iteraciones.
+func (m *CognitiveMap) Simulate(initial map[string]float64, opts SimulationOptions) (*Simulation, error) {
+	if opts.MaxIterations <= 0 {
+		opts.MaxIterations = 100
+	}
+	if opts.Epsilon <= 0 {
+		opts.Epsilon = 1e-6
+	}
+	if opts.Lambda <= 0 {
+		opts.Lambda = 1
+	}
+
+	n := len(m.concepts)
+	state := make([]float64, n)
+	for name, a := range initial {
+		i, ok := m.index[name]
+		if !ok {
+			return nil, fmt.Errorf("trunkv: unknown concept %q", name)
+		}
+		state[i] = a
+	}
+	weights := make([][]float64, n)
+	for i := range weights {
+		weights[i] = make([]float64, n)
+	}
+	for _, e := range m.Edges() {
+		weights[m.index[e.From]][m.index[e.To]] = e.Weight()
+	}
+
+	sim := &Simulation{}
+	next := make([]float64, n)
+	for sim.Iterations < opts.MaxIterations && !sim.Converged {
+		sim.Iterations++
+		delta := 0.0
+		for j := 0; j < n; j++ {
+			if opts.Clamped[m.concepts[j]] {
+				next[j] = state[j]
+				continue
+			}
+			sum := state[j]
+			for i := 0; i < n; i++ {
+				sum += state[i] * weights[i][j]
+			}
+			next[j] = 1 / (1 + math.Exp(-opts.Lambda*sum))
+			delta = math.Max(delta, math.Abs(next[j]-state[j]))
+		}
+		state, next = next, state
+		sim.Converged = delta < opts.Epsilon
+	}
+
+	sim.Activations = make(map[string]float64, n)
+	for i, name := range m.concepts {
+		sim.Activations[name] = state[i]
+	}
+	return sim, nil
+}
@@ -0,0 +1,208 @@
+// /nexusl/internal/trunKV/commits.go
+// .
+// Snapshots inmutables: commit, inspect, log y reset_hard
+// .
+// Ver docs/0b_funciones.md (secciones 1 a 3) y docs/1_commit.md.
+// .
+package trunkv
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// writeTree serializa un estado clave -> blob como objeto tree.
+// Las entradas se ordenan por clave, así que el mismo estado produce siempre el mismo hash.
+func (s *Store) writeTree(state map[string]Hash) (Hash, error) {
+	entries := make([]TreeEntry, 0, len(state))
+	for key, blob := range state {
+		entries = append(entries, TreeEntry{Key: key, Blob: blob})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return ZeroHash, fmt.Errorf("trunkv: failed to encode tree: %w", err)
+	}
+	return s.writeObject(TreeObject, data)
+}
+
+// readTree carga un objeto tree como mapa clave -> blob.
+func (s *Store) readTree(h Hash) (map[string]Hash, error) {
+	state := make(map[string]Hash)
+	if h == ZeroHash {
+		return state, nil
+	}
+	data, err := s.readObject(h, TreeObject)
+	if err != nil {
+		return nil, err
+	}
+	var entries []TreeEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("%w: tree %s: %v", ErrCorruptObject, h.Short(), err)
+	}
+	for _, e := range entries {
+		state[e.Key] = e.Blob
+	}
+	return state, nil
+}
+
+// readCommit carga un objeto commit.
+func (s *Store) readCommit(h Hash) (*Commit, error) {
+	data, err := s.readObject(h, CommitObject)
+	if err != nil {
+		return nil, err
+	}
+	c := &Commit{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("%w: commit %s: %v", ErrCorruptObject, h.Short(), err)
+	}
+	c.Hash = h
+	return c, nil
+}
+
+// commitState devuelve el estado clave -> blob de un commit (vacío para ZeroHash).
+func (s *Store) commitState(h Hash) (map[string]Hash, error) {
+	if h == ZeroHash {
+		return make(map[string]Hash), nil
+	}
+	c, err := s.readCommit(h)
+	if err != nil {
+		return nil, err
+	}
+	return s.readTree(c.Tree)
+}
+
+// loadWorkState reemplaza el estado de trabajo por el snapshot de un commit.
+func (s *Store) loadWorkState(h Hash) error {
+	state, err := s.commitState(h)
+	if err != nil {
+		return err
+	}
+	s.work = state
+	s.dirty = false
+	return nil
+}
+
+// writeCommit crea un objeto commit para un estado y mueve la rama activa hacia él.
+func (s *Store) writeCommit(state map[string]Hash, parents []Hash, message string) (Hash, error) {
+	tree, err := s.writeTree(state)
+	if err != nil {
+		return ZeroHash, err
+	}
+	c := Commit{Tree: tree, Parents: parents, Message: message, Time: time.Now().UTC()}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return ZeroHash, fmt.Errorf("trunkv: failed to encode commit: %w", err)
+	}
+	h, err := s.writeObject(CommitObject, data)
+	if err != nil {
+		return ZeroHash, err
+	}
+	if err := s.setRef(s.head, h); err != nil {
+		return ZeroHash, err
+	}
+	return h, s.sync()
+}
+
+// Commit guarda el estado de trabajo de la rama activa como un snapshot inmutable
+// y devuelve su hash. La rama activa pasa a apuntar al nuevo commit.
+func (s *Store) Commit(message string) (Hash, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ZeroHash, ErrClosed
+	}
+	var parents []Hash
+	if parent := s.refs[s.head]; parent != ZeroHash {
+		parents = []Hash{parent}
+	}
+	h, err := s.writeCommit(s.work, parents, message)
+	if err != nil {
+		return ZeroHash, err
+	}
+	s.dirty = false
+	return h, nil
+}
+
+// Head devuelve la rama activa y el commit al que apunta.
+func (s *Store) Head() (string, Hash) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.head, s.refs[s.head]
+}
+
+// Dirty indica si hay cambios en el estado de trabajo sin confirmar con Commit.
+func (s *Store) Dirty() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dirty
+}
+
+// Inspect devuelve los metadatos de un commit y su snapshot completo (clave -> valor).
+func (s *Store) Inspect(h Hash) (*Commit, map[string][]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, err := s.readCommit(h)
+	if err != nil {
+		return nil, nil, err
+	}
+	state, err := s.readTree(c.Tree)
+	if err != nil {
+		return nil, nil, err
+	}
+	values := make(map[string][]byte, len(state))
+	for key, blob := range state {
+		data, err := s.readObject(blob, BlobObject)
+		if err != nil {
+			return nil, nil, err
+		}
+		values[key] = data
+	}
+	return c, values, nil
+}
+
+// Log devuelve el historial de una rama, del commit más reciente al más antiguo.
+// En los commits de merge se sigue el primer padre.
+func (s *Store) Log(branch string) ([]*Commit, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, ok := s.refs[branch]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoSuchBranch, branch)
+	}
+	var history []*Commit
+	for h != ZeroHash {
+		c, err := s.readCommit(h)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, c)
+		if len(c.Parents) == 0 {
+			break
+		}
+		h = c.Parents[0]
+	}
+	return history, nil
+}
+
+// ResetHard restaura la rama activa a un commit anterior, descartando el estado de trabajo.
+// Los commits posteriores dejan de ser alcanzables desde la rama y los elimina GC.
+func (s *Store) ResetHard(h Hash) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	if _, err := s.readCommit(h); err != nil {
+		return err
+	}
+	if err := s.loadWorkState(h); err != nil {
+		return err
+	}
+	if err := s.setRef(s.head, h); err != nil {
+		return err
+	}
+	return s.sync()
+}
@@ -0,0 +1,53 @@
+// /nexusl/internal/trunKV/diff.go
+// .
+// Diferencias entre snapshots (ver docs/4_diff.md).
+// .
+package trunkv
+
+import "sort"
+
+// Diff compara los snapshots de dos commits y devuelve las claves agregadas,
+// eliminadas y modificadas al pasar de a a b. ZeroHash representa un snapshot vacío.
+func (s *Store) Diff(a, b Hash) ([]Change, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stateA, err := s.commitState(a)
+	if err != nil {
+		return nil, err
+	}
+	stateB, err := s.commitState(b)
+	if err != nil {
+		return nil, err
+	}
+	return diffStates(stateA, stateB), nil
+}
+
+// Status compara el commit de la rama activa con el estado de trabajo.
+func (s *Store) Status() ([]Change, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	committed, err := s.commitState(s.refs[s.head])
+	if err != nil {
+		return nil, err
+	}
+	return diffStates(committed, s.work), nil
+}
+
+// diffStates calcula las diferencias entre dos estados clave -> blob, ordenadas por clave.
+func diffStates(from, to map[string]Hash) []Change {
+	var changes []Change
+	for key, old := range from {
+		if cur, ok := to[key]; !ok {
+			changes = append(changes, Change{Type: Deleted, Key: key, Old: old})
+		} else if cur != old {
+			changes = append(changes, Change{Type: Modified, Key: key, Old: old, New: cur})
+		}
+	}
+	for key, cur := range to {
+		if _, ok := from[key]; !ok {
+			changes = append(changes, Change{Type: Added, Key: key, New: cur})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
@@ -0,0 +1,163 @@
+// /nexusl/internal/trunKV/fns.go
+// .
+// Evaluación neutrosófica fermateana de ramas (ver docs/8_FnEvaluation.md y
+// docs/10_FnBrachSet.md).
+// .
+// Cada tripleta puede llevar un ds.Truth; una rama se evalúa agregando los
+// valores de sus tripletas, y un conjunto de ramas se compara por puntuación
+// y por divergencia.
+// .
+package trunkv
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/devicemxl/nexusl/ds"
+)
+
+// WeightedAverage agrega valores con el operador de media ponderada fermateana:
+//
+//	T = ∛(1 − Π (1 − Tᵢ³)^wᵢ),  I = Π Iᵢ^wᵢ,  F = Π Fᵢ^wᵢ
+//
+// Los pesos se normalizan para que sumen 1; con weights nil todos pesan igual.
+// El resultado siempre cumple la restricción fermateana.
+func WeightedAverage(values []ds.Truth, weights []float64) (ds.Truth, error) {
+	if len(values) == 0 {
+		return ds.Truth{}, fmt.Errorf("trunkv: cannot aggregate an empty set of truth values")
+	}
+	if weights == nil {
+		weights = make([]float64, len(values))
+		for i := range weights {
+			weights[i] = 1
+		}
+	}
+	if len(weights) != len(values) {
+		return ds.Truth{}, fmt.Errorf("trunkv: got %d weights for %d truth values", len(weights), len(values))
+	}
+	total := 0.0
+	for _, w := range weights {
+		if w < 0 || math.IsNaN(w) {
+			return ds.Truth{}, fmt.Errorf("trunkv: invalid weight %v", w)
+		}
+		total += w
+	}
+	if total == 0 {
+		return ds.Truth{}, fmt.Errorf("trunkv: weights add up to zero")
+	}
+
+	notT, i, f := 1.0, 1.0, 1.0
+	for k, v := range values {
+		if !v.Valid() {
+			return ds.Truth{}, fmt.Errorf("%w: %s", ds.ErrNotFermatean, v)
+		}
+		w := weights[k] / total
+		notT *= math.Pow(1-v.T*v.T*v.T, w)
+		i *= math.Pow(v.I, w)
+		f *= math.Pow(v.F, w)
+	}
+	return ds.Truth{T: math.Cbrt(1 - notT), I: i, F: f}.Normalize(), nil
+}
+
+// Average agrega valores con pesos iguales.
+func Average(values []ds.Truth) (ds.Truth, error) {
+	return WeightedAverage(values, nil)
+}
+
+// Divergence es la divergencia media entre todos los pares de valores:
+// ≈0 cuando coinciden y crece cuando representan hipótesis opuestas.
+func Divergence(values []ds.Truth) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	sum, pairs := 0.0, 0
+	for i := range values {
+		for j := i + 1; j < len(values); j++ {
+			sum += values[i].Distance(values[j])
+			pairs++
+		}
+	}
+	return sum / float64(pairs)
+}
+
+// BranchEvaluation es el resultado de evaluar una rama.
+type BranchEvaluation struct {
+	Branch   string
+	Truth    ds.Truth
+	Triplets int
+}
+
+// EvaluateBranch agrega los grados de verdad de las tripletas del último commit
+// de una rama. Las tripletas sin grado cuentan como verdaderas; una rama sin
+// tripletas se evalúa como ds.TruthUnknown.
+func (s *Store) EvaluateBranch(name string) (BranchEvaluation, error) {
+	triplets, err := s.branchTriplets(name)
+	if err != nil {
+		return BranchEvaluation{}, err
+	}
+	eval := BranchEvaluation{Branch: name, Truth: ds.TruthUnknown, Triplets: len(triplets)}
+	if len(triplets) == 0 {
+		return eval, nil
+	}
+	values := make([]ds.Truth, len(triplets))
+	for i, t := range triplets {
+		values[i] = t.Degree()
+	}
+	if eval.Truth, err = Average(values); err != nil {
+		return BranchEvaluation{}, err
+	}
+	return eval, nil
+}
+
+// RankBranches evalúa varias ramas y las devuelve de la más a la menos creíble,
+// junto con la divergencia entre ellas.
+func (s *Store) RankBranches(names []string) ([]BranchEvaluation, float64, error) {
+	evals := make([]BranchEvaluation, 0, len(names))
+	values := make([]ds.Truth, 0, len(names))
+	for _, name := range names {
+		eval, err := s.EvaluateBranch(name)
+		if err != nil {
+			return nil, 0, err
+		}
+		evals = append(evals, eval)
+		values = append(values, eval.Truth)
+	}
+	sort.SliceStable(evals, func(i, j int) bool { return evals[i].Truth.Compare(evals[j].Truth) > 0 })
+	return evals, Divergence(values), nil
+}
+
+// branchTriplets reconstruye las tripletas del último commit de una rama.
+func (s *Store) branchTriplets(name string) ([]*ds.Triplet, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, ok := s.refs[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoSuchBranch, name)
+	}
+	state, err := s.commitState(h)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(state))
+	for key := range state {
+		if strings.HasPrefix(key, TripletPrefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	triplets := make([]*ds.Triplet, 0, len(keys))
+	for _, key := range keys {
+		data, err := s.readObject(state[key], BlobObject)
+		if err != nil {
+			return nil, err
+		}
+		t, err := DecodeTriplet(data)
+		if err != nil {
+			return nil, err
+		}
+		triplets = append(triplets, t)
+	}
+	return triplets, nil
+}
@@ -0,0 +1,138 @@
+// /nexusl/internal/trunKV/gc.go
+// .
+// Recolección de basura de versiones inalcanzables.
+// .
+// Como el log es append-only, los objetos que dejan de ser alcanzables (por
+// reset_hard, ramas borradas o valores sobrescritos antes de un commit)
+// siguen ocupando disco. GC marca todo lo alcanzable desde las ramas y el
+// estado de trabajo, escribe un log nuevo solo con eso y lo reemplaza de
+// forma atómica.
+// .
+package trunkv
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// GC compacta el log eliminando los objetos inalcanzables y devuelve cuántos se eliminaron.
+func (s *Store) GC() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return 0, ErrClosed
+	}
+
+	live, err := s.markReachable()
+	if err != nil {
+		return 0, err
+	}
+
+	// Se conservan en el orden original del log para que la compactación sea determinista.
+	hashes := make([]Hash, 0, len(live))
+	for h := range live {
+		hashes = append(hashes, h)
+	}
+	sort.Slice(hashes, func(i, j int) bool { return s.objects[hashes[i]].offset < s.objects[hashes[j]].offset })
+
+	tmpPath := filepath.Join(s.dir, logFileName+".gc")
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return 0, fmt.Errorf("trunkv: failed to create compacted log: %w", err)
+	}
+	abort := func(err error) (int, error) {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return 0, err
+	}
+	compact := &Store{
+		dir:     s.dir,
+		file:    tmp,
+		objects: make(map[Hash]objectLocation),
+		refs:    make(map[string]Hash),
+	}
+	if err := compact.replay(); err != nil {
+		return abort(err)
+	}
+	for _, h := range hashes {
+		loc := s.objects[h]
+		data, err := s.readObject(h, loc.kind)
+		if err != nil {
+			return abort(err)
+		}
+		if _, err := compact.writeObject(loc.kind, data); err != nil {
+			return abort(err)
+		}
+	}
+	names := make([]string, 0, len(s.refs))
+	for name := range s.refs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := compact.setRef(name, s.refs[name]); err != nil {
+			return abort(err)
+		}
+	}
+	if err := compact.appendRecord(recHead, []byte(s.head)); err != nil {
+		return abort(err)
+	}
+	if err := compact.sync(); err != nil {
+		return abort(err)
+	}
+
+	logPath := filepath.Join(s.dir, logFileName)
+	if err := os.Rename(tmpPath, logPath); err != nil {
+		return abort(fmt.Errorf("trunkv: failed to replace log: %w", err))
+	}
+	removed := len(s.objects) - len(compact.objects)
+	s.file.Close()
+	s.file = tmp
+	s.size = compact.size
+	s.objects = compact.objects
+	return removed, nil
+}
+
+// markReachable devuelve los objetos alcanzables desde las ramas y el estado de trabajo.
+func (s *Store) markReachable() (map[Hash]bool, error) {
+	live := make(map[Hash]bool)
+	for _, blob := range s.work {
+		live[blob] = true
+	}
+	var queue []Hash
+	for _, h := range s.refs {
+		queue = append(queue, h)
+	}
+	for len(queue) > 0 {
+		h := queue[0]
+		queue = queue[1:]
+		if h == ZeroHash || live[h] {
+			continue
+		}
+		live[h] = true
+		data, err := s.readObject(h, CommitObject)
+		if err != nil {
+			return nil, err
+		}
+		var c Commit
+		if err := json.Unmarshal(data, &c); err != nil {
+			return nil, fmt.Errorf("%w: commit %s: %v", ErrCorruptObject, h.Short(), err)
+		}
+		queue = append(queue, c.Parents...)
+		if live[c.Tree] {
+			continue
+		}
+		live[c.Tree] = true
+		state, err := s.readTree(c.Tree)
+		if err != nil {
+			return nil, err
+		}
+		for _, blob := range state {
+			live[blob] = true
+		}
+	}
+	return live, nil
+}
@@ -0,0 +1,192 @@
+// /nexusl/internal/trunKV/merge.go
+// .
+// Fusión de ramas de conocimiento (ver docs/5_Merge.md).
+// .
+// El merge es a tres vías sobre claves: se comparan ambas ramas con su
+// ancestro común. Si una clave cambió solo en una rama, se acepta ese cambio;
+// si cambió de forma distinta en ambas, es un conflicto que resuelve la
+// estrategia elegida.
+// .
+package trunkv
+
+import (
+	"fmt"
+	"sort"
+)
+
+// MergeStrategy decide qué hacer con las claves en conflicto.
+type MergeStrategy int
+
+const (
+	MergeFail   MergeStrategy = iota // No crea el commit y devuelve los conflictos.
+	MergeOurs                        // Conserva la versión de la rama activa.
+	MergeTheirs                      // Toma la versión de la rama fusionada.
+)
+
+// Merge fusiona la rama branch en la rama activa y devuelve el commit resultante.
+// Si la rama activa es ancestro de branch, solo avanza el puntero (fast-forward).
+// Con MergeFail y conflictos, devuelve los conflictos y ErrMergeConflict sin
+// modificar nada.
+func (s *Store) Merge(branch, message string, strategy MergeStrategy) (Hash, []Conflict, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ZeroHash, nil, ErrClosed
+	}
+	theirs, ok := s.refs[branch]
+	if !ok {
+		return ZeroHash, nil, fmt.Errorf("%w: %s", ErrNoSuchBranch, branch)
+	}
+	if s.dirty {
+		return ZeroHash, nil, ErrDirtyWorkState
+	}
+	ours := s.refs[s.head]
+
+	ancestorsOfOurs, err := s.ancestors(ours)
+	if err != nil {
+		return ZeroHash, nil, err
+	}
+	if theirs == ZeroHash || ancestorsOfOurs[theirs] {
+		return ours, nil, nil // Nada que fusionar.
+	}
+	ancestorsOfTheirs, err := s.ancestors(theirs)
+	if err != nil {
+		return ZeroHash, nil, err
+	}
+	if ours == ZeroHash || ancestorsOfTheirs[ours] {
+		if err := s.loadWorkState(theirs); err != nil {
+			return ZeroHash, nil, err
+		}
+		if err := s.setRef(s.head, theirs); err != nil {
+			return ZeroHash, nil, err
+		}
+		return theirs, nil, s.sync()
+	}
+
+	base, err := s.mergeBase(ancestorsOfOurs, theirs)
+	if err != nil {
+		return ZeroHash, nil, err
+	}
+	baseState, err := s.commitState(base)
+	if err != nil {
+		return ZeroHash, nil, err
+	}
+	ourState, err := s.commitState(ours)
+	if err != nil {
+		return ZeroHash, nil, err
+	}
+	theirState, err := s.commitState(theirs)
+	if err != nil {
+		return ZeroHash, nil, err
+	}
+
+	merged, conflicts := mergeStates(baseState, ourState, theirState)
+	if len(conflicts) > 0 {
+		switch strategy {
+		case MergeOurs:
+			for _, c := range conflicts {
+				setOrDelete(merged, c.Key, c.Ours)
+			}
+		case MergeTheirs:
+			for _, c := range conflicts {
+				setOrDelete(merged, c.Key, c.Theirs)
+			}
+		default:
+			return ZeroHash, conflicts, ErrMergeConflict
+		}
+	}
+
+	if message == "" {
+		message = fmt.Sprintf("merge %s into %s", branch, s.head)
+	}
+	h, err := s.writeCommit(merged, []Hash{ours, theirs}, message)
+	if err != nil {
+		return ZeroHash, conflicts, err
+	}
+	s.work = merged
+	s.dirty = false
+	return h, conflicts, nil
+}
+
+// mergeStates aplica la fusión a tres vías clave por clave.
+func mergeStates(base, ours, theirs map[string]Hash) (map[string]Hash, []Conflict) {
+	merged := make(map[string]Hash, len(ours))
+	for key, h := range ours {
+		merged[key] = h
+	}
+	var conflicts []Conflict
+	keys := make(map[string]struct{})
+	for key := range base {
+		keys[key] = struct{}{}
+	}
+	for key := range ours {
+		keys[key] = struct{}{}
+	}
+	for key := range theirs {
+		keys[key] = struct{}{}
+	}
+	for key := range keys {
+		b, o, t := base[key], ours[key], theirs[key]
+		switch {
+		case o == t: // Igual en ambas ramas.
+		case o == b: // Solo cambió en la rama fusionada.
+			setOrDelete(merged, key, t)
+		case t == b: // Solo cambió en la rama activa.
+		default:
+			conflicts = append(conflicts, Conflict{Key: key, Base: b, Ours: o, Theirs: t})
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Key < conflicts[j].Key })
+	return merged, conflicts
+}
+
+func setOrDelete(state map[string]Hash, key string, h Hash) {
+	if h == ZeroHash {
+		delete(state, key)
+	} else {
+		state[key] = h
+	}
+}
+
+// ancestors devuelve el conjunto de commits alcanzables desde h (incluido h).
+func (s *Store) ancestors(h Hash) (map[Hash]bool, error) {
+	seen := make(map[Hash]bool)
+	queue := []Hash{h}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		if cur == ZeroHash || seen[cur] {
+			continue
+		}
+		seen[cur] = true
+		c, err := s.readCommit(cur)
+		if err != nil {
+			return nil, err
+		}
+		queue = append(queue, c.Parents...)
+	}
+	return seen, nil
+}
+
+// mergeBase busca, en anchura desde theirs, el primer commit que también es ancestro de la rama activa.
+func (s *Store) mergeBase(ancestorsOfOurs map[Hash]bool, theirs Hash) (Hash, error) {
+	seen := make(map[Hash]bool)
+	queue := []Hash{theirs}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		if cur == ZeroHash || seen[cur] {
+			continue
+		}
+		if ancestorsOfOurs[cur] {
+			return cur, nil
+		}
+		seen[cur] = true
+		c, err := s.readCommit(cur)
+		if err != nil {
+			return ZeroHash, err
+		}
+		queue = append(queue, c.Parents...)
+	}
+	return ZeroHash, nil // Historias sin ancestro común: la base es el snapshot vacío.
+}
@@ -0,0 +1,115 @@
+// /nexusl/internal/trunKV/models.go
+// .
+// Modelos de datos de trunKV
+// .
+// trunKV guarda el estado del conocimiento como un árbol clave/valor
+// versionado, inspirado en los objetos de git v0.99 (blob, tree, commit).
+// Todos los objetos son inmutables y se direccionan por contenido:
+// su identificador es el SHA-256 de su tipo y su contenido serializado.
+// .
+package trunkv
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// Hash es el identificador de un objeto direccionado por contenido (SHA-256 en hex).
+type Hash string
+
+// ZeroHash representa la ausencia de objeto (por ejemplo, una rama sin commits).
+const ZeroHash Hash = ""
+
+// Short devuelve los primeros caracteres del hash, útil para mostrarlo.
+func (h Hash) Short() string {
+	if len(h) > 12 {
+		return string(h[:12])
+	}
+	return string(h)
+}
+
+// ObjectKind identifica el tipo de un objeto almacenado en el log.
+type ObjectKind byte
+
+const (
+	BlobObject   ObjectKind = 'b' // Valor serializado (tripleta, símbolo o bytes arbitrarios).
+	TreeObject   ObjectKind = 't' // Snapshot: lista ordenada de claves -> blob.
+	CommitObject ObjectKind = 'c' // Commit: apunta a un tree y a sus padres.
+)
+
+// String devuelve la representación en cadena de ObjectKind.
+func (k ObjectKind) String() string {
+	switch k {
+	case BlobObject:
+		return "blob"
+	case TreeObject:
+		return "tree"
+	case CommitObject:
+		return "commit"
+	default:
+		return "unknown"
+	}
+}
+
+// hashObject calcula el identificador de un objeto a partir de su tipo y contenido.
+func hashObject(kind ObjectKind, data []byte) Hash {
+	h := sha256.New()
+	h.Write([]byte{byte(kind), 0})
+	h.Write(data)
+	return Hash(hex.EncodeToString(h.Sum(nil)))
+}
+
+// TreeEntry es una entrada de un snapshot: una clave y el blob con su valor.
+type TreeEntry struct {
+	Key  string `json:"key"`
+	Blob Hash   `json:"blob"`
+}
+
+// Commit es un snapshot inmutable del estado del conocimiento.
+type Commit struct {
+	Hash    Hash      `json:"-"`
+	Tree    Hash      `json:"tree"`
+	Parents []Hash    `json:"parents,omitempty"`
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+// ChangeType clasifica una diferencia entre dos snapshots.
+type ChangeType string
+
+const (
+	Added    ChangeType = "added"
+	Deleted  ChangeType = "deleted"
+	Modified ChangeType = "modified"
+)
+
+// Change describe una clave que difiere entre dos snapshots.
+type Change struct {
+	Type ChangeType
+	Key  string
+	Old  Hash // Blob en el snapshot origen (vacío si Added).
+	New  Hash // Blob en el snapshot destino (vacío si Deleted).
+}
+
+// Conflict describe una clave modificada de forma distinta en ambas ramas de un merge.
+type Conflict struct {
+	Key    string
+	Base   Hash // Blob en el ancestro común (vacío si no existía).
+	Ours   Hash // Blob en la rama activa (vacío si se borró).
+	Theirs Hash // Blob en la rama fusionada (vacío si se borró).
+}
+
+// Errores devueltos por el almacén.
+var (
+	ErrNotFound       = errors.New("trunkv: not found")
+	ErrBranchExists   = errors.New("trunkv: branch already exists")
+	ErrNoSuchBranch   = errors.New("trunkv: branch not found")
+	ErrActiveBranch   = errors.New("trunkv: cannot delete the active branch")
+	ErrDirtyWorkState = errors.New("trunkv: uncommitted changes in the working state")
+	ErrMergeConflict  = errors.New("trunkv: merge conflict")
+	ErrCorruptObject  = errors.New("trunkv: corrupt object")
+	ErrCorruptLog     = errors.New("trunkv: corrupt record before the end of the log")
+	ErrClosed         = errors.New("trunkv: store is closed")
+)
@@ -0,0 +1,164 @@
+// /nexusl/internal/trunKV/ops.go
+// .
+// Operaciones sobre el estado de trabajo de la rama activa
+// .
+// Put/Get/Delete trabajan con bytes arbitrarios. PutTriplet y PutSymbol
+// serializan tripletas y símbolos de `ds` (ver codec.go) bajo claves con
+// prefijo, de modo que un diff entre commits se pueda leer por sujeto y predicado.
+// .
+package trunkv
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/devicemxl/nexusl/ds"
+)
+
+const (
+	TripletPrefix = "triplet/"
+	SymbolPrefix  = "symbol/"
+)
+
+// Put guarda un valor bajo una clave en el estado de trabajo.
+// El valor se escribe de inmediato en el log como blob, pero solo pasa a
+// formar parte de un snapshot al llamar a Commit.
+func (s *Store) Put(key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	if key == "" {
+		return fmt.Errorf("trunkv: key cannot be empty")
+	}
+	h, err := s.writeObject(BlobObject, value)
+	if err != nil {
+		return err
+	}
+	if s.work[key] != h {
+		s.work[key] = h
+		s.dirty = true
+	}
+	return nil
+}
+
+// Get recupera el valor de una clave en el estado de trabajo.
+func (s *Store) Get(key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, ok := s.work[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: key %q", ErrNotFound, key)
+	}
+	return s.readObject(h, BlobObject)
+}
+
+// Delete elimina una clave del estado de trabajo.
+func (s *Store) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	if _, ok := s.work[key]; !ok {
+		return fmt.Errorf("%w: key %q", ErrNotFound, key)
+	}
+	delete(s.work, key)
+	s.dirty = true
+	return nil
+}
+
+// Keys devuelve, ordenadas, las claves del estado de trabajo que empiezan por prefix.
+func (s *Store) Keys(prefix string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var keys []string
+	for key := range s.work {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// PutTriplet serializa una tripleta y la guarda en el estado de trabajo.
+// Devuelve la clave asignada, derivada de su sujeto, su predicado y su contenido.
+func (s *Store) PutTriplet(t *ds.Triplet) (string, error) {
+	data, err := EncodeTriplet(t)
+	if err != nil {
+		return "", err
+	}
+	key := TripletKey(t, data)
+	return key, s.Put(key, data)
+}
+
+// GetTriplet recupera y reconstruye la tripleta guardada bajo key.
+func (s *Store) GetTriplet(key string) (*ds.Triplet, error) {
+	data, err := s.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeTriplet(data)
+}
+
+// DeleteTriplet elimina una tripleta del estado de trabajo.
+func (s *Store) DeleteTriplet(t *ds.Triplet) error {
+	data, err := EncodeTriplet(t)
+	if err != nil {
+		return err
+	}
+	return s.Delete(TripletKey(t, data))
+}
+
+// Triplets reconstruye todas las tripletas del estado de trabajo.
+func (s *Store) Triplets() ([]*ds.Triplet, error) {
+	var triplets []*ds.Triplet
+	for _, key := range s.Keys(TripletPrefix) {
+		t, err := s.GetTriplet(key)
+		if err != nil {
+			return nil, err
+		}
+		triplets = append(triplets, t)
+	}
+	return triplets, nil
+}
+
+// PutSymbol guarda un símbolo bajo la clave symbol/<PublicName>.
+func (s *Store) PutSymbol(sym *ds.Symbol) error {
+	if sym == nil || sym.PublicName == "" {
+		return fmt.Errorf("trunkv: only named symbols can be stored")
+	}
+	data, err := EncodeSymbol(sym)
+	if err != nil {
+		return err
+	}
+	return s.Put(SymbolPrefix+sym.PublicName, data)
+}
+
+// GetSymbol recupera un símbolo por su nombre público.
+func (s *Store) GetSymbol(name string) (*ds.Symbol, error) {
+	data, err := s.Get(SymbolPrefix + name)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeSymbol(data)
+}
+
+// TripletKey construye la clave de una tripleta ya serializada.
+func TripletKey(t *ds.Triplet, encoded []byte) string {
+	return TripletPrefix + keyPart(t.Subject) + "/" + keyPart(t.Predicate) + "/" + hashObject(BlobObject, encoded).Short()
+}
+
+// keyPart devuelve un fragmento legible de clave para un componente de tripleta.
+func keyPart(v interface{}) string {
+	if sym, ok := v.(*ds.Symbol); ok && sym != nil {
+		if sym.PublicName != "" {
+			return strings.ReplaceAll(sym.PublicName, "/", "_")
+		}
+		return fmt.Sprintf("anon:%d", sym.ID)
+	}
+	return "_"
+}
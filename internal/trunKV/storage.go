@@ -0,0 +1,295 @@
+// /nexusl/internal/trunKV/storage.go
+// .
+// Persistencia de trunKV: log append-only en disco
+// .
+// Todo lo que trunKV guarda (objetos, punteros de rama y HEAD) se añade al
+// final de un único archivo de log. Nada se sobrescribe: el estado en memoria
+// se reconstruye al abrir el almacén reproduciendo el log de principio a fin.
+// Si el último registro quedó truncado (por ejemplo, tras un corte de luz),
+// se descarta y el log se recorta al último registro válido. Un registro
+// corrupto seguido de otros válidos no es un final truncado: Open falla con
+// ErrCorruptLog y no toca el archivo.
+// .
+// Formato de cada registro:
+//
+//	tipo (1 byte) | longitud (uint32 BE) | payload | crc32 (uint32 BE, sobre tipo+payload)
+//
+// .
+package trunkv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	logFileName   = "trunkv.log"
+	logMagic      = "TRUNKV1\n"
+	DefaultBranch = "main"
+
+	recordHeaderSize = 5 // tipo + longitud
+	recordTrailSize  = 4 // crc32
+)
+
+// recordType identifica el tipo de un registro del log.
+type recordType byte
+
+const (
+	recObject    recordType = 1 // payload: kind (1 byte) + datos del objeto
+	recRef       recordType = 2 // payload: nombre de rama + 0x00 + hash del commit
+	recDeleteRef recordType = 3 // payload: nombre de rama
+	recHead      recordType = 4 // payload: nombre de la rama activa
+)
+
+// objectLocation indica dónde están los datos de un objeto dentro del log.
+type objectLocation struct {
+	kind   ObjectKind
+	offset int64
+	length int
+}
+
+// Store es un almacén clave/valor versionado y ramificable sobre un log en disco.
+// Los cambios se hacen sobre el estado de trabajo de la rama activa y se
+// vuelven permanentes con Commit, que crea un snapshot inmutable.
+type Store struct {
+	mu     sync.Mutex
+	dir    string
+	file   *os.File
+	size   int64 // Final del último registro válido del log.
+	closed bool
+
+	objects map[Hash]objectLocation
+	refs    map[string]Hash // Ramas: nombre -> commit.
+	head    string          // Rama activa.
+
+	// Estado de trabajo de la rama activa: clave -> blob.
+	work  map[string]Hash
+	dirty bool
+}
+
+// Open abre (o crea) un almacén trunKV en el directorio dir.
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("trunkv: failed to create directory %s: %w", dir, err)
+	}
+	f, err := os.OpenFile(filepath.Join(dir, logFileName), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("trunkv: failed to open log: %w", err)
+	}
+	s := &Store{
+		dir:     dir,
+		file:    f,
+		objects: make(map[Hash]objectLocation),
+		refs:    make(map[string]Hash),
+		head:    DefaultBranch,
+		work:    make(map[string]Hash),
+	}
+	if err := s.replay(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := s.loadWorkState(s.refs[s.head]); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close cierra el almacén. Los cambios no confirmados con Commit se pierden.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	return s.file.Close()
+}
+
+// Dir devuelve el directorio del almacén.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// replay reconstruye los índices en memoria leyendo el log completo.
+func (s *Store) replay() error {
+	info, err := s.file.Stat()
+	if err != nil {
+		return fmt.Errorf("trunkv: failed to stat log: %w", err)
+	}
+	if info.Size() == 0 {
+		if _, err := s.file.WriteAt([]byte(logMagic), 0); err != nil {
+			return fmt.Errorf("trunkv: failed to initialise log: %w", err)
+		}
+		s.size = int64(len(logMagic))
+		return s.file.Sync()
+	}
+
+	magic := make([]byte, len(logMagic))
+	if _, err := s.file.ReadAt(magic, 0); err != nil || string(magic) != logMagic {
+		return fmt.Errorf("trunkv: %s is not a trunKV log", filepath.Join(s.dir, logFileName))
+	}
+
+	offset := int64(len(logMagic))
+	for offset < info.Size() {
+		typ, payload, next, err := s.readRecord(offset, info.Size())
+		if err != nil {
+			// Solo se descarta si es el final del log: si detrás hay registros
+			// válidos, recortar los perdería.
+			if s.validRecordAfter(offset, info.Size()) {
+				return fmt.Errorf("%w: offset %d of %s", ErrCorruptLog, offset, filepath.Join(s.dir, logFileName))
+			}
+			break
+		}
+		s.applyRecord(typ, payload, offset)
+		offset = next
+	}
+	s.size = offset
+	if offset < info.Size() {
+		if err := s.file.Truncate(offset); err != nil {
+			return fmt.Errorf("trunkv: failed to truncate torn log tail: %w", err)
+		}
+	}
+	return nil
+}
+
+// readRecord lee el registro que empieza en offset y devuelve el offset del
+// siguiente. Un registro que no cabe antes de size está incompleto.
+func (s *Store) readRecord(offset, size int64) (recordType, []byte, int64, error) {
+	var header [recordHeaderSize]byte
+	if _, err := s.file.ReadAt(header[:], offset); err != nil {
+		return 0, nil, 0, err
+	}
+	length := int64(binary.BigEndian.Uint32(header[1:]))
+	if offset+recordHeaderSize+length+recordTrailSize > size {
+		return 0, nil, 0, io.ErrUnexpectedEOF
+	}
+	body := make([]byte, length+recordTrailSize)
+	if _, err := s.file.ReadAt(body, offset+recordHeaderSize); err != nil {
+		return 0, nil, 0, err
+	}
+	payload := body[:length]
+	sum := binary.BigEndian.Uint32(body[length:])
+	if recordChecksum(header[0], payload) != sum {
+		return 0, nil, 0, ErrCorruptObject
+	}
+	return recordType(header[0]), payload, offset + recordHeaderSize + length + recordTrailSize, nil
+}
+
+// validRecordAfter indica si hay algún registro válido que empiece después
+// de offset, es decir, si el registro roto en offset no es el último.
+func (s *Store) validRecordAfter(offset, size int64) bool {
+	for o := offset + 1; o+recordHeaderSize+recordTrailSize <= size; o++ {
+		typ, _, _, err := s.readRecord(o, size)
+		if err == nil && typ >= recObject && typ <= recHead {
+			return true
+		}
+	}
+	return false
+}
+
+// applyRecord actualiza los índices en memoria con un registro ya validado.
+func (s *Store) applyRecord(typ recordType, payload []byte, offset int64) {
+	switch typ {
+	case recObject:
+		if len(payload) == 0 {
+			return
+		}
+		kind := ObjectKind(payload[0])
+		data := payload[1:]
+		s.objects[hashObject(kind, data)] = objectLocation{
+			kind:   kind,
+			offset: offset + recordHeaderSize + 1,
+			length: len(data),
+		}
+	case recRef:
+		if i := bytes.IndexByte(payload, 0); i >= 0 {
+			s.refs[string(payload[:i])] = Hash(payload[i+1:])
+		}
+	case recDeleteRef:
+		delete(s.refs, string(payload))
+	case recHead:
+		s.head = string(payload)
+	}
+}
+
+// appendRecord añade un registro al final del log y actualiza los índices.
+func (s *Store) appendRecord(typ recordType, payload []byte) error {
+	if s.closed {
+		return ErrClosed
+	}
+	buf := make([]byte, recordHeaderSize+len(payload)+recordTrailSize)
+	buf[0] = byte(typ)
+	binary.BigEndian.PutUint32(buf[1:], uint32(len(payload)))
+	copy(buf[recordHeaderSize:], payload)
+	binary.BigEndian.PutUint32(buf[recordHeaderSize+len(payload):], recordChecksum(byte(typ), payload))
+
+	if _, err := s.file.WriteAt(buf, s.size); err != nil {
+		return fmt.Errorf("trunkv: failed to append to log: %w", err)
+	}
+	s.applyRecord(typ, payload, s.size)
+	s.size += int64(len(buf))
+	return nil
+}
+
+func recordChecksum(typ byte, payload []byte) uint32 {
+	h := crc32.NewIEEE()
+	h.Write([]byte{typ})
+	h.Write(payload)
+	return h.Sum32()
+}
+
+// writeObject guarda un objeto si no existe ya y devuelve su hash.
+// Al estar direccionado por contenido, escribir dos veces el mismo objeto es una no-op.
+func (s *Store) writeObject(kind ObjectKind, data []byte) (Hash, error) {
+	h := hashObject(kind, data)
+	if _, ok := s.objects[h]; ok {
+		return h, nil
+	}
+	payload := make([]byte, 0, len(data)+1)
+	payload = append(payload, byte(kind))
+	payload = append(payload, data...)
+	if err := s.appendRecord(recObject, payload); err != nil {
+		return ZeroHash, err
+	}
+	return h, nil
+}
+
+// readObject recupera los datos de un objeto y verifica que sean del tipo esperado.
+func (s *Store) readObject(h Hash, want ObjectKind) ([]byte, error) {
+	loc, ok := s.objects[h]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s %s", ErrNotFound, want, h.Short())
+	}
+	if loc.kind != want {
+		return nil, fmt.Errorf("%w: %s is a %s, not a %s", ErrCorruptObject, h.Short(), loc.kind, want)
+	}
+	data := make([]byte, loc.length)
+	if _, err := s.file.ReadAt(data, loc.offset); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("trunkv: failed to read object %s: %w", h.Short(), err)
+	}
+	return data, nil
+}
+
+// setRef mueve (o crea) el puntero de una rama.
+func (s *Store) setRef(name string, h Hash) error {
+	payload := make([]byte, 0, len(name)+1+len(h))
+	payload = append(payload, name...)
+	payload = append(payload, 0)
+	payload = append(payload, h...)
+	return s.appendRecord(recRef, payload)
+}
+
+// sync fuerza la escritura del log en disco.
+func (s *Store) sync() error {
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("trunkv: failed to sync log: %w", err)
+	}
+	return nil
+}
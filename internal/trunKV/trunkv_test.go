@@ -0,0 +1,307 @@
+package trunkv_test
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/devicemxl/nexusl/ds"
+	trunkv "github.com/devicemxl/nexusl/internal/trunKV"
+)
+
+func fact(subject, predicate, object string) *ds.Triplet {
+	return ds.NewTriplet(
+		ds.NewConstantSymbol(subject, subject),
+		ds.NewConstantSymbol(predicate, predicate),
+		ds.NewConstantSymbol(object, object),
+		nil,
+	)
+}
+
+func TestBranchIsolationAndMerge(t *testing.T) {
+	s, err := trunkv.Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer s.Close()
+
+	if _, err := s.PutTriplet(fact("door", "is", "closed")); err != nil {
+		t.Fatalf("PutTriplet: %v", err)
+	}
+	base, err := s.Commit("initial knowledge")
+	if err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	// Razonamiento hipotético en una rama.
+	if err := s.Fork("hypothesis"); err != nil {
+		t.Fatalf("Fork: %v", err)
+	}
+	if _, err := s.PutTriplet(fact("door", "is", "unsafe")); err != nil {
+		t.Fatalf("PutTriplet: %v", err)
+	}
+	hyp, err := s.Commit("what if the door is unsafe")
+	if err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	if err := s.SwitchBranch(trunkv.DefaultBranch); err != nil {
+		t.Fatalf("SwitchBranch: %v", err)
+	}
+	triplets, err := s.Triplets()
+	if err != nil {
+		t.Fatalf("Triplets: %v", err)
+	}
+	if len(triplets) != 1 {
+		t.Fatalf("main branch was contaminated: got %d triplets, want 1", len(triplets))
+	}
+
+	changes, err := s.Diff(base, hyp)
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Type != trunkv.Added {
+		t.Fatalf("Diff = %+v, want one added key", changes)
+	}
+
+	merged, conflicts, err := s.Merge("hypothesis", "", trunkv.MergeFail)
+	if err != nil || len(conflicts) != 0 {
+		t.Fatalf("Merge: %v (conflicts %v)", err, conflicts)
+	}
+	if merged != hyp {
+		t.Errorf("expected fast-forward to %s, got %s", hyp.Short(), merged.Short())
+	}
+}
+
+func TestMergeConflict(t *testing.T) {
+	s, err := trunkv.Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer s.Close()
+
+	s.Put("robot/location", []byte("kitchen"))
+	s.Commit("base")
+	s.Fork("other")
+	s.Put("robot/location", []byte("garage"))
+	s.Commit("other says garage")
+	s.SwitchBranch(trunkv.DefaultBranch)
+	s.Put("robot/location", []byte("bedroom"))
+	s.Commit("main says bedroom")
+
+	_, conflicts, err := s.Merge("other", "", trunkv.MergeFail)
+	if !errors.Is(err, trunkv.ErrMergeConflict) || len(conflicts) != 1 {
+		t.Fatalf("expected one conflict, got %v (%v)", conflicts, err)
+	}
+	if _, _, err := s.Merge("other", "", trunkv.MergeTheirs); err != nil {
+		t.Fatalf("Merge theirs: %v", err)
+	}
+	got, _ := s.Get("robot/location")
+	if string(got) != "garage" {
+		t.Errorf("MergeTheirs kept %q, want %q", got, "garage")
+	}
+}
+
+func TestPersistenceAndGC(t *testing.T) {
+	dir := t.TempDir()
+	s, err := trunkv.Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	s.Put("k", []byte("v1"))
+	first, _ := s.Commit("v1")
+	s.Put("k", []byte("v2"))
+	s.Commit("v2")
+	if err := s.ResetHard(first); err != nil {
+		t.Fatalf("ResetHard: %v", err)
+	}
+	removed, err := s.GC()
+	if err != nil {
+		t.Fatalf("GC: %v", err)
+	}
+	if removed != 3 { // blob v2, su tree y su commit
+		t.Errorf("GC removed %d objects, want 3", removed)
+	}
+	s.Close()
+
+	// Simula un registro truncado al final del log.
+	f, err := os.OpenFile(filepath.Join(dir, "trunkv.log"), os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte{1, 0, 0, 0, 42, 'x'})
+	f.Close()
+
+	s, err = trunkv.Open(dir)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer s.Close()
+	if branch, head := s.Head(); branch != trunkv.DefaultBranch || head != first {
+		t.Errorf("Head() = %s %s, want %s %s", branch, head.Short(), trunkv.DefaultBranch, first.Short())
+	}
+	got, err := s.Get("k")
+	if err != nil || string(got) != "v1" {
+		t.Errorf("Get(k) = %q, %v; want v1", got, err)
+	}
+}
+
+func TestCorruptRecordInTheMiddle(t *testing.T) {
+	dir := t.TempDir()
+	s, err := trunkv.Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	s.Put("k", []byte("v1"))
+	s.Commit("v1")
+	s.Put("k", []byte("v2"))
+	s.Commit("v2")
+	s.Close()
+
+	// Corrompe el payload del primer registro; los que siguen son válidos.
+	path := filepath.Join(dir, "trunkv.log")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len("TRUNKV1\n")+5] ^= 0xff
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if s, err := trunkv.Open(dir); !errors.Is(err, trunkv.ErrCorruptLog) {
+		if err == nil {
+			s.Close()
+		}
+		t.Fatalf("Open = %v, want ErrCorruptLog", err)
+	}
+	// El log no se recorta: los registros válidos siguen en el archivo.
+	if after, err := os.ReadFile(path); err != nil || len(after) != len(data) {
+		t.Errorf("log size = %d (%v), want %d", len(after), err, len(data))
+	}
+}
+
+func graded(subject, predicate, object string, v ds.Truth) *ds.Triplet {
+	t := fact(subject, predicate, object)
+	t.Truth = &v
+	return t
+}
+
+func TestFermateanBranchEvaluation(t *testing.T) {
+	if _, err := ds.NewTruth(0.9, 0.8, 0.7); !errors.Is(err, ds.ErrNotFermatean) {
+		t.Fatalf("NewTruth accepted a non-Fermatean degree: %v", err)
+	}
+	dominance := []struct {
+		v, w ds.Truth
+		want bool
+	}{
+		{ds.TruthTrue, ds.TruthFalse, true},
+		{ds.TruthTrue, ds.TruthMaybe, true},
+		{ds.TruthTrue, ds.TruthUnknown, true},
+		{ds.TruthFalse, ds.TruthTrue, false},
+		{ds.TruthMaybe, ds.TruthUnknown, false}, // Ninguno domina: más verdad pero más falsedad.
+		{ds.TruthTrue, ds.TruthTrue, false},
+	}
+	for _, c := range dominance {
+		if got := c.v.Dominates(c.w); got != c.want {
+			t.Errorf("%v.Dominates(%v) = %v, want %v", c.v, c.w, got, c.want)
+		}
+	}
+
+	s, err := trunkv.Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer s.Close()
+
+	s.PutTriplet(graded("road", "is", "wet", ds.Truth{T: 0.8, I: 0.1, F: 0.05}))
+	s.Commit("confident")
+	s.Fork("doubtful")
+	s.PutTriplet(graded("road", "is", "icy", ds.Truth{T: 0.3, I: 0.6, F: 0.5}))
+	s.Commit("doubtful")
+
+	triplets, _ := s.Triplets()
+	for _, tr := range triplets {
+		if tr.Truth == nil {
+			t.Fatalf("truth degree lost in storage: %s", tr)
+		}
+	}
+
+	ranked, divergence, err := s.RankBranches([]string{"doubtful", trunkv.DefaultBranch})
+	if err != nil {
+		t.Fatalf("RankBranches: %v", err)
+	}
+	if ranked[0].Branch != trunkv.DefaultBranch {
+		t.Errorf("expected %s to rank first, got %+v", trunkv.DefaultBranch, ranked)
+	}
+	if divergence <= 0 {
+		t.Errorf("divergence = %v, want > 0", divergence)
+	}
+}
+
+func TestCognitiveMapConverges(t *testing.T) {
+	m := trunkv.CognitiveMapFromTriplets([]*ds.Triplet{
+		graded("rain", "do", "flood", ds.Truth{T: 0.9, F: 0.1}),
+		graded("dam", "do", "flood", ds.Truth{T: 0.1, F: 0.9}),
+		fact("flood", "is", "damage"),
+		fact("flood", "has", "water"), // No es causal.
+	})
+	if got := len(m.Concepts()); got != 4 {
+		t.Fatalf("got %d concepts, want 4", got)
+	}
+
+	clamped := map[string]bool{"rain": true, "dam": true}
+	withoutDam, err := m.Simulate(map[string]float64{"rain": 1}, trunkv.SimulationOptions{Clamped: clamped})
+	if err != nil {
+		t.Fatalf("Simulate: %v", err)
+	}
+	withDam, _ := m.Simulate(map[string]float64{"rain": 1, "dam": 1}, trunkv.SimulationOptions{Clamped: clamped})
+	if !withoutDam.Converged || !withDam.Converged {
+		t.Fatalf("simulation did not converge")
+	}
+	if withDam.Activations["flood"] >= withoutDam.Activations["flood"] {
+		t.Errorf("dam should inhibit flood: %v >= %v", withDam.Activations["flood"], withoutDam.Activations["flood"])
+	}
+	if _, err := m.Simulate(map[string]float64{"drought": 1}, trunkv.SimulationOptions{}); err == nil {
+		t.Errorf("expected an error for an unknown concept")
+	}
+}
+
+func TestExactNumbersRoundTrip(t *testing.T) {
+	s, err := trunkv.Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer s.Close()
+
+	serial, _ := new(big.Int).SetString("12345678901234567890", 10)
+	price, _ := ds.ParseDecimal("19.90")
+	objects := map[string]*ds.Symbol{
+		"serial": ds.NewIntegerSymbol(serial),
+		"price":  ds.NewDecimalSymbol(price),
+		"share":  ds.NewRationalSymbol(big.NewRat(1, 3)),
+		"phasor": ds.NewComplexSymbol(complex(2, -3)),
+	}
+	for predicate, object := range objects {
+		if _, err := s.PutTriplet(ds.NewTriplet(ds.NewConstantSymbol("item", "item"), ds.NewConstantSymbol(predicate, predicate), object, nil)); err != nil {
+			t.Fatalf("PutTriplet(%s): %v", predicate, err)
+		}
+	}
+	triplets, err := s.Triplets()
+	if err != nil {
+		t.Fatalf("Triplets: %v", err)
+	}
+	if len(triplets) != len(objects) {
+		t.Fatalf("got %d triplets, want %d", len(triplets), len(objects))
+	}
+	for _, tr := range triplets {
+		predicate := tr.Predicate.(*ds.Symbol).PublicName
+		got, want := tr.Object.(*ds.Symbol), objects[predicate]
+		if got.PublicName != want.PublicName || fmt.Sprintf("%T", got.Value) != fmt.Sprintf("%T", want.Value) {
+			t.Errorf("%s = %s (%T), want %s (%T)", predicate, got.PublicName, got.Value, want.PublicName, want.Value)
+		}
+	}
+}
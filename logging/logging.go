@@ -0,0 +1,197 @@
+// /nexusl/logging/logging.go
+// .
+// Registro estructurado y por niveles de nexusl
+// .
+// Cada subsistema (lexer, parser, unify, db, ds) tiene su propio
+// *slog.Logger y su propio nivel, de modo que se puede ver la traza del
+// parser sin el ruido de la unificación. Todos los loggers escriben a través
+// de un mismo slog.Handler (por defecto texto a os.Stderr) y añaden el
+// atributo "subsystem" a cada registro.
+// .
+// Por omisión solo se escriben los avisos y los errores. Los niveles se
+// cambian desde el código con SetLevel o Configure, o desde el entorno con
+// la variable NEXUSL_LOG, que se lee al iniciar el programa:
+//
+//	NEXUSL_LOG=debug                 todos los subsistemas en debug
+//	NEXUSL_LOG=parser=debug,db=info  solo esos subsistemas
+//	NEXUSL_LOG=info,unify=debug      nivel base y excepciones
+//
+// .
+// NEXUSL_LOG_FORMAT=json cambia la salida a JSON.
+// .
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Nombres de los subsistemas.
+const (
+	Lexer  = "lexer"
+	Parser = "parser"
+	Unify  = "unify"
+	DB     = "db"
+	DS     = "ds"
+)
+
+// Variables de entorno que se leen al iniciar.
+const (
+	EnvLevel  = "NEXUSL_LOG"
+	EnvFormat = "NEXUSL_LOG_FORMAT"
+)
+
+// DefaultLevel es el nivel de los subsistemas que no se configuran.
+const DefaultLevel = slog.LevelWarn
+
+// ErrBadSpec indica una especificación de niveles mal formada.
+var ErrBadSpec = errors.New("logging: bad level spec")
+
+var (
+	mu        sync.Mutex
+	levels    = map[string]*slog.LevelVar{}
+	baseLevel = DefaultLevel
+	output    atomic.Pointer[slog.Handler]
+)
+
+func init() {
+	SetHandler(nil)
+	if os.Getenv(EnvFormat) == "json" {
+		SetHandler(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+	if spec := os.Getenv(EnvLevel); spec != "" {
+		if err := Configure(spec); err != nil {
+			Logger(DS).Warn("ignoring "+EnvLevel, "err", err)
+		}
+	}
+}
+
+// Logger devuelve el logger del subsistema. Se puede guardar en una
+// variable de paquete: los cambios de nivel y de handler posteriores le
+// afectan igual.
+func Logger(subsystem string) *slog.Logger {
+	return slog.New(&handler{subsystem: subsystem, level: levelVar(subsystem)})
+}
+
+// SetLevel fija el nivel de un subsistema.
+func SetLevel(subsystem string, level slog.Level) {
+	levelVar(subsystem).Set(level)
+}
+
+// Level devuelve el nivel actual de un subsistema.
+func Level(subsystem string) slog.Level {
+	return levelVar(subsystem).Level()
+}
+
+// SetDefaultLevel fija el nivel de todos los subsistemas, incluidos los que
+// aún no han pedido su logger.
+func SetDefaultLevel(level slog.Level) {
+	mu.Lock()
+	defer mu.Unlock()
+	baseLevel = level
+	for _, v := range levels {
+		v.Set(level)
+	}
+}
+
+// SetHandler cambia el destino de todos los registros. Con nil vuelve al
+// texto en os.Stderr. El handler recibe todos los registros que pasan el
+// nivel de su subsistema, así que su propio nivel debería ser el mínimo.
+func SetHandler(h slog.Handler) {
+	if h == nil {
+		h = slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})
+	}
+	output.Store(&h)
+}
+
+// SetOutput escribe los registros como texto en w.
+func SetOutput(w io.Writer) {
+	SetHandler(slog.NewTextHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+// Configure aplica una especificación como la de NEXUSL_LOG: una lista
+// separada por comas de "nivel" (para todos) o "subsistema=nivel". Los
+// niveles son los de slog: debug, info, warn, error.
+func Configure(spec string) error {
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, lvl, scoped := strings.Cut(item, "=")
+		if !scoped {
+			lvl = name
+		}
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(strings.TrimSpace(lvl))); err != nil {
+			return fmt.Errorf("%w: %q", ErrBadSpec, item)
+		}
+		if scoped {
+			SetLevel(strings.TrimSpace(name), level)
+		} else {
+			SetDefaultLevel(level)
+		}
+	}
+	return nil
+}
+
+// levelVar devuelve (creándolo si hace falta) el nivel del subsistema.
+func levelVar(subsystem string) *slog.LevelVar {
+	mu.Lock()
+	defer mu.Unlock()
+	v, ok := levels[subsystem]
+	if !ok {
+		v = new(slog.LevelVar)
+		v.Set(baseLevel)
+		levels[subsystem] = v
+	}
+	return v
+}
+
+// handler filtra por el nivel del subsistema y reenvía al handler global
+// vigente en el momento de escribir. Los atributos y grupos de With se
+// guardan y se aplican sobre ese handler.
+type handler struct {
+	subsystem string
+	level     *slog.LevelVar
+	with      []func(slog.Handler) slog.Handler
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	out := *output.Load()
+	out = out.WithAttrs([]slog.Attr{slog.String("subsystem", h.subsystem)})
+	for _, with := range h.with {
+		out = with(out)
+	}
+	if !out.Enabled(ctx, r.Level) {
+		return nil
+	}
+	return out.Handle(ctx, r)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.extend(func(out slog.Handler) slog.Handler { return out.WithAttrs(attrs) })
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return h.extend(func(out slog.Handler) slog.Handler { return out.WithGroup(name) })
+}
+
+func (h *handler) extend(with func(slog.Handler) slog.Handler) slog.Handler {
+	return &handler{
+		subsystem: h.subsystem,
+		level:     h.level,
+		with:      append(h.with[:len(h.with):len(h.with)], with),
+	}
+}
@@ -0,0 +1,52 @@
+package logging_test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/devicemxl/nexusl/logging"
+)
+
+func TestSubsystemLevels(t *testing.T) {
+	var buf bytes.Buffer
+	logging.SetOutput(&buf)
+	defer logging.SetHandler(nil)
+	defer logging.SetDefaultLevel(logging.DefaultLevel)
+
+	parser := logging.Logger(logging.Parser)
+	unify := logging.Logger(logging.Unify).With("goal", "p(X)")
+
+	parser.Debug("hidden")
+	unify.Warn("shown by default")
+	if err := logging.Configure("error, parser=debug"); err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+	parser.Debug("parseStatement", "word", "fact")
+	unify.Warn("hidden")
+
+	want := []string{
+		`level=WARN msg="shown by default" subsystem=unify goal=p(X)`,
+		`level=DEBUG msg=parseStatement subsystem=parser word=fact`,
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(want), buf.String())
+	}
+	for i, line := range lines {
+		if !strings.HasSuffix(line, want[i]) {
+			t.Errorf("line %d = %q, want suffix %q", i, line, want[i])
+		}
+	}
+	if got := logging.Level(logging.DB); got != slog.LevelError {
+		t.Errorf("Level(db) = %v, want ERROR", got)
+	}
+}
+
+func TestConfigureBadSpec(t *testing.T) {
+	if err := logging.Configure("parser=loud"); !errors.Is(err, logging.ErrBadSpec) {
+		t.Fatalf("Configure(parser=loud) = %v, want ErrBadSpec", err)
+	}
+}
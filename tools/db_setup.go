@@ -67,6 +67,71 @@ func main() {
 	}
 	fmt.Println("Sample data inserted/replaced.")
 
+	// Crear la tabla keyword_aliases: otras formas de escribir las palabras
+	// clave en cada idioma. La palabra clave es la canónica (la de
+	// system_symbols o la del lexer) y el alias tiene su misma clase.
+	createAliasesSQL := `
+	CREATE TABLE IF NOT EXISTS keyword_aliases (
+		language TEXT NOT NULL, -- Idioma del pragma //nexusl:lang (es, ...)
+		alias TEXT NOT NULL,
+		keyword TEXT NOT NULL,
+		PRIMARY KEY (language, alias)
+	);
+	`
+	_, err = db.Exec(createAliasesSQL)
+	if err != nil {
+		log.Fatalf("Failed to create table: %v", err)
+	}
+	fmt.Println("Table 'keyword_aliases' created or already exists.")
+
+	insertAliasesSQL := `
+	INSERT OR REPLACE INTO keyword_aliases (language, alias, keyword) VALUES
+	-- Scopes y sentencias
+	('es', 'hecho',     'fact'),
+	('es', 'regla',     'rule'),
+	('es', 'lógica',    'logic'),
+	('es', 'retirar',   'retract'),
+	('es', 'explicar',  'explain'),
+	('es', 'rastrear',  'trace'),
+	('es', 'tabular',   'table'),
+	('es', 'definición', 'def'),
+	('es', 'función',   'func'),
+	('es', 'expresión', 'expr'),
+	('es', 'programa',  'program'),
+	('es', 'variable',  'var'),
+	('es', 'sea',       'let'),
+	('es', 'constante', 'const'),
+
+	-- Conectivas de las reglas
+	('es', 'si',        'if'),
+	('es', 'y',         'and'),
+
+	-- Predicados
+	('es', 'es',        'is'),
+	('es', 'tiene',     'has'),
+	('es', 'hace',      'do'),
+	('es', 'como',      'how'),
+	('es', 'donde',     'where'),
+	('es', 'cuando',    'when'),
+
+	-- Identificadores y literales
+	('es', 'símbolo',   'symbol'),
+	('es', 'verdadero', 'true'),
+	('es', 'falso',     'false'),
+
+	-- Álgebra simbólica
+	('es', 'simplificar', 'simplify'),
+	('es', 'expandir',    'expand'),
+	('es', 'derivar',     'derive'),
+	('es', 'sustituir',   'substitute'),
+	('es', 'resolver',    'eq_solve');
+	`
+	_, err = db.Exec(insertAliasesSQL)
+	if err != nil {
+		log.Fatalf("Failed to insert aliases: %v", err)
+	}
+	fmt.Println("Keyword aliases inserted/replaced.")
+
 	// Opcional: Consulta para verificar los datos
 	rows, err := db.Query("SELECT public_name, thing, embedding_data FROM system_symbols")
 	if err != nil {